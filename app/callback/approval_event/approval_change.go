@@ -0,0 +1,21 @@
+package approval_event
+
+import (
+	"github.com/pkg/errors"
+	"openscrm/app/services"
+	"openscrm/pkg/easywework"
+)
+
+// EventSysApprovalChangeHandler 审批申请状态变化，同步群发审批结果
+func EventSysApprovalChangeHandler(msg *workwx.RxMessage) error {
+	if msg.MsgType != workwx.MessageTypeEvent || msg.Event != workwx.EventTypeSysApprovalChange {
+		return errors.New("wrong handler for the callback event")
+	}
+
+	event, ok := msg.EventSysApprovalChange()
+	if !ok {
+		return errors.New("msg.EventSysApprovalChange failed")
+	}
+
+	return services.NewMassMsgApprovalService().HandleOAApproval(event.GetApprovalInfo(), msg.ToUserID)
+}
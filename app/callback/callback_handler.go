@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"net/http"
+	"openscrm/app/callback/approval_event"
 	"openscrm/app/callback/customer_event"
 	"openscrm/app/callback/department_event"
 	"openscrm/app/callback/group_chat_event"
@@ -116,6 +117,11 @@ func init() {
 			MessageType: workwx.MessageTypeEvent,
 			EventType:   workwx.EventTypeChangeExternalChat,
 			ChangeType:  workwx.ChangeTypeDismissChat}: group_chat_event.EventDismissExternalChatHandler,
+
+		//	审批申请状态变化事件
+		services.Event{
+			MessageType: workwx.MessageTypeEvent,
+			EventType:   workwx.EventTypeSysApprovalChange}: approval_event.EventSysApprovalChangeHandler,
 	}
 }
 
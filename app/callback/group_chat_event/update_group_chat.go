@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"openscrm/app/constants"
 	"openscrm/app/models"
+	"openscrm/app/services"
 	"openscrm/common/id_generator"
 	"openscrm/common/log"
 	"openscrm/common/we_work"
@@ -70,17 +71,31 @@ func EventUpdateExternalChatHandler(msg *workwx.RxMessage) error {
 	extCorpID := conf.Settings.WeWork.ExtCorpID
 	extChatID := chat.ChatID
 	updateDetail := eventCreateChat.GetUpdateDetail()
+
+	// 变动前的成员，用于记录入群退群历史
+	var before []models.GroupChatMember
+	if updateDetail == constants.GroupChatChangeTypeDelMember || updateDetail == constants.GroupChatChangeTypeAddMember {
+		before, err = models.GroupChatMember{}.QueryByChat(extCorpID, extChatID)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch updateDetail {
 	case constants.GroupChatChangeTypeDelMember:
 		// 成员减少, 删除不在ids中的成员
 		memberIDs := make([]string, 0)
+		after := make([]models.GroupChatMember, 0)
 		for _, m := range chat.MemberList {
 			memberIDs = append(memberIDs, m.Userid)
+			after = append(after, models.GroupChatMember{Userid: m.Userid})
 		}
 		err = models.GroupChatMember{}.Delete(extCorpID, extChatID, memberIDs)
 		if err != nil {
 			return err
 		}
+		recordMemberChanges(extCorpID, extChatID, constants.GroupChatMemberEventQuit,
+			before, after, int(eventCreateChat.GetQuitScene()))
 	case constants.GroupChatChangeTypeAddMember:
 		//	新增成员
 		members := make([]models.GroupChatMember, 0)
@@ -100,6 +115,12 @@ func EventUpdateExternalChatHandler(msg *workwx.RxMessage) error {
 		if err != nil {
 			return err
 		}
+		recordMemberChanges(extCorpID, extChatID, constants.GroupChatMemberEventJoin, before, members, 0)
+		// 黑名单成员入群提醒，失败不影响成员同步
+		err = services.NewGroupChatSpam().CheckBlacklistJoin(extCorpID, extChatID, before, members)
+		if err != nil {
+			log.Sugar.Errorw("CheckBlacklistJoin failed", "err", err, "extChatID", extChatID)
+		}
 	default:
 
 	}
@@ -114,3 +135,12 @@ func EventUpdateExternalChatHandler(msg *workwx.RxMessage) error {
 
 	return nil
 }
+
+// recordMemberChanges 记录入群或退群历史，失败不影响成员同步
+func recordMemberChanges(extCorpID string, extChatID string, eventType constants.GroupChatMemberEventType,
+	before []models.GroupChatMember, after []models.GroupChatMember, quitScene int) {
+	err := models.GroupChatMemberLog{}.RecordChanges(extCorpID, extChatID, eventType, before, after, quitScene, time.Now())
+	if err != nil {
+		log.Sugar.Errorw("RecordChanges failed", "err", err, "extChatID", extChatID)
+	}
+}
@@ -21,6 +21,7 @@ import (
 // 	1. 同步客户数据
 //	2. 发送欢迎语
 //	3. 更新客户数
+//	4. 通过渠道码添加时触发客户SOP
 // Param: msg  wx回调参数
 func EventAddExternalContactHandler(msg *gowx.RxMessage) error {
 	if msg.MsgType != gowx.MessageTypeEvent ||
@@ -92,6 +93,13 @@ func EventAddExternalContactHandler(msg *gowx.RxMessage) error {
 		return err
 	}
 
+	if contactWayID, _, ok := models.ParseContactWayState(eventAddExternalContact.GetState()); ok {
+		err = services.NewCustomerSop().TriggerByContactWay(contactWayID, extStaffID, extCustomerID, conf.Settings.WeWork.ExtCorpID)
+		if err != nil {
+			log.Sugar.Errorw("TriggerByContactWay failed", "err", err, "contactWayID", contactWayID)
+		}
+	}
+
 	// 渠道码已发过欢迎语，这里就不再发了
 	if shouldSendWelcomeMsg {
 		welcomeCode := eventAddExternalContact.GetWelcomeCode()
@@ -106,7 +114,7 @@ func EventAddExternalContactHandler(msg *gowx.RxMessage) error {
 
 			// send default welcome msg
 			staffService := services.NewStaffService()
-			return staffService.SendDefaultWelcomeMsg(welcomeCode, staff.ExtCorpID, staff.ExtID)
+			return staffService.SendDefaultWelcomeMsg(welcomeCode, staff.ExtCorpID, staff.ExtID, extCustomerID)
 		}
 	}
 
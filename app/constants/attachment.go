@@ -1,10 +1,26 @@
 package constants
 
+import gowx "openscrm/pkg/easywework"
+
 const (
 	ImageMsgType       AttachmentType = "image"
 	LinkMsgType        AttachmentType = "link"
 	MiniProgramMsgType AttachmentType = "miniprogram"
 	VideoMsgType       AttachmentType = "video"
+	// ProductMsgType 商品图册，发送时转换为链接或图片
+	ProductMsgType AttachmentType = "product"
 )
 
 type AttachmentType string
+
+// Attachment 消息附件，在企微附件的基础上扩展了商品图册
+type Attachment struct {
+	gowx.Attachments
+	Product ProductAttachment `json:"product"`
+}
+
+// ProductAttachment 商品图册附件
+type ProductAttachment struct {
+	// 商品ID
+	ProductID string `json:"product_id"`
+}
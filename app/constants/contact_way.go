@@ -39,4 +39,81 @@ const (
 	ContactWayAutoReplyTypeDisable ContactWayAutoReplyType = 3
 )
 
+// ContactWayDistributeStrategy 渠道码多人分配策略
+// 1-企微随机分配
+// 2-加权轮询
+// 3-当前客户数最少优先
+// 4-按顺序分配，前一个员工达到每日上限后再分配给下一个
+type ContactWayDistributeStrategy int
+
+const (
+	// ContactWayDistributeRandom 企微在全部在线员工中随机分配
+	ContactWayDistributeRandom ContactWayDistributeStrategy = 1
+	// ContactWayDistributeWeighted 按员工权重轮询，今日添加数与权重之比最小的员工优先
+	ContactWayDistributeWeighted ContactWayDistributeStrategy = 2
+	// ContactWayDistributeLeastCustomers 当前客户数最少的员工优先
+	ContactWayDistributeLeastCustomers ContactWayDistributeStrategy = 3
+	// ContactWayDistributeInOrder 按员工顺序分配
+	ContactWayDistributeInOrder ContactWayDistributeStrategy = 4
+)
+
+// ContactWayStatus 渠道码状态
+// 1-生效中
+// 2-已过期
+// 3-已达到添加上限
+type ContactWayStatus int
+
+const (
+	// ContactWayStatusActive 生效中
+	ContactWayStatusActive ContactWayStatus = 1
+	// ContactWayStatusExpired 已过期，企微配置已删除
+	ContactWayStatusExpired ContactWayStatus = 2
+	// ContactWayStatusExhausted 已达到添加上限，企微配置已删除
+	ContactWayStatusExhausted ContactWayStatus = 3
+)
+
+// ContactWayDefaultChatExpiresIn 企微临时会话默认有效期，添加好友后24小时
+const ContactWayDefaultChatExpiresIn = 24 * 60 * 60
+
+// ContactWayTempMaxExpiresIn 企微临时会话二维码最长有效期，14天
+const ContactWayTempMaxExpiresIn = 14 * 24 * 60 * 60
+
 const ContactWayStatePrefix = "ixj:"
+
+// ContactWaySubChannelSep 子渠道state中渠道码ID与子渠道编码的分隔符，子渠道state为 ContactWayStatePrefix+渠道码ID+分隔符+编码
+const ContactWaySubChannelSep = "-"
+
+// ContactWaySubChannelCodeLen 自动生成的子渠道编码长度，企微限制state不超过30个字符
+const ContactWaySubChannelCodeLen = 6
+
+// ContactWayAnalyticsDefaultDays 渠道码数据分析默认统计添加后7天内的留存和转化
+const ContactWayAnalyticsDefaultDays = 7
+
+// ContactWayImportRowStatus 渠道码导入的行状态
+type ContactWayImportRowStatus string
+
+const (
+	// ContactWayImportRowInvalid 校验未通过，不会导入
+	ContactWayImportRowInvalid ContactWayImportRowStatus = "invalid"
+	// ContactWayImportRowPending 校验通过，等待后台创建
+	ContactWayImportRowPending ContactWayImportRowStatus = "pending"
+	// ContactWayImportRowSuccess 已创建或更新
+	ContactWayImportRowSuccess ContactWayImportRowStatus = "success"
+	// ContactWayImportRowFailed 创建或更新企微配置失败
+	ContactWayImportRowFailed ContactWayImportRowStatus = "failed"
+)
+
+// ContactWayImportMaxRows 单次导入的最大行数
+const ContactWayImportMaxRows = 1000
+
+// ContactWayScheduleLogTrigger 渠道码接待员工变更的触发来源
+type ContactWayScheduleLogTrigger string
+
+const (
+	// ContactWayScheduleLogTriggerSchedule 到达调度时段的开始或结束时间
+	ContactWayScheduleLogTriggerSchedule ContactWayScheduleLogTrigger = "schedule"
+	// ContactWayScheduleLogTriggerStaffOnline 员工自行上线
+	ContactWayScheduleLogTriggerStaffOnline ContactWayScheduleLogTrigger = "staff_online"
+	// ContactWayScheduleLogTriggerStaffOffline 员工自行下线
+	ContactWayScheduleLogTriggerStaffOffline ContactWayScheduleLogTrigger = "staff_offline"
+)
@@ -0,0 +1,16 @@
+package constants
+
+// CorpCalendarDayType 企业日历特殊日期类型
+// 1-节假日，当天不按工作日排班
+// 2-调休上班日，当天按指定的星期排班
+type CorpCalendarDayType int
+
+const (
+	// CorpCalendarHoliday 节假日
+	CorpCalendarHoliday CorpCalendarDayType = 1
+	// CorpCalendarWorkday 调休上班日
+	CorpCalendarWorkday CorpCalendarDayType = 2
+)
+
+// CorpCalendarImportMaxDays 单次导入的最大日期数
+const CorpCalendarImportMaxDays = 1000
@@ -1,6 +1,26 @@
 package constants
 
+import "time"
+
 // FollowUserTagType 该成员添加此外部联系人所打标签类型
 // 1-企业设置
 // 2-用户自定义
 type FollowUserTagType int
+
+// CustomerIdentitySource 客户外部身份的来源应用
+// 1-微信小程序
+// 2-微信公众号
+type CustomerIdentitySource int
+
+const (
+	// CustomerIdentitySourceMiniProgram 微信小程序
+	CustomerIdentitySourceMiniProgram CustomerIdentitySource = 1
+	// CustomerIdentitySourceOfficialAccount 微信公众号
+	CustomerIdentitySourceOfficialAccount CustomerIdentitySource = 2
+)
+
+// CustomerIdentityMaxAttempts 补全unionid、关联客户身份的最多尝试次数，超过后不再尝试，客户再次登录时重新计数
+const CustomerIdentityMaxAttempts = 5
+
+// CustomerIdentityRetryInterval 补全unionid、关联客户身份失败后的重试间隔
+const CustomerIdentityRetryInterval = 24 * time.Hour
@@ -0,0 +1,80 @@
+package constants
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// SegmentMaxDepth 客户分群规则最多嵌套的层数
+const SegmentMaxDepth = 5
+
+// SegmentField 客户分群规则的筛选字段
+type SegmentField string
+
+const (
+	SegmentFieldTag             SegmentField = "tag"               // 企业标签
+	SegmentFieldInternalTag     SegmentField = "internal_tag"      // 内部标签
+	SegmentFieldRemark          SegmentField = "remark"            // 自定义信息，Key为自定义信息ID
+	SegmentFieldCustomerInfo    SegmentField = "customer_info"     // 客户画像，Key为age/email/phone_number/qq/address/birthday/weibo/description
+	SegmentFieldStaff           SegmentField = "staff"             // 所属员工
+	SegmentFieldDepartment      SegmentField = "department"        // 所属员工的部门
+	SegmentFieldContactWay      SegmentField = "contact_way"       // 来源渠道活码
+	SegmentFieldLastChatAt      SegmentField = "last_chat_at"      // 最近聊天时间，依赖会话存档
+	SegmentFieldChurnStatus     SegmentField = "churn_status"      // 流失状态 1-已流失 2-未流失
+	SegmentFieldGender          SegmentField = "gender"            // 性别 0-未知 1-男性 2-女性
+	SegmentFieldAddTime         SegmentField = "add_time"          // 添加时间
+	SegmentFieldGroupChat       SegmentField = "group_chat"        // 所在客户群
+	SegmentFieldSubChannel      SegmentField = "sub_channel"       // 来源子渠道
+	SegmentFieldSubChannelParam SegmentField = "sub_channel_param" // 来源子渠道的自定义参数，Key为参数名，如source/campaign/store
+)
+
+// SegmentOperator 客户分群规则的比较方式
+type SegmentOperator string
+
+const (
+	SegmentOpIn       SegmentOperator = "in"        // 包含任意一个
+	SegmentOpAll      SegmentOperator = "all"       // 包含全部，仅标签、内部标签可用
+	SegmentOpNotIn    SegmentOperator = "not_in"    // 不包含任意一个
+	SegmentOpEq       SegmentOperator = "eq"        // 等于
+	SegmentOpContains SegmentOperator = "contains"  // 模糊匹配
+	SegmentOpGte      SegmentOperator = "gte"       // 大于等于，时间字段为不早于
+	SegmentOpLte      SegmentOperator = "lte"       // 小于等于，时间字段为不晚于
+	SegmentOpBetween  SegmentOperator = "between"   // 区间，包含两端
+	SegmentOpEmpty    SegmentOperator = "empty"     // 为空，如无标签、从未聊天
+	SegmentOpNotEmpty SegmentOperator = "not_empty" // 不为空
+)
+
+// SegmentRule 客户分群规则
+// Logic不为空时为组合条件，由Rules按且/或组合；否则为单个条件
+type SegmentRule struct {
+	// 组合方式 and-且 or-或
+	Logic string `json:"logic,omitempty" validate:"omitempty,oneof=and or"`
+	// 子规则
+	Rules []SegmentRule `json:"rules,omitempty" validate:"omitempty,dive"`
+	// 筛选字段
+	Field SegmentField `json:"field,omitempty"`
+	// 字段的子键，自定义信息为自定义信息ID，客户画像为画像字段名
+	Key string `json:"key,omitempty"`
+	// 比较方式
+	Operator SegmentOperator `json:"operator,omitempty"`
+	// 比较值，时间字段格式为2006-01-02，between时依次为开始、结束时间
+	Values StringArrayField `json:"values,omitempty"`
+}
+
+// IsGroup 是否为组合条件
+func (o SegmentRule) IsGroup() bool {
+	return o.Logic != ""
+}
+
+func (o SegmentRule) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *SegmentRule) Scan(input interface{}) error {
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o SegmentRule) GormDataType() string {
+	return "json"
+}
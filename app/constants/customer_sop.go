@@ -0,0 +1,35 @@
+package constants
+
+// CustomerSopTriggerType 客户SOP的触发方式
+type CustomerSopTriggerType uint8
+
+const (
+	CustomerSopTriggerContactWay CustomerSopTriggerType = 1 // 通过指定渠道码添加
+	CustomerSopTriggerTag        CustomerSopTriggerType = 2 // 被打上指定标签
+)
+
+// CustomerSopDeliverType 客户SOP步骤的执行方式
+type CustomerSopDeliverType uint8
+
+const (
+	CustomerSopDeliverRemind      CustomerSopDeliverType = 1 // 通过应用消息提醒员工发送
+	CustomerSopDeliverMsgTemplate CustomerSopDeliverType = 2 // 创建单客户的企业群发，员工确认后发送
+)
+
+// CustomerSopTaskStatus 客户SOP任务状态
+type CustomerSopTaskStatus uint8
+
+const (
+	CustomerSopTaskPending  CustomerSopTaskStatus = 1 // 待执行
+	CustomerSopTaskDone     CustomerSopTaskStatus = 2 // 已执行
+	CustomerSopTaskSkipped  CustomerSopTaskStatus = 3 // 不满足条件，已跳过
+	CustomerSopTaskFailed   CustomerSopTaskStatus = 4 // 执行失败
+	CustomerSopTaskCanceled CustomerSopTaskStatus = 5 // SOP被停用或删除，已取消
+)
+
+// CustomerSopMaxSteps 单个SOP最多的步骤数
+const CustomerSopMaxSteps = 20
+
+// CustomerSopRemindMsg 提醒员工执行SOP步骤，依次为SOP名称、步骤序号、客户名称、消息内容
+const CustomerSopRemindMsg = `[ %s ] 第%d步：请给客户[ %s ]发送以下内容
+%s`
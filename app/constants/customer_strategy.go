@@ -0,0 +1,32 @@
+package constants
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	gowx "openscrm/pkg/easywework"
+)
+
+// CustomerStrategyRangeType 客户联系规则组管理范围的类型
+// 1-员工 2-部门
+type CustomerStrategyRangeType int
+
+const (
+	CustomerStrategyRangeTypeStaff      CustomerStrategyRangeType = 1
+	CustomerStrategyRangeTypeDepartment CustomerStrategyRangeType = 2
+)
+
+// CustomerStrategyPrivilege 客户联系规则组的权限配置
+type CustomerStrategyPrivilege gowx.CustomerStrategyPrivilege
+
+func (o CustomerStrategyPrivilege) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *CustomerStrategyPrivilege) Scan(input interface{}) error {
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o CustomerStrategyPrivilege) GormDataType() string {
+	return "json"
+}
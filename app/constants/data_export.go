@@ -7,6 +7,7 @@ const (
 	DataExportTypeCustomer              DataExportType = "customer_list"
 	DataExportTypeDeleteStaffWarning    DataExportType = "delete_staff_warning"
 	DataExportTypeDeleteCustomerWarning DataExportType = "delete_customer_warning"
+	DataExportTypeStaffBehavior         DataExportType = "staff_behavior"
 )
 
 const (
@@ -14,6 +15,9 @@ const (
 	DataExportGroupChatListPrefix          = "xjyk-GroupChatList"      //"小橘有客-群聊列表"
 	DataExportDeleteCustomerFilenamePrefix = "xjyk-DeleteCustomerList" //"小橘有客-删人提醒"
 	DataExportDeleteStaffFilenamePrefix    = "xjyk-DeleteStaffList"    //"小橘有客-客户流失提醒提醒"
+	DataExportStaffBehaviorFilenamePrefix  = "xjyk-StaffBehavior"      //"小橘有客-员工数据统计"
+	DataExportContactWayFilenamePrefix     = "xjyk-ContactWay"         //"小橘有客-渠道码数据分析"
+	DataExportContactWayListFilenamePrefix = "xjyk-ContactWayList"     //"小橘有客-渠道码列表"
 )
 
 const (
@@ -21,4 +25,21 @@ const (
 	DataExportGroupChatListSheetName      = "客户群列表"  //"小橘有客-群聊列表"
 	DataExportDeleteCustomerListSheetName = "删人提醒列表" //"小橘有客-删人提醒"
 	DataExportDeleteStaffListSheetName    = "流失提醒列表" //"小橘有客-客户流失提醒提醒"
+	DataExportStaffBehaviorSheetName      = "员工数据统计" //"小橘有客-员工数据统计"
+	DataExportDeptBehaviorSheetName       = "部门数据统计" //"小橘有客-部门数据统计"
+	DataExportContactWayDailySheetName    = "渠道每日数据" //"小橘有客-渠道码每日数据"
+	DataExportContactWayStaffSheetName    = "渠道员工数据" //"小橘有客-渠道码员工数据"
+	DataExportContactWayListSheetName     = "渠道码列表"  //"小橘有客-渠道码列表"
+)
+
+const (
+	DataExportGroupChatMemberFilenamePrefix = "xjyk-GroupChatMember" //"小橘有客-群成员分析"
+	DataExportGroupChatMemberLogSheetName   = "群成员变动记录"              //"小橘有客-群成员变动记录"
+	DataExportGroupChatMemberTrendSheetName = "群成员每日趋势"              //"小橘有客-群成员每日趋势"
+	DataExportGroupChatInviterSheetName     = "邀请入群排行"               //"小橘有客-邀请入群排行"
+)
+
+const (
+	DataExportGroupChatViolationFilenamePrefix = "xjyk-GroupChatViolation" //"小橘有客-客户群违规记录"
+	DataExportGroupChatViolationSheetName      = "客户群违规记录"                 //"小橘有客-客户群违规记录"
 )
@@ -20,6 +20,8 @@ type ExtCustomerFilter struct {
 	StartTime DateField `json:"start_time" form:"start_time"`
 	// 添加好友,结束时间
 	EndTime DateField `json:"end_time" form:"end_time"`
+	// 客户分群ID，与其他条件同时满足
+	SegmentID string `json:"segment_id" form:"segment_id" validate:"omitempty,int64"`
 }
 
 func (o ExtCustomerFilter) Value() (driver.Value, error) {
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/thoas/go-funk"
-	gowx "openscrm/pkg/easywework"
 	"strings"
 	"time"
 )
@@ -24,6 +23,29 @@ func (f *JSONArrayField) Scan(input interface{}) error {
 	return json.Unmarshal(input.([]byte), &f.V)
 }
 
+// StringMapField
+// 使gorm支持map[string]string结构
+type StringMapField map[string]string
+
+func (o StringMapField) Value() (driver.Value, error) {
+	if o == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *StringMapField) Scan(input interface{}) error {
+	if input == nil {
+		return nil
+	}
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o StringMapField) GormDataType() string {
+	return "json"
+}
+
 // StringArrayField
 // 使gorm支持[]string结构
 type StringArrayField []string
@@ -155,7 +177,7 @@ type MiniProgram struct {
 	Page string `json:"page"`
 }
 
-type AttachmentArrayField []gowx.Attachments
+type AttachmentArrayField []Attachment
 
 func (o AttachmentArrayField) Value() (driver.Value, error) {
 	b, err := json.Marshal(o)
@@ -14,3 +14,43 @@ const (
 	GroupChatChangeTypeChangeName   string = "change_name"   //		change_name : 群名变更
 	GroupChatChangeTypeChangeNotice string = "change_notice" //		change_notice : 群公告变更
 )
+
+// GroupChatMemberEventType 群成员变动类型
+type GroupChatMemberEventType int
+
+const (
+	// GroupChatMemberEventJoin 入群
+	GroupChatMemberEventJoin GroupChatMemberEventType = 1
+	// GroupChatMemberEventQuit 退群
+	GroupChatMemberEventQuit GroupChatMemberEventType = 2
+)
+
+// GroupChatMemberStatsMaxDays 群成员统计的最大天数
+const GroupChatMemberStatsMaxDays = 366
+
+// GroupChatMemberExportMaxRows 群成员变动记录单次导出的最大行数
+const GroupChatMemberExportMaxRows = 10000
+
+// GroupChatSpamRuleType 客户群防骚扰命中的规则类型
+type GroupChatSpamRuleType string
+
+const (
+	// GroupChatSpamRuleTypeKeyword 违禁词
+	GroupChatSpamRuleTypeKeyword GroupChatSpamRuleType = "keyword"
+	// GroupChatSpamRuleTypeLink 外部链接
+	GroupChatSpamRuleTypeLink GroupChatSpamRuleType = "link"
+	// GroupChatSpamRuleTypeQrImage 疑似二维码图片
+	GroupChatSpamRuleTypeQrImage GroupChatSpamRuleType = "qr_image"
+	// GroupChatSpamRuleTypeRapidPost 频繁发言
+	GroupChatSpamRuleTypeRapidPost GroupChatSpamRuleType = "rapid_post"
+	// GroupChatSpamRuleTypeNewMemberLink 新入群成员发送链接
+	GroupChatSpamRuleTypeNewMemberLink GroupChatSpamRuleType = "new_member_link"
+	// GroupChatSpamRuleTypeBlacklistJoin 黑名单成员加入其他群
+	GroupChatSpamRuleTypeBlacklistJoin GroupChatSpamRuleType = "blacklist_join"
+)
+
+// GroupChatSpamCheckCursorKey 客户群防骚扰已检查到的会话存档消息ID，按企业区分
+const GroupChatSpamCheckCursorKey = "GroupChatSpamCheckCursor:%s"
+
+// GroupChatSpamCheckBatchSize 客户群防骚扰每批检查的消息数
+const GroupChatSpamCheckBatchSize = 500
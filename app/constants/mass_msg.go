@@ -3,6 +3,9 @@ package constants
 const NotifyStaffSendMassMsg = `【管理员】提醒你发送群发任务
 任务创建于%s，将群发给%s等%d个客户，可前往【客户联系】中确认发送`
 
+const NotifyOwnerSendGroupChatMassMsg = `【管理员】提醒你发送客户群群发任务
+任务创建于%s，将群发给你的%d个客户群，可前往【客户联系】中确认发送`
+
 // ChatType 创建企业群发消息的发送类型
 type ChatType string
 
@@ -54,4 +57,54 @@ const (
 	Sent      SendMassMsgStatus = 3 // 发送成功，所有员工均已发送
 	Deleted   SendMassMsgStatus = 4 // 任务已取消
 	Failed    SendMassMsgStatus = 5 // 提交给微信时失败
+	Pending   SendMassMsgStatus = 6 // 待审批，审批通过后才会发送
+	Rejected  SendMassMsgStatus = 7 // 审批被驳回，不会发送
+)
+
+// MassMsgSendStatus 客户群发中单个客户的发送状态，与企微群发成员执行结果的status一致
+type MassMsgSendStatus uint8
+
+const (
+	MassMsgUnsent          MassMsgSendStatus = 0 // 未发送
+	MassMsgSent            MassMsgSendStatus = 1 // 已发送
+	MassMsgFailedNotFriend MassMsgSendStatus = 2 // 因客户不是好友导致发送失败
+	MassMsgFailedOverQuota MassMsgSendStatus = 3 // 因客户已经收到其他群发消息导致发送失败
+	MassMsgCanceled        MassMsgSendStatus = 4 // 群发已取消，员工未发送，非企微返回的状态
+	MassMsgSubmitFailure   MassMsgSendStatus = 5 // 重试后仍未能创建企微群发，员工无法发送，非企微返回的状态
 )
+
+// MassMsgMonthlyQuota 每位客户每个自然月最多接收的企业群发次数
+const MassMsgMonthlyQuota = 4
+
+// MassMsgQuotaMonthLayout 群发次数统计的月份格式
+const MassMsgQuotaMonthLayout = "2006-01"
+
+// MassMsgQuotaPolicy 创建群发时对本月群发次数已达上限客户的处理方式
+type MassMsgQuotaPolicy uint8
+
+const (
+	MassMsgQuotaIgnore  MassMsgQuotaPolicy = 0 // 不处理，照常发送
+	MassMsgQuotaWarn    MassMsgQuotaPolicy = 1 // 存在已达上限的客户时返回提示，不创建群发
+	MassMsgQuotaExclude MassMsgQuotaPolicy = 2 // 排除已达上限的客户
+)
+
+// MassMsgSubmitStatus 发送人的企微群发提交状态，分批发送时作为断点，已提交的不会重复提交
+type MassMsgSubmitStatus uint8
+
+const (
+	MassMsgSubmitPending MassMsgSubmitStatus = 0 // 待提交
+	MassMsgSubmitted     MassMsgSubmitStatus = 1 // 已创建企微群发
+	MassMsgSubmitFailed  MassMsgSubmitStatus = 2 // 重试后仍提交失败
+)
+
+// MassMsgDefaultBatchSize 每批群发任务默认包含的发送人数
+const MassMsgDefaultBatchSize = 50
+
+// MassMsgDefaultSendRate 默认每秒创建的企微群发数
+const MassMsgDefaultSendRate = 5
+
+// MassMsgBatchMaxRetry 单批群发任务的最大重试次数，超过后未提交的发送人标记为提交失败
+const MassMsgBatchMaxRetry = 5
+
+// MassMsgBatchTTR 单批群发任务的执行超时秒数，按速率创建企微群发耗时较长，超时后队列会重新投递
+const MassMsgBatchTTR = 600
@@ -0,0 +1,36 @@
+package constants
+
+// MassMsgApprovalMode 群发审批方式
+type MassMsgApprovalMode uint8
+
+const (
+	MassMsgApprovalLocal MassMsgApprovalMode = 1 // 在本系统中审批
+	MassMsgApprovalOA    MassMsgApprovalMode = 2 // 提交到企业微信审批应用中审批
+)
+
+// MassMsgApprovalStatus 群发审批单状态
+type MassMsgApprovalStatus uint8
+
+const (
+	MassMsgApprovalPending  MassMsgApprovalStatus = 1 // 待审批
+	MassMsgApprovalApproved MassMsgApprovalStatus = 2 // 已通过
+	MassMsgApprovalRejected MassMsgApprovalStatus = 3 // 已驳回
+	MassMsgApprovalCanceled MassMsgApprovalStatus = 4 // 群发已取消
+)
+
+// 企业微信审批单状态，见审批状态变化回调中的SpStatus
+const (
+	OASpStatusApproved = "2" // 已通过
+	OASpStatusRejected = "3" // 已驳回
+	OASpStatusCanceled = "4" // 已撤销
+)
+
+// MassMsgApprovalNotifyTitle 通知审批人的卡片标题
+const MassMsgApprovalNotifyTitle = "群发消息待审批"
+
+// MassMsgApprovalNotifyDesc 通知审批人的卡片内容，依次为创建人、发送时间、客户数、消息内容
+const MassMsgApprovalNotifyDesc = `<div class="gray">创建人：%s</div><div class="gray">发送时间：%s</div><div class="normal">将群发给%d个客户：%s</div>`
+
+// MassMsgApprovalResultMsg 通知创建人审批结果，依次为创建时间、审批结果、审批意见
+const MassMsgApprovalResultMsg = `你创建于%s的群发任务%s
+审批意见：%s`
@@ -0,0 +1,18 @@
+package constants
+
+// 消息模板变量，在消息文本中以{{变量}}或{{变量|默认值}}的形式使用，变量值为空时使用默认值
+const (
+	MsgVarCustomerName     = "customer_name"      // 客户昵称
+	MsgVarCustomerRemark   = "customer_remark"    // 员工对客户的备注名
+	MsgVarCustomerCorpName = "customer_corp_name" // 客户的企业名称，优先使用员工备注的企业名称
+	MsgVarCustomerDesc     = "customer_desc"      // 员工对客户的描述
+	MsgVarStaffName        = "staff_name"         // 员工姓名
+	MsgVarStaffAlias       = "staff_alias"        // 员工别名
+	// MsgVarInfoPrefix 客户画像，如{{info.phone_number}}，可用age/email/phone_number/qq/address/birthday/weibo/description
+	MsgVarInfoPrefix = "info."
+	// MsgVarRemarkPrefix 客户自定义信息，如{{remark.会员等级}}
+	MsgVarRemarkPrefix = "remark."
+)
+
+// RemarkFieldTypeOption 自定义信息的多选类型，值为选项ID
+const RemarkFieldTypeOption = "option_text"
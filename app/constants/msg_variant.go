@@ -0,0 +1,46 @@
+package constants
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// MsgVariantMaxNum 一条消息最多的内容变体数
+const MsgVariantMaxNum = 5
+
+// MsgVariantDefaultDays 统计变体转化数据的默认天数
+const MsgVariantDefaultDays = 7
+
+// MsgVariant 消息内容变体，用于A/B测试
+type MsgVariant struct {
+	// 变体标识，如A、B，同一消息内不可重复
+	Key string `json:"key" validate:"required,max=8"`
+	// 变体名称
+	Name string `json:"name" validate:"omitempty,max=64"`
+	// 分配到此变体的客户百分比，所有变体之和为100
+	Percent int `json:"percent" validate:"gt=0,lte=100"`
+	// 消息内容
+	Msg AutoReplyField `json:"msg"`
+}
+
+// MsgVariants 消息内容变体列表，为空时不做A/B测试
+type MsgVariants []MsgVariant
+
+func (o MsgVariants) Value() (driver.Value, error) {
+	if o == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *MsgVariants) Scan(input interface{}) error {
+	if input == nil {
+		return nil
+	}
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o MsgVariants) GormDataType() string {
+	return "json"
+}
@@ -0,0 +1,66 @@
+package constants
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// PosterStorageDir 生成的海报在文件存储中的目录
+const PosterStorageDir = "public/posters/"
+
+// PosterMaxBackgroundSize 海报背景图最大边长，像素，海报在请求中同步渲染，限制尺寸以控制耗时
+const PosterMaxBackgroundSize = 2048
+
+// PosterMaxPerRequest 单次请求最多生成的海报数
+const PosterMaxPerRequest = 100
+
+// PosterDefaultTextColor 海报文字的默认颜色
+const PosterDefaultTextColor = "#000000"
+
+// PosterSlotField 海报中图片的摆放位置，坐标以背景图左上角为原点，单位为像素
+type PosterSlotField struct {
+	// 左上角横坐标
+	X int `json:"x" validate:"gte=0"`
+	// 左上角纵坐标
+	Y int `json:"y" validate:"gte=0"`
+	// 边长，图片按正方形绘制，0为不绘制
+	Size int `json:"size" validate:"gte=0"`
+}
+
+func (o PosterSlotField) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *PosterSlotField) Scan(input interface{}) error {
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o PosterSlotField) GormDataType() string {
+	return "json"
+}
+
+// PosterTextSlotField 海报中文字的摆放位置，坐标为文字左上角，单位为像素
+type PosterTextSlotField struct {
+	// 左上角横坐标
+	X int `json:"x" validate:"gte=0"`
+	// 左上角纵坐标
+	Y int `json:"y" validate:"gte=0"`
+	// 字号，0为不绘制
+	FontSize int `json:"font_size" validate:"gte=0,lte=200"`
+	// 文字颜色，如#333333，为空时为黑色
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+func (o PosterTextSlotField) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *PosterTextSlotField) Scan(input interface{}) error {
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o PosterTextSlotField) GormDataType() string {
+	return "json"
+}
@@ -0,0 +1,10 @@
+package constants
+
+// StaffStatisticDimension 员工数据统计的维度
+// staff-按员工 department-按部门
+type StaffStatisticDimension string
+
+const (
+	StaffStatisticDimensionStaff      StaffStatisticDimension = "staff"
+	StaffStatisticDimensionDepartment StaffStatisticDimension = "department"
+)
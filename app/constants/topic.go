@@ -11,9 +11,12 @@ const (
 	DataExportTopic        Topic = "topic:DataExportTopic"
 	RemainderTopic         Topic = "topic:RemainderTopic"
 	MassMsgTopic           Topic = "topic:MassMsgTopic"
+	MassMsgBatchTopic      Topic = "topic:MassMsgBatchTopic"
 	GroupChatMassMsgTopic  Topic = "topic:GroupChatMassMsgTopic"
 	SyncCustomerDataTopic  Topic = "topic:SyncCustomerDataTopic"
 	RefreshContactWayTopic Topic = "topic:RefreshContactWayTopic"
+	CustomerSopTopic       Topic = "topic:CustomerSopTopic"
+	ContactWayImportTopic  Topic = "topic:ContactWayImportTopic"
 )
 
 type JobPrefix string
@@ -23,5 +26,6 @@ func (o JobPrefix) String() string {
 }
 
 const (
-	ContactWayJobPrefix JobPrefix = "job:contactWay:"
+	ContactWayJobPrefix   JobPrefix = "job:contactWay:"
+	MassMsgBatchJobPrefix JobPrefix = "job:massMsgBatch:"
 )
@@ -2,8 +2,11 @@ package consumers
 
 import (
 	"github.com/pkg/errors"
+	"openscrm/app/constants"
 	"openscrm/app/models"
+	"openscrm/app/services"
 	"openscrm/common/delay_queue"
+	"openscrm/common/log"
 	"openscrm/common/util"
 )
 
@@ -20,3 +23,18 @@ func (o contactWay) Refresh(job delay_queue.Job) (err error) {
 
 	return
 }
+
+// Import 执行渠道码批量导入任务，job.ID为导入任务ID
+func (o contactWay) Import(job delay_queue.Job) error {
+	if job.Topic != constants.ContactWayImportTopic {
+		log.Sugar.Infow("job.topic not match", "job.Topic", job.Topic)
+		return nil
+	}
+
+	err := services.NewContactWay().ExecuteImport(job.ID)
+	if err != nil {
+		log.Sugar.Errorw("execute contact way import failed", "job.id", job.ID, "err", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package consumers
+
+import (
+	"openscrm/app/constants"
+	"openscrm/app/services"
+	"openscrm/common/delay_queue"
+	"openscrm/common/log"
+)
+
+// ExecuteCustomerSopTask 执行到期的客户SOP步骤，job.ID为任务ID
+func ExecuteCustomerSopTask(job delay_queue.Job) error {
+	if job.Topic != constants.CustomerSopTopic {
+		log.Sugar.Infow("job.topic not match", "job.Topic", job.Topic)
+		return nil
+	}
+
+	err := services.NewCustomerSop().ExecuteTask(job.ID)
+	if err != nil {
+		log.Sugar.Errorw("execute customer sop task failed", "job.id", job.ID, "err", err)
+		return err
+	}
+
+	err = delay_queue.Remove(job.ID)
+	if err != nil {
+		log.Sugar.Errorw("remove customer sop job failed", "job.id", job.ID, "err", err)
+		return err
+	}
+	return nil
+}
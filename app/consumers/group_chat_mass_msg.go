@@ -27,6 +27,11 @@ func SendGroupChatMassMsg(job delay_queue.Job) error {
 		if err != nil {
 			return err
 		}
+		// 部分群主提交失败时，统计其未送达的群，其余群主都发送后群发即可完成
+		err = models.GroupChatMassMsg{}.RefreshStatistic(job.ID)
+		if err != nil {
+			log.Sugar.Errorw("RefreshStatistic failed", "err", err, "msgID", job.ID)
+		}
 		err = delay_queue.Remove(job.ID)
 		if err != nil {
 			return err
@@ -25,9 +25,12 @@ func registerHandler(topic constants.Topic, handler func(delay_queue.Job) error)
 func registerHandlers() {
 	registerHandler(constants.RefreshContactWayTopic, contactWay{}.Refresh)
 	registerHandler(constants.MassMsgTopic, SendMassMsg)
+	registerHandler(constants.MassMsgBatchTopic, SendMassMsgBatch)
 	registerHandler(constants.SyncCustomerDataTopic, SyncCustomerData)
 	registerHandler(constants.RemainderTopic, SendRemainderMsg)
 	registerHandler(constants.GroupChatMassMsgTopic, SendGroupChatMassMsg)
+	registerHandler(constants.CustomerSopTopic, ExecuteCustomerSopTask)
+	registerHandler(constants.ContactWayImportTopic, contactWay{}.Import)
 	dataExporter := NewDataExporter()
 	registerHandler(constants.DataExportTopic, dataExporter.DataExport)
 
@@ -1,34 +1,43 @@
 package consumers
 
 import (
+	"encoding/json"
+	"github.com/pkg/errors"
 	"openscrm/app/constants"
-	"openscrm/app/models"
+	"openscrm/app/requests"
 	"openscrm/app/services"
 	"openscrm/common/delay_queue"
 	"openscrm/common/log"
 )
 
+// SendMassMsg 群发到达发送时间，分批推送到发送队列
 func SendMassMsg(job delay_queue.Job) error {
 	log.Sugar.Info("job info:", job)
 	if job.Topic == constants.MassMsgTopic {
 		customerService := services.NewDefaultMassMsgService()
-		msgID, err := customerService.SendMassMsgToWx(job.Body, job.ID)
+		err := customerService.DispatchMassMsg(job.Body, job.ID)
 		if err != nil {
 			log.Sugar.Error(err)
 			return err
 		}
-		msg := models.MassMsg{
-			ExtCorpModel:  models.ExtCorpModel{ID: job.ID},
-			ExtMsgID:      msgID,
-			MissionStatus: constants.Sending,
-		}
-		// job body 是req
-		err = models.DB.Where("id = ?", job.ID).Updates(&msg).Error
+	}
+	return nil
+}
+
+// SendMassMsgBatch 发送一批群发，失败时由队列按断点重试
+func SendMassMsgBatch(job delay_queue.Job) error {
+	log.Sugar.Info("job info:", job)
+	if job.Topic == constants.MassMsgBatchTopic {
+		batch := requests.MassMsgBatchJob{}
+		err := json.Unmarshal([]byte(job.Body), &batch)
 		if err != nil {
-			return err
+			log.Sugar.Error("unmarshal mass msg batch failed", err)
+			return errors.WithStack(err)
 		}
-		err = delay_queue.Remove(job.ID)
+		customerService := services.NewDefaultMassMsgService()
+		err = customerService.SendMassMsgToWx(batch, job.FailedCount)
 		if err != nil {
+			log.Sugar.Error(err)
 			return err
 		}
 	}
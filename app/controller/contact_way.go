@@ -229,3 +229,125 @@ func (o *ContactWay) BatchUpdate(c *gin.Context) {
 	}
 	handler.ResponseItem(total)
 }
+
+// Analytics
+// @tags 渠道码
+// @Summary 渠道码数据分析
+// @Description 按日期和员工统计渠道码的添加人次，以及添加后N天内的流失、打标签和聊天人次
+// @Produce  json
+// @Param id path string true "渠道码ID"
+// @Param params query requests.QueryContactWayAnalyticsReq true "渠道码数据分析请求"
+// @Success 200 {object} app.JSONResult{data=responses.ContactWayAnalytics} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/analytics [get]
+func (o *ContactWay) Analytics(c *gin.Context) {
+	req := requests.QueryContactWayAnalyticsReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	res, err := o.srv.Analytics(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Analytics failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(res)
+}
+
+// ExportAnalytics
+// @tags 渠道码
+// @Summary 导出渠道码数据分析
+// @Produce  json
+// @Param id path string true "渠道码ID"
+// @Param params query requests.QueryContactWayAnalyticsReq true "渠道码数据分析请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/analytics/action/export [get]
+func (o *ContactWay) ExportAnalytics(c *gin.Context) {
+	req := requests.QueryContactWayAnalyticsReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportAnalytics(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportAnalytics failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// SimulateDistribution
+// @tags 渠道码
+// @Summary 模拟渠道码分配
+// @Description 按分配策略模拟接下来N次添加分别由哪些员工接待
+// @Produce  json
+// @Param id path string true "渠道码ID"
+// @Param params query requests.SimulateContactWayDistributionReq true "模拟渠道码分配请求"
+// @Success 200 {object} app.JSONResult{data=responses.ContactWayDistribution} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/distribution/action/simulate [get]
+func (o *ContactWay) SimulateDistribution(c *gin.Context) {
+	req := requests.SimulateContactWayDistributionReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	res, err := o.srv.SimulateDistribution(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "SimulateDistribution failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(res)
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type ContactWayFrontend struct {
+	Base
+	srv *services.ContactWay
+}
+
+func NewContactWayFrontend() *ContactWayFrontend {
+	return &ContactWayFrontend{srv: services.NewContactWay()}
+}
+
+// Query
+// @tags 渠道码
+// @Summary H5查询当前员工可自行上下线的渠道码
+// @Produce  json
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.StaffContactWay}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-frontend/contact-ways [get]
+func (o *ContactWayFrontend) Query(c *gin.Context) {
+	handler := app.NewHandler(c)
+	staff, err := o.GetStaffInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.QueryStaffContactWays(staff.ExtCorpID, staff.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryStaffContactWays failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// Online
+// @tags 渠道码
+// @Summary H5员工自行上下线，渠道码需开启员工自行上下线
+// @Produce  json
+// @Accept json
+// @Param id path string true "渠道码ID"
+// @Param params body requests.StaffOnlineContactWayReq true "员工自行上下线请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-frontend/contact-way/{id}/action/online [post]
+func (o *ContactWayFrontend) Online(c *gin.Context) {
+	req := requests.StaffOnlineContactWayReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staff, err := o.GetStaffInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffInfo failed", err)
+		return
+	}
+
+	err = o.srv.StaffOnline(id, req, staff.ExtCorpID, staff.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "StaffOnline failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
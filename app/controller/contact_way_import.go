@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+// Export
+// @tags 渠道码
+// @Summary 导出渠道码列表，格式与批量导入一致
+// @Produce  json
+// @Param params query requests.QueryContactWayReq true "查询渠道码列表请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/action/export [get]
+func (o *ContactWay) Export(c *gin.Context) {
+	req := requests.QueryContactWayReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportContactWays(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportContactWays failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// Import
+// @tags 渠道码
+// @Summary 批量导入渠道码，返回逐行校验结果，校验通过的行在后台创建或更新
+// @Produce  json
+// @Accept json
+// @Param params body requests.ImportContactWayReq true "导入渠道码请求"
+// @Success 200 {object} app.JSONResult{data=models.ContactWayImportTask} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/action/import [post]
+func (o *ContactWay) Import(c *gin.Context) {
+	req := requests.ImportContactWayReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	task, err := o.srv.Import(req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "Import failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(task)
+}
+
+// GetImportTask
+// @tags 渠道码
+// @Summary 查询渠道码导入任务的进度和逐行结果
+// @Produce  json
+// @Param id path string true "导入任务ID"
+// @Success 200 {object} app.JSONResult{data=models.ContactWayImportTask} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way_import/{id} [get]
+func (o *ContactWay) GetImportTask(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	task, err := o.srv.GetImportTask(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "GetImportTask failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(task)
+}
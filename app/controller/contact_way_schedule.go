@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+// QueryScheduleLogs
+// @tags 渠道码
+// @Summary 查询渠道码接待员工变更记录，包括调度时段切换和员工自行上下线
+// @Produce  json
+// @Param id path string true "渠道码ID"
+// @Param params query requests.QueryContactWayScheduleLogReq true "查询渠道码接待员工变更记录请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.ContactWayScheduleLog}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/schedule_logs [get]
+func (o *ContactWay) QueryScheduleLogs(c *gin.Context) {
+	req := requests.QueryContactWayScheduleLogReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryScheduleLogs(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryScheduleLogs failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+// QuerySubChannels
+// @tags 渠道码
+// @Summary 查询渠道码的子渠道
+// @Produce  json
+// @Param id path string true "渠道码ID"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.ContactWaySubChannel}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/sub_channels [get]
+func (o *ContactWay) QuerySubChannels(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.QuerySubChannels(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QuerySubChannels failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// CreateSubChannel
+// @tags 渠道码
+// @Summary 创建渠道码子渠道
+// @Description 子渠道使用独立的二维码，客户扫码添加后可按子渠道及其自定义参数筛选
+// @Produce  json
+// @Accept json
+// @Param id path string true "渠道码ID"
+// @Param params body requests.CreateContactWaySubChannelReq true "创建渠道码子渠道请求"
+// @Success 200 {object} app.JSONResult{data=models.ContactWaySubChannel} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way/{id}/sub_channel [post]
+func (o *ContactWay) CreateSubChannel(c *gin.Context) {
+	req := requests.CreateContactWaySubChannelReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.CreateSubChannel(id, req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "CreateSubChannel failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// UpdateSubChannel
+// @tags 渠道码
+// @Summary 更新渠道码子渠道
+// @Produce  json
+// @Accept json
+// @Param id path string true "子渠道ID"
+// @Param params body requests.UpdateContactWaySubChannelReq true "更新渠道码子渠道请求"
+// @Success 200 {object} app.JSONResult{data=bool} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way_sub_channel/{id} [put]
+func (o *ContactWay) UpdateSubChannel(c *gin.Context) {
+	req := requests.UpdateContactWaySubChannelReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.UpdateSubChannel(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateSubChannel failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
+
+// DeleteSubChannels
+// @tags 渠道码
+// @Summary 删除渠道码子渠道
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteContactWaySubChannelReq true "删除渠道码子渠道请求"
+// @Success 200 {object} app.JSONResult{data=bool} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/contact_way_sub_channel/action/delete [post]
+func (o *ContactWay) DeleteSubChannels(c *gin.Context) {
+	req := requests.DeleteContactWaySubChannelReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.DeleteSubChannels(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteSubChannels failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
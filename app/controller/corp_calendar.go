@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type CorpCalendar struct {
+	Base
+	srv *services.CorpCalendar
+}
+
+func NewCorpCalendar() *CorpCalendar {
+	return &CorpCalendar{srv: services.NewCorpCalendar()}
+}
+
+// Query
+// @tags 企业日历
+// @Summary 查询企业节假日和调休上班日
+// @Produce  json
+// @Param params query requests.QueryCorpCalendarDayReq true "查询企业日历请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CorpCalendarDay}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/corp_calendar_days [get]
+func (o *CorpCalendar) Query(c *gin.Context) {
+	req := requests.QueryCorpCalendarDayReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.Query(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// Save
+// @tags 企业日历
+// @Summary 保存企业节假日和调休上班日，同一天已存在时覆盖
+// @Produce  json
+// @Accept json
+// @Param params body requests.SaveCorpCalendarDaysReq true "保存企业日历请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/corp_calendar_days [post]
+func (o *CorpCalendar) Save(c *gin.Context) {
+	req := requests.SaveCorpCalendarDaysReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.Save(req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "Save failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
+
+// Import
+// @tags 企业日历
+// @Summary 从iCal或CSV文件导入企业日历，返回导入的天数
+// @Produce  json
+// @Accept json
+// @Param params body requests.ImportCorpCalendarReq true "导入企业日历请求"
+// @Success 200 {object} app.JSONResult{data=int} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/corp_calendar/action/import [post]
+func (o *CorpCalendar) Import(c *gin.Context) {
+	req := requests.ImportCorpCalendarReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	count, err := o.srv.Import(req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "Import failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(count)
+}
+
+// Delete
+// @tags 企业日历
+// @Summary 删除企业日历
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteCorpCalendarDayReq true "删除企业日历请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/corp_calendar_day/action/delete [post]
+func (o *CorpCalendar) Delete(c *gin.Context) {
+	req := requests.DeleteCorpCalendarDayReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
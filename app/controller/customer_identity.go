@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+	"openscrm/common/util"
+	"openscrm/conf"
+)
+
+type CustomerIdentity struct {
+	Base
+	srv *services.CustomerIdentity
+}
+
+func NewCustomerIdentity() *CustomerIdentity {
+	return &CustomerIdentity{srv: services.NewCustomerIdentity()}
+}
+
+// Lookup
+// @tags 客户管理
+// @Summary 根据unionid或手机号查找客户
+// @Produce  json
+// @Param params query requests.LookupCustomerReq true "查找客户请求"
+// @Success 200 {object} app.JSONResult{data=models.Customer} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/action/lookup [get]
+func (o CustomerIdentity) Lookup(c *gin.Context) {
+	req := requests.LookupCustomerReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	customer, err := o.srv.Lookup(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Lookup failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(customer)
+}
+
+// MiniProgramLogin
+// @tags 客户前台
+// @Summary 小程序/公众号客户登录
+// @Description 由小程序服务端签名调用，登录成功后写入与H5登录相同的客户会话
+// @Produce  json
+// @Accept json
+// @Param params body requests.MiniProgramLoginReq true "小程序登录请求"
+// @Success 200 {object} app.JSONResult{data=models.Customer} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/customer-frontend/action/mini-program-login [post]
+func (o CustomerIdentity) MiniProgramLogin(c *gin.Context) {
+	req := requests.MiniProgramLoginReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	item, err := o.srv.MiniProgramLogin(req, conf.Settings.WeWork.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "MiniProgramLogin failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.CustomerSession.Set(string(constants.CustomerInfo), util.JsonEncode(item))
+	err = handler.CustomerSession.Save()
+	if err != nil {
+		err = errors.Wrap(err, "sess.Save failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
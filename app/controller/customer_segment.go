@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type CustomerSegment struct {
+	Base
+	srv *services.CustomerSegment
+}
+
+func NewCustomerSegment() *CustomerSegment {
+	return &CustomerSegment{srv: services.NewCustomerSegment()}
+}
+
+// Query
+// @tags 客户分群
+// @Summary 查询客户分群
+// @Produce  json
+// @Param params query requests.QueryCustomerSegmentReq true "查询客户分群请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CustomerSegment}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segments [get]
+func (o *CustomerSegment) Query(c *gin.Context) {
+	req := requests.QueryCustomerSegmentReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Get
+// @tags 客户分群
+// @Summary 客户分群详情
+// @Produce  json
+// @Param id path string true "客户分群ID"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSegment} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment/{id} [get]
+func (o *CustomerSegment) Get(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Get(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Create
+// @tags 客户分群
+// @Summary 创建客户分群
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateCustomerSegmentReq true "创建客户分群请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSegment} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment [post]
+func (o *CustomerSegment) Create(c *gin.Context) {
+	req := requests.CreateCustomerSegmentReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Create(req, staffAdmin.ExtID, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Create failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Update
+// @tags 客户分群
+// @Summary 更新客户分群
+// @Produce  json
+// @Accept json
+// @Param id path string true "客户分群ID"
+// @Param params body requests.UpdateCustomerSegmentReq true "更新客户分群请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSegment} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment/{id} [put]
+func (o *CustomerSegment) Update(c *gin.Context) {
+	req := requests.UpdateCustomerSegmentReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Update(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Update failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Delete
+// @tags 客户分群
+// @Summary 删除客户分群
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteCustomerSegmentReq true "删除客户分群请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment/action/delete [post]
+func (o *CustomerSegment) Delete(c *gin.Context) {
+	req := requests.DeleteCustomerSegmentReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// Count
+// @tags 客户分群
+// @Summary 实时统计客户分群的客户数
+// @Produce  json
+// @Param id path string true "客户分群ID"
+// @Success 200 {object} app.JSONResult{data=models.SegmentCount} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment/{id}/count [get]
+func (o *CustomerSegment) Count(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	res, err := o.srv.Count(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Count failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(res)
+}
+
+// Preview
+// @tags 客户分群
+// @Summary 按规则预览客户数
+// @Description 编辑分群时使用，规则无需保存
+// @Produce  json
+// @Accept json
+// @Param params body requests.CountCustomerSegmentReq true "预览客户数请求"
+// @Success 200 {object} app.JSONResult{data=models.SegmentCount} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-segment/action/count [post]
+func (o *CustomerSegment) Preview(c *gin.Context) {
+	req := requests.CountCustomerSegmentReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	res, err := o.srv.Preview(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Preview failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(res)
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type CustomerSop struct {
+	Base
+	srv *services.CustomerSop
+}
+
+func NewCustomerSop() *CustomerSop {
+	return &CustomerSop{srv: services.NewCustomerSop()}
+}
+
+// Query
+// @tags 客户SOP
+// @Summary 查询客户SOP
+// @Produce  json
+// @Param params query requests.QueryCustomerSopReq true "查询客户SOP请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CustomerSop}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sops [get]
+func (o *CustomerSop) Query(c *gin.Context) {
+	req := requests.QueryCustomerSopReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Get
+// @tags 客户SOP
+// @Summary 客户SOP详情
+// @Produce  json
+// @Param id path string true "客户SOPID"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSop} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sop/{id} [get]
+func (o *CustomerSop) Get(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Get(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Create
+// @tags 客户SOP
+// @Summary 创建客户SOP
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateCustomerSopReq true "创建客户SOP请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSop} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sop [post]
+func (o *CustomerSop) Create(c *gin.Context) {
+	req := requests.CreateCustomerSopReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Create(req, staffAdmin.ExtID, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Create failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Update
+// @tags 客户SOP
+// @Summary 更新客户SOP
+// @Produce  json
+// @Accept json
+// @Param id path string true "客户SOPID"
+// @Param params body requests.UpdateCustomerSopReq true "更新客户SOP请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerSop} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sop/{id} [put]
+func (o *CustomerSop) Update(c *gin.Context) {
+	req := requests.UpdateCustomerSopReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Update(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Update failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Delete
+// @tags 客户SOP
+// @Summary 删除客户SOP
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteCustomerSopReq true "删除客户SOP请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sop/action/delete [post]
+func (o *CustomerSop) Delete(c *gin.Context) {
+	req := requests.DeleteCustomerSopReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// QueryTasks
+// @tags 客户SOP
+// @Summary 查询客户SOP的执行任务
+// @Produce  json
+// @Param params query requests.QueryCustomerSopTaskReq true "查询客户SOP任务请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CustomerSopTask}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-sop-tasks [get]
+func (o *CustomerSop) QueryTasks(c *gin.Context) {
+	req := requests.QueryCustomerSopTaskReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.QueryTasks(req, staffAdmin.ExtCorpID, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "QueryTasks failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type CustomerStrategy struct {
+	Base
+	srv *services.CustomerStrategy
+}
+
+func NewCustomerStrategy() *CustomerStrategy {
+	return &CustomerStrategy{srv: services.NewCustomerStrategy()}
+}
+
+// Query
+// @tags 客户联系规则组
+// @Summary 查询客户联系规则组
+// @Produce  json
+// @Param params query requests.QueryCustomerStrategyReq true "查询客户联系规则组请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CustomerStrategy}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategies [get]
+func (o *CustomerStrategy) Query(c *gin.Context) {
+	req := requests.QueryCustomerStrategyReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Get
+// @tags 客户联系规则组
+// @Summary 客户联系规则组详情
+// @Produce  json
+// @Param id path string true "规则组ID"
+// @Success 200 {object} app.JSONResult{data=models.CustomerStrategy} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategy/{id} [get]
+func (o *CustomerStrategy) Get(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Get(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Create
+// @tags 客户联系规则组
+// @Summary 创建客户联系规则组
+// @Description 绑定角色时，规则组负责人为该角色下的员工，并随角色授权变化自动同步
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateCustomerStrategyReq true "创建客户联系规则组请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerStrategy} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategy [post]
+func (o *CustomerStrategy) Create(c *gin.Context) {
+	req := requests.CreateCustomerStrategyReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Create(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Create failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Update
+// @tags 客户联系规则组
+// @Summary 更新客户联系规则组
+// @Produce  json
+// @Accept json
+// @Param id path string true "规则组ID"
+// @Param params body requests.UpdateCustomerStrategyReq true "更新客户联系规则组请求"
+// @Success 200 {object} app.JSONResult{data=models.CustomerStrategy} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategy/{id} [put]
+func (o *CustomerStrategy) Update(c *gin.Context) {
+	req := requests.UpdateCustomerStrategyReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Update(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Update failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Delete
+// @tags 客户联系规则组
+// @Summary 删除客户联系规则组
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteCustomerStrategyReq true "删除客户联系规则组请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategy/action/delete [post]
+func (o *CustomerStrategy) Delete(c *gin.Context) {
+	req := requests.DeleteCustomerStrategyReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// Sync
+// @tags 客户联系规则组
+// @Summary 从企微同步客户联系规则组
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer-strategy/action/sync [post]
+func (o *CustomerStrategy) Sync(c *gin.Context) {
+	handler := app.NewHandler(c)
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Sync(staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Sync failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
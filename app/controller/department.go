@@ -12,11 +12,12 @@ import (
 
 type Department struct {
 	Base
-	srv *services.Department
+	srv         *services.Department
+	strategySrv *services.CustomerStrategy
 }
 
 func NewDepartment() *Department {
-	return &Department{srv: services.NewDepartment()}
+	return &Department{srv: services.NewDepartment(), strategySrv: services.NewCustomerStrategy()}
 }
 
 // Query
@@ -107,3 +108,36 @@ func (d *Department) Get(c *gin.Context) {
 	}
 	handler.ResponseItem(departments)
 }
+
+// QueryCustomerStrategies
+// @tags 部门管理
+// @Summary 查询管理范围包含该部门的客户联系规则组
+// @Produce json
+// @Param params query requests.QueryCustomerStrategyReq true "查询客户联系规则组请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.CustomerStrategy}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/department/action/customer-strategies [get]
+func (d *Department) QueryCustomerStrategies(c *gin.Context) {
+	handler := app.NewHandler(c)
+	req := requests.QueryCustomerStrategyReq{}
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := d.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := d.strategySrv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query customer strategies failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
@@ -83,30 +83,153 @@ func (ch GroupChatMassMsg) Get(c *gin.Context) {
 	handler.ResponseItem(result)
 }
 
-//// GetSendMassMsgResult
-//// @tags 客户管理-客户群群发
-//// @Summary 获取创建群发消息的结果
-//// @Produce json
-//// @Success 200 {object} app.JSONResult{data=requests.SendMassMsgResp} "成功"
-//// @Failure 400 {object} app.JSONResult{} "请求错误"
-//// @Failure 500 {object} app.JSONResult{} "内部错误"
-//// @Router /api/v1/staff-admin/customer/mass-msg/result/{id} [get]
-//func (ch GroupChatMassMsg) GetSendMassMsgResult(c *gin.Context) {
-//	handler := app.NewHandler(c)
-//	missionID, err := handler.GetIDParam()
-//	if err != nil {
-//		err = errors.Wrap(err, "handler.GetIDParam failed")
-//		handler.ResponseBadRequestError(err)
-//		return
-//	}
-//	result, err := ch.srv.GetSendMassMsgResult(missionID)
-//	if err != nil {
-//		err = errors.Wrap(err, "get send group msg failed")
-//		handler.ResponseError(err)
-//		return
-//	}
-//	handler.ResponseItem(result)
-//}
+// GetSendMassMsgResult
+// @tags 客户群群发
+// @Summary 获取客户群群发的发送结果
+// @Produce json
+// @Param id path string true "消息id"
+// @Param params query requests.QueryGroupChatMassMsgResultReq true "查询客户群群发结果请求"
+// @Success 200 {object} app.JSONResult{data=requests.GroupChatMassMsgResultResp} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/group-chat/mass-msg/result/{id} [get]
+func (ch GroupChatMassMsg) GetSendMassMsgResult(c *gin.Context) {
+	req := requests.QueryGroupChatMassMsgResultReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	missionID, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(err)
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	result, err := ch.srv.GetSendMassMsgResult(missionID, req, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "get send group msg failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(result)
+}
+
+// Update
+// @tags 客户群群发
+// @Summary 修改定时的客户群群发
+// @Param id path string true "消息id"
+// @Param params body requests.UpdateGroupChatMassMsgReq true "修改客户群群发请求"
+// @Produce json
+// @Success 200 {object} app.JSONResult{data=models.GroupChatMassMsg} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/group-chat/mass-msg/{id} [put]
+func (ch GroupChatMassMsg) Update(c *gin.Context) {
+	req := requests.UpdateGroupChatMassMsgReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(err)
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	msg, err := ch.srv.Update(req, id, staffAdminInfo.ExtID, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "update group chat mass msg failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(msg)
+}
+
+// Notify
+// @tags 客户群群发
+// @Summary 提醒还未发送的群主发送客户群群发
+// @Param params body requests.GroupChatMassMsgNotifyReq true "提醒群主请求"
+// @Produce json
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/group-chat/mass-msg/action/notify [post]
+func (ch GroupChatMassMsg) Notify(c *gin.Context) {
+	req := requests.GroupChatMassMsgNotifyReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	err = ch.srv.Notify(req.IDs, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "notify owners failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(nil)
+}
+
+// CustomerFilter
+// @tags 客户群群发
+// @Summary 预览客户群群发将发送到的群
+// @Param params query requests.GroupChatMassMsgChatFilterReq true "预览目标群请求"
+// @Produce json
+// @Success 200 {object} app.JSONResult{data=requests.GroupChatMassMsgChatFilterResp} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/group-chat/mass-msg/customer-filter [get]
+func (ch GroupChatMassMsg) CustomerFilter(c *gin.Context) {
+	req := requests.GroupChatMassMsgChatFilterReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	res, err := ch.srv.ChatFilter(req, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "filter target chats failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(res)
+}
 
 // Delete
 // @tags 客户管理-客户群群发
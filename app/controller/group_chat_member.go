@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+// MemberTrend
+// @tags 客户群
+// @Summary 客户群每日入群、退群、净增人数和群人数
+// @Produce  json
+// @Param id path string true "客户群ID"
+// @Param params query requests.QueryGroupChatMemberTrendReq true "查询客户群成员趋势请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.GroupChatMemberTrend}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/{id}/member_trends [get]
+func (o GroupChat) MemberTrend(c *gin.Context) {
+	req := requests.QueryGroupChatMemberTrendReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.QueryMemberTrend(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryMemberTrend failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// ExportMemberTrend
+// @tags 客户群
+// @Summary 导出客户群每日成员趋势
+// @Produce  json
+// @Param id path string true "客户群ID"
+// @Param params query requests.QueryGroupChatMemberTrendReq true "查询客户群成员趋势请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/{id}/member_trends/action/export [get]
+func (o GroupChat) ExportMemberTrend(c *gin.Context) {
+	req := requests.QueryGroupChatMemberTrendReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportMemberTrend(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportMemberTrend failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// MemberLogs
+// @tags 客户群
+// @Summary 查询群成员入群退群记录，按成员查询时为客户加入和退出过的群
+// @Produce  json
+// @Param params query requests.QueryGroupChatMemberLogReq true "查询群成员变动记录请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.GroupChatMemberLog}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/member_logs [get]
+func (o GroupChat) MemberLogs(c *gin.Context) {
+	req := requests.QueryGroupChatMemberLogReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryMemberLogs(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryMemberLogs failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// ExportMemberLogs
+// @tags 客户群
+// @Summary 导出群成员入群退群记录
+// @Produce  json
+// @Param params query requests.QueryGroupChatMemberLogReq true "查询群成员变动记录请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/member_logs/action/export [get]
+func (o GroupChat) ExportMemberLogs(c *gin.Context) {
+	req := requests.QueryGroupChatMemberLogReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportMemberLogs(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportMemberLogs failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// Churns
+// @tags 客户群
+// @Summary 查询退群成员列表
+// @Produce  json
+// @Param params query requests.QueryGroupChatChurnReq true "查询退群成员请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.GroupChatMemberLog}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/member_churns [get]
+func (o GroupChat) Churns(c *gin.Context) {
+	req := requests.QueryGroupChatChurnReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryChurns(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryChurns failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// ExportChurns
+// @tags 客户群
+// @Summary 导出退群成员列表
+// @Produce  json
+// @Param params query requests.QueryGroupChatChurnReq true "查询退群成员请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/member_churns/action/export [get]
+func (o GroupChat) ExportChurns(c *gin.Context) {
+	req := requests.QueryGroupChatChurnReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportChurns(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportChurns failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// InviterRank
+// @tags 客户群
+// @Summary 员工邀请入群排行
+// @Produce  json
+// @Param params query requests.QueryGroupChatInviterRankReq true "查询邀请入群排行请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.GroupChatInviterRank}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/inviter_rank [get]
+func (o GroupChat) InviterRank(c *gin.Context) {
+	req := requests.QueryGroupChatInviterRankReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.QueryInviterRank(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryInviterRank failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// ExportInviterRank
+// @tags 客户群
+// @Summary 导出员工邀请入群排行
+// @Produce  json
+// @Param params query requests.QueryGroupChatInviterRankReq true "查询邀请入群排行请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/inviter_rank/action/export [get]
+func (o GroupChat) ExportInviterRank(c *gin.Context) {
+	req := requests.QueryGroupChatInviterRankReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportInviterRank(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportInviterRank failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
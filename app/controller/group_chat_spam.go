@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type GroupChatSpam struct {
+	Base
+	srv *services.GroupChatSpam
+}
+
+func NewGroupChatSpam() *GroupChatSpam {
+	return &GroupChatSpam{srv: services.NewGroupChatSpam()}
+}
+
+// QueryRules
+// @tags 客户群防骚扰
+// @Summary 查询客户群防骚扰规则
+// @Produce  json
+// @Param params query requests.QueryGroupChatSpamRuleReq true "查询防骚扰规则请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.GroupChatSpamRule}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/spam_rules [get]
+func (o *GroupChatSpam) QueryRules(c *gin.Context) {
+	req := requests.QueryGroupChatSpamRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryRules(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryRules failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// GetRule
+// @tags 客户群防骚扰
+// @Summary 获取客户群防骚扰规则详情
+// @Produce  json
+// @Param id path string true "规则ID"
+// @Success 200 {object} app.JSONResult{data=models.GroupChatSpamRule} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/spam_rule/{id} [get]
+func (o *GroupChatSpam) GetRule(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.GetRule(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "GetRule failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// CreateRule
+// @tags 客户群防骚扰
+// @Summary 创建客户群防骚扰规则
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateGroupChatSpamRuleReq true "创建防骚扰规则请求"
+// @Success 200 {object} app.JSONResult{data=models.GroupChatSpamRule} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/spam_rule [post]
+func (o *GroupChatSpam) CreateRule(c *gin.Context) {
+	req := requests.CreateGroupChatSpamRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.CreateRule(req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "CreateRule failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// UpdateRule
+// @tags 客户群防骚扰
+// @Summary 更新客户群防骚扰规则
+// @Produce  json
+// @Accept json
+// @Param id path string true "规则ID"
+// @Param params body requests.UpdateGroupChatSpamRuleReq true "更新防骚扰规则请求"
+// @Success 200 {object} app.JSONResult{data=models.GroupChatSpamRule} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/spam_rule/{id} [put]
+func (o *GroupChatSpam) UpdateRule(c *gin.Context) {
+	req := requests.UpdateGroupChatSpamRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.UpdateRule(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateRule failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// DeleteRules
+// @tags 客户群防骚扰
+// @Summary 删除客户群防骚扰规则
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteGroupChatSpamRuleReq true "删除防骚扰规则请求"
+// @Success 200 {object} app.JSONResult{data=bool} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/spam_rule/action/delete [post]
+func (o *GroupChatSpam) DeleteRules(c *gin.Context) {
+	req := requests.DeleteGroupChatSpamRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.DeleteRules(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteRules failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
+
+// QueryViolations
+// @tags 客户群防骚扰
+// @Summary 查询客户群违规记录
+// @Produce  json
+// @Param params query requests.QueryGroupChatViolationReq true "查询违规记录请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.GroupChatViolation}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/violations [get]
+func (o *GroupChatSpam) QueryViolations(c *gin.Context) {
+	req := requests.QueryGroupChatViolationReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryViolations(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryViolations failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// ExportViolations
+// @tags 客户群防骚扰
+// @Summary 导出客户群违规记录
+// @Produce  json
+// @Param params query requests.QueryGroupChatViolationReq true "查询违规记录请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/violations/action/export [get]
+func (o *GroupChatSpam) ExportViolations(c *gin.Context) {
+	req := requests.QueryGroupChatViolationReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.ExportViolations(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "ExportViolations failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
+
+// QueryBlacklist
+// @tags 客户群防骚扰
+// @Summary 查询客户群黑名单
+// @Produce  json
+// @Param params query requests.QueryGroupChatBlacklistReq true "查询黑名单请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.GroupChatBlacklist}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/blacklist [get]
+func (o *GroupChatSpam) QueryBlacklist(c *gin.Context) {
+	req := requests.QueryGroupChatBlacklistReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.QueryBlacklist(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryBlacklist failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// DeleteBlacklist
+// @tags 客户群防骚扰
+// @Summary 移出客户群黑名单，已打的客户标签不会移除
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteGroupChatBlacklistReq true "移出黑名单请求"
+// @Success 200 {object} app.JSONResult{data=bool} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/group_chat/blacklist/action/delete [post]
+func (o *GroupChatSpam) DeleteBlacklist(c *gin.Context) {
+	req := requests.DeleteGroupChatBlacklistReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.DeleteBlacklist(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteBlacklist failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
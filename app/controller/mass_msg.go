@@ -48,7 +48,7 @@ func (ch MassMsg) Create(c *gin.Context) {
 		handler.ResponseError(err)
 		return
 	}
-	msg, err := ch.srv.Create(req, staffAdminInfo.Name, staffAdminInfo.ExtCorpID)
+	msg, err := ch.srv.Create(req, staffAdminInfo)
 	if err != nil {
 		err := errors.Wrap(err, "send group msg failed")
 		handler.ResponseError(err)
@@ -92,19 +92,28 @@ func (ch MassMsg) Get(c *gin.Context) {
 // @tags 客户群发
 // @Summary 获取创建群发消息的结果
 // @Produce json
+// @Param id path string true "消息id"
+// @Param params query requests.QueryMassMsgResultReq true "查询群发结果请求"
 // @Success 200 {object} app.JSONResult{data=requests.SendMassMsgResp} "成功"
 // @Failure 400 {object} app.JSONResult{} "请求错误"
 // @Failure 500 {object} app.JSONResult{} "内部错误"
 // @Router /api/v1/staff-admin/customer/mass-msg/result/{id} [get]
 func (ch MassMsg) GetSendMassMsgResult(c *gin.Context) {
+	req := requests.QueryMassMsgResultReq{}
 	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
 	missionID, err := handler.GetIDParam()
 	if err != nil {
 		err = errors.Wrap(err, "handler.GetIDParam failed")
 		handler.ResponseBadRequestError(err)
 		return
 	}
-	result, err := ch.srv.GetSendMassMsgResult(missionID)
+	result, err := ch.srv.GetSendMassMsgResult(missionID, req)
 	if err != nil {
 		err = errors.Wrap(err, "get send group msg failed")
 		handler.ResponseError(err)
@@ -113,6 +122,47 @@ func (ch MassMsg) GetSendMassMsgResult(c *gin.Context) {
 	handler.ResponseItem(result)
 }
 
+// QueryVariantStats
+// @tags 客户群发
+// @Summary 查询群发各内容变体的送达和转化数据
+// @Produce json
+// @Param id path string true "消息id"
+// @Param params query requests.QueryMsgVariantStatsReq true "查询变体统计请求"
+// @Success 200 {object} app.JSONResult{data=[]requests.MsgVariantStat} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg/{id}/variant-stats [get]
+func (ch MassMsg) QueryVariantStats(c *gin.Context) {
+	req := requests.QueryMsgVariantStatsReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(err)
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	items, err := ch.srv.QueryVariantStats(id, req, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryVariantStats failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(items)
+}
+
 // Delete
 // @tags 客户群发
 // @Summary 删除定时群发的消息
@@ -142,6 +192,40 @@ func (ch MassMsg) Delete(c *gin.Context) {
 	handler.ResponseItem(nil)
 }
 
+// Cancel
+// @tags 客户群发
+// @Summary 取消群发消息
+// @Description 任意状态均可取消，已推送到企业微信的群发会停止，尚未发送的员工将无法再发送
+// @Param params body requests.CancelMassMsgReq true "取消群发请求"
+// @Produce json
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg/action/cancel [post]
+func (ch MassMsg) Cancel(c *gin.Context) {
+	req := requests.CancelMassMsgReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdminInfo, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	err = ch.srv.Cancel(req.IDs, staffAdminInfo.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "cancel mass msg failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(nil)
+}
+
 // Notify
 // @tags 客户群发
 // @Summary 提醒员工发送群发消息
@@ -212,7 +296,7 @@ func (ch MassMsg) Query(c *gin.Context) {
 // @Summary 群发消息-客户筛选
 // @Produce json
 // @Param params body  constants.ExtCustomerFilter true "群发消息-客户筛选请求"
-// @Success 200 {object} app.JSONResult{} "成功"
+// @Success 200 {object} app.JSONResult{data=requests.MassMsgCustomerFilterResp} "成功"
 // @Failure 400 {object} app.JSONResult{} "请求错误"
 // @Failure 500 {object} app.JSONResult{} "内部错误"
 // @Router /api/v1/staff-admin/customer/mass-msg/customer-filter [get]
@@ -225,13 +309,19 @@ func (ch MassMsg) CustomerFilter(c *gin.Context) {
 		return
 	}
 
-	_, total, err := ch.srv.GetStaffsCustomers([]string{}, req.ExtCustomerFilterEnable, req.ExtCustomerFilter)
+	info, err := ch.GetStaffAdminInfo(handler)
+	if err != nil {
+		handler.ResponseError(err)
+		return
+	}
+
+	res, err := ch.srv.CustomerFilter(req.ExtCustomerFilterEnable, req.ExtCustomerFilter, info.ExtCorpID)
 	if err != nil {
 		err := errors.Wrap(err, "query group msg failed")
 		handler.ResponseError(err)
 		return
 	}
-	handler.ResponseItem(total)
+	handler.ResponseItem(res)
 }
 
 // GetUploadUrl
@@ -318,7 +408,7 @@ func (ch MassMsg) Update(c *gin.Context) {
 		handler.ResponseError(err)
 		return
 	}
-	msgID, err := ch.srv.UpdateMassMsg(req, id, staffAdminInfo.Name, staffAdminInfo.ExtCorpID)
+	msgID, err := ch.srv.UpdateMassMsg(req, id, staffAdminInfo)
 	if err != nil {
 		err := errors.Wrap(err, "send group msg failed")
 		handler.ResponseError(err)
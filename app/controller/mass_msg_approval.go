@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type MassMsgApproval struct {
+	Base
+	srv *services.MassMsgApprovalService
+}
+
+func NewMassMsgApproval() *MassMsgApproval {
+	return &MassMsgApproval{srv: services.NewMassMsgApprovalService()}
+}
+
+// QueryRules
+// @tags 群发审批
+// @Summary 查询群发审批规则
+// @Produce  json
+// @Param params query requests.QueryMassMsgApprovalRuleReq true "查询群发审批规则请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.MassMsgApprovalRule}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval-rules [get]
+func (o *MassMsgApproval) QueryRules(c *gin.Context) {
+	req := requests.QueryMassMsgApprovalRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.QueryRules(staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "QueryRules failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// CreateRule
+// @tags 群发审批
+// @Summary 创建群发审批规则
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateMassMsgApprovalRuleReq true "创建群发审批规则请求"
+// @Success 200 {object} app.JSONResult{data=models.MassMsgApprovalRule} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval-rule [post]
+func (o *MassMsgApproval) CreateRule(c *gin.Context) {
+	req := requests.CreateMassMsgApprovalRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.CreateRule(req, staffAdmin.ExtID, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "CreateRule failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// UpdateRule
+// @tags 群发审批
+// @Summary 更新群发审批规则
+// @Produce  json
+// @Accept json
+// @Param id path string true "群发审批规则ID"
+// @Param params body requests.UpdateMassMsgApprovalRuleReq true "更新群发审批规则请求"
+// @Success 200 {object} app.JSONResult{data=models.MassMsgApprovalRule} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval-rule/{id} [put]
+func (o *MassMsgApproval) UpdateRule(c *gin.Context) {
+	req := requests.UpdateMassMsgApprovalRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.UpdateRule(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateRule failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// DeleteRules
+// @tags 群发审批
+// @Summary 删除群发审批规则
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteMassMsgApprovalRuleReq true "删除群发审批规则请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval-rule/action/delete [post]
+func (o *MassMsgApproval) DeleteRules(c *gin.Context) {
+	req := requests.DeleteMassMsgApprovalRuleReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.DeleteRules(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteRules failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// Query
+// @tags 群发审批
+// @Summary 查询群发审批单
+// @Produce  json
+// @Param params query requests.QueryMassMsgApprovalReq true "查询群发审批单请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.MassMsgApproval}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approvals [get]
+func (o *MassMsgApproval) Query(c *gin.Context) {
+	req := requests.QueryMassMsgApprovalReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Approve
+// @tags 群发审批
+// @Summary 通过群发审批
+// @Description 仅审批规则中的审批人可操作，通过后群发按原定时间发送
+// @Produce  json
+// @Accept json
+// @Param id path string true "群发审批单ID"
+// @Param params body requests.HandleMassMsgApprovalReq true "审批意见"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval/{id}/action/approve [post]
+func (o *MassMsgApproval) Approve(c *gin.Context) {
+	o.handle(c, o.srv.Approve)
+}
+
+// Reject
+// @tags 群发审批
+// @Summary 驳回群发审批
+// @Description 仅审批规则中的审批人可操作，驳回后群发不会发送
+// @Produce  json
+// @Accept json
+// @Param id path string true "群发审批单ID"
+// @Param params body requests.HandleMassMsgApprovalReq true "审批意见"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/mass-msg-approval/{id}/action/reject [post]
+func (o *MassMsgApproval) Reject(c *gin.Context) {
+	o.handle(c, o.srv.Reject)
+}
+
+func (o *MassMsgApproval) handle(
+	c *gin.Context, fn func(id string, req requests.HandleMassMsgApprovalReq, approver models.Staff) error) {
+	req := requests.HandleMassMsgApprovalReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = fn(id, req, staffAdmin)
+	if err != nil {
+		err = errors.Wrap(err, "handle mass msg approval failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type MsgTemplate struct {
+	Base
+	srv *services.MsgTemplate
+}
+
+func NewMsgTemplate() *MsgTemplate {
+	return &MsgTemplate{srv: services.NewMsgTemplate()}
+}
+
+// Preview
+// @tags 消息模板
+// @Summary 预览消息模板
+// @Description 用示例客户的数据渲染消息文本中的{{变量|默认值}}，用于群发、欢迎语和提醒
+// @Produce  json
+// @Accept json
+// @Param params body requests.PreviewMsgTemplateReq true "预览消息模板请求"
+// @Success 200 {object} app.JSONResult{data=string} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/msg-template/action/preview [post]
+func (o *MsgTemplate) Preview(c *gin.Context) {
+	req := requests.PreviewMsgTemplateReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	text, err := o.srv.Preview(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Preview failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(text)
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type PosterTemplate struct {
+	Base
+	srv *services.PosterTemplate
+}
+
+func NewPosterTemplate() *PosterTemplate {
+	return &PosterTemplate{srv: services.NewPosterTemplate()}
+}
+
+// Query
+// @tags 渠道码海报
+// @Summary 查询渠道码海报模板列表
+// @Produce  json
+// @Accept json
+// @Param params body requests.QueryPosterTemplateReq true "查询渠道码海报模板列表请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.PosterTemplate}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_templates [get]
+func (o *PosterTemplate) Query(c *gin.Context) {
+	req := requests.QueryPosterTemplateReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, total)
+}
+
+// Get
+// @tags 渠道码海报
+// @Summary 获取渠道码海报模板详情
+// @Produce  json
+// @Accept json
+// @Param id path string true "渠道码海报模板ID"
+// @Success 200 {object} app.JSONResult{data=models.PosterTemplate} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template/{id} [get]
+func (o *PosterTemplate) Get(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.Get(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// Create
+// @tags 渠道码海报
+// @Summary 创建渠道码海报模板
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreatePosterTemplateReq true "创建渠道码海报模板请求"
+// @Success 200 {object} app.JSONResult{data=models.PosterTemplate} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template [post]
+func (o *PosterTemplate) Create(c *gin.Context) {
+	req := requests.CreatePosterTemplateReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.Create(req, staffAdmin.ExtCorpID, staffAdmin.ExtID)
+	if err != nil {
+		err = errors.Wrap(err, "Create failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// Update
+// @tags 渠道码海报
+// @Summary 更新渠道码海报模板
+// @Produce  json
+// @Accept json
+// @Param id path string true "渠道码海报模板ID"
+// @Param params body requests.UpdatePosterTemplateReq true "更新渠道码海报模板请求"
+// @Success 200 {object} app.JSONResult{data=models.PosterTemplate} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template/{id} [put]
+func (o *PosterTemplate) Update(c *gin.Context) {
+	req := requests.UpdatePosterTemplateReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	item, err := o.srv.Update(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Update failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(item)
+}
+
+// Delete
+// @tags 渠道码海报
+// @Summary 删除渠道码海报模板
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeletePosterTemplateReq true "删除渠道码海报模板请求"
+// @Success 200 {object} app.JSONResult{data=bool} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template/action/delete [post]
+func (o *PosterTemplate) Delete(c *gin.Context) {
+	req := requests.DeletePosterTemplateReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(true)
+}
+
+// Generate
+// @tags 渠道码海报
+// @Summary 按模板生成渠道码海报
+// @Produce  json
+// @Accept json
+// @Param id path string true "海报模板ID"
+// @Param params body requests.GeneratePosterReq true "生成渠道码海报请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]responses.ContactWayPoster}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template/{id}/action/generate [post]
+func (o *PosterTemplate) Generate(c *gin.Context) {
+	req := requests.GeneratePosterReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.Generate(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Generate failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItems(items, int64(len(items)))
+}
+
+// Download
+// @tags 渠道码海报
+// @Summary 按模板批量生成渠道码海报并打包下载
+// @Produce  application/zip
+// @Param id path string true "海报模板ID"
+// @Param params query requests.GeneratePosterReq true "生成渠道码海报请求"
+// @Success 200 {file} file "zip文件"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff_admin/poster_template/{id}/action/download [get]
+func (o *PosterTemplate) Download(c *gin.Context) {
+	req := requests.GeneratePosterReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	buf, filename, err := o.srv.Download(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Download failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
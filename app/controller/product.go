@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type Product struct {
+	Base
+	srv *services.Product
+}
+
+func NewProduct() *Product {
+	return &Product{srv: services.NewProduct()}
+}
+
+// Query
+// @tags 商品图册
+// @Summary 查询商品图册
+// @Produce  json
+// @Param params query requests.QueryProductReq true "查询商品图册请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.Product}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/products [get]
+func (o *Product) Query(c *gin.Context) {
+	req := requests.QueryProductReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staffAdmin.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Get
+// @tags 商品图册
+// @Summary 商品图册详情
+// @Produce  json
+// @Param id path string true "商品ID"
+// @Success 200 {object} app.JSONResult{data=models.Product} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/product/{id} [get]
+func (o *Product) Get(c *gin.Context) {
+	handler := app.NewHandler(c)
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Get(id, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Create
+// @tags 商品图册
+// @Summary 创建商品图册
+// @Description 商品图片需先上传到文件存储，第一张图片作为商品封面
+// @Produce  json
+// @Accept json
+// @Param params body requests.CreateProductReq true "创建商品图册请求"
+// @Success 200 {object} app.JSONResult{data=models.Product} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/product [post]
+func (o *Product) Create(c *gin.Context) {
+	req := requests.CreateProductReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Create(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Create failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Update
+// @tags 商品图册
+// @Summary 更新商品图册
+// @Produce  json
+// @Accept json
+// @Param id path string true "商品ID"
+// @Param params body requests.UpdateProductReq true "更新商品图册请求"
+// @Success 200 {object} app.JSONResult{data=models.Product} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/product/{id} [put]
+func (o *Product) Update(c *gin.Context) {
+	req := requests.UpdateProductReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	item, err := o.srv.Update(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Update failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(item)
+}
+
+// Delete
+// @tags 商品图册
+// @Summary 删除商品图册
+// @Produce  json
+// @Accept json
+// @Param params body requests.DeleteProductReq true "删除商品图册请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/product/action/delete [post]
+func (o *Product) Delete(c *gin.Context) {
+	req := requests.DeleteProductReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Delete(req.IDs, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Delete failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// Sync
+// @tags 商品图册
+// @Summary 从企微同步商品图册
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "请求错误"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/product/action/sync [post]
+func (o *Product) Sync(c *gin.Context) {
+	handler := app.NewHandler(c)
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	err = o.srv.Sync(staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Sync failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItem(nil)
+}
+
+// QueryFrontend
+// @tags 商品图册
+// @Summary 侧边栏查询商品图册
+// @Produce  json
+// @Param params query requests.QueryProductReq true "查询商品图册请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.Product}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-frontend/products [get]
+func (o *Product) QueryFrontend(c *gin.Context) {
+	req := requests.QueryProductReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staff, err := o.GetStaffInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	items, total, err := o.srv.Query(req, staff.ExtCorpID, &req.Sorter, &req.Pager)
+	if err != nil {
+		err = errors.Wrap(err, "Query failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
@@ -181,7 +181,7 @@ func (o *Role) AssignToStaffs(c *gin.Context) {
 		return
 	}
 
-	total, err := o.srv.AssignToStaffs(req.ExtStaffIDs, req.RoleID)
+	total, err := o.srv.AssignToStaffs(req.ExtStaffIDs, req.RoleID, conf.Settings.WeWork.ExtCorpID)
 	if err != nil {
 		err = errors.Wrap(err, "AssignToStaffs failed")
 		handler.ResponseError(err)
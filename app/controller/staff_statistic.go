@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"openscrm/app/requests"
+	"openscrm/app/services"
+	"openscrm/common/app"
+	"openscrm/common/log"
+)
+
+type StaffStatistic struct {
+	Base
+	srv *services.StaffStatisticService
+}
+
+func NewStaffStatistic() *StaffStatistic {
+	return &StaffStatistic{srv: services.NewStaffStatisticService()}
+}
+
+// QueryStaffSummary
+// @tags 数据统计
+// @Summary 员工数据统计
+// @Description 联系客户统计和群聊数据统计，按员工汇总时间段内的数据
+// @Produce  json
+// @Param params query requests.QueryStaffBehaviorStatisticReq true "员工数据统计请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.StaffBehaviorSummary}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/staff-statistics [get]
+func (o StaffStatistic) QueryStaffSummary(c *gin.Context) {
+	req := requests.QueryStaffBehaviorStatisticReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	req.Pager.SetDefault()
+	items, total, err := o.srv.QueryStaffSummary(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryStaffSummary failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// QueryDepartmentSummary
+// @tags 数据统计
+// @Summary 部门数据统计
+// @Description 联系客户统计和群聊数据统计，按部门汇总时间段内的数据
+// @Produce  json
+// @Param params query requests.QueryStaffBehaviorStatisticReq true "部门数据统计请求"
+// @Success 200 {object} app.JSONResult{data=app.ItemsData{items=[]models.DepartmentBehaviorSummary}} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/department-statistics [get]
+func (o StaffStatistic) QueryDepartmentSummary(c *gin.Context) {
+	req := requests.QueryStaffBehaviorStatisticReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	req.Pager.SetDefault()
+	items, total, err := o.srv.QueryDepartmentSummary(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryDepartmentSummary failed")
+		handler.ResponseError(err)
+		return
+	}
+
+	handler.ResponseItems(items, total)
+}
+
+// Export
+// @tags 数据统计
+// @Summary 导出员工/部门数据统计
+// @Produce  json
+// @Param params query requests.ExportStaffBehaviorStatisticReq true "导出数据统计请求"
+// @Success 200 {object} app.JSONResult{} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/staff-statistics/action/export [get]
+func (o StaffStatistic) Export(c *gin.Context) {
+	req := requests.ExportStaffBehaviorStatisticReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		handler.ResponseError(err)
+		return
+	}
+
+	buf, filename, err := o.srv.Export(req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Export failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseFile(buf, filename)
+}
@@ -233,3 +233,44 @@ func (o *WelComeMsg) Get(c *gin.Context) {
 	}
 	handler.ResponseItem(item)
 }
+
+// QueryVariantStats
+// @tags 欢迎语
+// @Summary 查询欢迎语各内容变体的发送和转化数据
+// @Produce  json
+// @Param id path string true "欢迎语ID"
+// @Param params query requests.QueryMsgVariantStatsReq true "查询变体统计请求"
+// @Success 200 {object} app.JSONResult{data=[]requests.MsgVariantStat} "成功"
+// @Failure 400 {object} app.JSONResult{} "非法请求"
+// @Failure 500 {object} app.JSONResult{} "内部错误"
+// @Router /api/v1/staff-admin/customer/welcome-msg/{id}/variant-stats [get]
+func (o *WelComeMsg) QueryVariantStats(c *gin.Context) {
+	req := requests.QueryMsgVariantStatsReq{}
+	handler := app.NewHandler(c)
+	ok, err := handler.BindAndValidateReq(&req)
+	if !ok {
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	id, err := handler.GetIDParam()
+	if err != nil {
+		err = errors.Wrap(err, "handler.GetIDParam failed")
+		handler.ResponseBadRequestError(errors.WithStack(err))
+		return
+	}
+
+	staffAdmin, err := o.GetStaffAdminInfo(handler)
+	if err != nil {
+		log.TracedError("GetStaffAdminInfo failed", err)
+		return
+	}
+
+	items, err := o.srv.QueryVariantStats(id, req, staffAdmin.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "QueryVariantStats failed")
+		handler.ResponseError(err)
+		return
+	}
+	handler.ResponseItem(items)
+}
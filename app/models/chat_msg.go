@@ -8,6 +8,7 @@ import (
 	"openscrm/app/constants"
 	"openscrm/common/app"
 	"openscrm/common/util"
+	"strings"
 	"time"
 )
 
@@ -348,6 +349,38 @@ func (o ChatMsg) GetLatestSeq(extCorpID string) (maxSeq int64, err error) {
 	return int64(chatMsg.Seq), nil
 }
 
+// QueryGroupMsgsAfterSeq 按seq升序查询某seq之后发送的群聊消息
+func (o ChatMsg) QueryGroupMsgsAfterSeq(extCorpID string, seq int64, limit int) (msgs []ChatMsg, err error) {
+	msgs = make([]ChatMsg, 0)
+	err = DB.Model(&ChatMsg{}).Preload("ChatMsgContent").
+		Where("ext_corp_id = ? and seq > ? and room_id <> '' and action = ?", extCorpID, seq, "send").
+		Order("seq").Limit(limit).Find(&msgs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find ChatMsg failed")
+		return
+	}
+	// char类型的字段会补齐空格
+	for i := range msgs {
+		msgs[i].MsgID = strings.TrimSpace(msgs[i].MsgID)
+		msgs[i].From = strings.TrimSpace(msgs[i].From)
+		msgs[i].RoomID = strings.TrimSpace(msgs[i].RoomID)
+	}
+	return
+}
+
+// CountRoomMsgsBetween 统计群成员在一段时间内的发言条数，时间为毫秒时间戳
+func (o ChatMsg) CountRoomMsgsBetween(extCorpID string, roomID string, from string, start int64, end int64) (num int64, err error) {
+	err = DB.Model(&ChatMsg{}).
+		Where("ext_corp_id = ? and room_id = ? and \"from\" = ? and action = ?", extCorpID, roomID, from, "send").
+		Where("msg_time between ? and ?", start, end).
+		Count(&num).Error
+	if err != nil {
+		err = errors.Wrap(err, "Count ChatMsg failed")
+		return
+	}
+	return
+}
+
 func (o ChatMsg) QuerySessions(
 	extStaffID string, sessionType string, extCorpID string, sorter *app.Sorter, pager *app.Pager) (chatSessions []ChatSessions, total int64, err error) {
 
@@ -58,6 +58,20 @@ type ContactWay struct {
 	ScheduleEnable constants.Boolean `json:"schedule_enable" gorm:"comment:是否开启工作日调度" validate:"oneof=1 2"`
 	// StaffControlEnable 是否开启员工自行上下线
 	StaffControlEnable constants.Boolean `json:"staff_control_enable" gorm:"comment:是否开启员工自行上下线" validate:"oneof=1 2"`
+	// Status 状态 1-生效中 2-已过期 3-已达到添加上限
+	Status constants.ContactWayStatus `json:"status" gorm:"index;default:1;comment:状态 1-生效中 2-已过期 3-已达到添加上限"`
+	// ExpireAt 过期时间，为空则长期有效
+	ExpireAt *time.Time `json:"expire_at" gorm:"index;comment:过期时间"`
+	// DeactivateAttemptedAt 最近一次过期失效失败的时间，过期检查时排在其他渠道码之后重试
+	DeactivateAttemptedAt *time.Time `json:"-" gorm:"comment:最近一次过期失效失败的时间"`
+	// AddCustomerLimit 累计添加上限，0为不限制
+	AddCustomerLimit int `json:"add_customer_limit" gorm:"default:0;comment:累计添加上限" validate:"gte=0"`
+	// IsTemp 是否临时会话模式，临时会话只分配给一个员工
+	IsTemp constants.Boolean `json:"is_temp" gorm:"default:2;comment:是否临时会话模式"`
+	// ChatExpiresIn 临时会话有效期，秒
+	ChatExpiresIn int `json:"chat_expires_in" gorm:"default:0;comment:临时会话有效期"`
+	// DistributeStrategy 多人分配策略 1-企微随机分配 2-加权轮询 3-当前客户数最少优先 4-按顺序分配
+	DistributeStrategy constants.ContactWayDistributeStrategy `json:"distribute_strategy" gorm:"default:1;comment:多人分配策略" validate:"oneof=1 2 3 4"`
 	// Staffs 绑定员工
 	Staffs []ContactWayStaff `json:"staffs" gorm:"foreignKey:ContactWayID;"`
 	// BackupStaffs 绑定备份员工
@@ -207,9 +221,7 @@ func (o ContactWay) CalLatestStatus(item *ContactWay) (err error) {
 		item.SkipVerify = constants.True
 	}
 
-	item.ExtStaffIDs = make([]string, 0)
-
-	// 计算调度设置绑定的员工
+	// 调度时段的开始和结束由每分钟执行的调度检查任务触发刷新
 	if item.ScheduleEnable == constants.Enable {
 		// 每天凌晨触发工作时段控制
 		err = o.AddRefreshJob(item.ID, util.Today().Add(time.Hour*24+time.Second), "ScheduleEnable")
@@ -217,80 +229,33 @@ func (o ContactWay) CalLatestStatus(item *ContactWay) (err error) {
 			err = errors.Wrap(err, "AddRefreshJob failed")
 			return
 		}
-
-		for _, schedule := range item.Schedules {
-			// 工作周期开始工作时触发
-			err = o.AddRefreshJob(item.ID, util.Today().Add(schedule.StartTime.Duration()), "staff.StartTime")
-			if err != nil {
-				err = errors.Wrap(err, "AddRefreshJob failed")
-				return
-			}
-
-			//工作周期结束工作时触发
-			err = o.AddRefreshJob(item.ID, util.Today().Add(schedule.EndTime.Duration()), "staff.EndTime")
-			if err != nil {
-				err = errors.Wrap(err, "AddRefreshJob failed")
-				return
-			}
-
-			// 工作时间段以外，休息
-			if time.Now().Unix() < util.Today().Unix()+schedule.StartTime.Seconds() ||
-				time.Now().Unix() > util.Today().Unix()+schedule.EndTime.Seconds() {
-				continue
-			}
-
-			// 绑定调度计划设置的员工
-			for _, staff := range schedule.Staffs {
-				// 每日添加人数限制
-				if item.DailyAddCustomerLimitEnable.Bool() {
-					if staff.DailyAddCustomerLimit > 0 && staff.DailyAddCustomerLimit <= staff.DailyAddCustomerCount {
-						continue
-					}
-				}
-
-				// 员工自行上下线
-				if item.StaffControlEnable.Bool() {
-					if staff.Online == constants.False {
-						continue
-					}
-				}
-
-				item.ExtStaffIDs = append(item.ExtStaffIDs, staff.ExtStaffID)
-			}
-		}
 	}
 
-	// 计算普通绑定员工
-	if item.ScheduleEnable == constants.Disable {
-		for _, staff := range item.Staffs {
-			// 每日添加人数限制
-			if item.DailyAddCustomerLimitEnable.Bool() {
-				if staff.DailyAddCustomerLimit > 0 && staff.DailyAddCustomerLimit <= staff.DailyAddCustomerCount {
-					continue
-				}
-			}
+	item.ExtStaffIDs, err = o.ExpectedExtStaffIDs(item)
+	if err != nil {
+		return
+	}
 
-			// 员工自行上下线
-			if item.StaffControlEnable.Bool() {
-				if staff.Online == constants.False {
-					continue
-				}
-			}
+	return
+}
 
-			item.ExtStaffIDs = append(item.ExtStaffIDs, staff.ExtStaffID)
-		}
+// ExpectedExtStaffIDs 按当前的调度时段、员工在线状态和分配策略计算应接待的员工
+func (o ContactWay) ExpectedExtStaffIDs(item *ContactWay) ([]string, error) {
+	// 按分配策略从在线员工中选出实际接待的员工
+	candidates := o.OnlineCandidates(item)
+	extStaffIDs, err := o.distribute(item, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "distribute failed")
 	}
 
 	// 当没有有效关联员工时，使用备份员工
-	if len(item.ExtStaffIDs) == 0 {
+	if len(extStaffIDs) == 0 {
 		for _, staff := range item.BackupStaffs {
-			item.ExtStaffIDs = append(item.ExtStaffIDs, staff.ExtStaffID)
+			extStaffIDs = append(extStaffIDs, staff.ExtStaffID)
 		}
 	}
 
-	item.ExtStaffIDs = funk.UniqString(item.ExtStaffIDs)
-
-	return
+	return funk.UniqString(extStaffIDs), nil
 }
 
 func (o ContactWay) Create(param ContactWay, extCorpID string) (item ContactWay, err error) {
@@ -317,7 +282,7 @@ func (o ContactWay) Create(param ContactWay, extCorpID string) (item ContactWay,
 		return
 	}
 
-	item.ConfigID, err = client.Customer.AddContactWay(workwx.AddContactWay{
+	wxContactWayReq := workwx.AddContactWay{
 		IsTemp:     false,
 		Remark:     item.Remark,
 		Scene:      2,
@@ -325,7 +290,16 @@ func (o ContactWay) Create(param ContactWay, extCorpID string) (item ContactWay,
 		State:      item.State,
 		Type:       workwx.ContactWayTypeMultiple,
 		User:       item.ExtStaffIDs,
-	})
+	}
+	// 临时会话仅支持单人
+	if item.IsTemp == constants.True {
+		wxContactWayReq.IsTemp = true
+		wxContactWayReq.Type = workwx.ContactWayTypeSingle
+		wxContactWayReq.User = o.tempChatUsers(item)
+		wxContactWayReq.ExpiresIn = o.expiresIn(item)
+		wxContactWayReq.ChatExpiresIn = item.ChatExpiresIn
+	}
+	item.ConfigID, err = client.Customer.AddContactWay(wxContactWayReq)
 	if err != nil {
 		err = errors.Wrap(err, "wx AddContactWay failed")
 		return
@@ -357,6 +331,11 @@ func (o ContactWay) Update(id string, param ContactWay, extCorpID string) (item
 		err = errors.Wrap(err, "get ContactWay failed")
 		return
 	}
+	// 企微配置已删除，不能再修改
+	if item.Status > constants.ContactWayStatusActive {
+		err = errors.WithStack(ecode.ContactWayInactiveErr)
+		return
+	}
 
 	// 对于关联数据的处理行为是，带主键ID的进行修改，没有带主键ID进行添加，数据库里存在但请求没有带的记录进行删除
 	//err = DeleteRefRecord(tx, &ContactWayStaff{}, item.Staffs, param.Staffs, "ID")
@@ -377,11 +356,29 @@ func (o ContactWay) Update(id string, param ContactWay, extCorpID string) (item
 	//	return
 	//}
 
+	// 企微不支持修改是否临时会话
+	isTemp := item.IsTemp
 	err = copier.CopyWithOption(&item, param, copier.Option{IgnoreEmpty: true})
 	if err != nil {
 		err = errors.Wrap(err, "copy param failed")
 		return
 	}
+	item.IsTemp = isTemp
+
+	// 解绑的员工需删除其单人二维码
+	unboundExtStaffIDs := make([]string, 0)
+	for _, staff := range item.Staffs {
+		bound := false
+		for _, newStaff := range param.Staffs {
+			if newStaff.ExtStaffID == staff.ExtStaffID {
+				bound = true
+				break
+			}
+		}
+		if !bound {
+			unboundExtStaffIDs = append(unboundExtStaffIDs, staff.ExtStaffID)
+		}
+	}
 
 	item.Staffs = param.Staffs
 	item.BackupStaffs = param.BackupStaffs
@@ -398,18 +395,17 @@ func (o ContactWay) Update(id string, param ContactWay, extCorpID string) (item
 		return
 	}
 
-	_, err = client.Customer.UpdateContactWay(workwx.UpdateContactWay{
-		ConfigID:   item.ConfigID,
-		Remark:     item.Remark,
-		SkipVerify: item.SkipVerify == constants.True,
-		State:      item.State,
-		User:       item.ExtStaffIDs,
-	})
+	_, err = client.Customer.UpdateContactWay(o.wxUpdateReq(item))
 	if err != nil {
 		err = errors.Wrap(err, "wx UpdateContactWay failed")
 		return
 	}
 
+	err = ContactWaySubChannel{}.SyncConfig(tx, item)
+	if err != nil {
+		return
+	}
+
 	//err = tx.Save(&item).Error
 	//if err != nil {
 	//	err = errors.Wrap(err, "save ContactWay failed")
@@ -434,6 +430,13 @@ func (o ContactWay) Update(id string, param ContactWay, extCorpID string) (item
 		return
 	}
 
+	if len(unboundExtStaffIDs) > 0 {
+		err = ContactWayStaffQrCode{}.Delete(tx, extCorpID, []string{item.ID}, unboundExtStaffIDs)
+		if err != nil {
+			return
+		}
+	}
+
 	for i := range item.Schedules {
 		err = tx.Model(&item.Schedules[i]).Association("Staffs").Replace(&item.Schedules[i].Staffs)
 		if err != nil {
@@ -459,7 +462,7 @@ func (o ContactWay) Update(id string, param ContactWay, extCorpID string) (item
 
 // Refresh 计算渠道码最新状态并保存
 func (o ContactWay) Refresh(tx *gorm.DB, id string) (item ContactWay, err error) {
-	err = tx.Where("id = ?", id).First(&item).Error
+	err = tx.Preload(clause.Associations).Preload("Schedules.Staffs").Where("id = ?", id).First(&item).Error
 	// 如果找不到数据，不返回错误，直接跳过任务，重试没意义
 	if err == gorm.ErrRecordNotFound {
 		err = nil
@@ -469,7 +472,12 @@ func (o ContactWay) Refresh(tx *gorm.DB, id string) (item ContactWay, err error)
 		err = errors.Wrap(err, "First ContactWay failed")
 		return
 	}
+	// 已失效的渠道码企微配置已删除，无需刷新
+	if item.Status > constants.ContactWayStatusActive {
+		return
+	}
 
+	oldExtStaffIDs := item.ExtStaffIDs
 	err = o.CalLatestStatus(&item)
 	if err != nil {
 		err = errors.Wrap(err, "CalLatestStatus failed")
@@ -482,18 +490,20 @@ func (o ContactWay) Refresh(tx *gorm.DB, id string) (item ContactWay, err error)
 		return
 	}
 
-	_, err = client.Customer.UpdateContactWay(workwx.UpdateContactWay{
-		ConfigID:   item.ConfigID,
-		Remark:     item.Remark,
-		SkipVerify: item.SkipVerify == constants.True,
-		State:      item.State,
-		User:       item.ExtStaffIDs,
-	})
+	_, err = client.Customer.UpdateContactWay(o.wxUpdateReq(item))
 	if err != nil {
 		err = errors.Wrap(err, "wx UpdateContactWay failed")
 		return
 	}
 
+	// 刷新只会改变接待员工，员工不变时无需逐个更新子渠道
+	if !sameStaffIDs(oldExtStaffIDs, item.ExtStaffIDs) {
+		err = ContactWaySubChannel{}.SyncConfig(tx, item)
+		if err != nil {
+			return
+		}
+	}
+
 	err = tx.Omit(clause.Associations).Save(&item).Error
 	if err != nil {
 		err = errors.Wrap(err, "Save ContactWay failed")
@@ -512,6 +522,11 @@ func (o ContactWay) Delete(ids []string, extCorpID string) (total int64, err err
 	}
 	total = result.RowsAffected
 
+	err = ContactWayStaffQrCode{}.Delete(DB, extCorpID, ids, nil)
+	if err != nil {
+		return
+	}
+
 	return
 }
 
@@ -546,21 +561,28 @@ func (o ContactWay) StaffOnline(id string, extCorpID string, extStaffID string,
 		return
 	}
 
-	contactWayStaff := ContactWayStaff{}
-	err = DB.Where("contact_way_id = ?", item.ID).Where("ext_staff_id = ?", extStaffID).First(&contactWayStaff).Error
-	if err == gorm.ErrRecordNotFound {
-		err = errors.WithStack(ecode.ItemNotFoundError)
-		return
+	// 普通绑定和调度设置中的该员工一起上下线
+	var total int64
+	for _, model := range []interface{}{&ContactWayStaff{}, &ContactWayScheduleStaff{}} {
+		result := DB.Model(model).Where("contact_way_id = ?", item.ID).Where("ext_staff_id = ?", extStaffID).
+			Update("online", online)
+		if result.Error != nil {
+			err = errors.Wrap(result.Error, "update staff online failed")
+			return
+		}
+		total += result.RowsAffected
 	}
-	if err != nil {
-		err = errors.Wrap(err, "First contactWayStaff failed")
+	if total == 0 {
+		err = errors.WithStack(ecode.ItemNotFoundError)
 		return
 	}
 
-	err = DB.Model(&contactWayStaff).Update("online", online).Error
-	if err != nil {
-		err = errors.Wrap(err, "update ContactWay failed")
-		return
+	// 下线的员工不再接待，删除其单人二维码，上线后生成海报时重新生成
+	if online == constants.False {
+		err = ContactWayStaffQrCode{}.Delete(DB, extCorpID, []string{item.ID}, []string{extStaffID})
+		if err != nil {
+			return
+		}
 	}
 
 	return
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"time"
+)
+
+// ContactWayAddRecord 渠道码添加客户记录，客户每次通过渠道码添加员工时写入一条，用于渠道分析
+// 与渠道码上的累计计数不同，此表不会被每日清理
+type ContactWayAddRecord struct {
+	ExtCorpModel
+	// 渠道码ID
+	ContactWayID string `json:"contact_way_id" gorm:"type:bigint;index:idx_add_record_contact_way_id_date;comment:渠道码ID"`
+	// 子渠道ID，通过渠道码本身的二维码添加时为空
+	SubChannelID string `json:"sub_channel_id" gorm:"type:varchar(64);index;comment:子渠道ID"`
+	// 员工ID
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);index;comment:员工ID"`
+	// 客户ID
+	ExtCustomerID string `json:"ext_customer_id" gorm:"type:varchar(64);index;comment:客户ID"`
+	// 添加时间
+	AddedAt time.Time `json:"added_at" gorm:"comment:添加时间"`
+	// 添加日期
+	Date constants.DateField `json:"date" gorm:"type:date;index:idx_add_record_contact_way_id_date;comment:添加日期"`
+	Timestamp
+}
+
+// ContactWayAnalytics 渠道码添加客户的留存和转化指标
+// 流失、打标签、聊天均统计客户添加后N天内的数据
+type ContactWayAnalytics struct {
+	// 添加人次
+	AddNum int64 `json:"add_num"`
+	// 添加客户数，按客户去重
+	CustomerNum int64 `json:"customer_num"`
+	// N天内删除员工的人次
+	LostNum int64 `json:"lost_num"`
+	// N天内未流失的人次
+	RetainedNum int64 `json:"retained_num"`
+	// N天内被打上渠道码自动标签以外标签的人次
+	TaggedNum int64 `json:"tagged_num"`
+	// N天内给员工发过消息的人次
+	ActiveNum int64 `json:"active_num"`
+}
+
+// ContactWayDailyAnalytics 渠道码每日指标
+type ContactWayDailyAnalytics struct {
+	Date string `json:"date"`
+	ContactWayAnalytics
+}
+
+// ContactWayStaffAnalytics 渠道码员工维度指标
+type ContactWayStaffAnalytics struct {
+	ExtStaffID string `json:"ext_staff_id"`
+	StaffName  string `json:"staff_name"`
+	ContactWayAnalytics
+}
+
+func (o ContactWayAddRecord) Create(tx *gorm.DB, record ContactWayAddRecord) error {
+	err := tx.Create(&record).Error
+	if err != nil {
+		return errors.Wrap(err, "Create ContactWayAddRecord failed")
+	}
+	return nil
+}
+
+// analyticsDB 按条件筛选添加记录，并计算每条记录的流失、打标签、聊天情况
+func (o ContactWayAddRecord) analyticsDB(
+	contactWay ContactWay, req requests.QueryContactWayAnalyticsReq) *gorm.DB {
+	base := DB.Model(&ContactWayAddRecord{}).
+		Where("ext_corp_id = ? and contact_way_id = ? and date between ? and ?",
+			contactWay.ExtCorpID, contactWay.ID, req.StartTime, req.EndTime)
+	if len(req.ExtStaffIDs) > 0 {
+		base = base.Where("ext_staff_id in (?)", req.ExtStaffIDs)
+	}
+	if len(req.SubChannelIDs) > 0 {
+		base = base.Where("sub_channel_id in (?)", req.SubChannelIDs)
+	}
+
+	lost := "exists (select 1 from customer_staff_relation_history h " +
+		"where h.ext_corp_id = r.ext_corp_id and h.ext_staff_id = r.ext_staff_id and h.ext_customer_id = r.ext_customer_id " +
+		"and h.customer_delete_staff_at >= r.added_at and h.customer_delete_staff_at < r.added_at + make_interval(days => ?))"
+	tagged := "exists (select 1 from customer_staff cs join customer_staff_tag cst on cst.customer_staff_id = cs.id " +
+		"where cs.ext_corp_id = r.ext_corp_id and cs.ext_staff_id = r.ext_staff_id and cs.ext_customer_id = r.ext_customer_id " +
+		"and not (cst.ext_tag_id in (?)) " +
+		"and cst.created_at >= r.added_at and cst.created_at < r.added_at + make_interval(days => ?))"
+	active := "exists (select 1 from chat_msg m where m.ext_corp_id = r.ext_corp_id and m.room_id = '' " +
+		"and m.\"from\" = r.ext_customer_id and m.to_list @> jsonb_build_array(r.ext_staff_id) " +
+		"and m.msg_time >= extract(epoch from r.added_at) * 1000 " +
+		"and m.msg_time < extract(epoch from r.added_at + make_interval(days => ?)) * 1000)"
+
+	// 渠道码未配置自动标签时，用空字符串占位避免 in () 语法错误
+	autoTagIDs := []string(contactWay.CustomerTagExtIDs)
+	if len(autoTagIDs) == 0 {
+		autoTagIDs = []string{""}
+	}
+
+	return DB.Table("(?) as r", base).
+		Select("r.*, "+lost+" as lost, "+tagged+" as tagged, "+active+" as active",
+			req.RetentionDays, autoTagIDs, req.RetentionDays, req.RetentionDays)
+}
+
+// analyticsColumns 汇总指标的字段，需在analyticsDB的结果t上使用
+const analyticsColumns = "count(*) as add_num, count(distinct t.ext_customer_id) as customer_num, " +
+	"count(*) filter (where t.lost) as lost_num, count(*) filter (where not t.lost) as retained_num, " +
+	"count(*) filter (where t.tagged) as tagged_num, count(*) filter (where t.active) as active_num"
+
+// QuerySummary 渠道码在时间段内的汇总指标
+func (o ContactWayAddRecord) QuerySummary(
+	contactWay ContactWay, req requests.QueryContactWayAnalyticsReq) (item ContactWayAnalytics, err error) {
+	err = DB.Table("(?) as t", o.analyticsDB(contactWay, req)).
+		Select(analyticsColumns).
+		Scan(&item).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query ContactWayAnalytics summary failed")
+		return
+	}
+	return
+}
+
+// QueryDaily 渠道码每日指标，按日期升序
+func (o ContactWayAddRecord) QueryDaily(
+	contactWay ContactWay, req requests.QueryContactWayAnalyticsReq) (items []ContactWayDailyAnalytics, err error) {
+	err = DB.Table("(?) as t", o.analyticsDB(contactWay, req)).
+		Select("to_char(t.date, 'YYYY-MM-DD') as date, " + analyticsColumns).
+		Group("t.date").
+		Order("t.date").
+		Scan(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query ContactWayAnalytics daily failed")
+		return
+	}
+	return
+}
+
+// QueryStaffs 渠道码员工维度指标，按添加人次降序
+func (o ContactWayAddRecord) QueryStaffs(
+	contactWay ContactWay, req requests.QueryContactWayAnalyticsReq) (items []ContactWayStaffAnalytics, err error) {
+	err = DB.Table("(?) as t", o.analyticsDB(contactWay, req)).
+		Joins("left join staff s on s.ext_id = t.ext_staff_id and s.ext_corp_id = t.ext_corp_id").
+		Select("t.ext_staff_id, max(s.name) as staff_name, " + analyticsColumns).
+		Group("t.ext_staff_id").
+		Order("add_num desc, t.ext_staff_id").
+		Scan(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query ContactWayAnalytics staffs failed")
+		return
+	}
+	return
+}
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"openscrm/app/constants"
+	"openscrm/common/log"
+	"openscrm/common/util"
+	"sort"
+	"time"
+)
+
+// ContactWayDistributeCandidate 渠道码当前可接待客户的员工
+type ContactWayDistributeCandidate struct {
+	ExtStaffID string `json:"ext_staff_id"`
+	Name       string `json:"name"`
+	// 分配权重
+	Weight int `json:"weight"`
+	// 排序
+	Sort int `json:"sort"`
+	// 每日添加上限，0为不限制
+	DailyAddCustomerLimit int `json:"daily_add_customer_limit"`
+	// 今日通过此渠道码添加的人次
+	TodayAddNum int64 `json:"today_add_num"`
+	// 当前客户数
+	CustomerNum int64 `json:"customer_num"`
+}
+
+// ContactWayDistributeStep 模拟的单次添加分配结果
+type ContactWayDistributeStep struct {
+	// 第几次添加
+	Seq int `json:"seq"`
+	// 可能分配到的员工，企微随机分配时为全部在线员工
+	ExtStaffIDs []string `json:"ext_staff_ids"`
+}
+
+// OnlineCandidates 计算渠道码当前在线的员工，不含备份员工
+// Detail: 排除工作日和工作时段以外、达到每日添加上限、自行下线的员工，结果按排序升序
+func (o ContactWay) OnlineCandidates(item *ContactWay) []ContactWayDistributeCandidate {
+	candidates := make([]ContactWayDistributeCandidate, 0)
+	appendCandidate := func(extStaffID string, name string, weight int, sort int,
+		limit int, count int, online constants.Boolean) {
+		if !item.DailyAddCustomerLimitEnable.Bool() {
+			limit = 0
+		}
+		// 每日添加人数限制
+		if limit > 0 && limit <= count {
+			return
+		}
+		// 员工自行上下线
+		if item.StaffControlEnable.Bool() && online == constants.False {
+			return
+		}
+		candidates = append(candidates, ContactWayDistributeCandidate{
+			ExtStaffID:            extStaffID,
+			Name:                  name,
+			Weight:                weight,
+			Sort:                  sort,
+			DailyAddCustomerLimit: limit,
+		})
+	}
+
+	// 调度设置绑定的员工，工作日和工作时间段以外休息，节假日和调休按企业日历
+	if item.ScheduleEnable == constants.Enable {
+		now := time.Now()
+		weekday, isHoliday, err := CorpCalendarDay{}.ResolveWeekday(item.ExtCorpID, now)
+		if err != nil {
+			log.Sugar.Errorw("ResolveWeekday failed", "err", err, "contactWayID", item.ID)
+		}
+		for _, schedule := range item.Schedules {
+			if isHoliday || !schedule.ActiveAt(now, weekday) {
+				continue
+			}
+			for _, staff := range schedule.Staffs {
+				appendCandidate(staff.ExtStaffID, staff.Name, staff.Weight, staff.Sort,
+					staff.DailyAddCustomerLimit, staff.DailyAddCustomerCount, staff.Online)
+			}
+		}
+	}
+
+	// 普通绑定员工
+	if item.ScheduleEnable == constants.Disable {
+		for _, staff := range item.Staffs {
+			appendCandidate(staff.ExtStaffID, staff.Name, staff.Weight, staff.Sort,
+				staff.DailyAddCustomerLimit, staff.DailyAddCustomerCount, staff.Online)
+		}
+	}
+
+	// 同一员工在多个调度时段中出现时只保留第一个
+	uniq := make([]ContactWayDistributeCandidate, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if seen[candidate.ExtStaffID] {
+			continue
+		}
+		seen[candidate.ExtStaffID] = true
+		if candidate.Weight <= 0 {
+			candidate.Weight = 1
+		}
+		uniq = append(uniq, candidate)
+	}
+	sort.SliceStable(uniq, func(i, j int) bool {
+		return uniq[i].Sort < uniq[j].Sort
+	})
+
+	return uniq
+}
+
+// distribute 按分配策略选出接待员工，企微随机分配时返回全部在线员工
+func (o ContactWay) distribute(item *ContactWay, candidates []ContactWayDistributeCandidate) ([]string, error) {
+	extStaffIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		extStaffIDs = append(extStaffIDs, candidate.ExtStaffID)
+	}
+	if item.DistributeStrategy <= constants.ContactWayDistributeRandom || len(candidates) <= 1 {
+		return funk.UniqString(extStaffIDs), nil
+	}
+
+	err := o.LoadDistributeStats(item, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// 都已达到每日上限时返回空，由备份员工接待
+	i := PickDistributeCandidate(item.DistributeStrategy, candidates)
+	if i < 0 {
+		return []string{}, nil
+	}
+	return []string{candidates[i].ExtStaffID}, nil
+}
+
+// LoadDistributeStats 查询员工今日通过此渠道码的添加人次和当前客户数
+func (o ContactWay) LoadDistributeStats(item *ContactWay, candidates []ContactWayDistributeCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	extStaffIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		extStaffIDs = append(extStaffIDs, candidate.ExtStaffID)
+	}
+
+	type staffNum struct {
+		ExtStaffID string
+		Num        int64
+	}
+	todayAddNums := make([]staffNum, 0)
+	err := DB.Model(&ContactWayAddRecord{}).
+		Select("ext_staff_id, count(*) as num").
+		Where("contact_way_id = ? and date = ? and ext_staff_id in (?)",
+			item.ID, util.Today().Format(constants.DateLayout), extStaffIDs).
+		Group("ext_staff_id").
+		Scan(&todayAddNums).Error
+	if err != nil {
+		return errors.Wrap(err, "Query ContactWayAddRecord num failed")
+	}
+
+	customerNums := make([]staffNum, 0)
+	err = DB.Model(&CustomerStaff{}).
+		Select("ext_staff_id, count(*) as num").
+		Where("ext_corp_id = ? and ext_staff_id in (?)", item.ExtCorpID, extStaffIDs).
+		Group("ext_staff_id").
+		Scan(&customerNums).Error
+	if err != nil {
+		return errors.Wrap(err, "Query CustomerStaff num failed")
+	}
+
+	todayAddNumMap := make(map[string]int64)
+	for _, num := range todayAddNums {
+		todayAddNumMap[num.ExtStaffID] = num.Num
+	}
+	customerNumMap := make(map[string]int64)
+	for _, num := range customerNums {
+		customerNumMap[num.ExtStaffID] = num.Num
+	}
+	for i := range candidates {
+		candidates[i].TodayAddNum = todayAddNumMap[candidates[i].ExtStaffID]
+		candidates[i].CustomerNum = customerNumMap[candidates[i].ExtStaffID]
+	}
+	return nil
+}
+
+// PickDistributeCandidate 按分配策略选出下一个接待的员工，返回其下标，没有可分配的员工时返回-1
+// Detail: 今日添加人次已达到每日上限的员工不参与分配，条件相同时排序靠前的员工优先
+func PickDistributeCandidate(strategy constants.ContactWayDistributeStrategy, candidates []ContactWayDistributeCandidate) int {
+	picked := -1
+	for i, candidate := range candidates {
+		if candidate.DailyAddCustomerLimit > 0 && candidate.TodayAddNum >= int64(candidate.DailyAddCustomerLimit) {
+			continue
+		}
+		if picked < 0 {
+			picked = i
+			if strategy == constants.ContactWayDistributeInOrder {
+				return picked
+			}
+			continue
+		}
+
+		current := candidates[picked]
+		switch strategy {
+		case constants.ContactWayDistributeWeighted:
+			// 比较 今日添加人次/权重，交叉相乘避免精度问题
+			if candidate.TodayAddNum*int64(current.Weight) < current.TodayAddNum*int64(candidate.Weight) {
+				picked = i
+			}
+		case constants.ContactWayDistributeLeastCustomers:
+			if candidate.CustomerNum < current.CustomerNum ||
+				(candidate.CustomerNum == current.CustomerNum && candidate.TodayAddNum < current.TodayAddNum) {
+				picked = i
+			}
+		}
+	}
+	return picked
+}
+
+// SimulateDistribution 模拟接下来num次添加的分配结果，不会修改渠道码
+// Detail: 假设期间员工在线状态和工作时段不变，每次添加后累加员工的今日添加人次和客户数
+func (o ContactWay) SimulateDistribution(item ContactWay, num int) (
+	steps []ContactWayDistributeStep, candidates []ContactWayDistributeCandidate, err error) {
+	candidates = o.OnlineCandidates(&item)
+	err = o.LoadDistributeStats(&item, candidates)
+	if err != nil {
+		return
+	}
+
+	backupExtStaffIDs := make([]string, 0, len(item.BackupStaffs))
+	for _, staff := range item.BackupStaffs {
+		backupExtStaffIDs = append(backupExtStaffIDs, staff.ExtStaffID)
+	}
+
+	steps = make([]ContactWayDistributeStep, 0, num)
+	for seq := 1; seq <= num; seq++ {
+		step := ContactWayDistributeStep{Seq: seq, ExtStaffIDs: make([]string, 0)}
+		picked := -1
+		if item.DistributeStrategy > constants.ContactWayDistributeRandom {
+			picked = PickDistributeCandidate(item.DistributeStrategy, candidates)
+		}
+
+		switch {
+		case picked >= 0:
+			step.ExtStaffIDs = append(step.ExtStaffIDs, candidates[picked].ExtStaffID)
+			candidates[picked].TodayAddNum++
+			candidates[picked].CustomerNum++
+		default:
+			for _, candidate := range candidates {
+				if candidate.DailyAddCustomerLimit > 0 && candidate.TodayAddNum >= int64(candidate.DailyAddCustomerLimit) {
+					continue
+				}
+				step.ExtStaffIDs = append(step.ExtStaffIDs, candidate.ExtStaffID)
+			}
+		}
+		// 在线员工都已达到上限时使用备份员工
+		if len(step.ExtStaffIDs) == 0 {
+			step.ExtStaffIDs = append(step.ExtStaffIDs, backupExtStaffIDs...)
+		}
+		steps = append(steps, step)
+	}
+
+	return
+}
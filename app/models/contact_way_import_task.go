@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/ecode"
+)
+
+// ContactWayImportTask 渠道码批量导入任务，校验通过的行由后台任务逐个创建企微配置
+type ContactWayImportTask struct {
+	ExtCorpModel
+	// 导入文件在文件存储中的ObjectKey
+	ObjectKey string `json:"object_key" gorm:"type:varchar(255);comment:导入文件ObjectKey"`
+	// 任务状态 creating-导入中 success-已完成 failed-失败
+	Status constants.AsyncTaskStatus `json:"status" gorm:"type:varchar(16);comment:任务状态"`
+	// 总行数
+	Total int `json:"total" gorm:"comment:总行数"`
+	// 校验未通过的行数
+	InvalidNum int `json:"invalid_num" gorm:"comment:校验未通过的行数"`
+	// 导入成功的行数
+	SuccessNum int `json:"success_num" gorm:"comment:导入成功的行数"`
+	// 导入失败的行数
+	FailedNum int `json:"failed_num" gorm:"comment:导入失败的行数"`
+	// 逐行的校验和导入结果
+	Rows ContactWayImportRows `json:"rows" gorm:"type:jsonb;comment:逐行导入结果"`
+	Timestamp
+}
+
+// ContactWayImportRow 渠道码导入的单行结果
+type ContactWayImportRow struct {
+	// 表格中的行号
+	Row int `json:"row"`
+	// 渠道码名称
+	Name string `json:"name"`
+	// 渠道码ID，更新时为表格中的ID，创建成功后为新渠道码的ID
+	ContactWayID string `json:"contact_way_id"`
+	// 状态 invalid-校验未通过 pending-等待导入 success-成功 failed-失败
+	Status constants.ContactWayImportRowStatus `json:"status"`
+	// 错误信息
+	Errors []string `json:"errors"`
+	// 校验通过后待执行的创建或更新参数，执行后清空
+	Req *requests.CreateContactWayReq `json:"req,omitempty"`
+}
+
+type ContactWayImportRows []ContactWayImportRow
+
+func (o ContactWayImportRows) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	return string(b), err
+}
+
+func (o *ContactWayImportRows) Scan(input interface{}) error {
+	return json.Unmarshal(input.([]byte), o)
+}
+
+func (o ContactWayImportRows) GormDataType() string {
+	return "json"
+}
+
+func (o ContactWayImportTask) Get(id string, extCorpID string) (item ContactWayImportTask, err error) {
+	db := DB.Model(&ContactWayImportTask{}).Where("id = ?", id)
+	if extCorpID != "" {
+		db = db.Where("ext_corp_id = ?", extCorpID)
+	}
+	err = db.First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First ContactWayImportTask failed")
+		return
+	}
+	return
+}
+
+func (o ContactWayImportTask) Create(item ContactWayImportTask) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create ContactWayImportTask failed")
+	}
+	return nil
+}
+
+// UpdateProgress 保存导入进度和逐行结果
+func (o ContactWayImportTask) UpdateProgress(item ContactWayImportTask) error {
+	err := DB.Model(&ContactWayImportTask{}).
+		Where("id = ?", item.ID).
+		Select("status", "success_num", "failed_num", "rows").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update ContactWayImportTask failed")
+	}
+	return nil
+}
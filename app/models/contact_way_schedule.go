@@ -1,9 +1,11 @@
 package models
 
 import (
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"openscrm/app/constants"
+	"time"
 )
 
 // ContactWaySchedule 渠道码调度设置（根据时间自动上下线员工）
@@ -38,3 +40,37 @@ func (o ContactWaySchedule) BeforeCreate(tx *gorm.DB) (err error) {
 	})
 	return nil
 }
+
+// ActiveAt t时刻调度时段是否生效，weekday为按企业日历调休后的星期，包含开始和结束时刻
+func (o ContactWaySchedule) ActiveAt(t time.Time, weekday time.Weekday) bool {
+	if !WeekdayIn(weekday, o.Weekdays) {
+		return false
+	}
+	t = t.In(constants.PRCLocation)
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, constants.PRCLocation)
+	seconds := t.Unix() - dayStart.Unix()
+	return seconds >= o.StartTime.Seconds() && seconds <= o.EndTime.Seconds()
+}
+
+// FindScheduleEnabled 分批查询开启调度的生效中渠道码，加载调度时段及其员工和备份员工
+func (o ContactWay) FindScheduleEnabled(fn func(items []ContactWay) error) error {
+	items := make([]ContactWay, 0)
+	err := DB.Model(&ContactWay{}).Preload("Schedules.Staffs").Preload("BackupStaffs").
+		Where("schedule_enable = ? and status = ?", constants.Enable, constants.ContactWayStatusActive).
+		FindInBatches(&items, 200, func(tx *gorm.DB, batch int) error {
+			return fn(items)
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, "FindInBatches ContactWay failed")
+	}
+	return nil
+}
+
+// ScheduleStaffChanged 当前应接待的员工与已保存的接待员工是否不一致
+func (o ContactWay) ScheduleStaffChanged(item *ContactWay) (bool, error) {
+	expected, err := o.ExpectedExtStaffIDs(item)
+	if err != nil {
+		return false, err
+	}
+	return !sameStaffIDs(expected, item.ExtStaffIDs), nil
+}
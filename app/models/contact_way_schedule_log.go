@@ -0,0 +1,104 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"openscrm/app/constants"
+	"openscrm/common/app"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+)
+
+// ContactWayScheduleLog 渠道码接待员工变更记录，调度时段切换和员工自行上下线时记录
+type ContactWayScheduleLog struct {
+	ExtCorpModel
+	// 渠道码ID
+	ContactWayID string `json:"contact_way_id" gorm:"index;type:bigint;comment:渠道码ID"`
+	// 触发来源 schedule-调度时段切换 staff_online-员工自行上线 staff_offline-员工自行下线
+	Trigger constants.ContactWayScheduleLogTrigger `json:"trigger" gorm:"type:varchar(16);comment:触发来源"`
+	// 自行上下线的员工ExtID
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);comment:自行上下线的员工ExtID"`
+	// 变更前的接待员工
+	BeforeExtStaffIDs constants.StringArrayField `json:"before_ext_staff_ids" gorm:"type:jsonb;comment:变更前的接待员工"`
+	// 变更后的接待员工
+	AfterExtStaffIDs constants.StringArrayField `json:"after_ext_staff_ids" gorm:"type:jsonb;comment:变更后的接待员工"`
+	Timestamp
+}
+
+func (o ContactWayScheduleLog) Create(item ContactWayScheduleLog) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create ContactWayScheduleLog failed")
+	}
+	return nil
+}
+
+func (o ContactWayScheduleLog) Query(
+	contactWayID string, extCorpID string, pager *app.Pager) (items []ContactWayScheduleLog, total int64, err error) {
+	items = make([]ContactWayScheduleLog, 0)
+	db := DB.Model(&ContactWayScheduleLog{}).Where("ext_corp_id = ? and contact_way_id = ?", extCorpID, contactWayID)
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count ContactWayScheduleLog failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("created_at desc").Offset(pager.GetOffset()).Limit(pager.GetLimit()).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find ContactWayScheduleLog failed")
+		return
+	}
+	return
+}
+
+// RefreshWithLog 刷新渠道码接待员工，自行上下线或接待员工有变化时记录变更
+func (o ContactWayScheduleLog) RefreshWithLog(
+	id string, trigger constants.ContactWayScheduleLogTrigger, extStaffID string) (item ContactWay, err error) {
+	before := ContactWay{}
+	err = DB.Select("id", "ext_staff_ids").Where("id = ?", id).First(&before).Error
+	if err != nil {
+		err = errors.Wrap(err, "First ContactWay failed")
+		return
+	}
+
+	item, err = ContactWay{}.Refresh(DB, id)
+	if err != nil {
+		return
+	}
+
+	if trigger == constants.ContactWayScheduleLogTriggerSchedule && sameStaffIDs(before.ExtStaffIDs, item.ExtStaffIDs) {
+		return
+	}
+	log.Sugar.Infow("[渠道码][接待员工变更]", "contactWayID", id, "trigger", trigger,
+		"before", before.ExtStaffIDs, "after", item.ExtStaffIDs)
+	err = o.Create(ContactWayScheduleLog{
+		ExtCorpModel: ExtCorpModel{
+			ID:           id_generator.StringID(),
+			ExtCorpID:    item.ExtCorpID,
+			ExtCreatorID: item.ExtCreatorID,
+		},
+		ContactWayID:      id,
+		Trigger:           trigger,
+		ExtStaffID:        extStaffID,
+		BeforeExtStaffIDs: before.ExtStaffIDs,
+		AfterExtStaffIDs:  item.ExtStaffIDs,
+	})
+	return
+}
+
+func sameStaffIDs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
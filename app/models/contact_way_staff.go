@@ -17,6 +17,10 @@ type ContactWayStaff struct {
 	DailyAddCustomerCount int    `json:"daily_add_customer_count" gorm:"->;default:0;comment:'员工每日添加客户计数'"`
 	DailyAddCustomerLimit int    `json:"daily_add_customer_limit" gorm:"comment:'员工每日添加客户上限'"`
 	ExtStaffID            string `json:"ext_staff_id" gorm:"index:contactWayIndex;comment:'外部员工ID'"`
+	// 分配权重，加权轮询时使用
+	Weight int `json:"weight" gorm:"default:1;comment:分配权重"`
+	// 排序，按顺序分配时使用
+	Sort int `json:"sort" gorm:"default:0;comment:排序"`
 	// 员工名称
 	Name string `gorm:"type:varchar(255);comment:员工名" json:"name"`
 	// 头像url
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	"openscrm/pkg/easywework"
+)
+
+// ContactWayStaffQrCode 渠道码接待员工的单人二维码，按员工生成海报时使用
+// 单人「联系我」的state与所属渠道码相同，扫码添加的客户仍归属该渠道码
+// 扫码直接添加该员工，不经过调度、每日上限和分配策略；渠道码失效、删除，员工解绑或下线时删除
+type ContactWayStaffQrCode struct {
+	ExtCorpModel
+	// 所属渠道码ID
+	ContactWayID string `json:"contact_way_id" gorm:"type:bigint;uniqueIndex:idx_staff_qr_code_contact_way_id_staff;comment:渠道码ID"`
+	// 接待员工ExtID
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);uniqueIndex:idx_staff_qr_code_contact_way_id_staff;comment:员工ExtID"`
+	// 渠道码配置ID
+	ConfigID string `json:"config_id" gorm:"type:varchar(64);comment:渠道码配置ID"`
+	// 联系二维码的URL
+	QrCode string `json:"qr_code" gorm:"type:varchar(255);comment:联系二维码的URL"`
+	Timestamp
+}
+
+// GetOrCreate 查询员工在渠道码下的单人二维码，不存在时按渠道码的设置生成
+// Detail: 渠道码需生效中，临时会话和过期时间与渠道码一致
+func (o ContactWayStaffQrCode) GetOrCreate(contactWay ContactWay, extStaffID string) (item ContactWayStaffQrCode, err error) {
+	if contactWay.Status > constants.ContactWayStatusActive {
+		err = errors.WithStack(ecode.ContactWayInactiveErr)
+		return
+	}
+
+	err = DB.Model(&ContactWayStaffQrCode{}).
+		Where("contact_way_id = ? and ext_staff_id = ?", contactWay.ID, extStaffID).
+		First(&item).Error
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.Wrap(err, "First ContactWayStaffQrCode failed")
+		return
+	}
+
+	client, err := we_work.Clients.Get(contactWay.ExtCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "get Client failed")
+		return
+	}
+
+	item = ContactWayStaffQrCode{
+		ExtCorpModel: ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: contactWay.ExtCorpID, ExtCreatorID: contactWay.ExtCreatorID},
+		ContactWayID: contactWay.ID,
+		ExtStaffID:   extStaffID,
+	}
+	wxContactWayReq := workwx.AddContactWay{
+		IsTemp:     false,
+		Remark:     contactWay.Remark,
+		Scene:      2,
+		SkipVerify: contactWay.SkipVerify == constants.True,
+		State:      contactWay.State,
+		Type:       workwx.ContactWayTypeSingle,
+		User:       []string{extStaffID},
+	}
+	if contactWay.IsTemp == constants.True {
+		wxContactWayReq.IsTemp = true
+		wxContactWayReq.ExpiresIn = ContactWay{}.expiresIn(contactWay)
+		wxContactWayReq.ChatExpiresIn = contactWay.ChatExpiresIn
+	}
+	item.ConfigID, err = client.Customer.AddContactWay(wxContactWayReq)
+	if err != nil {
+		err = errors.Wrap(err, "wx AddContactWay failed")
+		return
+	}
+
+	wxContactWay, err := client.Customer.GetContactWay(item.ConfigID)
+	if err != nil {
+		err = errors.Wrap(err, "wx GetContactWay failed")
+		return
+	}
+	item.QrCode = wxContactWay.QrCode
+
+	err = DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error
+	if err != nil {
+		err = errors.Wrap(err, "Create ContactWayStaffQrCode failed")
+		return
+	}
+	return
+}
+
+// Delete 删除渠道码下员工单人二维码的企微配置，extStaffIDs为空时删除全部员工的
+// Detail: 企微配置删除失败时只记录日志并保留记录
+func (o ContactWayStaffQrCode) Delete(tx *gorm.DB, extCorpID string, contactWayIDs []string, extStaffIDs []string) error {
+	items := make([]ContactWayStaffQrCode, 0)
+	db := tx.Where("ext_corp_id = ? and contact_way_id in (?)", extCorpID, contactWayIDs)
+	if len(extStaffIDs) > 0 {
+		db = db.Where("ext_staff_id in (?)", extStaffIDs)
+	}
+	err := db.Find(&items).Error
+	if err != nil {
+		return errors.Wrap(err, "Find ContactWayStaffQrCode failed")
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.Wrap(err, "get Client failed")
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		_, err = client.Customer.DelContactWay(item.ConfigID)
+		if err != nil && !isContactWayNotFoundErr(err) {
+			log.Sugar.Errorw("wx DelContactWay failed", "err", err, "staffQrCodeID", item.ID)
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 物理删除，员工重新绑定或上线后可重新生成
+	err = tx.Unscoped().Where("id in (?)", ids).Delete(&ContactWayStaffQrCode{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete ContactWayStaffQrCode failed")
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	"openscrm/pkg/easywework"
+	"openscrm/pkg/easywework/errcodes"
+	"time"
+)
+
+// tempChatUsers 临时会话模式的接待员工，只取第一个
+func (o ContactWay) tempChatUsers(item ContactWay) []string {
+	if len(item.ExtStaffIDs) == 0 {
+		return nil
+	}
+	return item.ExtStaffIDs[:1]
+}
+
+// expiresIn 临时会话二维码的有效期，秒，未设置过期时间时使用企微默认值
+// Detail: 过期时间在创建和修改时已校验，这里只兼容刷新时刚好过期和超过企微上限的情况
+func (o ContactWay) expiresIn(item ContactWay) int {
+	if item.ExpireAt == nil {
+		return 0
+	}
+	seconds := int(time.Until(*item.ExpireAt).Seconds())
+	if seconds < 1 {
+		return 1
+	}
+	if seconds > constants.ContactWayTempMaxExpiresIn {
+		return constants.ContactWayTempMaxExpiresIn
+	}
+	return seconds
+}
+
+// wxUpdateReq 根据渠道码最新状态生成企微更新请求
+func (o ContactWay) wxUpdateReq(item ContactWay) workwx.UpdateContactWay {
+	req := workwx.UpdateContactWay{
+		ConfigID:   item.ConfigID,
+		Remark:     item.Remark,
+		SkipVerify: item.SkipVerify == constants.True,
+		State:      item.State,
+		User:       item.ExtStaffIDs,
+	}
+	if item.IsTemp == constants.True {
+		req.User = o.tempChatUsers(item)
+		req.ExpiresIn = o.expiresIn(item)
+		req.ChatExpiresIn = item.ChatExpiresIn
+	}
+	return req
+}
+
+// QueryExpired 查询已到过期时间但仍生效中的渠道码，失效失败过的按上次尝试时间排在最后
+func (o ContactWay) QueryExpired(limit int) (items []ContactWay, err error) {
+	err = DB.Model(&ContactWay{}).
+		Where("status = ? and expire_at is not null and expire_at <= ?", constants.ContactWayStatusActive, time.Now()).
+		Order("deactivate_attempted_at nulls first, expire_at").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find expired ContactWay failed")
+		return
+	}
+	return
+}
+
+// AddDeactivateAttempt 记录一次过期失效失败的尝试
+func (o ContactWay) AddDeactivateAttempt(id string) error {
+	err := DB.Model(&ContactWay{}).Where("id = ?", id).Update("deactivate_attempted_at", time.Now()).Error
+	if err != nil {
+		return errors.Wrap(err, "Update ContactWay deactivate_attempted_at failed")
+	}
+	return nil
+}
+
+// isContactWayNotFoundErr 企微中已不存在该「联系我」配置，视为已删除
+func isContactWayNotFoundErr(err error) bool {
+	clientErr, ok := errors.Cause(err).(*workwx.ClientError)
+	return ok && clientErr.Code == errcodes.ErrCode41044
+}
+
+// CountAdded 渠道码累计添加人次
+func (o ContactWay) CountAdded(tx *gorm.DB, id string) (total int64, err error) {
+	err = tx.Model(&ContactWayAddRecord{}).Where("contact_way_id = ?", id).Count(&total).Error
+	return total, errors.Wrap(err, "Count ContactWayAddRecord failed")
+}
+
+// Deactivate 渠道码过期或达到添加上限后，删除企微配置使二维码失效，过期时还会结束进行中的临时会话
+// Detail: 只处理生效中的渠道码；先删除企微配置，删除失败时不修改状态，由过期检查任务或下次添加客户时重试
+//  子渠道和员工单人二维码的企微配置一并删除
+func (o ContactWay) Deactivate(tx *gorm.DB, item ContactWay, status constants.ContactWayStatus) error {
+	if item.Status > constants.ContactWayStatusActive {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(item.ExtCorpID)
+	if err != nil {
+		return errors.Wrap(err, "get Client failed")
+	}
+
+	_, err = client.Customer.DelContactWay(item.ConfigID)
+	if err != nil && !isContactWayNotFoundErr(err) {
+		return errors.Wrap(err, "wx DelContactWay failed")
+	}
+
+	result := tx.Model(&ContactWay{}).
+		Where("id = ? and status = ?", item.ID, constants.ContactWayStatusActive).
+		Update("status", status)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "Update ContactWay status failed")
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	subChannels := make([]ContactWaySubChannel, 0)
+	err = tx.Where("contact_way_id = ?", item.ID).Find(&subChannels).Error
+	if err != nil {
+		return errors.Wrap(err, "Find ContactWaySubChannel failed")
+	}
+	for _, subChannel := range subChannels {
+		_, err = client.Customer.DelContactWay(subChannel.ConfigID)
+		if err != nil {
+			log.Sugar.Errorw("wx DelContactWay failed", "err", err, "subChannelID", subChannel.ID)
+		}
+	}
+	err = ContactWayStaffQrCode{}.Delete(tx, item.ExtCorpID, []string{item.ID}, nil)
+	if err != nil {
+		log.Sugar.Errorw("Delete ContactWayStaffQrCode failed", "err", err, "contactWayID", item.ID)
+	}
+
+	// 达到添加上限时刚添加的客户仍在会话中，只在活动过期时结束临时会话
+	if item.IsTemp != constants.True || status != constants.ContactWayStatusExpired {
+		return nil
+	}
+
+	// 结束仍在有效期内的临时会话
+	chatExpiresIn := item.ChatExpiresIn
+	if chatExpiresIn == 0 {
+		chatExpiresIn = constants.ContactWayDefaultChatExpiresIn
+	}
+	records := make([]ContactWayAddRecord, 0)
+	err = tx.Where("contact_way_id = ? and added_at > ?",
+		item.ID, time.Now().Add(-time.Duration(chatExpiresIn)*time.Second)).
+		Find(&records).Error
+	if err != nil {
+		return errors.Wrap(err, "Find ContactWayAddRecord failed")
+	}
+	for _, record := range records {
+		_, err = client.Customer.CloseTempChat(record.ExtCustomerID, record.ExtStaffID)
+		if err != nil {
+			log.Sugar.Warnw("wx CloseTempChat failed", "err", err,
+				"contactWayID", item.ID, "extCustomerID", record.ExtCustomerID)
+		}
+	}
+
+	return nil
+}
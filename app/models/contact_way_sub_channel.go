@@ -0,0 +1,206 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/common/ecode"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	"openscrm/pkg/easywework"
+	"strings"
+)
+
+// ContactWaySubChannel 渠道码的子渠道，同一渠道码投放在不同位置时用于区分来源
+// 每个子渠道有独立的企微「联系我」配置和二维码，接待员工、自动通过好友等设置与所属渠道码保持一致
+type ContactWaySubChannel struct {
+	ExtCorpModel
+	// 所属渠道码ID
+	ContactWayID string `json:"contact_way_id" gorm:"type:bigint;uniqueIndex:idx_sub_channel_contact_way_id_code;comment:渠道码ID"`
+	// 子渠道名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:子渠道名称"`
+	// 子渠道编码，拼接在渠道码state之后
+	Code string `json:"code" gorm:"type:varchar(8);uniqueIndex:idx_sub_channel_contact_way_id_code;comment:子渠道编码"`
+	// 自定义参数，如source、campaign、store
+	Params constants.StringMapField `json:"params" gorm:"type:jsonb;default:'{}';comment:自定义参数"`
+	// 企业自定义的state参数
+	State string `json:"state" gorm:"type:varchar(30);index;comment:企业自定义的state参数"`
+	// 渠道码配置ID
+	ConfigID string `json:"config_id" gorm:"type:varchar(64);comment:渠道码配置ID"`
+	// 联系二维码的URL
+	QrCode string `json:"qr_code" gorm:"type:varchar(255);comment:联系二维码的URL"`
+	Timestamp
+}
+
+// ParseContactWayState 解析渠道码的state，子渠道的state会同时返回子渠道编码
+func ParseContactWayState(state string) (contactWayID string, subChannelCode string, ok bool) {
+	if !strings.HasPrefix(state, constants.ContactWayStatePrefix) {
+		return
+	}
+	contactWayID = strings.TrimPrefix(state, constants.ContactWayStatePrefix)
+	if i := strings.Index(contactWayID, constants.ContactWaySubChannelSep); i >= 0 {
+		subChannelCode = contactWayID[i+len(constants.ContactWaySubChannelSep):]
+		contactWayID = contactWayID[:i]
+	}
+	return contactWayID, subChannelCode, contactWayID != ""
+}
+
+func (o ContactWaySubChannel) Get(id string, extCorpID string) (item ContactWaySubChannel, err error) {
+	err = DB.Model(&ContactWaySubChannel{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First ContactWaySubChannel failed")
+		return
+	}
+	return
+}
+
+// GetByCode 按编码查询渠道码的子渠道，包括已删除的，删除前扫码的客户仍能归因
+func (o ContactWaySubChannel) GetByCode(contactWayID string, code string) (item ContactWaySubChannel, err error) {
+	err = DB.Unscoped().Model(&ContactWaySubChannel{}).Where("contact_way_id = ? and code = ?", contactWayID, code).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First ContactWaySubChannel failed")
+		return
+	}
+	return
+}
+
+// Query 查询渠道码的全部子渠道
+func (o ContactWaySubChannel) Query(contactWayID string, extCorpID string) (items []ContactWaySubChannel, err error) {
+	err = DB.Model(&ContactWaySubChannel{}).
+		Where("ext_corp_id = ? and contact_way_id = ?", extCorpID, contactWayID).
+		Order("created_at").
+		Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find ContactWaySubChannel failed")
+		return
+	}
+	return
+}
+
+// CodeExists 编码是否已被渠道码的子渠道使用过，包括已删除的
+func (o ContactWaySubChannel) CodeExists(contactWayID string, code string) (bool, error) {
+	var count int64
+	err := DB.Unscoped().Model(&ContactWaySubChannel{}).
+		Where("contact_way_id = ? and code = ?", contactWayID, code).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "Count ContactWaySubChannel failed")
+	}
+	return count > 0, nil
+}
+
+// Create 按所属渠道码的设置生成企微配置和二维码，并保存子渠道
+func (o ContactWaySubChannel) Create(item ContactWaySubChannel, contactWay ContactWay) (ContactWaySubChannel, error) {
+	item.ContactWayID = contactWay.ID
+	item.State = contactWay.State + constants.ContactWaySubChannelSep + item.Code
+
+	client, err := we_work.Clients.Get(contactWay.ExtCorpID)
+	if err != nil {
+		return item, errors.Wrap(err, "get Client failed")
+	}
+
+	item.ConfigID, err = client.Customer.AddContactWay(workwx.AddContactWay{
+		IsTemp:     false,
+		Remark:     contactWay.Remark,
+		Scene:      2,
+		SkipVerify: contactWay.SkipVerify == constants.True,
+		State:      item.State,
+		Type:       workwx.ContactWayTypeMultiple,
+		User:       contactWay.ExtStaffIDs,
+	})
+	if err != nil {
+		return item, errors.Wrap(err, "wx AddContactWay failed")
+	}
+
+	wxContactWay, err := client.Customer.GetContactWay(item.ConfigID)
+	if err != nil {
+		return item, errors.Wrap(err, "wx GetContactWay failed")
+	}
+	item.QrCode = wxContactWay.QrCode
+
+	err = DB.Create(&item).Error
+	if err != nil {
+		return item, errors.Wrap(err, "Create ContactWaySubChannel failed")
+	}
+	return item, nil
+}
+
+// Update 只更新名称和自定义参数，编码和二维码不可修改
+func (o ContactWaySubChannel) Update(item ContactWaySubChannel) error {
+	err := DB.Model(&ContactWaySubChannel{}).
+		Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+		Select("name", "params").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update ContactWaySubChannel failed")
+	}
+	return nil
+}
+
+// Delete 删除子渠道及其企微配置，删除后二维码失效
+func (o ContactWaySubChannel) Delete(ids []string, extCorpID string) error {
+	items := make([]ContactWaySubChannel, 0)
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Find(&items).Error
+	if err != nil {
+		return errors.Wrap(err, "Find ContactWaySubChannel failed")
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.Wrap(err, "get Client failed")
+	}
+	for _, item := range items {
+		_, err = client.Customer.DelContactWay(item.ConfigID)
+		if err != nil {
+			log.Sugar.Errorw("wx DelContactWay failed", "err", err, "subChannelID", item.ID)
+		}
+	}
+
+	err = DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&ContactWaySubChannel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete ContactWaySubChannel failed")
+	}
+	return nil
+}
+
+// SyncConfig 渠道码设置变化后，同步更新子渠道的企微配置
+// Detail: 单个子渠道同步失败只记录日志，不影响渠道码本身的更新
+func (o ContactWaySubChannel) SyncConfig(tx *gorm.DB, contactWay ContactWay) error {
+	items := make([]ContactWaySubChannel, 0)
+	err := tx.Where("contact_way_id = ?", contactWay.ID).Find(&items).Error
+	if err != nil {
+		return errors.Wrap(err, "Find ContactWaySubChannel failed")
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(contactWay.ExtCorpID)
+	if err != nil {
+		return errors.Wrap(err, "get Client failed")
+	}
+	for _, item := range items {
+		_, err = client.Customer.UpdateContactWay(workwx.UpdateContactWay{
+			ConfigID:   item.ConfigID,
+			Remark:     contactWay.Remark,
+			SkipVerify: contactWay.SkipVerify == constants.True,
+			State:      item.State,
+			User:       contactWay.ExtStaffIDs,
+		})
+		if err != nil {
+			log.Sugar.Errorw("wx UpdateContactWay failed", "err", err, "subChannelID", item.ID)
+		}
+	}
+	return nil
+}
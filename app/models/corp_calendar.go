@@ -0,0 +1,116 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"time"
+)
+
+// CorpCalendarDay 企业日历中的节假日和调休上班日，渠道码员工调度和分时段欢迎语按此判断当天是否工作日
+// 删除时直接物理删除，同一天可重新导入
+type CorpCalendarDay struct {
+	// ID
+	ID string `json:"id" gorm:"primaryKey;type:bigint;comment:'ID'" validate:"int64"`
+	// ExtCorpID 外部企业ID
+	ExtCorpID string `json:"ext_corp_id" gorm:"uniqueIndex:idx_corp_calendar_ext_corp_id_date;type:char(18);comment:外部企业ID"`
+	// ExtCreatorID 创建者外部员工ID
+	ExtCreatorID string `json:"ext_creator_id" gorm:"type:char(64);comment:创建者外部员工ID"`
+	// 日期，如2026-10-01
+	Date string `json:"date" gorm:"type:char(10);uniqueIndex:idx_corp_calendar_ext_corp_id_date;comment:日期"`
+	// 类型 1-节假日 2-调休上班日
+	Type constants.CorpCalendarDayType `json:"type" gorm:"comment:类型"`
+	// 名称，如国庆节
+	Name string `json:"name" gorm:"type:varchar(64);comment:名称"`
+	// 调休上班日按星期几排班，0为周日，默认周一
+	AsWeekday int       `json:"as_weekday" gorm:"comment:调休上班日按星期几排班"`
+	CreatedAt time.Time `gorm:"comment:'创建时间'" json:"created_at"`
+	UpdatedAt time.Time `gorm:"comment:'更新时间'" json:"updated_at"`
+}
+
+func (o CorpCalendarDay) Query(
+	req requests.QueryCorpCalendarDayReq, extCorpID string) (items []CorpCalendarDay, err error) {
+	db := DB.Model(&CorpCalendarDay{}).Where("ext_corp_id = ?", extCorpID)
+	if req.StartDate != "" {
+		db = db.Where("date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		db = db.Where("date <= ?", req.EndDate)
+	}
+	err = db.Order("date").Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CorpCalendarDay failed")
+		return
+	}
+	return
+}
+
+// Upsert 按日期保存，同一天已存在时覆盖
+func (o CorpCalendarDay) Upsert(items []CorpCalendarDay) error {
+	if len(items) == 0 {
+		return nil
+	}
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ext_corp_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "name", "as_weekday", "updated_at"}),
+	}).Create(&items).Error
+	if err != nil {
+		return errors.Wrap(err, "Upsert CorpCalendarDay failed")
+	}
+	return nil
+}
+
+// Delete 删除日历，返回被删除的日期，用于刷新受影响的调度
+func (o CorpCalendarDay) Delete(ids []string, extCorpID string) (items []CorpCalendarDay, err error) {
+	err = DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CorpCalendarDay failed")
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	err = DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&CorpCalendarDay{}).Error
+	if err != nil {
+		err = errors.Wrap(err, "Delete CorpCalendarDay failed")
+		return
+	}
+	return
+}
+
+// ResolveWeekday 按企业日历计算某天应按星期几排班
+// Detail: 节假日返回isHoliday为true，调休上班日返回其指定的星期，其余日期返回实际星期
+func (o CorpCalendarDay) ResolveWeekday(extCorpID string, t time.Time) (weekday time.Weekday, isHoliday bool, err error) {
+	t = t.In(constants.PRCLocation)
+	weekday = t.Weekday()
+
+	item := CorpCalendarDay{}
+	err = DB.Model(&CorpCalendarDay{}).
+		Where("ext_corp_id = ? and date = ?", extCorpID, t.Format(constants.DateLayout)).
+		First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return weekday, false, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First CorpCalendarDay failed")
+		return
+	}
+
+	if item.Type == constants.CorpCalendarHoliday {
+		return weekday, true, nil
+	}
+	return time.Weekday(item.AsWeekday), false, nil
+}
+
+// WeekdayIn weekday是否在weekdays中，weekdays为周一至周日
+func WeekdayIn(weekday time.Weekday, weekdays []string) bool {
+	for _, name := range weekdays {
+		if day, ok := constants.WeekdayMap[name]; ok && time.Weekday(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ import (
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
 	"openscrm/app/requests"
 	app "openscrm/common/app"
+	"openscrm/common/util"
 	"time"
 )
 
@@ -23,6 +25,7 @@ type CustomerExportItem struct {
 	Gender           int64           `json:"gender"`
 	Birthday         string          `json:"birthday"`
 	PhoneNumber      string          `json:"phone_number"`
+	SubChannelName   string          `json:"sub_channel_name"`
 	Staffs           []CustomerStaff `gorm:"foreignKey:ExtCustomerID;references:ExtID" json:"staff_relations"`
 }
 
@@ -43,6 +46,10 @@ type Customer struct {
 	// 0-未知 1-男性 2-女性
 	Gender  int    `gorm:"type:smallint;comment:性别,0-未知 1-男性 2-女性" json:"gender"`
 	Unionid string `gorm:"type:varchar(128);comment:微信开放平台的唯一身份标识(微信unionID)" json:"unionid"`
+	// 补全unionid的尝试次数
+	UnionidAttempts int `gorm:"default:0;comment:补全unionid的尝试次数" json:"-"`
+	// 最近一次补全unionid的时间
+	UnionidAttemptedAt *time.Time `gorm:"comment:最近一次补全unionid的时间" json:"-"`
 	// 仅当联系人类型是企业微信用户时有此字段
 	ExternalProfile ExternalProfile `gorm:"type:jsonb;comment:仅当联系人类型是企业微信用户时有此字段" json:"external_profile"`
 	// 所属员工
@@ -171,6 +178,7 @@ func (o Customer) ExportQuery(
 		Joins("left join customer_staff_tag cst on cst.customer_staff_id = cs.id").
 		Joins("join staff s on s.ext_id = cs.ext_staff_id").
 		Joins("left join customer_info ci on customer.ext_id = ci.ext_customer_id").
+		Joins("left join contact_way_sub_channel csc on csc.state = cs.state and cs.state <> ''").
 		Where("cs.ext_corp_id = ?", extCorpID)
 
 	if req.Name != "" {
@@ -194,6 +202,16 @@ func (o Customer) ExportQuery(
 	if req.ChannelType > 0 {
 		db = db.Where("cs.add_way = ?", req.ChannelType)
 	}
+	if len(req.SubChannelIDs) > 0 {
+		db = db.Where("cs.state in (select sc.state from contact_way_sub_channel sc where sc.id in (?))", req.SubChannelIDs)
+	}
+	if req.SegmentID != "" {
+		segment, err := CustomerSegment{}.Get(req.SegmentID, extCorpID)
+		if err != nil {
+			return nil, 0, err
+		}
+		db = db.Scopes(SegmentScope(segment.Rule))
+	}
 
 	var total int64
 	// 使用新的 Session 进行 count，避免 Distinct 影响后续查询
@@ -209,7 +227,7 @@ func (o Customer) ExportQuery(
 		Select("customer.name as customer_name, customer.corp_name as customer_corp_name, " +
 			" s.name as staff_name, cs.remark, cs.description, " +
 			" CASE WHEN cs.deleted_at IS NULL THEN '未流失' ELSE '已流失' END as status," +
-			" cs.createtime, cs.add_way, ci.age, customer.gender, ci.birthday, ci.phone_number").
+			" cs.createtime, cs.add_way, ci.age, customer.gender, ci.birthday, ci.phone_number, csc.name as sub_channel_name").
 		Find(&customers).Error
 	if err != nil {
 		err = errors.WithStack(err)
@@ -252,6 +270,21 @@ func (o Customer) Query(
 	if req.ChannelType > 0 {
 		filterDB = filterDB.Where("cs.add_way = ?", req.ChannelType)
 	}
+	if len(req.SubChannelIDs) > 0 {
+		filterDB = filterDB.Where("cs.state in (select sc.state from contact_way_sub_channel sc where sc.id in (?))", req.SubChannelIDs)
+	}
+	if req.SegmentID != "" {
+		segment, err := CustomerSegment{}.Get(req.SegmentID, extCorpID)
+		if err != nil {
+			return nil, 0, err
+		}
+		// 已指定流失状态时不再默认只保留未流失的关系
+		if req.OutFlowStatus > 0 {
+			filterDB = filterDB.Scopes(segmentRuleScope(segment.Rule))
+		} else {
+			filterDB = filterDB.Scopes(SegmentScope(segment.Rule))
+		}
+	}
 	if req.OutFlowStatus == 1 {
 		filterDB = filterDB.Unscoped().Where("cs.deleted_at is not null")
 	} else if req.OutFlowStatus == 2 {
@@ -322,6 +355,67 @@ func (o Customer) GetByExtID(
 	return customer, nil
 }
 
+// GetByUnionid 根据unionid获取客户
+func (o Customer) GetByUnionid(unionid string, extCorpID string) (customer Customer, err error) {
+	err = DB.Model(&Customer{}).
+		Where("ext_corp_id = ?", extCorpID).
+		Where("unionid = ?", unionid).
+		First(&customer).Error
+	if err != nil {
+		err = errors.Wrap(err, "Get customer by unionid failed")
+		return
+	}
+	return
+}
+
+// GetByPhoneNumber 根据客户画像或员工备注的手机号获取客户
+func (o Customer) GetByPhoneNumber(phoneNumber string, extCorpID string) (customer Customer, err error) {
+	err = DB.Model(&Customer{}).
+		Joins("left join customer_info ci on ci.ext_customer_id = customer.ext_id").
+		Joins("left join customer_staff cs on cs.ext_customer_id = customer.ext_id and cs.deleted_at is null").
+		Where("customer.ext_corp_id = ?", extCorpID).
+		Where("ci.phone_number = ? or cs.remark_mobiles @> ?::jsonb", phoneNumber, util.ToJSONBSingleArray(phoneNumber)).
+		Select("customer.*").
+		First(&customer).Error
+	if err != nil {
+		err = errors.Wrap(err, "Get customer by phone number failed")
+		return
+	}
+	return
+}
+
+// QueryWithoutUnionid 按ID分页查询缺少unionid的微信客户，跳过尝试次数已用完和未到重试时间的
+func (o Customer) QueryWithoutUnionid(extCorpID string, lastID string, limit int) (customers []Customer, err error) {
+	db := DB.Model(&Customer{}).
+		Where("ext_corp_id = ?", extCorpID).
+		Where("type = ?", 1).
+		Where("unionid = '' or unionid is null").
+		Where("unionid_attempts < ?", constants.CustomerIdentityMaxAttempts).
+		Where("unionid_attempted_at is null or unionid_attempted_at < ?", time.Now().Add(-constants.CustomerIdentityRetryInterval))
+	if lastID != "" {
+		db = db.Where("id > ?", lastID)
+	}
+	err = db.Order("id asc").Limit(limit).Find(&customers).Error
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
+// UpdateUnionid 更新客户unionid
+func (o Customer) UpdateUnionid(extCustomerID string, unionid string) error {
+	return DB.Model(&Customer{}).Where("ext_id = ?", extCustomerID).Update("unionid", unionid).Error
+}
+
+// AddUnionidAttempt 记录一次未能补全unionid的尝试
+func (o Customer) AddUnionidAttempt(extCustomerID string) error {
+	return DB.Model(&Customer{}).Where("ext_id = ?", extCustomerID).Updates(map[string]interface{}{
+		"unionid_attempts":     gorm.Expr("unionid_attempts + 1"),
+		"unionid_attempted_at": time.Now(),
+	}).Error
+}
+
 type CustomerSummary struct {
 	CorpName               string `json:"corp_name"`
 	TotalStaffsNum         int64  `json:"total_staffs_num"`
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"time"
+)
+
+// CustomerIdentity 客户在小程序、公众号中的身份，用于将unionid/openid关联到企微客户
+// 客户尚未添加员工时只能拿到pending_id，ExtCustomerID为空，由定时任务补全
+type CustomerIdentity struct {
+	ExtCorpModel
+	// 来源应用类型 1-微信小程序 2-微信公众号
+	SourceType constants.CustomerIdentitySource `gorm:"type:smallint;comment:来源应用类型,1-微信小程序 2-微信公众号" json:"source_type"`
+	// 来源应用的AppID
+	AppID string `gorm:"type:varchar(64);uniqueIndex:idx_app_id_openid;comment:来源应用的AppID" json:"app_id"`
+	// 客户在来源应用中的openid
+	Openid string `gorm:"type:varchar(128);uniqueIndex:idx_app_id_openid;comment:客户在来源应用中的openid" json:"openid"`
+	// 微信开放平台的唯一身份标识
+	Unionid string `gorm:"type:varchar(128);index;comment:微信开放平台的唯一身份标识(微信unionID)" json:"unionid"`
+	// 客户未添加员工时企微返回的临时ID，有效期90天
+	PendingID string `gorm:"type:varchar(128);comment:客户未添加员工时企微返回的临时ID" json:"pending_id"`
+	// 关联的企微客户ID
+	ExtCustomerID string `gorm:"type:varchar(64);index;comment:关联的企微客户ID" json:"ext_customer_id"`
+	// 最近一次登录时间
+	LastLoginAt time.Time `gorm:"comment:最近一次登录时间" json:"last_login_at"`
+	// 关联企微客户的尝试次数，登录时重新计数
+	LinkAttempts int `gorm:"default:0;comment:关联企微客户的尝试次数" json:"-"`
+	// 最近一次尝试关联企微客户的时间
+	LinkAttemptedAt *time.Time `gorm:"comment:最近一次尝试关联企微客户的时间" json:"-"`
+	Timestamp
+}
+
+// Upsert 按AppID+openid更新身份信息，并重置关联尝试次数
+func (o CustomerIdentity) Upsert(identity CustomerIdentity) error {
+	return DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "app_id"}, {Name: "openid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"unionid", "pending_id", "ext_customer_id", "last_login_at",
+			"link_attempts", "link_attempted_at", "updated_at"}),
+	}).Create(&identity).Error
+}
+
+// QueryUnlinked 按ID分页查询尚未关联企微客户的身份，跳过尝试次数已用完和未到重试时间的
+func (o CustomerIdentity) QueryUnlinked(extCorpID string, lastID string, limit int) (identities []CustomerIdentity, err error) {
+	db := DB.Model(&CustomerIdentity{}).
+		Where("ext_corp_id = ?", extCorpID).
+		Where("ext_customer_id = ''").
+		Where("unionid <> ''").
+		Where("link_attempts < ?", constants.CustomerIdentityMaxAttempts).
+		Where("link_attempted_at is null or link_attempted_at < ?", time.Now().Add(-constants.CustomerIdentityRetryInterval))
+	if lastID != "" {
+		db = db.Where("id > ?", lastID)
+	}
+	err = db.Order("id asc").Limit(limit).Find(&identities).Error
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
+// LinkByUnionid 将同一unionid下未关联的身份关联到企微客户
+func (o CustomerIdentity) LinkByUnionid(extCorpID string, unionid string, extCustomerID string) (int64, error) {
+	res := DB.Model(&CustomerIdentity{}).
+		Where("ext_corp_id = ?", extCorpID).
+		Where("unionid = ?", unionid).
+		Where("ext_customer_id = ''").
+		Updates(map[string]interface{}{"ext_customer_id": extCustomerID, "pending_id": ""})
+	return res.RowsAffected, res.Error
+}
+
+// AddLinkAttempt 记录一次未能关联企微客户的尝试
+func (o CustomerIdentity) AddLinkAttempt(id string) error {
+	return DB.Model(&CustomerIdentity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"link_attempts":     gorm.Expr("link_attempts + 1"),
+		"link_attempted_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/common/id_generator"
+)
+
+// CustomerMassMsgQuota 客户每个自然月收到的企业群发次数
+// 企微限制每位客户每月最多接收4条企业群发，根据群发的发送结果累计
+type CustomerMassMsgQuota struct {
+	ExtCorpModel
+	// 客户ID
+	ExtCustomerID string `gorm:"type:varchar(64);uniqueIndex:idx_customer_month;comment:客户ID" json:"ext_customer_id"`
+	// 自然月，如2021-06
+	Month string `gorm:"type:varchar(7);uniqueIndex:idx_customer_month;comment:自然月" json:"month"`
+	// 当月已收到的群发次数
+	ReceivedNum int `gorm:"default:0;comment:当月已收到的群发次数" json:"received_num"`
+	Timestamp
+}
+
+// IncrReceivedNum 累加客户当月收到的群发次数
+func (o CustomerMassMsgQuota) IncrReceivedNum(extCorpID string, month string, extCustomerIDs []string) error {
+	return o.upsert(extCorpID, month, extCustomerIDs, 1,
+		gorm.Expr("customer_mass_msg_quota.received_num + excluded.received_num"))
+}
+
+// MarkExhausted 企微返回客户已收到其他群发时，直接将客户当月次数记为已满
+func (o CustomerMassMsgQuota) MarkExhausted(extCorpID string, month string, extCustomerIDs []string) error {
+	return o.upsert(extCorpID, month, extCustomerIDs, constants.MassMsgMonthlyQuota,
+		gorm.Expr("greatest(customer_mass_msg_quota.received_num, excluded.received_num)"))
+}
+
+func (o CustomerMassMsgQuota) upsert(
+	extCorpID string, month string, extCustomerIDs []string, num int, expr clause.Expr) error {
+	if len(extCustomerIDs) == 0 {
+		return nil
+	}
+
+	quotas := make([]CustomerMassMsgQuota, 0, len(extCustomerIDs))
+	for _, extCustomerID := range extCustomerIDs {
+		quotas = append(quotas, CustomerMassMsgQuota{
+			ExtCorpModel:  ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+			ExtCustomerID: extCustomerID,
+			Month:         month,
+			ReceivedNum:   num,
+		})
+	}
+
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ext_customer_id"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"received_num": expr}),
+	}).CreateInBatches(&quotas, 500).Error
+	if err != nil {
+		return errors.Wrap(err, "Upsert customer mass msg quota failed")
+	}
+	return nil
+}
+
+// QueryExhaustedCustomerIDs 查询指定客户中当月群发次数已达上限的客户
+func (o CustomerMassMsgQuota) QueryExhaustedCustomerIDs(
+	extCorpID string, month string, extCustomerIDs []string) (ids []string, err error) {
+	ids = make([]string, 0)
+	if len(extCustomerIDs) == 0 {
+		return
+	}
+
+	err = DB.Model(&CustomerMassMsgQuota{}).
+		Where("ext_corp_id = ? and month = ?", extCorpID, month).
+		Where("ext_customer_id in (?)", extCustomerIDs).
+		Where("received_num >= ?", constants.MassMsgMonthlyQuota).
+		Pluck("ext_customer_id", &ids).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query exhausted customers failed")
+		return
+	}
+	return
+}
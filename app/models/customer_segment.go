@@ -0,0 +1,490 @@
+package models
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CustomerSegment 客户分群，保存命名的客户筛选规则
+// 规则按员工-客户关系计算，可在群发、客户导出等使用客户筛选条件的地方引用
+type CustomerSegment struct {
+	ExtCorpModel
+	// 分群名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:分群名称"`
+	// 分群描述
+	Description string `json:"description" gorm:"type:varchar(255);comment:分群描述"`
+	// 筛选规则
+	Rule constants.SegmentRule `json:"rule" gorm:"type:jsonb;comment:筛选规则"`
+	Timestamp
+}
+
+// SegmentCount 客户分群的人数
+type SegmentCount struct {
+	// 客户数
+	CustomerNum int64 `json:"customer_num"`
+	// 员工-客户关系数
+	RelationNum int64 `json:"relation_num"`
+}
+
+// customerInfoColumns 客户画像可用于分群的字段，value为是否为数值字段
+var customerInfoColumns = map[string]bool{
+	"age":          true,
+	"email":        false,
+	"phone_number": false,
+	"qq":           false,
+	"address":      false,
+	"birthday":     false,
+	"weibo":        false,
+	"description":  false,
+}
+
+func (o CustomerSegment) Get(id string, extCorpID string) (item CustomerSegment, err error) {
+	err = DB.Model(&CustomerSegment{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First CustomerSegment failed")
+		return
+	}
+
+	return
+}
+
+func (o CustomerSegment) Query(
+	req requests.QueryCustomerSegmentReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []CustomerSegment, total int64, err error) {
+	db := DB.Model(&CustomerSegment{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.Name != "" {
+		db = db.Where("name like ?", "%"+req.Name+"%")
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count CustomerSegment failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerSegment failed")
+		return
+	}
+
+	return
+}
+
+func (o CustomerSegment) Create(item CustomerSegment) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create CustomerSegment failed")
+	}
+	return nil
+}
+
+func (o CustomerSegment) Update(item CustomerSegment) error {
+	err := DB.Model(&CustomerSegment{}).
+		Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+		Select("name", "description", "rule").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update CustomerSegment failed")
+	}
+	return nil
+}
+
+func (o CustomerSegment) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&CustomerSegment{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete CustomerSegment failed")
+	}
+	return nil
+}
+
+// Count 统计规则匹配的客户数和员工-客户关系数
+func (o CustomerSegment) Count(rule constants.SegmentRule, extCorpID string) (res SegmentCount, err error) {
+	err = DB.Table("customer_staff cs").
+		Joins("join customer on customer.ext_id = cs.ext_customer_id").
+		Where("cs.ext_corp_id = ?", extCorpID).
+		Scopes(SegmentScope(rule)).
+		Select("count(distinct cs.ext_customer_id) as customer_num, count(*) as relation_num").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Count segment customers failed")
+		return
+	}
+	return
+}
+
+// SegmentScope 按分群规则筛选员工-客户关系
+// 查询中客户表为customer，员工-客户关系表别名为cs；规则未使用流失状态时只保留未流失的关系
+func SegmentScope(rule constants.SegmentRule) func(db *gorm.DB) *gorm.DB {
+	return segmentScope(rule, true)
+}
+
+// segmentRuleScope 只按分群规则筛选，调用方已按流失状态筛选时使用
+func segmentRuleScope(rule constants.SegmentRule) func(db *gorm.DB) *gorm.DB {
+	return segmentScope(rule, false)
+}
+
+func segmentScope(rule constants.SegmentRule, defaultActive bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sql, args, err := buildSegmentCondition(rule, 1)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		if defaultActive && !segmentUsesField(rule, constants.SegmentFieldChurnStatus) {
+			db = db.Where("cs.deleted_at is null")
+		}
+		if sql == "" {
+			return db
+		}
+		return db.Where("("+sql+")", args...)
+	}
+}
+
+// ValidateSegmentRule 校验分群规则
+func ValidateSegmentRule(rule constants.SegmentRule) error {
+	_, _, err := buildSegmentCondition(rule, 1)
+	return err
+}
+
+func segmentUsesField(rule constants.SegmentRule, field constants.SegmentField) bool {
+	if !rule.IsGroup() {
+		return rule.Field == field
+	}
+	for _, r := range rule.Rules {
+		if segmentUsesField(r, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidSegmentRule(format string, args ...interface{}) error {
+	return errors.Wrapf(ecode.InvalidSegmentRuleErr, format, args...)
+}
+
+// buildSegmentCondition 将分群规则转换为SQL条件
+func buildSegmentCondition(rule constants.SegmentRule, depth int) (string, []interface{}, error) {
+	if depth > constants.SegmentMaxDepth {
+		return "", nil, invalidSegmentRule("rule nested too deep")
+	}
+
+	if !rule.IsGroup() {
+		if rule.Field == "" {
+			return "", nil, nil
+		}
+		return buildSegmentLeaf(rule)
+	}
+
+	if rule.Logic != constants.LogicalConditionAND && rule.Logic != constants.LogicalConditionOR {
+		return "", nil, invalidSegmentRule("unknown logic %s", rule.Logic)
+	}
+
+	conds := make([]string, 0, len(rule.Rules))
+	args := make([]interface{}, 0)
+	for _, r := range rule.Rules {
+		sql, subArgs, err := buildSegmentCondition(r, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		conds = append(conds, "("+sql+")")
+		args = append(args, subArgs...)
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+
+	return strings.Join(conds, " "+rule.Logic+" "), args, nil
+}
+
+func buildSegmentLeaf(rule constants.SegmentRule) (string, []interface{}, error) {
+	values := rule.Values.ToStringArray()
+	switch rule.Field {
+	case constants.SegmentFieldTag:
+		return buildSegmentTag(rule.Operator, values)
+	case constants.SegmentFieldInternalTag:
+		return buildSegmentInternalTag(rule.Operator, values)
+	case constants.SegmentFieldRemark:
+		return buildSegmentRemark(rule.Key, rule.Operator, values)
+	case constants.SegmentFieldCustomerInfo:
+		return buildSegmentCustomerInfo(rule.Key, rule.Operator, values)
+	case constants.SegmentFieldStaff:
+		return buildSegmentIn("cs.ext_staff_id", rule.Operator, values)
+	case constants.SegmentFieldDepartment:
+		ids := make([]int64, 0, len(values))
+		for _, v := range values {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", nil, invalidSegmentRule("invalid department id %s", v)
+			}
+			ids = append(ids, id)
+		}
+		sql := "cs.ext_staff_id in (select sd.ext_staff_id from staff_department sd where sd.ext_department_id in (?))"
+		return buildSegmentSubquery(sql, rule.Operator, ids)
+	case constants.SegmentFieldContactWay:
+		// 渠道码下子渠道添加的客户也属于该渠道码
+		sql := "cs.state in (select s.state from (" +
+			"select cw.id, cw.state from contact_way cw union all " +
+			"select sc.contact_way_id, sc.state from contact_way_sub_channel sc" +
+			") s where s.id in (?) and s.state <> '')"
+		return buildSegmentSubquery(sql, rule.Operator, values)
+	case constants.SegmentFieldSubChannel:
+		sql := "cs.state in (select sc.state from contact_way_sub_channel sc where sc.id in (?))"
+		return buildSegmentSubquery(sql, rule.Operator, values)
+	case constants.SegmentFieldSubChannelParam:
+		return buildSegmentSubChannelParam(rule.Key, rule.Operator, values)
+	case constants.SegmentFieldGroupChat:
+		sql := "exists (select 1 from group_chat_member gcm where gcm.userid = cs.ext_customer_id and gcm.ext_chat_id in (?))"
+		return buildSegmentSubquery(sql, rule.Operator, values)
+	case constants.SegmentFieldGender:
+		return buildSegmentIn("customer.gender", rule.Operator, values)
+	case constants.SegmentFieldChurnStatus:
+		if rule.Operator != constants.SegmentOpEq || len(values) != 1 {
+			return "", nil, invalidSegmentRule("churn_status only supports eq")
+		}
+		if values[0] == strconv.Itoa(int(constants.True)) {
+			return "cs.deleted_at is not null", nil, nil
+		}
+		return "cs.deleted_at is null", nil, nil
+	case constants.SegmentFieldAddTime:
+		return buildSegmentTime("cs.createtime", rule.Operator, values, false)
+	case constants.SegmentFieldLastChatAt:
+		// 单聊消息中员工和客户互发的最后一条消息时间，毫秒
+		sql := "(select max(m.msg_time) from chat_msg m where m.room_id = '' and (" +
+			"(m.\"from\" = cs.ext_customer_id and m.to_list @> jsonb_build_array(cs.ext_staff_id)) or " +
+			"(m.\"from\" = cs.ext_staff_id and m.to_list @> jsonb_build_array(cs.ext_customer_id))))"
+		return buildSegmentTime(sql, rule.Operator, values, true)
+	}
+
+	return "", nil, invalidSegmentRule("unknown field %s", rule.Field)
+}
+
+// buildSegmentIn 字段值是否在列表中
+func buildSegmentIn(column string, op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("%s requires values", column)
+	}
+	switch op {
+	case constants.SegmentOpIn, constants.SegmentOpEq:
+		return column + " in (?)", []interface{}{values}, nil
+	case constants.SegmentOpNotIn:
+		return column + " not in (?)", []interface{}{values}, nil
+	}
+	return "", nil, invalidSegmentRule("%s does not support %s", column, op)
+}
+
+// buildSegmentSubquery 是否满足子查询条件
+func buildSegmentSubquery(sql string, op constants.SegmentOperator, values interface{}) (string, []interface{}, error) {
+	switch op {
+	case constants.SegmentOpIn:
+		return sql, []interface{}{values}, nil
+	case constants.SegmentOpNotIn:
+		return "not (" + sql + ")", []interface{}{values}, nil
+	}
+	return "", nil, invalidSegmentRule("operator %s is not supported", op)
+}
+
+// buildSegmentSubChannelParam 来源子渠道的自定义参数是否在列表中
+func buildSegmentSubChannelParam(key string, op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	if key == "" {
+		return "", nil, invalidSegmentRule("sub_channel_param requires key")
+	}
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("sub_channel_param requires values")
+	}
+	sql := "cs.state in (select sc.state from contact_way_sub_channel sc where sc.params ->> ? in (?))"
+	switch op {
+	case constants.SegmentOpEq, constants.SegmentOpIn:
+		return sql, []interface{}{key, values}, nil
+	case constants.SegmentOpNotIn:
+		return "not (" + sql + ")", []interface{}{key, values}, nil
+	}
+	return "", nil, invalidSegmentRule("sub_channel_param does not support %s", op)
+}
+
+func buildSegmentTag(op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	tagSQL := "select 1 from customer_staff_tag cst where cst.customer_staff_id = cs.id and cst.deleted_at is null"
+	switch op {
+	case constants.SegmentOpEmpty:
+		return "not exists (" + tagSQL + ")", nil, nil
+	case constants.SegmentOpNotEmpty:
+		return "exists (" + tagSQL + ")", nil, nil
+	}
+
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("tag requires values")
+	}
+	switch op {
+	case constants.SegmentOpIn:
+		return "exists (" + tagSQL + " and cst.ext_tag_id in (?))", []interface{}{values}, nil
+	case constants.SegmentOpNotIn:
+		return "not exists (" + tagSQL + " and cst.ext_tag_id in (?))", []interface{}{values}, nil
+	case constants.SegmentOpAll:
+		return "(select count(distinct cst.ext_tag_id) from customer_staff_tag cst " +
+				"where cst.customer_staff_id = cs.id and cst.deleted_at is null and cst.ext_tag_id in (?)) = ?",
+			[]interface{}{values, len(values)}, nil
+	}
+	return "", nil, invalidSegmentRule("tag does not support %s", op)
+}
+
+func buildSegmentInternalTag(op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	switch op {
+	case constants.SegmentOpEmpty:
+		return "coalesce(jsonb_array_length(cs.internal_tag_ids), 0) = 0", nil, nil
+	case constants.SegmentOpNotEmpty:
+		return "coalesce(jsonb_array_length(cs.internal_tag_ids), 0) > 0", nil, nil
+	}
+
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("internal_tag requires values")
+	}
+	tagSQL := "select 1 from jsonb_array_elements_text(cs.internal_tag_ids) it where it.value in (?)"
+	switch op {
+	case constants.SegmentOpIn:
+		return "exists (" + tagSQL + ")", []interface{}{values}, nil
+	case constants.SegmentOpNotIn:
+		return "not exists (" + tagSQL + ")", []interface{}{values}, nil
+	case constants.SegmentOpAll:
+		return "(select count(distinct it.value) from jsonb_array_elements_text(cs.internal_tag_ids) it " +
+				"where it.value in (?)) = ?",
+			[]interface{}{values, len(values)}, nil
+	}
+	return "", nil, invalidSegmentRule("internal_tag does not support %s", op)
+}
+
+func buildSegmentRemark(remarkID string, op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	if remarkID == "" {
+		return "", nil, invalidSegmentRule("remark requires key")
+	}
+	remarkSQL := "select 1 from customer_info ci, jsonb_array_elements(ci.remark_field) rf " +
+		"where ci.ext_customer_id = cs.ext_customer_id and ci.ext_staff_id = cs.ext_staff_id " +
+		"and rf->>'remark_id' = ? and coalesce(rf->>'remark_value', '') <> ''"
+	switch op {
+	case constants.SegmentOpEmpty:
+		return "not exists (" + remarkSQL + ")", []interface{}{remarkID}, nil
+	case constants.SegmentOpNotEmpty:
+		return "exists (" + remarkSQL + ")", []interface{}{remarkID}, nil
+	}
+
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("remark requires values")
+	}
+	switch op {
+	case constants.SegmentOpEq, constants.SegmentOpIn:
+		return "exists (" + remarkSQL + " and rf->>'remark_value' in (?))", []interface{}{remarkID, values}, nil
+	case constants.SegmentOpNotIn:
+		return "not exists (" + remarkSQL + " and rf->>'remark_value' in (?))", []interface{}{remarkID, values}, nil
+	case constants.SegmentOpContains:
+		return "exists (" + remarkSQL + " and rf->>'remark_value' like ?)", []interface{}{remarkID, "%" + values[0] + "%"}, nil
+	}
+	return "", nil, invalidSegmentRule("remark does not support %s", op)
+}
+
+func buildSegmentCustomerInfo(key string, op constants.SegmentOperator, values []string) (string, []interface{}, error) {
+	numeric, ok := customerInfoColumns[key]
+	if !ok {
+		return "", nil, invalidSegmentRule("unknown customer_info key %s", key)
+	}
+	infoSQL := "select 1 from customer_info ci where ci.ext_customer_id = cs.ext_customer_id and ci.ext_staff_id = cs.ext_staff_id"
+	column := "ci." + key
+
+	notEmpty := fmt.Sprintf("coalesce(%s, '') <> ''", column)
+	if numeric {
+		notEmpty = fmt.Sprintf("coalesce(%s, 0) > 0", column)
+	}
+	switch op {
+	case constants.SegmentOpEmpty:
+		return "not exists (" + infoSQL + " and " + notEmpty + ")", nil, nil
+	case constants.SegmentOpNotEmpty:
+		return "exists (" + infoSQL + " and " + notEmpty + ")", nil, nil
+	}
+
+	if len(values) == 0 {
+		return "", nil, invalidSegmentRule("customer_info requires values")
+	}
+	args := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if !numeric {
+			args = append(args, v)
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return "", nil, invalidSegmentRule("invalid number %s", v)
+		}
+		args = append(args, n)
+	}
+
+	switch {
+	case op == constants.SegmentOpEq:
+		return "exists (" + infoSQL + " and " + column + " = ?)", args[:1], nil
+	case op == constants.SegmentOpContains && !numeric:
+		return "exists (" + infoSQL + " and " + column + " like ?)", []interface{}{"%" + values[0] + "%"}, nil
+	case op == constants.SegmentOpGte && numeric:
+		return "exists (" + infoSQL + " and " + column + " >= ?)", args[:1], nil
+	case op == constants.SegmentOpLte && numeric:
+		return "exists (" + infoSQL + " and " + column + " <= ?)", args[:1], nil
+	case op == constants.SegmentOpBetween && numeric && len(args) == 2:
+		return "exists (" + infoSQL + " and " + column + " between ? and ?)", args, nil
+	}
+	return "", nil, invalidSegmentRule("customer_info %s does not support %s", key, op)
+}
+
+// buildSegmentTime 时间字段比较，日期按天计算，inMillis为毫秒时间戳字段
+func buildSegmentTime(
+	column string, op constants.SegmentOperator, values []string, inMillis bool) (string, []interface{}, error) {
+	switch op {
+	case constants.SegmentOpEmpty:
+		return column + " is null", nil, nil
+	case constants.SegmentOpNotEmpty:
+		return column + " is not null", nil, nil
+	}
+
+	days := make([]time.Time, 0, len(values))
+	for _, v := range values {
+		t, err := time.ParseInLocation(constants.DateLayout, v, time.Local)
+		if err != nil {
+			return "", nil, invalidSegmentRule("invalid date %s", v)
+		}
+		days = append(days, t)
+	}
+	toArg := func(t time.Time) interface{} {
+		if inMillis {
+			return t.UnixNano() / int64(time.Millisecond)
+		}
+		return t
+	}
+
+	switch {
+	case op == constants.SegmentOpGte && len(days) == 1:
+		return column + " >= ?", []interface{}{toArg(days[0])}, nil
+	case op == constants.SegmentOpLte && len(days) == 1:
+		return column + " < ?", []interface{}{toArg(days[0].AddDate(0, 0, 1))}, nil
+	case op == constants.SegmentOpBetween && len(days) == 2:
+		return column + " >= ? and " + column + " < ?",
+			[]interface{}{toArg(days[0]), toArg(days[1].AddDate(0, 0, 1))}, nil
+	}
+	return "", nil, invalidSegmentRule("%s does not support %s", column, op)
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"time"
+)
+
+// CustomerSop 客户SOP，客户被触发后按步骤定时跟进
+type CustomerSop struct {
+	ExtCorpModel
+	// SOP名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:SOP名称"`
+	// 是否启用
+	Enable constants.Boolean `json:"enable" gorm:"type:smallint;default:1;comment:是否启用 1-是 2-否"`
+	// 触发方式
+	TriggerType constants.CustomerSopTriggerType `json:"trigger_type" gorm:"type:smallint;comment:触发方式 1-通过渠道码添加 2-被打上标签"`
+	// 触发的渠道码ID，触发方式为渠道码时有效
+	TriggerContactWayIDs constants.StringArrayField `json:"trigger_contact_way_ids" gorm:"type:jsonb;default:'[]';comment:触发的渠道码ID"`
+	// 触发的标签ExtID，命中任意一个即触发，触发方式为标签时有效
+	TriggerExtTagIDs constants.StringArrayField `json:"trigger_ext_tag_ids" gorm:"type:jsonb;default:'[]';comment:触发的标签ExtID"`
+	// 执行方式
+	DeliverType constants.CustomerSopDeliverType `json:"deliver_type" gorm:"type:smallint;comment:执行方式 1-提醒员工 2-单客户群发"`
+	// 步骤
+	Steps []CustomerSopStep `json:"steps" gorm:"foreignKey:CustomerSopID;references:ID"`
+	Timestamp
+}
+
+// CustomerSopStep 客户SOP的步骤
+type CustomerSopStep struct {
+	ExtCorpModel
+	// SOP ID
+	CustomerSopID string `json:"customer_sop_id" gorm:"type:bigint;index;comment:SOP ID"`
+	// 步骤序号，从1开始
+	Seq int `json:"seq" gorm:"type:int;comment:步骤序号"`
+	// 触发后延迟执行的分钟数
+	DelayMinutes int `json:"delay_minutes" gorm:"type:int;comment:触发后延迟分钟数"`
+	// 消息内容
+	Msg constants.AutoReplyField `json:"msg" gorm:"type:jsonb;comment:消息内容"`
+	// 素材库素材ID，作为附件追加在消息内容之后
+	MaterialID string `json:"material_id" gorm:"type:varchar(20);comment:素材库素材ID"`
+	// 执行条件，客户需已有全部标签，为空不限制
+	ConditionExtTagIDs constants.StringArrayField `json:"condition_ext_tag_ids" gorm:"type:jsonb;default:'[]';comment:执行条件标签ExtID"`
+	Timestamp
+}
+
+// CustomerSopTask 客户SOP步骤的执行任务，客户被触发时为每个步骤生成一条
+type CustomerSopTask struct {
+	ExtCorpModel
+	// SOP ID
+	CustomerSopID string `json:"customer_sop_id" gorm:"type:bigint;uniqueIndex:idx_customer_sop_task;comment:SOP ID"`
+	// 步骤ID
+	StepID string `json:"step_id" gorm:"type:bigint;uniqueIndex:idx_customer_sop_task;comment:步骤ID"`
+	// 步骤序号
+	Seq int `json:"seq" gorm:"type:int;comment:步骤序号"`
+	// 跟进员工
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:char(64);uniqueIndex:idx_customer_sop_task;comment:员工ID"`
+	// 客户
+	ExtCustomerID string `json:"ext_customer_id" gorm:"type:char(64);uniqueIndex:idx_customer_sop_task;comment:客户ID"`
+	// 计划执行时间
+	ExecuteAt time.Time `json:"execute_at" gorm:"comment:计划执行时间"`
+	// 任务状态
+	Status constants.CustomerSopTaskStatus `json:"status" gorm:"type:smallint;index;comment:任务状态 1-待执行 2-已执行 3-已跳过 4-执行失败 5-已取消"`
+	// 跳过或失败的原因
+	Reason string `json:"reason" gorm:"type:varchar(255);comment:跳过或失败原因"`
+	// 单客户群发的企业微信消息ID
+	ExtMsgID string `json:"ext_msg_id" gorm:"type:varchar(64);comment:企业微信群发消息ID"`
+	// 实际执行时间
+	FinishedAt *time.Time `json:"finished_at" gorm:"comment:实际执行时间"`
+	Timestamp
+}
+
+func (o CustomerSop) Get(id string, extCorpID string) (item CustomerSop, err error) {
+	err = DB.Model(&CustomerSop{}).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("seq")
+		}).
+		Where("ext_corp_id = ? and id = ?", extCorpID, id).
+		First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First CustomerSop failed")
+		return
+	}
+
+	return
+}
+
+func (o CustomerSop) Query(
+	req requests.QueryCustomerSopReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []CustomerSop, total int64, err error) {
+	db := DB.Model(&CustomerSop{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.Name != "" {
+		db = db.Where("name like ?", "%"+req.Name+"%")
+	}
+	if req.TriggerType != 0 {
+		db = db.Where("trigger_type = ?", req.TriggerType)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count CustomerSop failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("seq")
+	}).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerSop failed")
+		return
+	}
+
+	return
+}
+
+// QueryEnabledByTrigger 查询企业启用的指定触发方式的SOP
+func (o CustomerSop) QueryEnabledByTrigger(
+	triggerType constants.CustomerSopTriggerType, extCorpID string) (items []CustomerSop, err error) {
+	err = DB.Model(&CustomerSop{}).
+		Preload("Steps").
+		Where("ext_corp_id = ? and enable = ? and trigger_type = ?", extCorpID, constants.True, triggerType).
+		Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerSop failed")
+		return
+	}
+	return
+}
+
+// Create 创建SOP及其步骤
+func (o CustomerSop) Create(item CustomerSop) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create CustomerSop failed")
+	}
+	return nil
+}
+
+// Update 更新SOP，未出现在新步骤中的旧步骤会被删除
+func (o CustomerSop) Update(item CustomerSop) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&CustomerSop{}).
+			Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+			Select("name", "enable", "trigger_type", "trigger_contact_way_ids", "trigger_ext_tag_ids", "deliver_type").
+			Updates(&item).Error
+		if err != nil {
+			return errors.Wrap(err, "Update CustomerSop failed")
+		}
+
+		stepIDs := make([]string, 0, len(item.Steps))
+		for _, step := range item.Steps {
+			stepIDs = append(stepIDs, step.ID)
+		}
+		err = tx.Where("customer_sop_id = ? and id not in (?)", item.ID, stepIDs).Delete(&CustomerSopStep{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerSopStep failed")
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"seq", "delay_minutes", "msg", "material_id", "condition_ext_tag_ids", "updated_at"}),
+		}).Create(&item.Steps).Error
+		if err != nil {
+			return errors.Wrap(err, "Upsert CustomerSopStep failed")
+		}
+		return nil
+	})
+}
+
+func (o CustomerSop) Delete(ids []string, extCorpID string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&CustomerSop{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerSop failed")
+		}
+		err = tx.Where("ext_corp_id = ? and customer_sop_id in (?)", extCorpID, ids).Delete(&CustomerSopStep{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerSopStep failed")
+		}
+		return nil
+	})
+}
+
+// Create 创建任务，同一客户的同一步骤已有任务时不再创建
+// return: created 是否新建了任务
+func (o CustomerSopTask) Create(task CustomerSopTask) (created bool, err error) {
+	res := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&task)
+	if res.Error != nil {
+		err = errors.Wrap(res.Error, "Create CustomerSopTask failed")
+		return
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (o CustomerSopTask) Get(id string) (item CustomerSopTask, err error) {
+	err = DB.Model(&CustomerSopTask{}).Where("id = ?", id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First CustomerSopTask failed")
+		return
+	}
+	return
+}
+
+// Finish 记录任务执行结果，只处理待执行的任务
+func (o CustomerSopTask) Finish(id string, status constants.CustomerSopTaskStatus, reason string, extMsgID string) error {
+	err := DB.Model(&CustomerSopTask{}).
+		Where("id = ? and status = ?", id, constants.CustomerSopTaskPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reason":      reason,
+			"ext_msg_id":  extMsgID,
+			"finished_at": time.Now(),
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, "Update CustomerSopTask failed")
+	}
+	return nil
+}
+
+// CancelPending 取消SOP下待执行的任务
+func (o CustomerSopTask) CancelPending(customerSopIDs []string, extCorpID string) error {
+	err := DB.Model(&CustomerSopTask{}).
+		Where("ext_corp_id = ? and customer_sop_id in (?) and status = ?",
+			extCorpID, customerSopIDs, constants.CustomerSopTaskPending).
+		Updates(map[string]interface{}{
+			"status":      constants.CustomerSopTaskCanceled,
+			"finished_at": time.Now(),
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, "Cancel CustomerSopTask failed")
+	}
+	return nil
+}
+
+func (o CustomerSopTask) Query(
+	req requests.QueryCustomerSopTaskReq, extCorpID string, pager *app.Pager) (items []CustomerSopTask, total int64, err error) {
+	db := DB.Model(&CustomerSopTask{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.CustomerSopID != "" {
+		db = db.Where("customer_sop_id = ?", req.CustomerSopID)
+	}
+	if req.ExtStaffID != "" {
+		db = db.Where("ext_staff_id = ?", req.ExtStaffID)
+	}
+	if req.ExtCustomerID != "" {
+		db = db.Where("ext_customer_id = ?", req.ExtCustomerID)
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count CustomerSopTask failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("execute_at desc").
+		Offset(pager.GetOffset()).Limit(pager.GetLimit()).
+		Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerSopTask failed")
+		return
+	}
+
+	return
+}
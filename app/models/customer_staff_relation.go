@@ -368,3 +368,45 @@ func (o CustomerStaff) QueryIDs(signatures []string) (ids []RelationIDSign, err
 		Find(&ids).Error
 	return
 }
+
+// CustomerTemplateData 渲染消息模板所需的客户数据
+type CustomerTemplateData struct {
+	ExtCustomerID    string `json:"ext_customer_id"`
+	CustomerName     string `json:"customer_name"`
+	CustomerCorpName string `json:"customer_corp_name"`
+	// 员工对客户的备注、描述和备注的企业名称
+	Remark         string `json:"remark"`
+	Description    string `json:"description"`
+	RemarkCorpName string `json:"remark_corp_name"`
+	// 员工编辑的客户画像
+	Age             int                           `json:"age"`
+	Email           string                        `json:"email"`
+	PhoneNumber     string                        `json:"phone_number"`
+	QQ              string                        `json:"qq"`
+	Address         string                        `json:"address"`
+	Birthday        string                        `json:"birthday"`
+	Weibo           string                        `json:"weibo"`
+	InfoDescription string                        `json:"info_description"`
+	RemarkField     constants.CustomerRemarkField `json:"remark_field"`
+}
+
+// QueryTemplateData 查询员工的客户用于渲染消息模板的数据
+func (o CustomerStaff) QueryTemplateData(extStaffID string, extCustomerIDs []string) (res []CustomerTemplateData, err error) {
+	err = DB.Table("customer_staff cs").
+		Joins("join customer on customer.ext_id = cs.ext_customer_id").
+		Joins("left join customer_info ci on ci.ext_customer_id = cs.ext_customer_id and ci.ext_staff_id = cs.ext_staff_id").
+		Where("cs.ext_staff_id = ? and cs.ext_customer_id in (?)", extStaffID, extCustomerIDs).
+		Where("cs.deleted_at is null").
+		Select("cs.ext_customer_id, customer.name as customer_name, customer.corp_name as customer_corp_name, " +
+			"cs.remark, cs.description, cs.remark_corp_name, " +
+			"coalesce(ci.age, 0) as age, coalesce(ci.email, '') as email, coalesce(ci.phone_number, '') as phone_number, " +
+			"coalesce(ci.qq, '') as qq, coalesce(ci.address, '') as address, coalesce(ci.birthday, '') as birthday, " +
+			"coalesce(ci.weibo, '') as weibo, coalesce(ci.description, '') as info_description, " +
+			"coalesce(ci.remark_field, '[]'::jsonb) as remark_field").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query customer template data failed")
+		return
+	}
+	return
+}
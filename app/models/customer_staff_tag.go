@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/pkg/errors"
 	"gorm.io/gorm/clause"
 	"openscrm/app/constants"
 )
@@ -51,3 +52,30 @@ func (c CustomerStaffTag) Upsert(tag []CustomerStaffTag) error {
 		),
 	}).CreateInBatches(&tag, len(tag)).Error
 }
+
+// QueryExtTagIDs 查询员工给客户打的标签ID
+func (c CustomerStaffTag) QueryExtTagIDs(customerStaffID string) (extTagIDs []string, err error) {
+	err = DB.Model(&CustomerStaffTag{}).
+		Where("customer_staff_id = ?", customerStaffID).
+		Pluck("ext_tag_id", &extTagIDs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Pluck ext_tag_id failed")
+		return
+	}
+	return
+}
+
+// HasAllTags 员工是否给客户打了全部指定标签
+func (c CustomerStaffTag) HasAllTags(extStaffID string, extCustomerID string, extTagIDs []string) (bool, error) {
+	var count int64
+	err := DB.Table("customer_staff_tag cst").
+		Joins("join customer_staff cs on cs.id = cst.customer_staff_id").
+		Where("cs.ext_staff_id = ? and cs.ext_customer_id = ? and cs.deleted_at is null", extStaffID, extCustomerID).
+		Where("cst.ext_tag_id in (?) and cst.deleted_at is null", extTagIDs).
+		Distinct("cst.ext_tag_id").
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "Count customer staff tags failed")
+	}
+	return count == int64(len(extTagIDs)), nil
+}
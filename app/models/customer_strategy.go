@@ -0,0 +1,190 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/util"
+)
+
+// CustomerStrategy 客户联系规则组，企微规则组在本地的镜像
+// 规则组可绑定角色，绑定后规则组的管理员与该角色下的员工保持一致
+type CustomerStrategy struct {
+	ExtCorpModel
+	// 企微规则组ID
+	ExtStrategyID int64 `json:"ext_strategy_id" gorm:"uniqueIndex:idx_ext_corp_id_ext_strategy_id;comment:企微规则组ID"`
+	// 企微父规则组ID，没有父规则组时为0
+	ExtParentID int64 `json:"ext_parent_id" gorm:"comment:企微父规则组ID"`
+	// 规则组名称
+	Name string `json:"name" gorm:"type:varchar(255);comment:规则组名称"`
+	// 规则组管理员外部员工ID
+	AdminList constants.StringArrayField `json:"admin_list" gorm:"type:jsonb;comment:规则组管理员外部员工ID"`
+	// 权限配置
+	Privilege constants.CustomerStrategyPrivilege `json:"privilege" gorm:"type:jsonb;comment:权限配置"`
+	// 绑定的角色ID
+	RoleID *string `json:"role_id" gorm:"type:bigint;index;comment:绑定的角色ID"`
+	// 管理范围
+	Ranges []CustomerStrategyRange `json:"ranges" gorm:"foreignKey:CustomerStrategyID"`
+	Timestamp
+}
+
+// CustomerStrategyRange 客户联系规则组的管理范围
+type CustomerStrategyRange struct {
+	ExtCorpModel
+	// 规则组ID
+	CustomerStrategyID string `json:"customer_strategy_id" gorm:"type:bigint;index;comment:规则组ID"`
+	// 范围类型 1-员工 2-部门
+	Type constants.CustomerStrategyRangeType `json:"type" gorm:"type:smallint;comment:范围类型,1-员工 2-部门"`
+	// 外部员工ID
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);index;comment:外部员工ID"`
+	// 外部部门ID
+	ExtDepartmentID int64 `json:"ext_department_id" gorm:"index;comment:外部部门ID"`
+	Timestamp
+}
+
+func (o CustomerStrategy) Get(id string, extCorpID string) (item CustomerStrategy, err error) {
+	err = DB.Model(&CustomerStrategy{}).Preload("Ranges").
+		Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First CustomerStrategy failed")
+		return
+	}
+
+	return
+}
+
+func (o CustomerStrategy) Query(
+	req requests.QueryCustomerStrategyReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []CustomerStrategy, total int64, err error) {
+	db := DB.Model(&CustomerStrategy{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.Name != "" {
+		db = db.Where("name like ?", req.Name+"%")
+	}
+
+	if req.RoleID != "" {
+		db = db.Where("role_id = ?", req.RoleID)
+	}
+
+	if req.ExtDepartmentID != 0 {
+		db = db.Where("id in (?)", DB.Model(&CustomerStrategyRange{}).Select("customer_strategy_id").
+			Where("type = ? and ext_department_id = ?", constants.CustomerStrategyRangeTypeDepartment, req.ExtDepartmentID))
+	}
+
+	if req.ExtStaffID != "" {
+		db = db.Where("id in (?) or admin_list @> ?::jsonb",
+			DB.Model(&CustomerStrategyRange{}).Select("customer_strategy_id").
+				Where("type = ? and ext_staff_id = ?", constants.CustomerStrategyRangeTypeStaff, req.ExtStaffID),
+			util.ToJSONBSingleArray(req.ExtStaffID))
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count CustomerStrategy failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Preload("Ranges").Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerStrategy failed")
+		return
+	}
+
+	return
+}
+
+// GetByRoleIDs 查询绑定了指定角色的规则组
+func (o CustomerStrategy) GetByRoleIDs(roleIDs []string) (items []CustomerStrategy, err error) {
+	err = DB.Model(&CustomerStrategy{}).Where("role_id in (?)", roleIDs).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerStrategy failed")
+		return
+	}
+	return
+}
+
+// GetExtStrategyIDs 查询本地已有的企微规则组ID
+func (o CustomerStrategy) GetExtStrategyIDs(extCorpID string) (res map[int64]string, err error) {
+	items := make([]CustomerStrategy, 0)
+	err = DB.Model(&CustomerStrategy{}).Select("id, ext_strategy_id").Where("ext_corp_id = ?", extCorpID).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find CustomerStrategy failed")
+		return
+	}
+
+	res = make(map[int64]string, len(items))
+	for _, item := range items {
+		res[item.ExtStrategyID] = item.ID
+	}
+	return
+}
+
+// Save 保存规则组及其管理范围，管理范围整体替换
+func (o CustomerStrategy) Save(item CustomerStrategy) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		ranges := item.Ranges
+		item.Ranges = nil
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ext_parent_id", "name", "admin_list", "privilege", "role_id", "updated_at"}),
+		}).Create(&item).Error
+		if err != nil {
+			return errors.Wrap(err, "Save CustomerStrategy failed")
+		}
+
+		err = tx.Unscoped().Where("customer_strategy_id = ?", item.ID).Delete(&CustomerStrategyRange{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerStrategyRange failed")
+		}
+
+		if len(ranges) == 0 {
+			return nil
+		}
+
+		for i := range ranges {
+			ranges[i].CustomerStrategyID = item.ID
+			ranges[i].ExtCorpID = item.ExtCorpID
+		}
+		err = tx.CreateInBatches(&ranges, 500).Error
+		if err != nil {
+			return errors.Wrap(err, "Create CustomerStrategyRange failed")
+		}
+
+		return nil
+	})
+}
+
+// UpdateAdminList 更新规则组管理员
+func (o CustomerStrategy) UpdateAdminList(id string, adminList []string) error {
+	return DB.Model(&CustomerStrategy{}).Where("id = ?", id).
+		Update("admin_list", constants.StringArrayField(adminList)).Error
+}
+
+func (o CustomerStrategy) Delete(ids []string, extCorpID string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&CustomerStrategy{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerStrategy failed")
+		}
+
+		err = tx.Where("customer_strategy_id in (?)", ids).Delete(&CustomerStrategyRange{}).Error
+		if err != nil {
+			return errors.Wrap(err, "Delete CustomerStrategyRange failed")
+		}
+
+		return nil
+	})
+}
@@ -2,9 +2,12 @@ package models
 
 import (
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"openscrm/app/constants"
+	"openscrm/app/requests"
 	"openscrm/common/app"
+	"time"
 )
 
 // GroupChatMassMsg 客户群群发消息内容
@@ -36,8 +39,211 @@ type GroupChatMassMsg struct {
 	Timestamp
 }
 
-func (m GroupChatMassMsg) Create(msg GroupChatMassMsg) error {
-	return DB.Create(&msg).Error
+// GroupChatMassMsgChat 客户群群发的目标群及发送结果
+type GroupChatMassMsgChat struct {
+	ExtCorpModel
+	GroupChatMassMsgID string `gorm:"type:bigint;index;comment:客户群群发ID" json:"group_chat_mass_msg_id"`
+	// 群主
+	ExtStaffID string `gorm:"type:char(64);index;comment:群主ExtID" json:"ext_staff_id"`
+	// 群聊ID
+	ExtChatID string `gorm:"type:char(64);comment:群聊ID" json:"ext_chat_id"`
+	// 群主对应的wx消息ID
+	ExtMsgID string `gorm:"type:varchar(64);index;comment:群主对应的微信消息ID" json:"ext_msg_id"`
+	// 群主的企微群发提交状态 0-待提交 1-已提交 2-提交失败
+	SubmitStatus constants.MassMsgSubmitStatus `gorm:"type:smallint;default:0;comment:0-待提交 1-已提交 2-提交失败" json:"submit_status"`
+	// 发送状态 0-未发送 1-已发送 5-群主提交失败
+	SendStatus constants.MassMsgSendStatus `gorm:"type:smallint;default:0;comment:0-未发送 1-已发送 5-群主提交失败" json:"send_status"`
+	// 群主发送的时间
+	SendTime *time.Time `gorm:"comment:发送时间" json:"send_time"`
+	Timestamp
+}
+
+// GroupChatMassMsgOwner 已提交到企微的群主
+type GroupChatMassMsgOwner struct {
+	GroupChatMassMsgID string `json:"group_chat_mass_msg_id"`
+	ExtStaffID         string `json:"ext_staff_id"`
+	ExtMsgID           string `json:"ext_msg_id"`
+}
+
+func (m GroupChatMassMsg) Create(msg GroupChatMassMsg, chats []GroupChatMassMsgChat) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&msg).Error
+		if err != nil {
+			return err
+		}
+		if len(chats) > 0 {
+			return tx.CreateInBatches(chats, 1000).Error
+		}
+		return nil
+	})
+}
+
+// Update 修改定时群发，目标群按新的群主重新生成
+func (m GroupChatMassMsg) Update(msg GroupChatMassMsg, chats []GroupChatMassMsgChat) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", msg.ID).Updates(&msg).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("group_chat_mass_msg_id = ?", msg.ID).Delete(&GroupChatMassMsgChat{}).Error
+		if err != nil {
+			return err
+		}
+		if len(chats) > 0 {
+			return tx.CreateInBatches(chats, 1000).Error
+		}
+		return nil
+	})
+}
+
+// RefreshStatistic 按群的发送结果刷新群发统计，已提交的群主都已发送时群发完成
+// 提交失败的群主无法发送，其群计入未送达，不计入未发送群主
+func (m GroupChatMassMsg) RefreshStatistic(id string) error {
+	res := struct {
+		DeliveredNum   int
+		UnDeliveredNum int
+		SuccessNum     int
+		FailedNum      int
+	}{}
+	err := DB.Raw("select count(*) filter (where o.sent > 0) as delivered_num, "+
+		"count(*) filter (where o.sent = 0 and o.submitted > 0) as un_delivered_num, "+
+		"coalesce(sum(o.sent), 0) as success_num, coalesce(sum(o.total - o.sent), 0) as failed_num "+
+		"from (select ext_staff_id, count(*) as total, count(*) filter (where send_status = ?) as sent, "+
+		"count(*) filter (where submit_status = ?) as submitted "+
+		"from group_chat_mass_msg_chat where group_chat_mass_msg_id = ? and deleted_at is null group by ext_staff_id) o",
+		constants.MassMsgSent, constants.MassMsgSubmitted, id).Scan(&res).Error
+	if err != nil {
+		return errors.Wrap(err, "Count group chat mass msg result failed")
+	}
+
+	updates := map[string]interface{}{
+		"delivered_num":    res.DeliveredNum,
+		"un_delivered_num": res.UnDeliveredNum,
+		"success_num":      res.SuccessNum,
+		"failed_num":       res.FailedNum,
+	}
+	if res.UnDeliveredNum == 0 && res.DeliveredNum > 0 {
+		updates["mission_status"] = constants.Sent
+	}
+	err = DB.Model(&GroupChatMassMsg{}).Where("id = ? and mission_status = ?", id, constants.Sending).
+		Updates(updates).Error
+	if err != nil {
+		return errors.Wrap(err, "Update group chat mass msg statistic failed")
+	}
+	return nil
+}
+
+// QueryTargetChats 群主名下未解散的群，即客户群群发的目标群
+func (o GroupChatMassMsgChat) QueryTargetChats(
+	extCorpID string, extStaffIDs []string) (res []requests.GroupChatMassMsgChatResult, err error) {
+	err = DB.Model(&GroupChat{}).
+		Where("ext_corp_id = ? and owner in (?) and status = ?", extCorpID, extStaffIDs, constants.GroupChatStatusNotDismissed).
+		Select("owner as ext_staff_id, ext_chat_id, name as chat_name").
+		Order("owner, ext_chat_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query target chats failed")
+		return
+	}
+	return
+}
+
+// SetExtMsgID 记录群主的wx消息ID，群主的群标记为已提交
+func (o GroupChatMassMsgChat) SetExtMsgID(msgID string, extStaffID string, extMsgID string) error {
+	return DB.Model(&GroupChatMassMsgChat{}).
+		Where("group_chat_mass_msg_id = ? and ext_staff_id = ?", msgID, extStaffID).
+		Updates(map[string]interface{}{
+			"ext_msg_id":    extMsgID,
+			"submit_status": constants.MassMsgSubmitted,
+			"send_status":   constants.MassMsgUnsent,
+		}).Error
+}
+
+// SetSubmitFailed 群主的企微群发提交失败，其群标记为无法发送
+func (o GroupChatMassMsgChat) SetSubmitFailed(msgID string, extStaffID string) error {
+	return DB.Model(&GroupChatMassMsgChat{}).
+		Where("group_chat_mass_msg_id = ? and ext_staff_id = ?", msgID, extStaffID).
+		Updates(map[string]interface{}{
+			"submit_status": constants.MassMsgSubmitFailed,
+			"send_status":   constants.MassMsgSubmitFailure,
+		}).Error
+}
+
+// QueryUnfinishedOwners 查询发送中的客户群群发里还有群未发送的群主
+func (o GroupChatMassMsgChat) QueryUnfinishedOwners(extCorpID string) (res []GroupChatMassMsgOwner, err error) {
+	err = DB.Model(&GroupChatMassMsgChat{}).
+		Joins("join group_chat_mass_msg on group_chat_mass_msg.id = group_chat_mass_msg_chat.group_chat_mass_msg_id").
+		Where("group_chat_mass_msg.ext_corp_id = ? and group_chat_mass_msg.mission_status = ?", extCorpID, constants.Sending).
+		Where("group_chat_mass_msg_chat.ext_msg_id != '' and group_chat_mass_msg_chat.send_status = ?", constants.MassMsgUnsent).
+		Distinct("group_chat_mass_msg_chat.group_chat_mass_msg_id", "group_chat_mass_msg_chat.ext_staff_id",
+			"group_chat_mass_msg_chat.ext_msg_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query unfinished owners failed")
+		return
+	}
+	return
+}
+
+// SetSent 将群主已发送的群标记为已发送
+func (o GroupChatMassMsgChat) SetSent(msgID string, extStaffID string, extChatID string, sendTime time.Time) error {
+	return DB.Model(&GroupChatMassMsgChat{}).
+		Where("group_chat_mass_msg_id = ? and ext_staff_id = ? and ext_chat_id = ?", msgID, extStaffID, extChatID).
+		Updates(map[string]interface{}{
+			"send_status": constants.MassMsgSent,
+			"send_time":   sendTime,
+		}).Error
+}
+
+// QueryOwnerResult 按群主统计已发送和提交失败的群数
+func (o GroupChatMassMsgChat) QueryOwnerResult(msgID string) (res []requests.GroupChatMassMsgOwnerResult, err error) {
+	err = DB.Model(&GroupChatMassMsgChat{}).
+		Joins("left join staff on staff.ext_id = group_chat_mass_msg_chat.ext_staff_id and staff.ext_corp_id = group_chat_mass_msg_chat.ext_corp_id").
+		Where("group_chat_mass_msg_chat.group_chat_mass_msg_id = ?", msgID).
+		Select("group_chat_mass_msg_chat.ext_staff_id, max(staff.name) as staff_name, count(*) as total, "+
+			"count(*) filter (where send_status = ?) as sent, count(*) filter (where submit_status = ?) as submit_failed",
+			constants.MassMsgSent, constants.MassMsgSubmitFailed).
+		Group("group_chat_mass_msg_chat.ext_staff_id").
+		Order("group_chat_mass_msg_chat.ext_staff_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query owner result failed")
+		return
+	}
+	return
+}
+
+// QueryChatResult 分页查询每个群的发送状态
+func (o GroupChatMassMsgChat) QueryChatResult(
+	msgID string, req requests.QueryGroupChatMassMsgResultReq, pager *app.Pager) (
+	res []requests.GroupChatMassMsgChatResult, total int64, err error) {
+	db := DB.Model(&GroupChatMassMsgChat{}).
+		Joins("left join group_chat on group_chat.ext_chat_id = group_chat_mass_msg_chat.ext_chat_id").
+		Where("group_chat_mass_msg_chat.group_chat_mass_msg_id = ?", msgID)
+	if req.ExtStaffID != "" {
+		db = db.Where("group_chat_mass_msg_chat.ext_staff_id = ?", req.ExtStaffID)
+	}
+	if req.SendStatus != nil {
+		db = db.Where("group_chat_mass_msg_chat.send_status = ?", *req.SendStatus)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count chat result failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Select("group_chat_mass_msg_chat.ext_staff_id, group_chat_mass_msg_chat.ext_chat_id, " +
+		"group_chat.name as chat_name, group_chat_mass_msg_chat.send_status").
+		Order("group_chat_mass_msg_chat.id").
+		Offset(pager.GetOffset()).Limit(pager.GetLimit()).
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query chat result failed")
+		return
+	}
+	return
 }
 
 func (m GroupChatMassMsg) Get(id string) (msg GroupChatMassMsg, err error) {
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/id_generator"
+	"time"
+)
+
+// GroupChatMemberLog 群成员入群退群记录，群成员变动回调时写入，不会被每日清理
+type GroupChatMemberLog struct {
+	ExtCorpModel
+	// 群聊ID
+	ExtChatID string `json:"ext_chat_id" gorm:"type:varchar(64);index:idx_member_log_chat_id_date;comment:群聊ID"`
+	// 群成员ID
+	Userid string `json:"userid" gorm:"type:varchar(64);index;comment:群成员ID"`
+	// 成员类型 1-企业成员 2-外部联系人
+	Type int `json:"type" gorm:"type:smallint;comment:成员类型"`
+	// 变动类型 1-入群 2-退群
+	EventType constants.GroupChatMemberEventType `json:"event_type" gorm:"type:smallint;comment:变动类型"`
+	// 入群方式 1-由成员直接邀请入群 2-由成员通过邀请链接入群 3-通过扫描群二维码入群
+	JoinScene int `json:"join_scene" gorm:"type:smallint;comment:入群方式"`
+	// 退群方式 0-自己退群 1-群主或群管理员移出，入群记录为0
+	QuitScene int `json:"quit_scene" gorm:"type:smallint;comment:退群方式"`
+	// 邀请者ExtStaffID
+	Invitor string `json:"invitor" gorm:"type:varchar(64);index;comment:邀请者"`
+	// 入群时间，退群记录为此前的入群时间
+	JoinTime int `json:"join_time" gorm:"type:bigint;comment:入群时间"`
+	// 变动时间
+	EventTime time.Time `json:"event_time" gorm:"comment:变动时间"`
+	// 变动日期
+	Date constants.DateField `json:"date" gorm:"type:date;index:idx_member_log_chat_id_date;comment:变动日期"`
+	Timestamp
+}
+
+func (o GroupChatMemberLog) TableName() string {
+	return "group_chat_member_log"
+}
+
+// GroupChatMemberDailyNum 客户群每日入群退群人数
+type GroupChatMemberDailyNum struct {
+	Date    string `json:"date"`
+	JoinNum int64  `json:"join_num"`
+	QuitNum int64  `json:"quit_num"`
+}
+
+// GroupChatInviterRank 员工邀请入群人数
+type GroupChatInviterRank struct {
+	Invitor     string `json:"invitor"`
+	InvitorName string `json:"invitor_name"`
+	// 邀请入群人次
+	JoinNum int64 `json:"join_num"`
+	// 被邀请的成员中仍在群内的人数
+	RetainedNum int64 `json:"retained_num"`
+}
+
+// RecordChanges 对比变动前后的群成员，记录入群或退群
+// Detail: 企微回调不含变动的成员，需要对比本地保存的成员和最新的群成员列表，只记录eventType对应的变动
+func (o GroupChatMemberLog) RecordChanges(extCorpID string, extChatID string, eventType constants.GroupChatMemberEventType,
+	before []GroupChatMember, after []GroupChatMember, quitScene int, eventTime time.Time) error {
+	beforeMap := make(map[string]GroupChatMember, len(before))
+	for _, member := range before {
+		beforeMap[member.Userid] = member
+	}
+	afterMap := make(map[string]GroupChatMember, len(after))
+	for _, member := range after {
+		afterMap[member.Userid] = member
+	}
+
+	newLog := func(member GroupChatMember, eventType constants.GroupChatMemberEventType) GroupChatMemberLog {
+		return GroupChatMemberLog{
+			ExtCorpModel: ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+			ExtChatID:    extChatID,
+			Userid:       member.Userid,
+			Type:         member.Type,
+			EventType:    eventType,
+			JoinScene:    member.JoinScene,
+			Invitor:      member.Invitor,
+			JoinTime:     member.JoinTime,
+			EventTime:    eventTime,
+			Date:         constants.DateField(eventTime.In(constants.PRCLocation).Format(constants.DateLayout)),
+		}
+	}
+
+	logs := make([]GroupChatMemberLog, 0)
+	for _, member := range after {
+		if _, ok := beforeMap[member.Userid]; !ok && eventType == constants.GroupChatMemberEventJoin {
+			logs = append(logs, newLog(member, constants.GroupChatMemberEventJoin))
+		}
+	}
+	for _, member := range before {
+		if _, ok := afterMap[member.Userid]; !ok && eventType == constants.GroupChatMemberEventQuit {
+			item := newLog(member, constants.GroupChatMemberEventQuit)
+			item.QuitScene = quitScene
+			logs = append(logs, item)
+		}
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	err := DB.CreateInBatches(&logs, 200).Error
+	if err != nil {
+		return errors.Wrap(err, "Create GroupChatMemberLog failed")
+	}
+	return nil
+}
+
+// queryDB 按条件筛选群成员变动记录
+func (o GroupChatMemberLog) queryDB(req requests.QueryGroupChatMemberLogReq, extCorpID string) *gorm.DB {
+	db := DB.Model(&GroupChatMemberLog{}).Where("ext_corp_id = ?", extCorpID)
+	if req.ExtChatID != "" {
+		db = db.Where("ext_chat_id = ?", req.ExtChatID)
+	}
+	if req.Userid != "" {
+		db = db.Where("userid = ?", req.Userid)
+	}
+	if req.EventType > 0 {
+		db = db.Where("event_type = ?", req.EventType)
+	}
+	if req.QuitScene != nil {
+		db = db.Where("quit_scene = ?", *req.QuitScene)
+	}
+	if req.Invitor != "" {
+		db = db.Where("invitor = ?", req.Invitor)
+	}
+	if req.StartDate != "" {
+		db = db.Where("date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		db = db.Where("date <= ?", req.EndDate)
+	}
+	return db
+}
+
+func (o GroupChatMemberLog) Query(
+	req requests.QueryGroupChatMemberLogReq, extCorpID string, pager *app.Pager) (items []GroupChatMemberLog, total int64, err error) {
+	items = make([]GroupChatMemberLog, 0)
+	db := o.queryDB(req, extCorpID)
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count GroupChatMemberLog failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("event_time desc").Offset(pager.GetOffset()).Limit(pager.GetLimit()).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatMemberLog failed")
+		return
+	}
+	return
+}
+
+// QueryDaily 客户群每日入群退群人数，只返回有变动的日期，按日期升序
+func (o GroupChatMemberLog) QueryDaily(
+	extCorpID string, extChatID string, startDate string, endDate string) (items []GroupChatMemberDailyNum, err error) {
+	items = make([]GroupChatMemberDailyNum, 0)
+	err = DB.Model(&GroupChatMemberLog{}).
+		Select("to_char(date, 'YYYY-MM-DD') as date, "+
+			"count(*) filter (where event_type = ?) as join_num, count(*) filter (where event_type = ?) as quit_num",
+			constants.GroupChatMemberEventJoin, constants.GroupChatMemberEventQuit).
+		Where("ext_corp_id = ? and ext_chat_id = ? and date between ? and ?", extCorpID, extChatID, startDate, endDate).
+		Group("date").
+		Order("date").
+		Scan(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query GroupChatMemberLog daily failed")
+		return
+	}
+	return
+}
+
+// CountNetAfter 某日期之后的净入群人数，用于从当前群人数倒推历史群人数
+func (o GroupChatMemberLog) CountNetAfter(extCorpID string, extChatID string, date string) (num int64, err error) {
+	err = DB.Model(&GroupChatMemberLog{}).
+		Select("coalesce(sum(case when event_type = ? then 1 else -1 end), 0)", constants.GroupChatMemberEventJoin).
+		Where("ext_corp_id = ? and ext_chat_id = ? and date > ?", extCorpID, extChatID, date).
+		Scan(&num).Error
+	if err != nil {
+		err = errors.Wrap(err, "Count GroupChatMemberLog net failed")
+		return
+	}
+	return
+}
+
+// QueryInviterRank 邀请入群人次排行，按邀请人次降序
+func (o GroupChatMemberLog) QueryInviterRank(
+	req requests.QueryGroupChatInviterRankReq, extCorpID string) (items []GroupChatInviterRank, err error) {
+	items = make([]GroupChatInviterRank, 0)
+	db := DB.Table("group_chat_member_log as l").
+		Joins("left join group_chat_member m on m.ext_chat_id = l.ext_chat_id and m.userid = l.userid").
+		Joins("left join staff s on s.ext_id = l.invitor and s.ext_corp_id = l.ext_corp_id").
+		Select("l.invitor, max(s.name) as invitor_name, count(*) as join_num, count(m.id) as retained_num").
+		Where("l.ext_corp_id = ? and l.event_type = ? and l.invitor <> ''", extCorpID, constants.GroupChatMemberEventJoin).
+		Where("l.date between ? and ?", req.StartDate, req.EndDate)
+	if len(req.ExtChatIDs) > 0 {
+		db = db.Where("l.ext_chat_id in (?)", req.ExtChatIDs)
+	}
+	err = db.Group("l.invitor").Order("join_num desc, l.invitor").Limit(req.Limit).Scan(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query GroupChatInviterRank failed")
+		return
+	}
+	return
+}
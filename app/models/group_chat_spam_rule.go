@@ -0,0 +1,194 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"net/url"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"regexp"
+	"strings"
+)
+
+// linkPattern 消息文本中的外部链接
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s<>"'，。！？、）)]+`)
+
+// GroupChatSpamRule 客户群防骚扰规则，按群聊或群标签生效，基于会话存档的群消息检测
+type GroupChatSpamRule struct {
+	ExtCorpModel
+	// 规则名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:规则名称"`
+	// 生效的群聊ExtID
+	ExtChatIDs constants.StringArrayField `json:"ext_chat_ids" gorm:"type:jsonb;comment:生效的群聊"`
+	// 生效的群标签ID，群聊和群标签都为空时对全部群生效
+	GroupChatTagIDs constants.StringArrayField `json:"group_chat_tag_ids" gorm:"type:jsonb;comment:生效的群标签"`
+	// 违禁词
+	Keywords constants.StringArrayField `json:"keywords" gorm:"type:jsonb;comment:违禁词"`
+	// 是否检测外部链接
+	LinkEnable constants.Boolean `json:"link_enable" gorm:"default:2;comment:是否检测外部链接"`
+	// 允许发送的链接域名，包含子域名
+	LinkWhitelist constants.StringArrayField `json:"link_whitelist" gorm:"type:jsonb;comment:允许发送的链接域名"`
+	// 是否检测二维码图片，会话存档不解析图片内容，开启后外部成员发送的图片均视为疑似二维码，命中时只提醒，不加入黑名单
+	QrImageEnable constants.Boolean `json:"qr_image_enable" gorm:"default:2;comment:是否检测二维码图片"`
+	// 是否检测频繁发言
+	RapidPostEnable constants.Boolean `json:"rapid_post_enable" gorm:"default:2;comment:是否检测频繁发言"`
+	// RapidPostSeconds秒内发言达到RapidPostNum条视为频繁发言
+	RapidPostNum int `json:"rapid_post_num" gorm:"comment:频繁发言条数"`
+	// 频繁发言的统计时长，单位秒
+	RapidPostSeconds int `json:"rapid_post_seconds" gorm:"comment:频繁发言统计秒数"`
+	// 是否检测新入群成员发送链接，不受链接白名单限制
+	NewMemberLinkEnable constants.Boolean `json:"new_member_link_enable" gorm:"default:2;comment:是否检测新入群成员发送链接"`
+	// 入群不满多少小时视为新成员
+	NewMemberHours int `json:"new_member_hours" gorm:"comment:新成员小时数"`
+	// 命中后是否加入黑名单，黑名单成员加入其他群时提醒群主，疑似二维码图片不加入
+	BlacklistEnable constants.Boolean `json:"blacklist_enable" gorm:"default:2;comment:命中后是否加入黑名单"`
+	// 加入黑名单时给客户打的企业标签ExtID
+	BlacklistTagExtIDs constants.StringArrayField `json:"blacklist_tag_ext_ids" gorm:"type:jsonb;comment:加入黑名单时打的客户标签"`
+	// 是否启用
+	Enable constants.Boolean `json:"enable" gorm:"default:1;comment:是否启用"`
+	Timestamp
+}
+
+func (o GroupChatSpamRule) Get(id string, extCorpID string) (item GroupChatSpamRule, err error) {
+	err = DB.Model(&GroupChatSpamRule{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First GroupChatSpamRule failed")
+		return
+	}
+	return
+}
+
+func (o GroupChatSpamRule) Query(
+	req requests.QueryGroupChatSpamRuleReq, extCorpID string, pager *app.Pager) (items []GroupChatSpamRule, total int64, err error) {
+	items = make([]GroupChatSpamRule, 0)
+	db := DB.Model(&GroupChatSpamRule{}).Where("ext_corp_id = ?", extCorpID)
+	if req.Name != "" {
+		db = db.Where("name like ?", "%"+req.Name+"%")
+	}
+	if req.Enable > 0 {
+		db = db.Where("enable = ?", req.Enable)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count GroupChatSpamRule failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("created_at desc").Offset(pager.GetOffset()).Limit(pager.GetLimit()).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatSpamRule failed")
+		return
+	}
+	return
+}
+
+// QueryEnabled 查询已启用的规则，extCorpID为空时查询全部企业
+func (o GroupChatSpamRule) QueryEnabled(extCorpID string) (items []GroupChatSpamRule, err error) {
+	items = make([]GroupChatSpamRule, 0)
+	db := DB.Model(&GroupChatSpamRule{}).Where("enable = ?", constants.True)
+	if extCorpID != "" {
+		db = db.Where("ext_corp_id = ?", extCorpID)
+	}
+	err = db.Order("created_at").Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatSpamRule failed")
+		return
+	}
+	return
+}
+
+func (o GroupChatSpamRule) Create(item GroupChatSpamRule) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create GroupChatSpamRule failed")
+	}
+	return nil
+}
+
+func (o GroupChatSpamRule) Update(item GroupChatSpamRule) error {
+	err := DB.Model(&GroupChatSpamRule{}).
+		Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+		Select("name", "ext_chat_ids", "group_chat_tag_ids", "keywords", "link_enable", "link_whitelist",
+			"qr_image_enable", "rapid_post_enable", "rapid_post_num", "rapid_post_seconds",
+			"new_member_link_enable", "new_member_hours", "blacklist_enable", "blacklist_tag_ext_ids", "enable").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update GroupChatSpamRule failed")
+	}
+	return nil
+}
+
+func (o GroupChatSpamRule) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&GroupChatSpamRule{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete GroupChatSpamRule failed")
+	}
+	return nil
+}
+
+// AppliesTo 规则是否对该群生效
+func (o GroupChatSpamRule) AppliesTo(extChatID string, tagIDs []string) bool {
+	if len(o.ExtChatIDs) == 0 && len(o.GroupChatTagIDs) == 0 {
+		return true
+	}
+	for _, id := range o.ExtChatIDs {
+		if id == extChatID {
+			return true
+		}
+	}
+	for _, id := range o.GroupChatTagIDs {
+		for _, tagID := range tagIDs {
+			if id == tagID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchKeyword 返回文本中命中的第一个违禁词，不区分大小写
+func (o GroupChatSpamRule) MatchKeyword(text string) (string, bool) {
+	text = strings.ToLower(text)
+	for _, keyword := range o.Keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// MatchLink 返回文本中第一个不在白名单内的链接，checkWhitelist为false时不检查白名单
+func (o GroupChatSpamRule) MatchLink(text string, checkWhitelist bool) (string, bool) {
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		if !checkWhitelist || !o.allowedLink(link) {
+			return link, true
+		}
+	}
+	return "", false
+}
+
+func (o GroupChatSpamRule) allowedLink(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range o.LinkWhitelist {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
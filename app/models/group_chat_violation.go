@@ -0,0 +1,177 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"time"
+)
+
+// GroupChatViolation 客户群违规记录，防骚扰规则命中或黑名单成员入群时写入
+type GroupChatViolation struct {
+	ExtCorpModel
+	// 命中的规则ID，黑名单成员入群时为加入黑名单时的规则
+	RuleID string `json:"rule_id" gorm:"type:bigint;index;comment:规则ID"`
+	// 命中的规则名称
+	RuleName string `json:"rule_name" gorm:"type:varchar(64);comment:规则名称"`
+	// 命中的规则类型
+	RuleType constants.GroupChatSpamRuleType `json:"rule_type" gorm:"type:varchar(32);comment:规则类型"`
+	// 群聊ID
+	ExtChatID string `json:"ext_chat_id" gorm:"type:varchar(64);index;comment:群聊ID"`
+	// 群名称
+	ChatName string `json:"chat_name" gorm:"type:varchar(255);comment:群名称"`
+	// 违规成员ID
+	Userid string `json:"userid" gorm:"type:varchar(64);index;comment:违规成员ID"`
+	// 会话存档消息ID，黑名单成员入群时为空
+	MsgID string `json:"msg_id" gorm:"type:varchar(128);comment:消息ID"`
+	// 消息类型
+	MsgType string `json:"msg_type" gorm:"type:varchar(64);comment:消息类型"`
+	// 消息内容
+	Content string `json:"content" gorm:"type:text;comment:消息内容"`
+	// 命中详情，如违禁词、链接
+	Detail string `json:"detail" gorm:"type:varchar(255);comment:命中详情"`
+	// 违规时间
+	EventTime time.Time `json:"event_time" gorm:"index;comment:违规时间"`
+	Timestamp
+}
+
+func (o GroupChatViolation) TableName() string {
+	return "group_chat_violation"
+}
+
+func (o GroupChatViolation) Create(item GroupChatViolation) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create GroupChatViolation failed")
+	}
+	return nil
+}
+
+// queryDB 按条件筛选违规记录
+func (o GroupChatViolation) queryDB(req requests.QueryGroupChatViolationReq, extCorpID string) *gorm.DB {
+	db := DB.Model(&GroupChatViolation{}).Where("ext_corp_id = ?", extCorpID)
+	if req.RuleID != "" {
+		db = db.Where("rule_id = ?", req.RuleID)
+	}
+	if req.RuleType != "" {
+		db = db.Where("rule_type = ?", req.RuleType)
+	}
+	if req.ExtChatID != "" {
+		db = db.Where("ext_chat_id = ?", req.ExtChatID)
+	}
+	if req.Userid != "" {
+		db = db.Where("userid = ?", req.Userid)
+	}
+	if req.StartDate != "" {
+		db = db.Where("event_time >= ?::date", req.StartDate)
+	}
+	if req.EndDate != "" {
+		db = db.Where("event_time < ?::date + 1", req.EndDate)
+	}
+	return db
+}
+
+func (o GroupChatViolation) Query(
+	req requests.QueryGroupChatViolationReq, extCorpID string, pager *app.Pager) (items []GroupChatViolation, total int64, err error) {
+	items = make([]GroupChatViolation, 0)
+	db := o.queryDB(req, extCorpID)
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count GroupChatViolation failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("event_time desc").Offset(pager.GetOffset()).Limit(pager.GetLimit()).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatViolation failed")
+		return
+	}
+	return
+}
+
+// GroupChatBlacklist 客户群黑名单，命中开启了黑名单的防骚扰规则时加入，同一企业内成员唯一
+type GroupChatBlacklist struct {
+	// ID
+	ID string `json:"id" gorm:"primaryKey;type:bigint;comment:'ID'" validate:"int64"`
+	// ExtCorpID 外部企业ID
+	ExtCorpID string `json:"ext_corp_id" gorm:"uniqueIndex:idx_blacklist_ext_corp_id_userid;type:char(18);comment:外部企业ID"`
+	// ExtCreatorID 创建者外部员工ID
+	ExtCreatorID string `json:"ext_creator_id" gorm:"type:char(64);comment:创建者外部员工ID"`
+	// 成员ID，一般为ExtCustomerID
+	Userid string `json:"userid" gorm:"type:varchar(64);uniqueIndex:idx_blacklist_ext_corp_id_userid;comment:成员ID"`
+	// 加入黑名单的规则ID
+	RuleID string `json:"rule_id" gorm:"type:bigint;comment:规则ID"`
+	// 加入黑名单的违规记录ID
+	ViolationID string `json:"violation_id" gorm:"type:bigint;comment:违规记录ID"`
+	// 违规的群聊ID
+	ExtChatID string `json:"ext_chat_id" gorm:"type:varchar(64);comment:违规的群聊ID"`
+	// 违规类型
+	RuleType constants.GroupChatSpamRuleType `json:"rule_type" gorm:"type:varchar(32);comment:违规类型"`
+	Timestamp
+}
+
+func (o GroupChatBlacklist) TableName() string {
+	return "group_chat_blacklist"
+}
+
+// Upsert 加入黑名单，已在黑名单时更新为最近一次违规
+func (o GroupChatBlacklist) Upsert(item GroupChatBlacklist) error {
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ext_corp_id"}, {Name: "userid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rule_id", "violation_id", "ext_chat_id", "rule_type", "updated_at"}),
+	}).Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Upsert GroupChatBlacklist failed")
+	}
+	return nil
+}
+
+func (o GroupChatBlacklist) Query(
+	req requests.QueryGroupChatBlacklistReq, extCorpID string, pager *app.Pager) (items []GroupChatBlacklist, total int64, err error) {
+	items = make([]GroupChatBlacklist, 0)
+	db := DB.Model(&GroupChatBlacklist{}).Where("ext_corp_id = ?", extCorpID)
+	if req.Userid != "" {
+		db = db.Where("userid = ?", req.Userid)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count GroupChatBlacklist failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Order("updated_at desc").Offset(pager.GetOffset()).Limit(pager.GetLimit()).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatBlacklist failed")
+		return
+	}
+	return
+}
+
+// QueryByUserids 查询在黑名单中的成员
+func (o GroupChatBlacklist) QueryByUserids(extCorpID string, userids []string) (items []GroupChatBlacklist, err error) {
+	items = make([]GroupChatBlacklist, 0)
+	if len(userids) == 0 {
+		return
+	}
+	err = DB.Model(&GroupChatBlacklist{}).Where("ext_corp_id = ? and userid in (?)", extCorpID, userids).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatBlacklist failed")
+		return
+	}
+	return
+}
+
+func (o GroupChatBlacklist) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&GroupChatBlacklist{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete GroupChatBlacklist failed")
+	}
+	return nil
+}
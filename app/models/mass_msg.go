@@ -24,8 +24,12 @@ type MassMsg struct {
 	ExtDepartmentIDs constants.Int64ArrayField `gorm:"type:JSON" json:"ext_department_ids"`
 	// 消息内容
 	Msg constants.AutoReplyField `gorm:"type:jsonb;comment:消息内容" json:"msg"`
+	// 内容变体，为空时所有客户都发送Msg
+	Variants constants.MsgVariants `gorm:"type:jsonb;default:'[]';comment:内容变体" json:"variants"`
 	// wx消息ID
 	ExtMsgID string `gorm:"type:varchar(33);comment:微信消息ID;index" json:"ext_msg_id"`
+	// 每个发送人对应的wx消息ID
+	ExtMsgIDs constants.StringArrayField `gorm:"type:jsonb;comment:所有发送人的微信消息ID" json:"ext_msg_ids"`
 	// 任务状态 1-预约发送,2-发送中,3-发送成功,4-发送失败,5-已取消; <=1  可修改,其余不可改
 	MissionStatus constants.SendMassMsgStatus `gorm:"comment:创建企业群发消息的状态,1-预约发送,2-发送中,3-发送成功,4-发送失败,5-已取消;type:smallint;" json:"mission_status"`
 	// 是否有筛选条件
@@ -55,7 +59,7 @@ type MassMsg struct {
 func (o MassMsg) GetExtStaffIDs(msgID string) (staffsCustomers []StaffsCustomers, err error) {
 	err = DB.Model(&MassMsgStaff{}).Where("mass_msg_id = ?", msgID).
 		Where("is_sent = ?", constants.False).
-		Select("ext_staff_id, ext_customer_id").
+		Select("ext_staff_id, ext_customer_id, variant_key").
 		Find(&staffsCustomers).Error
 	return
 }
@@ -106,3 +110,22 @@ func (o MassMsg) Query(extCorpID string, sorter *app.Sorter, pager *app.Pager) (
 	}
 	return items, total, nil
 }
+
+// UpdateStatistic 更新群发的发送统计和任务状态
+func (o MassMsg) UpdateStatistic(msg MassMsg) error {
+	return DB.Model(&MassMsg{}).Where("id = ?", msg.ID).
+		Updates(map[string]interface{}{
+			"delivered_num":    msg.DeliveredNum,
+			"success_num":      msg.SuccessNum,
+			"un_delivered_num": msg.UnDeliveredNum,
+			"failed_num":       msg.FailedNum,
+			// 已取消的群发只更新统计，保持已取消状态
+			"mission_status": gorm.Expr("case when mission_status = ? then mission_status else ? end",
+				constants.Deleted, msg.MissionStatus),
+		}).Error
+}
+
+// UpdateMissionStatus 更新群发任务状态
+func (o MassMsg) UpdateMissionStatus(id string, status constants.SendMassMsgStatus) error {
+	return DB.Model(&MassMsg{}).Where("id = ?", id).Update("mission_status", status).Error
+}
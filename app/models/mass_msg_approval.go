@@ -0,0 +1,262 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/util"
+	"time"
+)
+
+// MassMsgApprovalRule 群发审批规则
+// 客户数、创建人角色均为可选条件，已配置的条件全部满足时群发需要审批
+type MassMsgApprovalRule struct {
+	ExtCorpModel
+	// 规则名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:规则名称"`
+	// 是否启用
+	Enable constants.Boolean `json:"enable" gorm:"type:smallint;default:1;comment:是否启用 1-是 2-否"`
+	// 发送客户数不少于此值时需审批，0为不限制
+	MinCustomerNum int `json:"min_customer_num" gorm:"type:int;default:0;comment:需审批的最小客户数"`
+	// 创建人为这些角色时需审批，为空不限制
+	CreatorRoleTypes constants.StringArrayField `json:"creator_role_types" gorm:"type:jsonb;comment:需审批的创建人角色"`
+	// 审批人外部员工ID，任意一人审批即可
+	ApproverExtStaffIDs constants.StringArrayField `json:"approver_ext_staff_ids" gorm:"type:jsonb;comment:审批人"`
+	// 审批方式
+	Mode constants.MassMsgApprovalMode `json:"mode" gorm:"type:smallint;default:1;comment:审批方式 1-本系统审批 2-企业微信审批"`
+	// 企业微信审批模板ID，审批方式为企业微信审批时必填
+	OATemplateID string `json:"oa_template_id" gorm:"type:varchar(64);comment:企业微信审批模板ID"`
+	// 企业微信审批模板中用于填写群发内容的多行文本控件ID
+	OAControlID string `json:"oa_control_id" gorm:"type:varchar(64);comment:企业微信审批模板控件ID"`
+	// 审批页面地址，本系统审批时通知卡片点击后跳转，为空时以文本消息通知
+	ApprovalURL string `json:"approval_url" gorm:"type:varchar(255);comment:审批页面地址"`
+	Timestamp
+}
+
+// MassMsgApproval 群发审批单
+type MassMsgApproval struct {
+	ExtCorpModel
+	// 群发ID
+	MassMsgID string `json:"mass_msg_id" gorm:"type:bigint;index;comment:群发ID"`
+	// 审批规则ID
+	RuleID string `json:"rule_id" gorm:"type:bigint;comment:审批规则ID"`
+	// 审批方式
+	Mode constants.MassMsgApprovalMode `json:"mode" gorm:"type:smallint;comment:审批方式 1-本系统审批 2-企业微信审批"`
+	// 创建人外部员工ID，用于通知审批结果
+	CreatorExtStaffID string `json:"creator_ext_staff_id" gorm:"type:varchar(64);comment:创建人外部员工ID"`
+	// 可审批人外部员工ID
+	ApproverExtStaffIDs constants.StringArrayField `json:"approver_ext_staff_ids" gorm:"type:jsonb;comment:可审批人"`
+	// 客户数
+	CustomerNum int `json:"customer_num" gorm:"type:int;comment:客户数"`
+	// 审批状态
+	Status constants.MassMsgApprovalStatus `json:"status" gorm:"type:smallint;index;comment:审批状态 1-待审批 2-已通过 3-已驳回 4-已取消"`
+	// 实际审批人外部员工ID
+	ExtApproverID string `json:"ext_approver_id" gorm:"type:varchar(64);comment:实际审批人"`
+	// 审批意见
+	Comment string `json:"comment" gorm:"type:varchar(255);comment:审批意见"`
+	// 审批时间
+	ApprovedAt *time.Time `json:"approved_at" gorm:"comment:审批时间"`
+	// 企业微信审批单号
+	SpNo string `json:"sp_no" gorm:"type:varchar(64);index;comment:企业微信审批单号"`
+	// 群发任务的发送请求，审批通过后原样推送到发送队列
+	Body string `json:"-" gorm:"type:text;comment:群发发送请求"`
+	Timestamp
+}
+
+func (o MassMsgApprovalRule) Get(id string, extCorpID string) (item MassMsgApprovalRule, err error) {
+	err = DB.Model(&MassMsgApprovalRule{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First MassMsgApprovalRule failed")
+		return
+	}
+
+	return
+}
+
+func (o MassMsgApprovalRule) Query(extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []MassMsgApprovalRule, total int64, err error) {
+	db := DB.Model(&MassMsgApprovalRule{}).Where("ext_corp_id = ?", extCorpID)
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count MassMsgApprovalRule failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find MassMsgApprovalRule failed")
+		return
+	}
+
+	return
+}
+
+// QueryEnabled 查询企业启用的审批规则，按创建时间先后排列
+func (o MassMsgApprovalRule) QueryEnabled(extCorpID string) (items []MassMsgApprovalRule, err error) {
+	err = DB.Model(&MassMsgApprovalRule{}).
+		Where("ext_corp_id = ? and enable = ?", extCorpID, constants.True).
+		Order("created_at").
+		Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find MassMsgApprovalRule failed")
+		return
+	}
+	return
+}
+
+// Match 群发是否命中此规则
+func (o MassMsgApprovalRule) Match(customerNum int, creatorRoleType string) bool {
+	if o.MinCustomerNum > 0 && customerNum < o.MinCustomerNum {
+		return false
+	}
+	if len(o.CreatorRoleTypes) > 0 {
+		for _, roleType := range o.CreatorRoleTypes {
+			if roleType == creatorRoleType {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (o MassMsgApprovalRule) Create(item MassMsgApprovalRule) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create MassMsgApprovalRule failed")
+	}
+	return nil
+}
+
+func (o MassMsgApprovalRule) Update(item MassMsgApprovalRule) error {
+	err := DB.Model(&MassMsgApprovalRule{}).
+		Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+		Select("name", "enable", "min_customer_num", "creator_role_types", "approver_ext_staff_ids",
+			"mode", "oa_template_id", "oa_control_id", "approval_url").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update MassMsgApprovalRule failed")
+	}
+	return nil
+}
+
+func (o MassMsgApprovalRule) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&MassMsgApprovalRule{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete MassMsgApprovalRule failed")
+	}
+	return nil
+}
+
+func (o MassMsgApproval) Create(item MassMsgApproval) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create MassMsgApproval failed")
+	}
+	return nil
+}
+
+func (o MassMsgApproval) Get(id string, extCorpID string) (item MassMsgApproval, err error) {
+	err = DB.Model(&MassMsgApproval{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First MassMsgApproval failed")
+		return
+	}
+
+	return
+}
+
+// GetBySpNo 按企业微信审批单号查找审批单
+func (o MassMsgApproval) GetBySpNo(spNo string, extCorpID string) (item MassMsgApproval, err error) {
+	err = DB.Model(&MassMsgApproval{}).Where("ext_corp_id = ? and sp_no = ?", extCorpID, spNo).First(&item).Error
+	if err != nil {
+		err = errors.Wrap(err, "First MassMsgApproval failed")
+		return
+	}
+	return
+}
+
+func (o MassMsgApproval) Query(
+	req requests.QueryMassMsgApprovalReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []MassMsgApproval, total int64, err error) {
+	db := DB.Model(&MassMsgApproval{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.Status > 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.ExtApproverID != "" {
+		db = db.Where("approver_ext_staff_ids @> ?::jsonb", util.ToJSONBSingleArray(req.ExtApproverID))
+	}
+	if req.MassMsgID != "" {
+		db = db.Where("mass_msg_id = ?", req.MassMsgID)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count MassMsgApproval failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find MassMsgApproval failed")
+		return
+	}
+
+	return
+}
+
+// Finish 记录审批结果，仅待审批的审批单可更新，返回是否更新成功
+func (o MassMsgApproval) Finish(item MassMsgApproval) (bool, error) {
+	res := DB.Model(&MassMsgApproval{}).
+		Where("id = ? and status = ?", item.ID, constants.MassMsgApprovalPending).
+		Select("status", "ext_approver_id", "comment", "approved_at").
+		Updates(&item)
+	if res.Error != nil {
+		return false, errors.Wrap(res.Error, "Update MassMsgApproval failed")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// CancelByMassMsgID 群发取消时关闭待审批的审批单
+func (o MassMsgApproval) CancelByMassMsgID(massMsgID string) error {
+	err := DB.Model(&MassMsgApproval{}).
+		Where("mass_msg_id = ? and status = ?", massMsgID, constants.MassMsgApprovalPending).
+		Update("status", constants.MassMsgApprovalCanceled).Error
+	if err != nil {
+		return errors.Wrap(err, "Cancel MassMsgApproval failed")
+	}
+	return nil
+}
+
+// SetSpNo 记录企业微信审批单号
+func (o MassMsgApproval) SetSpNo(id string, spNo string) error {
+	err := DB.Model(&MassMsgApproval{}).Where("id = ?", id).Update("sp_no", spNo).Error
+	if err != nil {
+		return errors.Wrap(err, "Update MassMsgApproval sp_no failed")
+	}
+	return nil
+}
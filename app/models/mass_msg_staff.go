@@ -4,6 +4,9 @@ import (
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/conf"
 )
 
 type MassMsgStaff struct {
@@ -21,6 +24,16 @@ type MassMsgStaff struct {
 	IsDelivered uint8 `json:"is_delivered" gorm:"type:smallint;default:2"`
 	// 失败原因
 	FailedReason uint8 `json:"failed_reason" gorm:"type:smallint"`
+	// 发送人对应的wx消息ID
+	ExtMsgID string `json:"ext_msg_id" gorm:"type:varchar(64);index;comment:发送人对应的微信消息ID"`
+	// 客户的发送状态
+	SendStatus constants.MassMsgSendStatus `json:"send_status" gorm:"type:smallint;default:0;comment:0-未发送 1-已发送 2-因客户不是好友导致发送失败 3-因客户已经收到其他群发消息导致发送失败 4-群发已取消"`
+	// 客户分配到的内容变体，未做A/B测试时为空
+	VariantKey string `json:"variant_key" gorm:"type:varchar(8);default:'';comment:分配的内容变体"`
+	// 分批发送的批次号
+	BatchNo int `json:"batch_no" gorm:"type:int;default:0;comment:分批发送的批次号"`
+	// 企微群发提交状态，断点续发时跳过已提交的发送人
+	SubmitStatus constants.MassMsgSubmitStatus `json:"submit_status" gorm:"type:smallint;default:0;comment:0-待提交 1-已创建企微群发 2-提交失败"`
 	Timestamp
 }
 
@@ -39,6 +52,7 @@ func (g MassMsgStaff) QueryExtStaffIds(ids []string) (res []MassMsgStaff, err er
 type StaffsCustomers struct {
 	ExtStaffID    string `json:"ext_staff_id"`
 	ExtCustomerID string `json:"ext_customer_id"`
+	VariantKey    string `json:"variant_key"`
 }
 
 func (g MassMsgStaff) GetStaffsCustomers(
@@ -86,6 +100,15 @@ func (g MassMsgStaff) GetStaffsCustomers(
 				}
 			}
 		}
+
+		if filter.SegmentID != "" {
+			segment, segmentErr := CustomerSegment{}.Get(filter.SegmentID, conf.Settings.WeWork.ExtCorpID)
+			if segmentErr != nil {
+				err = segmentErr
+				return
+			}
+			db = db.Scopes(SegmentScope(segment.Rule))
+		}
 	}
 
 	db = db.Group("cs.ext_staff_id").Group("cs.ext_customer_id")
@@ -126,3 +149,247 @@ func (g MassMsgStaff) QueryNotSentMsg(massMsgStaff MassMsgStaff) (res []MsgMainI
 func (g MassMsgStaff) Update(staff MassMsgStaff) (err error) {
 	return DB.Model(&MassMsgStaff{}).Where("id = ?", staff.ID).Updates(&staff).Error
 }
+
+// MassMsgSender 已提交到企微、仍有客户未发送的群发发送人
+type MassMsgSender struct {
+	MassMsgID  string `json:"mass_msg_id"`
+	ExtStaffID string `json:"ext_staff_id"`
+	ExtMsgID   string `json:"ext_msg_id"`
+}
+
+// SetExtMsgID 记录发送人发给这些客户的wx消息ID
+// 同时记录提交断点，重试时不再为这些客户创建企微群发
+func (g MassMsgStaff) SetExtMsgID(massMsgID string, extStaffID string, extCustomerIDs []string, extMsgID string) error {
+	return DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and ext_staff_id = ? and ext_customer_id in (?)", massMsgID, extStaffID, extCustomerIDs).
+		Updates(map[string]interface{}{
+			"ext_msg_id":    extMsgID,
+			"submit_status": constants.MassMsgSubmitted,
+		}).Error
+}
+
+// AssignBatches
+// Description: 将群发中待提交的发送人按batchSize分批，写入批次号
+// return: 批次数，批次号从1开始
+func (g MassMsgStaff) AssignBatches(massMsgID string, batchSize int) (batchNum int, err error) {
+	var extStaffIDs []string
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and is_sent = ? and submit_status = ?", massMsgID, constants.False, constants.MassMsgSubmitPending).
+		Distinct("ext_staff_id").Order("ext_staff_id").
+		Pluck("ext_staff_id", &extStaffIDs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query pending senders failed")
+		return
+	}
+
+	for start := 0; start < len(extStaffIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(extStaffIDs) {
+			end = len(extStaffIDs)
+		}
+		batchNum++
+		err = DB.Model(&MassMsgStaff{}).
+			Where("mass_msg_id = ? and ext_staff_id in (?)", massMsgID, extStaffIDs[start:end]).
+			Update("batch_no", batchNum).Error
+		if err != nil {
+			err = errors.Wrap(err, "Update batch_no failed")
+			return
+		}
+	}
+	return
+}
+
+// GetBatchStaffsCustomers 查询一批中尚未提交的员工-客户
+func (g MassMsgStaff) GetBatchStaffsCustomers(massMsgID string, batchNo int) (staffsCustomers []StaffsCustomers, err error) {
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and batch_no = ?", massMsgID, batchNo).
+		Where("is_sent = ? and submit_status = ?", constants.False, constants.MassMsgSubmitPending).
+		Select("ext_staff_id, ext_customer_id, variant_key").
+		Find(&staffsCustomers).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query batch staffs customers failed")
+		return
+	}
+	return
+}
+
+// SetBatchSubmitFailed 重试次数用尽后，将一批中仍未提交的客户标记为提交失败，发送状态同时置为终态
+func (g MassMsgStaff) SetBatchSubmitFailed(massMsgID string, batchNo int) error {
+	err := DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and batch_no = ? and submit_status = ?", massMsgID, batchNo, constants.MassMsgSubmitPending).
+		Updates(map[string]interface{}{
+			"submit_status": constants.MassMsgSubmitFailed,
+			"send_status":   constants.MassMsgSubmitFailure,
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, "Set batch submit failed failed")
+	}
+	return nil
+}
+
+// CountSubmitStatus 统计群发各提交状态的客户数
+func (g MassMsgStaff) CountSubmitStatus(massMsgID string) (pending int64, submitted int64, err error) {
+	res := struct {
+		Pending   int64
+		Submitted int64
+	}{}
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ?", massMsgID).
+		Select("count(*) filter (where submit_status = ?) as pending, count(*) filter (where submit_status = ?) as submitted",
+			constants.MassMsgSubmitPending, constants.MassMsgSubmitted).
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Count submit status failed")
+		return
+	}
+	return res.Pending, res.Submitted, nil
+}
+
+// QueryExtMsgIDs 群发已创建的所有企微消息ID
+func (g MassMsgStaff) QueryExtMsgIDs(massMsgID string) (extMsgIDs []string, err error) {
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and ext_msg_id != ''", massMsgID).
+		Distinct("ext_msg_id").
+		Pluck("ext_msg_id", &extMsgIDs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query ext msg ids failed")
+		return
+	}
+	return
+}
+
+// UpdateSendStatus 更新客户的发送状态，同时维护是否投递和是否送达
+func (g MassMsgStaff) UpdateSendStatus(
+	massMsgID string, extStaffID string, extCustomerIDs []string, status constants.MassMsgSendStatus) error {
+	isSent, isDelivered := constants.True, constants.False
+	if status == constants.MassMsgUnsent {
+		isSent = constants.False
+	}
+	if status == constants.MassMsgSent {
+		isDelivered = constants.True
+	}
+
+	return DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and ext_staff_id = ? and ext_customer_id in (?)", massMsgID, extStaffID, extCustomerIDs).
+		Updates(map[string]interface{}{
+			"send_status":  status,
+			"is_sent":      isSent,
+			"is_delivered": isDelivered,
+		}).Error
+}
+
+// FilterUnsentCustomers 过滤出发送人在群发中仍未发送的客户，用于避免重复累计群发次数
+func (g MassMsgStaff) FilterUnsentCustomers(
+	massMsgID string, extStaffID string, extCustomerIDs []string) (ids []string, err error) {
+	ids = make([]string, 0)
+	if len(extCustomerIDs) == 0 {
+		return
+	}
+
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and ext_staff_id = ?", massMsgID, extStaffID).
+		Where("ext_customer_id in (?) and send_status = ?", extCustomerIDs, constants.MassMsgUnsent).
+		Pluck("ext_customer_id", &ids).Error
+	if err != nil {
+		err = errors.Wrap(err, "Filter unsent customers failed")
+		return
+	}
+	return
+}
+
+// QueryUnfinishedSenders 查询发送中的群发里，还有客户未发送的发送人
+func (g MassMsgStaff) QueryUnfinishedSenders(extCorpID string) (res []MassMsgSender, err error) {
+	err = DB.Model(&MassMsgStaff{}).
+		Joins("join mass_msg on mass_msg.id = mass_msg_staff.mass_msg_id").
+		Where("mass_msg.ext_corp_id = ? and mass_msg.mission_status = ?", extCorpID, constants.Sending).
+		Where("mass_msg_staff.ext_msg_id != '' and mass_msg_staff.send_status = ?", constants.MassMsgUnsent).
+		Distinct("mass_msg_staff.mass_msg_id", "mass_msg_staff.ext_staff_id", "mass_msg_staff.ext_msg_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query unfinished senders failed")
+		return
+	}
+	return
+}
+
+// QueryMsgUnfinishedSenders 查询群发里还有客户未发送的发送人
+func (g MassMsgStaff) QueryMsgUnfinishedSenders(massMsgID string) (res []MassMsgSender, err error) {
+	err = DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and ext_msg_id != '' and send_status = ?", massMsgID, constants.MassMsgUnsent).
+		Distinct("mass_msg_id", "ext_staff_id", "ext_msg_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query unfinished senders failed")
+		return
+	}
+	return
+}
+
+// CancelUnsent 群发取消后，将仍未发送的客户标记为已取消
+func (g MassMsgStaff) CancelUnsent(massMsgID string) error {
+	err := DB.Model(&MassMsgStaff{}).
+		Where("mass_msg_id = ? and send_status = ?", massMsgID, constants.MassMsgUnsent).
+		Update("send_status", constants.MassMsgCanceled).Error
+	if err != nil {
+		return errors.Wrap(err, "Cancel unsent customers failed")
+	}
+	return nil
+}
+
+// QueryStaffResult 按员工统计群发中各发送状态的客户数
+func (g MassMsgStaff) QueryStaffResult(massMsgID string) (res []requests.MassMsgStaffResult, err error) {
+	err = DB.Model(&MassMsgStaff{}).
+		Joins("left join staff on staff.ext_id = mass_msg_staff.ext_staff_id and staff.ext_corp_id = mass_msg_staff.ext_corp_id").
+		Where("mass_msg_staff.mass_msg_id = ?", massMsgID).
+		Select("mass_msg_staff.ext_staff_id, max(staff.name) as staff_name, count(*) as total, "+
+			"count(*) filter (where send_status = ?) as unsent, "+
+			"count(*) filter (where send_status = ?) as sent, "+
+			"count(*) filter (where send_status = ?) as failed_not_friend, "+
+			"count(*) filter (where send_status = ?) as failed_over_quota, "+
+			"count(*) filter (where send_status = ?) as canceled, "+
+			"count(*) filter (where send_status = ?) as submit_failed",
+			constants.MassMsgUnsent, constants.MassMsgSent, constants.MassMsgFailedNotFriend, constants.MassMsgFailedOverQuota,
+			constants.MassMsgCanceled, constants.MassMsgSubmitFailure).
+		Group("mass_msg_staff.ext_staff_id").
+		Order("mass_msg_staff.ext_staff_id").
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query staff result failed")
+		return
+	}
+	return
+}
+
+// QueryCustomerResult 分页查询群发中每个客户的发送状态
+func (g MassMsgStaff) QueryCustomerResult(
+	massMsgID string, req requests.QueryMassMsgResultReq, pager *app.Pager) (res []requests.MassMsgCustomerResult, total int64, err error) {
+	db := DB.Model(&MassMsgStaff{}).
+		Joins("left join customer on customer.ext_id = mass_msg_staff.ext_customer_id").
+		Where("mass_msg_staff.mass_msg_id = ?", massMsgID)
+
+	if req.ExtStaffID != "" {
+		db = db.Where("mass_msg_staff.ext_staff_id = ?", req.ExtStaffID)
+	}
+
+	if req.SendStatus != nil {
+		db = db.Where("mass_msg_staff.send_status = ?", *req.SendStatus)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count MassMsgStaff failed")
+		return
+	}
+
+	pager.SetDefault()
+	err = db.Select("mass_msg_staff.ext_staff_id, mass_msg_staff.ext_customer_id, customer.name as customer_name, " +
+		"mass_msg_staff.send_status, mass_msg_staff.variant_key").
+		Order("mass_msg_staff.id").
+		Offset(pager.GetOffset()).Limit(pager.GetLimit()).
+		Scan(&res).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query customer result failed")
+		return
+	}
+	return
+}
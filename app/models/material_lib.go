@@ -6,6 +6,7 @@ import (
 	"gorm.io/gorm/clause"
 	"openscrm/app/constants"
 	"openscrm/app/requests"
+	"openscrm/common/ecode"
 	"openscrm/common/util"
 )
 
@@ -43,6 +44,19 @@ func (m Material) Create(material Material) error {
 	return DB.Create(&material).Error
 }
 
+func (m Material) Get(id string, extCorpID string) (item Material, err error) {
+	err = DB.Model(&Material{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First material failed")
+		return
+	}
+	return
+}
+
 func (m Material) Delete(ids []string, extCorpID string) (int64, error) {
 	result := DB.Where("ext_corp_id = ?", extCorpID).Where("id in (?)", ids).Delete(&Material{})
 	err := result.Error
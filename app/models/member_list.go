@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm/clause"
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+	"strings"
+)
 
 type GroupChatMember struct {
 	ExtCorpModel
@@ -50,3 +54,18 @@ func (m GroupChatMember) Upsert(list []GroupChatMember) error {
 func (m GroupChatMember) Delete(extCorpID string, extChatID string, userIDs []string) error {
 	return DB.Where("ext_corp_id = ? and ext_chat_id = ?", extCorpID, extChatID).Where("userid not in (?)", userIDs).Delete(&GroupChatMember{}).Error
 }
+
+// QueryByChat 查询群聊当前保存的成员
+func (m GroupChatMember) QueryByChat(extCorpID string, extChatID string) ([]GroupChatMember, error) {
+	members := make([]GroupChatMember, 0)
+	err := DB.Where("ext_corp_id = ? and ext_chat_id = ?", extCorpID, extChatID).Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find GroupChatMember failed")
+	}
+	// char类型的字段会补齐空格
+	for i := range members {
+		members[i].Userid = strings.TrimSpace(members[i].Userid)
+		members[i].Invitor = strings.TrimSpace(members[i].Invitor)
+	}
+	return members, nil
+}
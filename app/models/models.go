@@ -67,6 +67,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&CustomerInfoDisplayRule{},
 		&CustomerStaff{},
 		&CustomerStaffRelationHistory{},
+		&CustomerIdentity{},
 		&Staff{},
 		&Department{},
 		&MaterialLibTag{},
@@ -101,6 +102,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&GroupChatWelcomeMsg{},
 		&GroupChatMassMsg{},
 		&CustomerStatistic{},
+		&StaffBehaviorStatistic{},
+		&GroupChatStatistic{},
 		&DataExport{},
 		&ContactWayGroup{},
 		&ContactWay{},
@@ -114,6 +117,20 @@ func AutoMigrate(db *gorm.DB) error {
 		&TagGroup{},
 		&Staff{},
 		&Role{},
+		&CustomerStrategy{},
+		&CustomerStrategyRange{},
+		&Product{},
+		&CustomerMassMsgQuota{},
+		&CustomerSegment{},
+		&MassMsgApprovalRule{},
+		&MassMsgApproval{},
+		&WelcomeMsgVariantRecord{},
+		&GroupChatMassMsgChat{},
+		&CustomerSop{},
+		&CustomerSopStep{},
+		&CustomerSopTask{},
+		&ContactWayAddRecord{}, &ContactWaySubChannel{}, &PosterTemplate{}, &ContactWayStaffQrCode{}, &ContactWayImportTask{}, &CorpCalendarDay{}, &ContactWayScheduleLog{}, &GroupChatMemberLog{},
+		&GroupChatSpamRule{}, &GroupChatViolation{}, &GroupChatBlacklist{},
 	)
 	if err != nil {
 		log.Sugar.Errorw(err.Error())
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"time"
+)
+
+// WelcomeMsgVariantRecord 欢迎语内容变体的发送记录
+type WelcomeMsgVariantRecord struct {
+	ExtCorpModel
+	// 欢迎语ID
+	WelcomeMsgID string `gorm:"type:bigint;index;comment:欢迎语ID" json:"welcome_msg_id"`
+	// 变体标识
+	VariantKey string `gorm:"type:varchar(8);comment:变体标识" json:"variant_key"`
+	// 发送人
+	ExtStaffID string `gorm:"type:char(64);comment:员工ID" json:"ext_staff_id"`
+	// 接收客户
+	ExtCustomerID string `gorm:"type:char(64);index;comment:客户ID" json:"ext_customer_id"`
+	// 发送时间
+	SentAt time.Time `gorm:"comment:发送时间" json:"sent_at"`
+	Timestamp
+}
+
+func (o WelcomeMsgVariantRecord) Create(record WelcomeMsgVariantRecord) error {
+	return DB.Create(&record).Error
+}
+
+// MsgVariantStat 变体的发送和转化统计
+type MsgVariantStat struct{}
+
+// QueryMassMsgVariantStats
+// Description: 按变体统计群发的送达、回复、打标签和流失客户数
+// Detail: 以群发时间为起点，统计days天内的转化数据
+func (o MsgVariantStat) QueryMassMsgVariantStats(
+	msg MassMsg, days int) (stats []requests.MsgVariantStat, err error) {
+	startAt := time.Unix(msg.SendAt.ToInt64(), 0)
+	base := DB.Model(&MassMsgStaff{}).
+		Select("variant_key, ext_staff_id, ext_customer_id, send_status = ? as sent, ?::timestamptz as start_at",
+			constants.MassMsgSent, startAt).
+		Where("ext_corp_id = ? and mass_msg_id = ? and variant_key != ''", msg.ExtCorpID, msg.ID)
+	return o.queryStats(base, msg.ExtCorpID, days)
+}
+
+// QueryWelcomeMsgVariantStats
+// Description: 按变体统计欢迎语的发送、回复、打标签和流失客户数
+// Detail: 以每个客户收到欢迎语的时间为起点，统计days天内的转化数据
+func (o MsgVariantStat) QueryWelcomeMsgVariantStats(
+	welcomeMsgID string, extCorpID string, days int) (stats []requests.MsgVariantStat, err error) {
+	base := DB.Model(&WelcomeMsgVariantRecord{}).
+		Select("variant_key, ext_staff_id, ext_customer_id, true as sent, sent_at as start_at").
+		Where("ext_corp_id = ? and welcome_msg_id = ?", extCorpID, welcomeMsgID)
+	return o.queryStats(base, extCorpID, days)
+}
+
+// queryStats base需返回 variant_key, ext_staff_id, ext_customer_id, sent, start_at
+func (o MsgVariantStat) queryStats(base *gorm.DB, extCorpID string, days int) (stats []requests.MsgVariantStat, err error) {
+	replied := "exists (select 1 from chat_msg m where m.ext_corp_id = ? and m.room_id = '' " +
+		"and m.\"from\" = t.ext_customer_id and m.to_list @> jsonb_build_array(t.ext_staff_id) " +
+		"and m.msg_time >= extract(epoch from t.start_at) * 1000 " +
+		"and m.msg_time < extract(epoch from t.start_at + make_interval(days => ?)) * 1000)"
+	tagAdded := "exists (select 1 from customer_staff cs join customer_staff_tag cst on cst.customer_staff_id = cs.id " +
+		"where cs.ext_corp_id = ? and cs.ext_staff_id = t.ext_staff_id and cs.ext_customer_id = t.ext_customer_id " +
+		"and cst.created_at >= t.start_at and cst.created_at < t.start_at + make_interval(days => ?))"
+	lost := "exists (select 1 from customer_staff_relation_history h " +
+		"where h.ext_corp_id = ? and h.ext_staff_id = t.ext_staff_id and h.ext_customer_id = t.ext_customer_id " +
+		"and h.customer_delete_staff_at >= t.start_at and h.customer_delete_staff_at < t.start_at + make_interval(days => ?))"
+
+	err = DB.Table("(?) as t", base).
+		Select("t.variant_key, count(*) as total, count(*) filter (where t.sent) as sent, "+
+			"count(*) filter (where t.sent and "+replied+") as replied, "+
+			"count(*) filter (where t.sent and "+tagAdded+") as tag_added, "+
+			"count(*) filter (where t.sent and "+lost+") as lost",
+			extCorpID, days, extCorpID, days, extCorpID, days).
+		Group("t.variant_key").
+		Scan(&stats).Error
+	if err != nil {
+		err = errors.Wrap(err, "Query variant stats failed")
+		return
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+)
+
+// PosterTemplate 渠道码海报模板，在背景图上合成渠道码二维码、员工头像和员工名称
+type PosterTemplate struct {
+	ExtCorpModel
+	// 模板名称
+	Name string `json:"name" gorm:"type:varchar(64);comment:模板名称"`
+	// 背景图在文件存储中的ObjectKey，仅支持png、jpg
+	Background string `json:"background" gorm:"type:varchar(255);comment:背景图ObjectKey"`
+	// 背景图宽度
+	Width int `json:"width" gorm:"comment:背景图宽度"`
+	// 背景图高度
+	Height int `json:"height" gorm:"comment:背景图高度"`
+	// 二维码位置
+	QrCodeSlot constants.PosterSlotField `json:"qr_code_slot" gorm:"type:jsonb;comment:二维码位置"`
+	// 员工头像位置，Size为0时不绘制
+	AvatarSlot constants.PosterSlotField `json:"avatar_slot" gorm:"type:jsonb;comment:员工头像位置"`
+	// 员工头像是否裁剪为圆形
+	AvatarRound constants.Boolean `json:"avatar_round" gorm:"default:1;comment:头像是否裁剪为圆形"`
+	// 员工名称位置，FontSize为0时不绘制
+	NameSlot constants.PosterTextSlotField `json:"name_slot" gorm:"type:jsonb;default:'{}';comment:员工名称位置"`
+	Timestamp
+}
+
+func (o PosterTemplate) Get(id string, extCorpID string) (item PosterTemplate, err error) {
+	err = DB.Model(&PosterTemplate{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First PosterTemplate failed")
+		return
+	}
+	return
+}
+
+func (o PosterTemplate) Query(
+	req requests.QueryPosterTemplateReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []PosterTemplate, total int64, err error) {
+	db := DB.Model(&PosterTemplate{}).Where("ext_corp_id = ?", extCorpID)
+	if req.Name != "" {
+		db = db.Where("name like ?", "%"+req.Name+"%")
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count PosterTemplate failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find PosterTemplate failed")
+		return
+	}
+	return
+}
+
+func (o PosterTemplate) Create(item PosterTemplate) error {
+	err := DB.Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Create PosterTemplate failed")
+	}
+	return nil
+}
+
+func (o PosterTemplate) Update(item PosterTemplate) error {
+	err := DB.Model(&PosterTemplate{}).
+		Where("ext_corp_id = ? and id = ?", item.ExtCorpID, item.ID).
+		Select("name", "background", "width", "height", "qr_code_slot", "avatar_slot", "avatar_round", "name_slot").
+		Updates(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Update PosterTemplate failed")
+	}
+	return nil
+}
+
+func (o PosterTemplate) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&PosterTemplate{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete PosterTemplate failed")
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+)
+
+// Product 商品图册，企微商品图册在本地的镜像
+// 商品可作为欢迎语、群发消息的附件发送给客户
+type Product struct {
+	ExtCorpModel
+	// 企微商品ID
+	ExtProductID string `json:"ext_product_id" gorm:"type:varchar(64);uniqueIndex:idx_ext_corp_id_ext_product_id;comment:企微商品ID"`
+	// 商品的名称、特色等
+	Description string `json:"description" gorm:"type:varchar(1024);comment:商品描述"`
+	// 商品价格，单位为分
+	Price int64 `json:"price" gorm:"comment:商品价格,单位为分"`
+	// 商品编码
+	ProductSN string `json:"product_sn" gorm:"type:varchar(128);comment:商品编码"`
+	// 商品图片在文件存储中的objectKey
+	Images constants.StringArrayField `json:"images" gorm:"type:jsonb;comment:商品图片objectKey"`
+	// 商品封面在企微的永久图片URL，作为消息附件发送时使用
+	CoverURL string `json:"cover_url" gorm:"type:varchar(1024);comment:商品封面企微图片URL"`
+	// 商品详情链接，设置后作为链接消息发送，否则作为图片发送
+	URL string `json:"url" gorm:"type:varchar(1024);comment:商品详情链接"`
+	Timestamp
+}
+
+func (o Product) Get(id string, extCorpID string) (item Product, err error) {
+	err = DB.Model(&Product{}).Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(ecode.ItemNotFoundError)
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "First Product failed")
+		return
+	}
+
+	return
+}
+
+// GetByIDs 批量查询商品，返回以ID为key的map
+func (o Product) GetByIDs(ids []string, extCorpID string) (res map[string]Product, err error) {
+	items := make([]Product, 0)
+	err = DB.Model(&Product{}).Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find Product failed")
+		return
+	}
+
+	res = make(map[string]Product, len(items))
+	for _, item := range items {
+		res[item.ID] = item
+	}
+	return
+}
+
+func (o Product) Query(
+	req requests.QueryProductReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []Product, total int64, err error) {
+	db := DB.Model(&Product{}).Where("ext_corp_id = ?", extCorpID)
+
+	if req.Description != "" {
+		db = db.Where("description like ?", "%"+req.Description+"%")
+	}
+
+	if req.ProductSN != "" {
+		db = db.Where("product_sn = ?", req.ProductSN)
+	}
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count Product failed")
+		return
+	}
+
+	sorter.SetDefault()
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: string(sorter.SortField)}, Desc: sorter.SortType == constants.SortTypeDesc})
+
+	pager.SetDefault()
+	db = db.Offset(pager.GetOffset()).Limit(pager.GetLimit())
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find Product failed")
+		return
+	}
+
+	return
+}
+
+// GetExtProductIDs 查询本地已有的企微商品ID
+func (o Product) GetExtProductIDs(extCorpID string) (res map[string]string, err error) {
+	items := make([]Product, 0)
+	err = DB.Model(&Product{}).Select("id, ext_product_id").Where("ext_corp_id = ?", extCorpID).Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find Product failed")
+		return
+	}
+
+	res = make(map[string]string, len(items))
+	for _, item := range items {
+		res[item.ExtProductID] = item.ID
+	}
+	return
+}
+
+// Save 保存商品，已存在时更新
+func (o Product) Save(item Product) error {
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "price", "product_sn", "images", "cover_url", "url", "updated_at"}),
+	}).Create(&item).Error
+	if err != nil {
+		return errors.Wrap(err, "Save Product failed")
+	}
+	return nil
+}
+
+// UpdateFromWx 使用企微的商品信息更新本地商品，不修改本地维护的图片和链接
+func (o Product) UpdateFromWx(item Product) error {
+	err := DB.Model(&Product{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"description": item.Description,
+		"price":       item.Price,
+		"product_sn":  item.ProductSN,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "Update Product failed")
+	}
+	return nil
+}
+
+func (o Product) Delete(ids []string, extCorpID string) error {
+	err := DB.Where("ext_corp_id = ? and id in (?)", extCorpID, ids).Delete(&Product{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Delete Product failed")
+	}
+	return nil
+}
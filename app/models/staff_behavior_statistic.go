@@ -0,0 +1,247 @@
+package models
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/util"
+)
+
+// StaffBehaviorStatistic 员工联系客户统计，每天从企微「联系客户统计」同步
+// unique_index: ext_staff_id - date
+type StaffBehaviorStatistic struct {
+	ExtCorpModel
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);uniqueIndex:idx_behavior_ext_staff_id_date;comment:外部员工ID"`
+	// 聊天总数
+	ChatCnt int64 `json:"chat_cnt" gorm:"type:bigint;comment:聊天总数"`
+	// 发送消息数
+	MessageCnt int64 `json:"message_cnt" gorm:"type:bigint;comment:发送消息数"`
+	// 已回复聊天占比，单位为百分比
+	ReplyPercentage float64 `json:"reply_percentage" gorm:"type:numeric(5,2);comment:已回复聊天占比"`
+	// 平均首次回复时长，单位为分钟
+	AvgReplyTime int64 `json:"avg_reply_time" gorm:"type:bigint;comment:平均首次回复时长(分钟)"`
+	// 删除/拉黑成员的客户数
+	NegativeFeedbackCnt int64 `json:"negative_feedback_cnt" gorm:"type:bigint;comment:删除/拉黑成员的客户数"`
+	// 发起申请数
+	NewApplyCnt int64 `json:"new_apply_cnt" gorm:"type:bigint;comment:发起申请数"`
+	// 新增客户数
+	NewContactCnt int64 `json:"new_contact_cnt" gorm:"type:bigint;comment:新增客户数"`
+	// 日期
+	Date constants.DateField `json:"date" gorm:"type:date;uniqueIndex:idx_behavior_ext_staff_id_date;comment:日期"`
+	Timestamp
+}
+
+// GroupChatStatistic 群主的客户群统计，每天从企微「群聊数据统计」同步
+// unique_index: ext_staff_id - date
+type GroupChatStatistic struct {
+	ExtCorpModel
+	// 群主外部员工ID
+	ExtStaffID string `json:"ext_staff_id" gorm:"type:varchar(64);uniqueIndex:idx_group_chat_ext_staff_id_date;comment:群主外部员工ID"`
+	// 新增客户群数量
+	NewChatCnt int64 `json:"new_chat_cnt" gorm:"type:bigint;comment:新增客户群数量"`
+	// 截至当天客户群总数量
+	ChatTotal int64 `json:"chat_total" gorm:"type:bigint;comment:截至当天客户群总数量"`
+	// 有发过消息的客户群数量
+	ChatHasMsg int64 `json:"chat_has_msg" gorm:"type:bigint;comment:有发过消息的客户群数量"`
+	// 客户群新增群人数
+	NewMemberCnt int64 `json:"new_member_cnt" gorm:"type:bigint;comment:客户群新增群人数"`
+	// 截至当天客户群总人数
+	MemberTotal int64 `json:"member_total" gorm:"type:bigint;comment:截至当天客户群总人数"`
+	// 有发过消息的群成员数
+	MemberHasMsg int64 `json:"member_has_msg" gorm:"type:bigint;comment:有发过消息的群成员数"`
+	// 客户群消息总数
+	MsgTotal int64 `json:"msg_total" gorm:"type:bigint;comment:客户群消息总数"`
+	// 日期
+	Date constants.DateField `json:"date" gorm:"type:date;uniqueIndex:idx_group_chat_ext_staff_id_date;comment:日期"`
+	Timestamp
+}
+
+// StaffBehaviorSummary 员工在时间段内的统计汇总
+type StaffBehaviorSummary struct {
+	ExtStaffID string `json:"ext_staff_id"`
+	StaffName  string `json:"staff_name"`
+	BehaviorSummary
+}
+
+// DepartmentBehaviorSummary 部门在时间段内的统计汇总
+type DepartmentBehaviorSummary struct {
+	ExtDepartmentID int64  `json:"ext_department_id"`
+	DepartmentName  string `json:"department_name"`
+	// 部门员工数
+	StaffNum int64 `json:"staff_num"`
+	BehaviorSummary
+}
+
+// BehaviorSummary 时间段内的汇总指标
+// 总数类指标取时间段内的最大值，比例和时长类指标取平均值，其余指标求和
+type BehaviorSummary struct {
+	ChatCnt             int64   `json:"chat_cnt"`
+	MessageCnt          int64   `json:"message_cnt"`
+	ReplyPercentage     float64 `json:"reply_percentage"`
+	AvgReplyTime        float64 `json:"avg_reply_time"`
+	NegativeFeedbackCnt int64   `json:"negative_feedback_cnt"`
+	NewApplyCnt         int64   `json:"new_apply_cnt"`
+	NewContactCnt       int64   `json:"new_contact_cnt"`
+	NewChatCnt          int64   `json:"new_chat_cnt"`
+	ChatTotal           int64   `json:"chat_total"`
+	NewMemberCnt        int64   `json:"new_member_cnt"`
+	MemberTotal         int64   `json:"member_total"`
+	MsgTotal            int64   `json:"msg_total"`
+}
+
+func (o StaffBehaviorStatistic) BatchUpsert(items []StaffBehaviorStatistic) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ext_staff_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chat_cnt", "message_cnt", "reply_percentage", "avg_reply_time",
+			"negative_feedback_cnt", "new_apply_cnt", "new_contact_cnt", "updated_at"}),
+	}).CreateInBatches(&items, 500).Error
+}
+
+func (o GroupChatStatistic) BatchUpsert(items []GroupChatStatistic) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ext_staff_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"new_chat_cnt", "chat_total", "chat_has_msg", "new_member_cnt",
+			"member_total", "member_has_msg", "msg_total", "updated_at"}),
+	}).CreateInBatches(&items, 500).Error
+}
+
+// behaviorSummaryColumns 员工维度的汇总字段
+const behaviorSummaryColumns = "coalesce(b.chat_cnt, 0) as chat_cnt, " +
+	"coalesce(b.message_cnt, 0) as message_cnt, " +
+	"coalesce(b.reply_percentage, 0) as reply_percentage, " +
+	"coalesce(b.avg_reply_time, 0) as avg_reply_time, " +
+	"coalesce(b.negative_feedback_cnt, 0) as negative_feedback_cnt, " +
+	"coalesce(b.new_apply_cnt, 0) as new_apply_cnt, " +
+	"coalesce(b.new_contact_cnt, 0) as new_contact_cnt, " +
+	"coalesce(g.new_chat_cnt, 0) as new_chat_cnt, " +
+	"coalesce(g.chat_total, 0) as chat_total, " +
+	"coalesce(g.new_member_cnt, 0) as new_member_cnt, " +
+	"coalesce(g.member_total, 0) as member_total, " +
+	"coalesce(g.msg_total, 0) as msg_total"
+
+// staffBehaviorDB 按员工汇总时间段内的两张统计表
+func (o StaffBehaviorStatistic) staffBehaviorDB(req requests.QueryStaffBehaviorStatisticReq, extCorpID string) *gorm.DB {
+	behaviorDB := DB.Model(&StaffBehaviorStatistic{}).
+		Select("ext_staff_id, sum(chat_cnt) as chat_cnt, sum(message_cnt) as message_cnt, "+
+			"avg(reply_percentage) filter (where chat_cnt > 0) as reply_percentage, "+
+			"avg(avg_reply_time) filter (where chat_cnt > 0) as avg_reply_time, "+
+			"sum(negative_feedback_cnt) as negative_feedback_cnt, sum(new_apply_cnt) as new_apply_cnt, "+
+			"sum(new_contact_cnt) as new_contact_cnt").
+		Where("ext_corp_id = ? and date between ? and ?", extCorpID, req.StartTime, req.EndTime).
+		Group("ext_staff_id")
+
+	groupChatDB := DB.Model(&GroupChatStatistic{}).
+		Select("ext_staff_id, sum(new_chat_cnt) as new_chat_cnt, max(chat_total) as chat_total, "+
+			"sum(new_member_cnt) as new_member_cnt, max(member_total) as member_total, sum(msg_total) as msg_total").
+		Where("ext_corp_id = ? and date between ? and ?", extCorpID, req.StartTime, req.EndTime).
+		Group("ext_staff_id")
+
+	db := DB.Table("staff s").
+		Joins("left join (?) b on b.ext_staff_id = s.ext_id", behaviorDB).
+		Joins("left join (?) g on g.ext_staff_id = s.ext_id", groupChatDB).
+		Where("s.ext_corp_id = ? and s.deleted_at is null", extCorpID)
+
+	if len(req.ExtStaffIDs) > 0 {
+		db = db.Where("s.ext_id in (?)", req.ExtStaffIDs)
+	}
+
+	if req.ExtDepartmentID != 0 {
+		db = db.Where("s.dept_ids @> ?::jsonb", util.ToJSONBSingleArray(req.ExtDepartmentID))
+	}
+
+	return db
+}
+
+// QueryStaffSummary 按员工查询时间段内的统计汇总
+func (o StaffBehaviorStatistic) QueryStaffSummary(
+	req requests.QueryStaffBehaviorStatisticReq, extCorpID string) (items []StaffBehaviorSummary, total int64, err error) {
+	db := o.staffBehaviorDB(req, extCorpID)
+
+	err = db.Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count StaffBehaviorSummary failed")
+		return
+	}
+
+	db = db.Select("s.ext_id as ext_staff_id, s.name as staff_name, " + behaviorSummaryColumns)
+	db = o.order(db, req, "s.ext_id")
+	if req.PageSize > 0 {
+		req.Pager.SetDefault()
+		db = db.Offset(req.GetOffset()).Limit(req.GetLimit())
+	}
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find StaffBehaviorSummary failed")
+		return
+	}
+
+	return
+}
+
+// QueryDepartmentSummary 按部门查询时间段内的统计汇总，员工属于多个部门时在每个部门中都会统计
+func (o StaffBehaviorStatistic) QueryDepartmentSummary(
+	req requests.QueryStaffBehaviorStatisticReq, extCorpID string) (items []DepartmentBehaviorSummary, total int64, err error) {
+	staffDB := o.staffBehaviorDB(req, extCorpID).
+		Select("s.ext_id, s.dept_ids, " + behaviorSummaryColumns)
+
+	db := DB.Table("(?) ss", staffDB).
+		Joins("cross join jsonb_array_elements_text(ss.dept_ids) as sd(ext_dept_id)").
+		Joins("join department d on d.ext_id = sd.ext_dept_id::int and d.ext_corp_id = ? and d.deleted_at is null", extCorpID).
+		Group("d.ext_id, d.name")
+
+	if req.ExtDepartmentID != 0 {
+		db = db.Where("d.ext_id = ?", req.ExtDepartmentID)
+	}
+
+	err = DB.Table("(?) t", db.Select("d.ext_id")).Count(&total).Error
+	if err != nil || total == 0 {
+		err = errors.Wrap(err, "Count DepartmentBehaviorSummary failed")
+		return
+	}
+
+	db = db.Select("d.ext_id as ext_department_id, d.name as department_name, count(ss.ext_id) as staff_num, " +
+		"sum(ss.chat_cnt) as chat_cnt, sum(ss.message_cnt) as message_cnt, " +
+		"coalesce(avg(ss.reply_percentage) filter (where ss.chat_cnt > 0), 0) as reply_percentage, " +
+		"coalesce(avg(ss.avg_reply_time) filter (where ss.chat_cnt > 0), 0) as avg_reply_time, " +
+		"sum(ss.negative_feedback_cnt) as negative_feedback_cnt, sum(ss.new_apply_cnt) as new_apply_cnt, " +
+		"sum(ss.new_contact_cnt) as new_contact_cnt, sum(ss.new_chat_cnt) as new_chat_cnt, " +
+		"sum(ss.chat_total) as chat_total, sum(ss.new_member_cnt) as new_member_cnt, " +
+		"sum(ss.member_total) as member_total, sum(ss.msg_total) as msg_total")
+	db = o.order(db, req, "d.ext_id")
+	if req.PageSize > 0 {
+		req.Pager.SetDefault()
+		db = db.Offset(req.GetOffset()).Limit(req.GetLimit())
+	}
+
+	err = db.Find(&items).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find DepartmentBehaviorSummary failed")
+		return
+	}
+
+	return
+}
+
+// order 按汇总字段排序，字段已在请求中校验
+func (o StaffBehaviorStatistic) order(db *gorm.DB, req requests.QueryStaffBehaviorStatisticReq, tieBreaker string) *gorm.DB {
+	sortField := req.SortField
+	if sortField == "" {
+		sortField = "chat_cnt"
+	}
+	sortType := constants.SortTypeDesc
+	if req.SortType == constants.SortTypeAsc {
+		sortType = constants.SortTypeAsc
+	}
+
+	return db.Order(fmt.Sprintf("%s %s, %s", sortField, sortType, tieBreaker))
+}
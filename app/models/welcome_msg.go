@@ -17,6 +17,8 @@ type WelcomeMsg struct {
 	Name string `json:"name" gorm:"type:char(128);comment:标题"`
 	// 欢迎语内容
 	WelcomeMsg constants.AutoReplyField `gorm:"type:jsonb" json:"welcome_msg"`
+	// 内容变体，为空时发送WelcomeMsg；分时段欢迎语生效时和渠道码使用渠道欢迎语时不经过变体
+	Variants constants.MsgVariants `gorm:"type:jsonb;default:'[]';comment:内容变体" json:"variants"`
 	// 主欢迎语id
 	MainWelcomeMsgID *string `gorm:"type:bigint,comment:主欢迎语id" json:"main_welcome_msg_id"`
 	// 启用分时欢迎语
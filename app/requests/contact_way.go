@@ -9,6 +9,8 @@ type ContactWayStaffParam struct {
 	ID                    string `json:"id" gorm:"primaryKey;type:bigint;comment:'ID'" validate:"omitempty,int64"`
 	DailyAddCustomerLimit int    `json:"daily_add_customer_limit" gorm:"comment:'员工每日添加客户上限'"`
 	ExtStaffID            string `json:"ext_staff_id" gorm:"index:contactWayIdAndExtStaffId;comment:'外部员工ID'" validate:"required,word"`
+	// 分配权重，加权轮询时使用，默认1
+	Weight int `json:"weight" validate:"omitempty,gte=1,lte=100"`
 }
 
 type ContactWayScheduleParam struct {
@@ -47,7 +49,17 @@ type CreateContactWayReq struct {
 	DailyAddCustomerLimit int64 `json:"daily_add_customer_limit" gorm:"comment:员工每日添加上限" validate:"gte=0"`
 	// ScheduleEnable 是否开启自动上下线
 	ScheduleEnable constants.Boolean `json:"schedule_enable" gorm:"comment:是否开启自动上下线" validate:"oneof=1 2"`
-	// Staffs 绑定员工参数
+	// DistributeStrategy 多人分配策略 1-企微随机分配 2-加权轮询 3-当前客户数最少优先 4-按顺序分配
+	DistributeStrategy constants.ContactWayDistributeStrategy `json:"distribute_strategy" validate:"omitempty,oneof=1 2 3 4"`
+	// ExpireTime 过期时间，过期后二维码失效，不传则长期有效
+	ExpireTime constants.DateTimeFiled `json:"expire_at"`
+	// AddCustomerLimit 累计添加上限，达到后二维码失效，0为不限制
+	AddCustomerLimit int `json:"add_customer_limit" validate:"gte=0"`
+	// IsTemp 是否临时会话模式，仅创建时有效，临时会话只分配给一个员工
+	IsTemp constants.Boolean `json:"is_temp" validate:"omitempty,oneof=1 2"`
+	// ChatExpiresIn 临时会话有效期，秒，默认24小时，最长14天
+	ChatExpiresIn int `json:"chat_expires_in" validate:"omitempty,gte=1,lte=1209600"`
+	// Staffs 绑定员工参数，按顺序分配时按此顺序
 	Staffs []ContactWayStaffParam `json:"staffs" validate:"required_if=ScheduleEnable 2,dive"`
 	// BackupStaffs 绑定备份员工参数
 	BackupStaffs []ContactWayStaffParam `json:"backup_staffs" validate:"required"`
@@ -94,6 +106,8 @@ type QueryContactWayReq struct {
 	ConfigID string `json:"config_id" form:"config_id" gorm:"comment:渠道码配置ID" validate:"omitempty,word"`
 	// GroupID 渠道码分组ID
 	GroupID string `json:"group_id" form:"group_id" gorm:"type:bigint;comment:活码分组ID" validate:"omitempty,int64"`
+	// Status 状态 1-生效中 2-已过期 3-已达到添加上限
+	Status constants.ContactWayStatus `json:"status" form:"status" validate:"omitempty,oneof=1 2 3"`
 	// CreatedAtStart 创建时间范围开始
 	CreatedAtStart constants.DateField `json:"created_at_start" form:"created_at_start" gorm:"-" validate:"omitempty,date"`
 	// CreatedAtEnd 创建时间范围结束
@@ -113,3 +127,65 @@ type BatchUpdateContactWayReq struct {
 	// 渠道码ID
 	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
 }
+
+// QueryContactWayAnalyticsReq 渠道码数据分析请求参数
+type QueryContactWayAnalyticsReq struct {
+	// 开始日期
+	StartTime constants.DateField `form:"start_time" json:"start_time" validate:"required,date"`
+	// 结束日期
+	EndTime constants.DateField `form:"end_time" json:"end_time" validate:"required,date"`
+	// 员工外部ID
+	ExtStaffIDs []string `form:"ext_staff_ids" json:"ext_staff_ids" validate:"omitempty,dive,word"`
+	// 子渠道ID
+	SubChannelIDs []string `form:"sub_channel_ids" json:"sub_channel_ids" validate:"omitempty,dive,int64"`
+	// 统计添加后多少天内的流失、打标签和聊天，默认7天
+	RetentionDays int `form:"retention_days" json:"retention_days" validate:"omitempty,gte=1,lte=90"`
+}
+
+// CreateContactWaySubChannelReq 创建渠道码子渠道
+type CreateContactWaySubChannelReq struct {
+	// 子渠道名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 子渠道编码，拼接在渠道码state之后，不传则自动生成
+	Code string `json:"code" validate:"omitempty,alphanum,max=6"`
+	// 自定义参数，如source、campaign、store
+	Params constants.StringMapField `json:"params" validate:"omitempty,max=10,dive,keys,required,max=32,endkeys,max=64"`
+}
+
+// UpdateContactWaySubChannelReq 更新渠道码子渠道，编码不可修改
+type UpdateContactWaySubChannelReq struct {
+	// 子渠道名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 自定义参数
+	Params constants.StringMapField `json:"params" validate:"omitempty,max=10,dive,keys,required,max=32,endkeys,max=64"`
+}
+
+// DeleteContactWaySubChannelReq 删除渠道码子渠道
+type DeleteContactWaySubChannelReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// SimulateContactWayDistributionReq 模拟渠道码接下来的分配结果
+type SimulateContactWayDistributionReq struct {
+	// 模拟的添加次数
+	Num int `form:"num" json:"num" validate:"required,gte=1,lte=500"`
+	// 分配策略，不传则使用渠道码当前的策略
+	DistributeStrategy constants.ContactWayDistributeStrategy `form:"distribute_strategy" json:"distribute_strategy" validate:"omitempty,oneof=1 2 3 4"`
+}
+
+// ImportContactWayReq 批量导入渠道码
+type ImportContactWayReq struct {
+	// 导入文件ObjectKey，先通过文件上传接口上传，支持xlsx、csv，格式与导出的渠道码列表一致
+	ObjectKey string `json:"object_key" validate:"required,max=255"`
+}
+
+// QueryContactWayScheduleLogReq 查询渠道码接待员工变更记录
+type QueryContactWayScheduleLogReq struct {
+	app.Pager
+}
+
+// StaffOnlineContactWayReq 员工自行上下线
+type StaffOnlineContactWayReq struct {
+	// 1-上线 2-下线
+	Online constants.Boolean `json:"online" validate:"oneof=1 2"`
+}
@@ -0,0 +1,40 @@
+package requests
+
+import "openscrm/app/constants"
+
+// QueryCorpCalendarDayReq 查询企业日历
+type QueryCorpCalendarDayReq struct {
+	// 开始日期，如2026-01-01
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	// 结束日期，如2026-12-31
+	EndDate string `form:"end_date" json:"end_date" validate:"omitempty,date"`
+}
+
+type CorpCalendarDayParam struct {
+	// 日期，如2026-10-01
+	Date string `json:"date" validate:"required,date"`
+	// 类型 1-节假日 2-调休上班日
+	Type constants.CorpCalendarDayType `json:"type" validate:"oneof=1 2"`
+	// 名称，如国庆节
+	Name string `json:"name" validate:"max=64"`
+	// 调休上班日按星期几排班，0为周日，默认周一
+	AsWeekday *int `json:"as_weekday" validate:"omitempty,gte=0,lte=6"`
+}
+
+// SaveCorpCalendarDaysReq 保存企业日历，同一天已存在时覆盖
+type SaveCorpCalendarDaysReq struct {
+	Days []CorpCalendarDayParam `json:"days" validate:"gt=0,lte=1000,dive"`
+}
+
+// ImportCorpCalendarReq 从iCal或CSV文件导入企业日历
+type ImportCorpCalendarReq struct {
+	// 导入文件ObjectKey，先通过文件上传接口上传，支持ics、csv
+	// CSV列依次为 日期,类型(休/班),名称,调休按星期几排班(可选)
+	// iCal中标题含「班」的日程为调休上班日，其余为节假日
+	ObjectKey string `json:"object_key" validate:"required,max=255"`
+}
+
+// DeleteCorpCalendarDayReq 删除企业日历
+type DeleteCorpCalendarDayReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
@@ -11,12 +11,14 @@ type QueryCustomerReq struct {
 	ExtStaffIDs   []string            `json:"ext_staff_ids" form:"ext_staff_ids"` // 所属客服
 	ExtTagIDs     []string            `form:"ext_tag_ids" json:"ext_tag_ids"`     // 企业标签
 	TagUnionType  string              `form:"tag_union_type" json:"tag_union_type" `
-	ChannelType   int                 `form:"channel_type" json:"channel_type"`                      // 添加渠道
-	Gender        int                 `form:"gender" json:"gender" validate:"omitempty,oneof=0 1 2"` // 性别
-	OutFlowStatus int                 `form:"out_flow_status" json:"out_flow_status "`               // 流失状态 1-已经流失 2-未流失
-	Type          int                 `form:"type" json:"type" validate:"omitempty,oneof=0 1 2"`     // 客户类型 1-微信用户, 2-企业微信用户
-	StartTime     constants.DateField `form:"start_time" json:"start_time"`                          // 添加客户的时间
-	EndTime       constants.DateField `form:"end_time" json:"end_time"`                              // 添加客户的时间
+	ChannelType   int                 `form:"channel_type" json:"channel_type"`                                       // 添加渠道
+	Gender        int                 `form:"gender" json:"gender" validate:"omitempty,oneof=0 1 2"`                  // 性别
+	OutFlowStatus int                 `form:"out_flow_status" json:"out_flow_status "`                                // 流失状态 1-已经流失 2-未流失
+	Type          int                 `form:"type" json:"type" validate:"omitempty,oneof=0 1 2"`                      // 客户类型 1-微信用户, 2-企业微信用户
+	StartTime     constants.DateField `form:"start_time" json:"start_time"`                                           // 添加客户的时间
+	EndTime       constants.DateField `form:"end_time" json:"end_time"`                                               // 添加客户的时间
+	SegmentID     string              `form:"segment_id" json:"segment_id" validate:"omitempty,int64"`                // 客户分群ID
+	SubChannelIDs []string            `form:"sub_channel_ids" json:"sub_channel_ids" validate:"omitempty,dive,int64"` // 来源子渠道ID
 	app.Pager
 	app.Sorter
 }
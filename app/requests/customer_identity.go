@@ -0,0 +1,38 @@
+package requests
+
+import "openscrm/app/constants"
+
+// LookupCustomerReq 根据unionid或手机号查找客户
+type LookupCustomerReq struct {
+	// 微信开放平台的唯一身份标识
+	Unionid string `form:"unionid" json:"unionid" validate:"required_without=PhoneNumber"`
+	// 客户在小程序/公众号中的openid，本地未找到时用于向企微换取external_userid
+	Openid string `form:"openid" json:"openid" validate:"omitempty"`
+	// 手机号，匹配客户画像或员工备注的手机号
+	PhoneNumber string `form:"phone_number" json:"phone_number" validate:"required_without=Unionid"`
+}
+
+// MiniProgramLoginReq 小程序登录请求，由小程序服务端换取unionid/openid后签名调用
+type MiniProgramLoginReq struct {
+	// 来源应用类型 1-微信小程序 2-微信公众号
+	SourceType constants.CustomerIdentitySource `form:"source_type" json:"source_type" validate:"required,oneof=1 2"`
+	// 小程序或公众号的AppID
+	AppID string `form:"app_id" json:"app_id" validate:"required"`
+	// 客户在来源应用中的openid
+	Openid string `form:"openid" json:"openid" validate:"required"`
+	// 微信开放平台的唯一身份标识
+	Unionid string `form:"unionid" json:"unionid" validate:"required"`
+	// 签名时间戳，单位秒
+	Timestamp int64 `form:"timestamp" json:"timestamp" validate:"required,gt=0"`
+	// 使用内部服务key对其余字段做HMAC-SHA256的签名
+	Signature string `form:"signature" json:"signature" validate:"required"`
+}
+
+// MiniProgramLoginSignParams 小程序登录参与签名的字段
+type MiniProgramLoginSignParams struct {
+	SourceType constants.CustomerIdentitySource
+	AppID      string
+	Openid     string
+	Unionid    string
+	Timestamp  int64
+}
@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CreateCustomerSegmentReq 创建客户分群
+type CreateCustomerSegmentReq struct {
+	// 分群名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 分群描述
+	Description string `json:"description" validate:"omitempty,max=255"`
+	// 筛选规则
+	Rule constants.SegmentRule `json:"rule"`
+}
+
+// UpdateCustomerSegmentReq 更新客户分群
+type UpdateCustomerSegmentReq struct {
+	CreateCustomerSegmentReq
+}
+
+// QueryCustomerSegmentReq 查询客户分群
+type QueryCustomerSegmentReq struct {
+	// 分群名称
+	Name string `form:"name" json:"name"`
+	app.Pager
+	app.Sorter
+}
+
+// DeleteCustomerSegmentReq 删除客户分群
+type DeleteCustomerSegmentReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// CountCustomerSegmentReq 按规则实时统计客户数，用于编辑分群时预览
+type CountCustomerSegmentReq struct {
+	// 筛选规则
+	Rule constants.SegmentRule `json:"rule"`
+}
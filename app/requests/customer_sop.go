@@ -0,0 +1,71 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CustomerSopStepReq 客户SOP步骤
+type CustomerSopStepReq struct {
+	// 步骤ID，更新时传入已有步骤的ID，新增步骤不传
+	ID string `json:"id" validate:"omitempty,int64"`
+	// 触发后延迟执行的分钟数
+	DelayMinutes int `json:"delay_minutes" validate:"gte=0"`
+	// 消息内容
+	Msg constants.AutoReplyField `json:"msg"`
+	// 素材库素材ID
+	MaterialID string `json:"material_id" validate:"omitempty,int64"`
+	// 执行条件，客户需已有全部标签
+	ConditionExtTagIDs constants.StringArrayField `json:"condition_ext_tag_ids" validate:"omitempty,dive,ext_id"`
+}
+
+// CreateCustomerSopReq 创建客户SOP
+type CreateCustomerSopReq struct {
+	// SOP名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 是否启用
+	Enable constants.Boolean `json:"enable" validate:"oneof=1 2"`
+	// 触发方式 1-通过渠道码添加 2-被打上标签
+	TriggerType constants.CustomerSopTriggerType `json:"trigger_type" validate:"oneof=1 2"`
+	// 触发的渠道码ID
+	TriggerContactWayIDs constants.StringArrayField `json:"trigger_contact_way_ids" validate:"omitempty,dive,int64"`
+	// 触发的标签ExtID
+	TriggerExtTagIDs constants.StringArrayField `json:"trigger_ext_tag_ids" validate:"omitempty,dive,ext_id"`
+	// 执行方式 1-提醒员工 2-单客户群发
+	DeliverType constants.CustomerSopDeliverType `json:"deliver_type" validate:"oneof=1 2"`
+	// 步骤，按执行顺序排列
+	Steps []CustomerSopStepReq `json:"steps" validate:"gt=0,dive"`
+}
+
+// UpdateCustomerSopReq 更新客户SOP，只对之后触发的客户生效，已删除步骤的待执行任务会被取消
+type UpdateCustomerSopReq struct {
+	CreateCustomerSopReq
+}
+
+// QueryCustomerSopReq 查询客户SOP
+type QueryCustomerSopReq struct {
+	// SOP名称
+	Name string `form:"name" json:"name"`
+	// 触发方式
+	TriggerType constants.CustomerSopTriggerType `form:"trigger_type" json:"trigger_type" validate:"omitempty,oneof=1 2"`
+	app.Pager
+	app.Sorter
+}
+
+// DeleteCustomerSopReq 删除客户SOP
+type DeleteCustomerSopReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// QueryCustomerSopTaskReq 查询客户SOP的执行任务
+type QueryCustomerSopTaskReq struct {
+	// SOP ID
+	CustomerSopID string `form:"customer_sop_id" json:"customer_sop_id" validate:"omitempty,int64"`
+	// 员工ID
+	ExtStaffID string `form:"ext_staff_id" json:"ext_staff_id"`
+	// 客户ID
+	ExtCustomerID string `form:"ext_customer_id" json:"ext_customer_id"`
+	// 任务状态
+	Status constants.CustomerSopTaskStatus `form:"status" json:"status" validate:"omitempty,oneof=1 2 3 4 5"`
+	app.Pager
+}
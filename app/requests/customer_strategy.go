@@ -0,0 +1,65 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CustomerStrategyRange 规则组管理范围
+type CustomerStrategyRange struct {
+	// 范围类型 1-员工 2-部门
+	Type constants.CustomerStrategyRangeType `json:"type" validate:"oneof=1 2"`
+	// 外部员工ID，type为1时必填
+	ExtStaffID string `json:"ext_staff_id" validate:"required_if=Type 1"`
+	// 外部部门ID，type为2时必填
+	ExtDepartmentID int64 `json:"ext_department_id" validate:"required_if=Type 2"`
+}
+
+// CreateCustomerStrategyReq 创建客户联系规则组
+type CreateCustomerStrategyReq struct {
+	// 企微父规则组ID
+	ExtParentID int64 `json:"ext_parent_id" validate:"gte=0"`
+	// 规则组名称
+	Name string `json:"name" validate:"required,max=255"`
+	// 规则组管理员，绑定角色时由角色下的员工决定
+	AdminList []string `json:"admin_list" validate:"required_without=RoleID,max=20"`
+	// 绑定的角色ID
+	RoleID string `json:"role_id" validate:"omitempty,int64"`
+	// 权限配置
+	Privilege *constants.CustomerStrategyPrivilege `json:"privilege"`
+	// 管理范围
+	Ranges []CustomerStrategyRange `json:"ranges" validate:"gt=0,max=1000,dive"`
+}
+
+// UpdateCustomerStrategyReq 更新客户联系规则组
+type UpdateCustomerStrategyReq struct {
+	// 规则组名称
+	Name string `json:"name" validate:"required,max=255"`
+	// 规则组管理员，绑定角色时由角色下的员工决定
+	AdminList []string `json:"admin_list" validate:"required_without=RoleID,max=20"`
+	// 绑定的角色ID
+	RoleID string `json:"role_id" validate:"omitempty,int64"`
+	// 权限配置
+	Privilege *constants.CustomerStrategyPrivilege `json:"privilege"`
+	// 管理范围
+	Ranges []CustomerStrategyRange `json:"ranges" validate:"gt=0,max=1000,dive"`
+}
+
+// QueryCustomerStrategyReq 查询客户联系规则组
+type QueryCustomerStrategyReq struct {
+	// 规则组名称
+	Name string `form:"name" json:"name"`
+	// 绑定的角色ID
+	RoleID string `form:"role_id" json:"role_id" validate:"omitempty,int64"`
+	// 管理范围包含的部门
+	ExtDepartmentID int64 `form:"ext_department_id" json:"ext_department_id"`
+	// 管理范围包含或作为管理员的员工
+	ExtStaffID string `form:"ext_staff_id" json:"ext_staff_id"`
+	app.Pager
+	app.Sorter
+}
+
+// DeleteCustomerStrategyReq 删除客户联系规则组
+type DeleteCustomerStrategyReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
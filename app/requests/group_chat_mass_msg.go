@@ -1,6 +1,9 @@
 package requests
 
-import "openscrm/app/constants"
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
 
 type SendGroupChatMassMsgReq struct {
 	// ExtStaffIDs为群主IDs
@@ -14,3 +17,82 @@ type SendGroupChatMassMsgReq struct {
 	// 消息体
 	Msg constants.AutoReplyField `json:"msg" validate:"omitempty,gte=0"`
 }
+
+// UpdateGroupChatMassMsgReq 修改定时的客户群群发
+type UpdateGroupChatMassMsgReq struct {
+	// ExtStaffIDs为群主IDs
+	ExtStaffIDs constants.StringArrayField `json:"ext_staff_ids" form:"ext_staff_ids" validate:"gt=0"`
+	// 定时发送时间戳
+	SendAt constants.DateTimeFiled `json:"send_at" validate:"required"`
+	// 消息体
+	Msg constants.AutoReplyField `json:"msg" validate:"omitempty,gte=0"`
+}
+
+// GroupChatMassMsgNotifyReq 提醒群主发送客户群群发
+type GroupChatMassMsgNotifyReq struct {
+	// 客户群群发ids
+	IDs constants.StringArrayField `json:"ids" form:"ids" validate:"gt=0"`
+}
+
+// QueryGroupChatMassMsgResultReq 查询客户群群发结果
+type QueryGroupChatMassMsgResultReq struct {
+	// 只看该群主的群
+	ExtStaffID string `form:"ext_staff_id" json:"ext_staff_id"`
+	// 群的发送状态 0-未发送 1-已发送 5-群主提交失败
+	SendStatus *constants.MassMsgSendStatus `form:"send_status" json:"send_status" validate:"omitempty,oneof=0 1 5"`
+	app.Pager
+}
+
+// GroupChatMassMsgResultResp 客户群群发结果
+type GroupChatMassMsgResultResp struct {
+	MissionID     string                      `json:"mission_id"`
+	MissionStatus constants.SendMassMsgStatus `json:"mission_status"`
+	// 已发送群主计数
+	DeliveredNum int `json:"delivered_num"`
+	// 已送达群聊数
+	SuccessNum int `json:"success_num"`
+	// 未发送群主计数
+	UnDeliveredNum int `json:"undelivered_num"`
+	// 未送达群聊数
+	FailedNum int `json:"failed_num"`
+	// 每个群主的发送结果
+	Owners []GroupChatMassMsgOwnerResult `json:"owners"`
+	// 每个群的发送结果，分页返回
+	Chats []GroupChatMassMsgChatResult `json:"chats"`
+	// 符合条件的群总数
+	ChatTotal int64 `json:"chat_total"`
+}
+
+// GroupChatMassMsgOwnerResult 群主维度的客户群群发结果
+type GroupChatMassMsgOwnerResult struct {
+	ExtStaffID string `json:"ext_staff_id"`
+	StaffName  string `json:"staff_name"`
+	// 需要发送的群数
+	Total int64 `json:"total"`
+	// 已发送的群数
+	Sent int64 `json:"sent"`
+	// 群主提交失败、无法发送的群数
+	SubmitFailed int64 `json:"submit_failed"`
+}
+
+// GroupChatMassMsgChatResult 群维度的客户群群发结果
+type GroupChatMassMsgChatResult struct {
+	ExtStaffID string                      `json:"ext_staff_id"`
+	ExtChatID  string                      `json:"ext_chat_id"`
+	ChatName   string                      `json:"chat_name"`
+	SendStatus constants.MassMsgSendStatus `json:"send_status"`
+}
+
+// GroupChatMassMsgChatFilterReq 预览客户群群发的目标群
+type GroupChatMassMsgChatFilterReq struct {
+	// 群主IDs
+	ExtStaffIDs []string `form:"ext_staff_ids" json:"ext_staff_ids" validate:"gt=0"`
+}
+
+// GroupChatMassMsgChatFilterResp 客户群群发目标群预览
+type GroupChatMassMsgChatFilterResp struct {
+	// 目标群总数
+	Total int64 `json:"total"`
+	// 目标群
+	Chats []GroupChatMassMsgChatResult `json:"chats"`
+}
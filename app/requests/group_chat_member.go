@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// QueryGroupChatMemberTrendReq 查询客户群每日入群退群趋势，默认最近30天
+type QueryGroupChatMemberTrendReq struct {
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	EndDate   string `form:"end_date" json:"end_date" validate:"omitempty,date"`
+}
+
+// QueryGroupChatMemberLogReq 查询群成员入群退群记录，可查询客户加入和退出过的群
+type QueryGroupChatMemberLogReq struct {
+	// 群聊ExtID
+	ExtChatID string `form:"ext_chat_id" json:"ext_chat_id" validate:"omitempty,max=64"`
+	// 群成员ID，客户为ExtCustomerID，员工为ExtStaffID
+	Userid string `form:"userid" json:"userid" validate:"omitempty,max=64"`
+	// 变动类型 1-入群 2-退群
+	EventType constants.GroupChatMemberEventType `form:"event_type" json:"event_type" validate:"omitempty,oneof=1 2"`
+	// 退群方式 0-自己退群 1-群主或群管理员移出，不传则查询全部
+	QuitScene *int `form:"quit_scene" json:"quit_scene" validate:"omitempty,oneof=0 1"`
+	// 邀请者ExtStaffID
+	Invitor   string `form:"invitor" json:"invitor" validate:"omitempty,max=64"`
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	EndDate   string `form:"end_date" json:"end_date" validate:"omitempty,date"`
+	app.Pager
+}
+
+// QueryGroupChatChurnReq 查询退群客户列表
+type QueryGroupChatChurnReq struct {
+	// 群聊ExtID
+	ExtChatID string `form:"ext_chat_id" json:"ext_chat_id" validate:"omitempty,max=64"`
+	// 退群方式 0-自己退群 1-群主或群管理员移出，不传则查询全部
+	QuitScene *int   `form:"quit_scene" json:"quit_scene" validate:"omitempty,oneof=0 1"`
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	EndDate   string `form:"end_date" json:"end_date" validate:"omitempty,date"`
+	app.Pager
+}
+
+// QueryGroupChatInviterRankReq 查询邀请入群排行
+type QueryGroupChatInviterRankReq struct {
+	// 群聊ExtID，不传则统计全部群
+	ExtChatIDs []string `form:"ext_chat_ids" json:"ext_chat_ids" validate:"omitempty,dive,max=64"`
+	StartDate  string   `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	EndDate    string   `form:"end_date" json:"end_date" validate:"omitempty,date"`
+	// 返回前N名，默认20
+	Limit int `form:"limit" json:"limit" validate:"omitempty,gte=1,lte=100"`
+}
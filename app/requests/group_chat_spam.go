@@ -0,0 +1,85 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CreateGroupChatSpamRuleReq 创建客户群防骚扰规则
+type CreateGroupChatSpamRuleReq struct {
+	// 规则名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 生效的群聊ExtID
+	ExtChatIDs constants.StringArrayField `json:"ext_chat_ids" validate:"omitempty,dive,max=64"`
+	// 生效的群标签ID，群聊和群标签都为空时对全部群生效
+	GroupChatTagIDs constants.StringArrayField `json:"group_chat_tag_ids" validate:"omitempty,dive,int64"`
+	// 违禁词
+	Keywords constants.StringArrayField `json:"keywords" validate:"omitempty,lte=200,dive,required,max=64"`
+	// 是否检测外部链接
+	LinkEnable constants.Boolean `json:"link_enable" validate:"oneof=1 2"`
+	// 允许发送的链接域名，包含子域名
+	LinkWhitelist constants.StringArrayField `json:"link_whitelist" validate:"omitempty,lte=100,dive,required,max=128"`
+	// 是否检测二维码图片，命中时只提醒，不加入黑名单
+	QrImageEnable constants.Boolean `json:"qr_image_enable" validate:"oneof=1 2"`
+	// 是否检测频繁发言
+	RapidPostEnable constants.Boolean `json:"rapid_post_enable" validate:"oneof=1 2"`
+	// RapidPostSeconds秒内发言达到RapidPostNum条视为频繁发言
+	RapidPostNum     int `json:"rapid_post_num" validate:"required_if=RapidPostEnable 1,omitempty,gte=2,lte=100"`
+	RapidPostSeconds int `json:"rapid_post_seconds" validate:"required_if=RapidPostEnable 1,omitempty,gte=1,lte=3600"`
+	// 是否检测新入群成员发送链接
+	NewMemberLinkEnable constants.Boolean `json:"new_member_link_enable" validate:"oneof=1 2"`
+	// 入群不满多少小时视为新成员
+	NewMemberHours int `json:"new_member_hours" validate:"required_if=NewMemberLinkEnable 1,omitempty,gte=1,lte=720"`
+	// 命中后是否加入黑名单
+	BlacklistEnable constants.Boolean `json:"blacklist_enable" validate:"oneof=1 2"`
+	// 加入黑名单时给客户打的企业标签ExtID
+	BlacklistTagExtIDs constants.StringArrayField `json:"blacklist_tag_ext_ids" validate:"omitempty,dive,max=64"`
+	// 是否启用
+	Enable constants.Boolean `json:"enable" validate:"oneof=1 2"`
+}
+
+// UpdateGroupChatSpamRuleReq 更新客户群防骚扰规则
+type UpdateGroupChatSpamRuleReq struct {
+	CreateGroupChatSpamRuleReq
+}
+
+// QueryGroupChatSpamRuleReq 查询客户群防骚扰规则
+type QueryGroupChatSpamRuleReq struct {
+	// 规则名称
+	Name string `form:"name" json:"name" validate:"omitempty,max=64"`
+	// 是否启用
+	Enable constants.Boolean `form:"enable" json:"enable" validate:"omitempty,oneof=1 2"`
+	app.Pager
+}
+
+// DeleteGroupChatSpamRuleReq 删除客户群防骚扰规则
+type DeleteGroupChatSpamRuleReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// QueryGroupChatViolationReq 查询客户群违规记录
+type QueryGroupChatViolationReq struct {
+	// 规则ID
+	RuleID string `form:"rule_id" json:"rule_id" validate:"omitempty,int64"`
+	// 规则类型
+	RuleType constants.GroupChatSpamRuleType `form:"rule_type" json:"rule_type" validate:"omitempty,oneof=keyword link qr_image rapid_post new_member_link blacklist_join"`
+	// 群聊ExtID
+	ExtChatID string `form:"ext_chat_id" json:"ext_chat_id" validate:"omitempty,max=64"`
+	// 违规成员ID
+	Userid    string `form:"userid" json:"userid" validate:"omitempty,max=64"`
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,date"`
+	EndDate   string `form:"end_date" json:"end_date" validate:"omitempty,date"`
+	app.Pager
+}
+
+// QueryGroupChatBlacklistReq 查询客户群黑名单
+type QueryGroupChatBlacklistReq struct {
+	// 成员ID
+	Userid string `form:"userid" json:"userid" validate:"omitempty,max=64"`
+	app.Pager
+}
+
+// DeleteGroupChatBlacklistReq 移出客户群黑名单
+type DeleteGroupChatBlacklistReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
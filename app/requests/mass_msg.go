@@ -16,6 +16,55 @@ type SendMassMsgResp struct {
 	UnDeliveredNum int `json:"undelivered_num"`
 	// 未送达客户计数
 	FailedNum int `json:"failed_num"`
+	// 每个员工的发送结果
+	Staffs []MassMsgStaffResult `json:"staffs"`
+	// 每个客户的发送结果，分页返回
+	Customers []MassMsgCustomerResult `json:"customers"`
+	// 符合条件的客户总数
+	CustomerTotal int64 `json:"customer_total"`
+}
+
+// CancelMassMsgReq 取消群发
+type CancelMassMsgReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// MassMsgStaffResult 员工维度的群发结果
+type MassMsgStaffResult struct {
+	ExtStaffID string `json:"ext_staff_id"`
+	StaffName  string `json:"staff_name"`
+	// 需要发送的客户数
+	Total int64 `json:"total"`
+	// 未发送的客户数
+	Unsent int64 `json:"unsent"`
+	// 已发送的客户数
+	Sent int64 `json:"sent"`
+	// 因客户不是好友导致发送失败的客户数
+	FailedNotFriend int64 `json:"failed_not_friend"`
+	// 因客户已经收到其他群发消息导致发送失败的客户数
+	FailedOverQuota int64 `json:"failed_over_quota"`
+	// 群发取消时仍未发送的客户数
+	Canceled int64 `json:"canceled"`
+	// 创建企微群发失败的客户数
+	SubmitFailed int64 `json:"submit_failed"`
+}
+
+// MassMsgCustomerResult 客户维度的群发结果
+type MassMsgCustomerResult struct {
+	ExtStaffID    string                      `json:"ext_staff_id"`
+	ExtCustomerID string                      `json:"ext_customer_id"`
+	CustomerName  string                      `json:"customer_name"`
+	SendStatus    constants.MassMsgSendStatus `json:"send_status"`
+	VariantKey    string                      `json:"variant_key"`
+}
+
+// QueryMassMsgResultReq 查询群发结果
+type QueryMassMsgResultReq struct {
+	// 只看该员工的客户
+	ExtStaffID string `form:"ext_staff_id" json:"ext_staff_id"`
+	// 客户的发送状态 0-未发送 1-已发送 2-因客户不是好友导致发送失败 3-因客户已经收到其他群发消息导致发送失败 4-群发已取消 5-创建企微群发失败
+	SendStatus *constants.MassMsgSendStatus `form:"send_status" json:"send_status" validate:"omitempty,oneof=0 1 2 3 4 5"`
+	app.Pager
 }
 
 type SendMassMsgReq struct {
@@ -33,8 +82,12 @@ type SendMassMsgReq struct {
 	// 是否有筛选条件
 	ExtCustomerFilterEnable constants.Boolean           `json:"ext_customer_filter_enable" form:"ext_customer_filter_enable"`
 	ExtCustomerFilter       constants.ExtCustomerFilter `json:"ext_customer_filter" validate:"omitempty"`
+	// 本月群发次数已达上限客户的处理方式 0-不处理 1-存在时提示 2-排除
+	QuotaPolicy constants.MassMsgQuotaPolicy `json:"quota_policy" validate:"omitempty,oneof=0 1 2"`
 	// 消息体
 	Msg constants.AutoReplyField `json:"msg" validate:"omitempty"`
+	// 内容变体，用于A/B测试，为空时所有客户都发送Msg
+	Variants constants.MsgVariants `json:"variants" validate:"omitempty,dive"`
 }
 
 type UpdateMassMsgReq struct {
@@ -51,8 +104,12 @@ type UpdateMassMsgReq struct {
 	// 是否有筛选条件
 	ExtCustomerFilterEnable constants.Boolean           `json:"ext_customer_filter_enable" form:"ext_customer_filter_enable"`
 	ExtCustomerFilter       constants.ExtCustomerFilter `json:"ext_customer_filter" validate:"omitempty"`
+	// 本月群发次数已达上限客户的处理方式 0-不处理 1-存在时提示 2-排除
+	QuotaPolicy constants.MassMsgQuotaPolicy `json:"quota_policy" validate:"omitempty,oneof=0 1 2"`
 	// 消息体
 	Msg constants.AutoReplyField `json:"msg" validate:"omitempty"`
+	// 内容变体，用于A/B测试，为空时所有客户都发送Msg
+	Variants constants.MsgVariants `json:"variants" validate:"omitempty,dive"`
 }
 
 // QueryMassMsgReq 查询群发消息列表请求参数
@@ -61,11 +118,26 @@ type QueryMassMsgReq struct {
 	app.Sorter
 }
 
+// MassMsgBatchJob 群发分批发送任务
+type MassMsgBatchJob struct {
+	MassMsgID string         `json:"mass_msg_id"`
+	BatchNo   int            `json:"batch_no"`
+	Req       SendMassMsgReq `json:"req"`
+}
+
 type MassMsgNotifyReq struct {
 	//  群发消息ids
 	IDs constants.StringArrayField `json:"ids" form:"ids" validate:"gt=0"`
 }
 
+// MassMsgCustomerFilterResp 群发客户筛选预览
+type MassMsgCustomerFilterResp struct {
+	// 筛选出的员工-客户数
+	Total int64 `json:"total"`
+	// 其中本月群发次数已达上限的数量
+	ExhaustedNum int64 `json:"exhausted_num"`
+}
+
 type CustomerNum struct {
 	Total int64 `json:"total"`
 }
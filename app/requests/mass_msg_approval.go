@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CreateMassMsgApprovalRuleReq 创建群发审批规则
+type CreateMassMsgApprovalRuleReq struct {
+	// 规则名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 是否启用 1-是 2-否
+	Enable constants.Boolean `json:"enable" validate:"oneof=1 2"`
+	// 发送客户数不少于此值时需审批，0为不限制
+	MinCustomerNum int `json:"min_customer_num" validate:"gte=0"`
+	// 创建人为这些角色时需审批，为空不限制
+	CreatorRoleTypes []string `json:"creator_role_types" validate:"omitempty,dive,oneof=superAdmin admin departmentAdmin staff"`
+	// 审批人外部员工ID
+	ApproverExtStaffIDs []string `json:"approver_ext_staff_ids" validate:"gt=0,dive,required"`
+	// 审批方式 1-本系统审批 2-企业微信审批
+	Mode constants.MassMsgApprovalMode `json:"mode" validate:"oneof=1 2"`
+	// 企业微信审批模板ID
+	OATemplateID string `json:"oa_template_id" validate:"required_if=Mode 2,max=64"`
+	// 企业微信审批模板中用于填写群发内容的多行文本控件ID
+	OAControlID string `json:"oa_control_id" validate:"required_if=Mode 2,max=64"`
+	// 审批页面地址，为空时以文本消息通知审批人
+	ApprovalURL string `json:"approval_url" validate:"omitempty,url,max=255"`
+}
+
+// UpdateMassMsgApprovalRuleReq 更新群发审批规则
+type UpdateMassMsgApprovalRuleReq struct {
+	CreateMassMsgApprovalRuleReq
+}
+
+// QueryMassMsgApprovalRuleReq 查询群发审批规则
+type QueryMassMsgApprovalRuleReq struct {
+	app.Pager
+	app.Sorter
+}
+
+// DeleteMassMsgApprovalRuleReq 删除群发审批规则
+type DeleteMassMsgApprovalRuleReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// QueryMassMsgApprovalReq 查询群发审批单
+type QueryMassMsgApprovalReq struct {
+	// 审批状态 1-待审批 2-已通过 3-已驳回 4-已取消
+	Status constants.MassMsgApprovalStatus `form:"status" json:"status" validate:"omitempty,oneof=1 2 3 4"`
+	// 可审批人外部员工ID
+	ExtApproverID string `form:"ext_approver_id" json:"ext_approver_id"`
+	// 群发ID
+	MassMsgID string `form:"mass_msg_id" json:"mass_msg_id" validate:"omitempty,int64"`
+	app.Pager
+	app.Sorter
+}
+
+// HandleMassMsgApprovalReq 审批群发
+type HandleMassMsgApprovalReq struct {
+	// 审批意见
+	Comment string `json:"comment" validate:"omitempty,max=255"`
+}
@@ -0,0 +1,11 @@
+package requests
+
+// PreviewMsgTemplateReq 预览消息模板
+type PreviewMsgTemplateReq struct {
+	// 消息文本，可包含{{变量|默认值}}
+	Text string `json:"text" validate:"required"`
+	// 发送消息的员工
+	ExtStaffID string `json:"ext_staff_id" validate:"required"`
+	// 示例客户，不传时取该员工的任意一个客户
+	ExtCustomerID string `json:"ext_customer_id"`
+}
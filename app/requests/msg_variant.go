@@ -0,0 +1,27 @@
+package requests
+
+// QueryMsgVariantStatsReq 查询内容变体统计
+type QueryMsgVariantStatsReq struct {
+	// 统计发送后多少天内的转化，默认7天
+	Days int `form:"days" json:"days" validate:"omitempty,gte=1,lte=90"`
+}
+
+// MsgVariantStat 内容变体的发送和转化统计
+type MsgVariantStat struct {
+	// 变体标识
+	Key string `json:"key" gorm:"column:variant_key"`
+	// 变体名称
+	Name string `json:"name" gorm:"-"`
+	// 分配比例
+	Percent int `json:"percent" gorm:"-"`
+	// 分配到此变体的客户数
+	Total int64 `json:"total"`
+	// 已送达客户数
+	Sent int64 `json:"sent"`
+	// 已回复客户数，来自会话存档
+	Replied int64 `json:"replied"`
+	// 被打标签客户数
+	TagAdded int64 `json:"tag_added"`
+	// 流失客户数
+	Lost int64 `json:"lost"`
+}
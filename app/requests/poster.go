@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// CreatePosterTemplateReq 创建海报模板
+type CreatePosterTemplateReq struct {
+	// 模板名称
+	Name string `json:"name" validate:"required,max=64"`
+	// 背景图ObjectKey，先通过文件上传接口上传，仅支持png、jpg
+	Background string `json:"background" validate:"required,max=255"`
+	// 二维码位置
+	QrCodeSlot constants.PosterSlotField `json:"qr_code_slot" validate:"required"`
+	// 员工头像位置，Size为0时不绘制
+	AvatarSlot constants.PosterSlotField `json:"avatar_slot"`
+	// 员工头像是否裁剪为圆形
+	AvatarRound constants.Boolean `json:"avatar_round" validate:"oneof=1 2"`
+	// 员工名称位置，FontSize为0时不绘制
+	NameSlot constants.PosterTextSlotField `json:"name_slot"`
+}
+
+// UpdatePosterTemplateReq 更新海报模板
+type UpdatePosterTemplateReq struct {
+	CreatePosterTemplateReq
+}
+
+// QueryPosterTemplateReq 查询海报模板
+type QueryPosterTemplateReq struct {
+	// 模板名称
+	Name string `form:"name" json:"name"`
+	app.Pager
+	app.Sorter
+}
+
+// DeletePosterTemplateReq 删除海报模板
+type DeletePosterTemplateReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
+
+// GeneratePosterReq 按模板生成渠道码海报
+type GeneratePosterReq struct {
+	// 渠道码ID
+	ContactWayIDs []string `form:"contact_way_ids" json:"contact_way_ids" validate:"gt=0,lte=50,dive,int64"`
+	// 是否为渠道码的每个接待员工分别生成，带上员工头像
+	PerStaff constants.Boolean `form:"per_staff" json:"per_staff" validate:"omitempty,oneof=1 2"`
+}
@@ -0,0 +1,39 @@
+package requests
+
+import (
+	"openscrm/common/app"
+)
+
+// CreateProductReq 创建商品
+type CreateProductReq struct {
+	// 商品的名称、特色等，不超过300个字
+	Description string `json:"description" validate:"required,max=300"`
+	// 商品价格，单位为分，最大不超过5万元
+	Price int64 `json:"price" validate:"gt=0,lte=5000000"`
+	// 商品编码，只能输入数字和字母
+	ProductSN string `json:"product_sn" validate:"omitempty,max=128,alphanum"`
+	// 商品图片在文件存储中的objectKey，第一张作为封面
+	Images []string `json:"images" validate:"gt=0,max=9,dive,required"`
+	// 商品详情链接
+	URL string `json:"url" validate:"omitempty,url,max=1024"`
+}
+
+// UpdateProductReq 更新商品
+type UpdateProductReq struct {
+	CreateProductReq
+}
+
+// QueryProductReq 查询商品
+type QueryProductReq struct {
+	// 商品描述
+	Description string `form:"description" json:"description"`
+	// 商品编码
+	ProductSN string `form:"product_sn" json:"product_sn"`
+	app.Pager
+	app.Sorter
+}
+
+// DeleteProductReq 删除商品
+type DeleteProductReq struct {
+	IDs []string `json:"ids" validate:"gt=0,dive,int64"`
+}
@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"openscrm/app/constants"
+	"openscrm/common/app"
+)
+
+// QueryStaffBehaviorStatisticReq 员工/部门数据统计
+type QueryStaffBehaviorStatisticReq struct {
+	// 员工外部ID
+	ExtStaffIDs []string `json:"ext_staff_ids" form:"ext_staff_ids" validate:"omitempty"`
+	// 部门外部ID
+	ExtDepartmentID int64 `json:"ext_department_id" form:"ext_department_id" validate:"omitempty"`
+	// 开始时间
+	StartTime constants.DateField `form:"start_time" json:"start_time" validate:"required"`
+	// 结束时间
+	EndTime constants.DateField `form:"end_time" json:"end_time" validate:"required"`
+	// 排序字段
+	SortField string `form:"sort_field" json:"sort_field" validate:"omitempty,oneof=chat_cnt message_cnt reply_percentage avg_reply_time negative_feedback_cnt new_contact_cnt new_chat_cnt chat_total new_member_cnt member_total msg_total"`
+	// 排序方式 asc desc
+	SortType constants.SortType `form:"sort_type" json:"sort_type" validate:"omitempty,oneof=asc desc"`
+	app.Pager
+}
+
+// ExportStaffBehaviorStatisticReq 导出员工/部门数据统计
+type ExportStaffBehaviorStatisticReq struct {
+	// 统计维度 staff-按员工 department-按部门
+	Dimension constants.StaffStatisticDimension `form:"dimension" json:"dimension" validate:"oneof=staff department"`
+	QueryStaffBehaviorStatisticReq
+}
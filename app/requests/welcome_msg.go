@@ -17,6 +17,8 @@ type UpdateWelcomeMsgReq struct {
 	TimePeriodMsg []TimePeriodMsg `json:"time_period_msg" validate:"dive"`
 	// 启用分时欢迎语
 	EnableTimePeriodMsg constants.Boolean `json:"enable_time_period_msg" validate:"oneof=1 2"`
+	// 内容变体，用于A/B测试，为空时发送主欢迎语内容；分时段欢迎语生效时和渠道码使用渠道欢迎语时不生效
+	Variants constants.MsgVariants `json:"variants" validate:"omitempty,dive"`
 }
 
 type CreateWelcomeMsgReq struct {
@@ -32,6 +34,8 @@ type CreateWelcomeMsgReq struct {
 	EnableTimePeriodMsg constants.Boolean `json:"enable_time_period_msg" validate:"oneof=1 2"`
 	// 分时迎语内容
 	TimePeriodMsg []TimePeriodMsg `json:"time_period_msg" validate:"dive"`
+	// 内容变体，用于A/B测试，为空时发送主欢迎语内容；分时段欢迎语生效时和渠道码使用渠道欢迎语时不生效
+	Variants constants.MsgVariants `json:"variants" validate:"omitempty,dive"`
 }
 
 // TimePeriodMsg 分时欢迎语
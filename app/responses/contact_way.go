@@ -1,6 +1,7 @@
 package responses
 
 import (
+	"openscrm/app/constants"
 	"openscrm/app/models"
 )
 
@@ -10,3 +11,46 @@ type ContactWay struct {
 	// CustomerTags 自动打标签绑定的标签
 	CustomerTags []models.Tag `json:"customer_tags" gorm:"type:json;comment:'自动打标签绑定的标签'"`
 }
+
+// ContactWayAnalytics 渠道码数据分析
+type ContactWayAnalytics struct {
+	// 汇总
+	Summary models.ContactWayAnalytics `json:"summary"`
+	// 每日数据
+	Daily []models.ContactWayDailyAnalytics `json:"daily"`
+	// 员工数据
+	Staffs []models.ContactWayStaffAnalytics `json:"staffs"`
+}
+
+// ContactWayDistribution 渠道码分配模拟结果
+type ContactWayDistribution struct {
+	// 使用的分配策略
+	DistributeStrategy constants.ContactWayDistributeStrategy `json:"distribute_strategy"`
+	// 每次添加分配到的员工
+	Steps []models.ContactWayDistributeStep `json:"steps"`
+	// 模拟结束后各在线员工的今日添加人次和客户数
+	Staffs []models.ContactWayDistributeCandidate `json:"staffs"`
+}
+
+// ContactWayPoster 生成的渠道码海报
+type ContactWayPoster struct {
+	ContactWayID string `json:"contact_way_id"`
+	// 按员工生成时的员工ID和名称
+	ExtStaffID string `json:"ext_staff_id"`
+	StaffName  string `json:"staff_name"`
+	// 海报在文件存储中的ObjectKey
+	ObjectKey string `json:"object_key"`
+	// 海报下载地址
+	URL string `json:"url"`
+}
+
+// StaffContactWay 员工可自行上下线的渠道码
+type StaffContactWay struct {
+	ContactWayID string `json:"contact_way_id"`
+	Name         string `json:"name"`
+	QrCode       string `json:"qr_code"`
+	// 员工是否在线
+	Online constants.Boolean `json:"online"`
+	// 员工当前是否在接待，离开调度时段或达到每日上限时不接待
+	Receiving constants.Boolean `json:"receiving"`
+}
@@ -0,0 +1,22 @@
+package responses
+
+import "openscrm/app/models"
+
+// GroupChatMemberTrend 客户群每日成员趋势
+type GroupChatMemberTrend struct {
+	models.GroupChatMemberDailyNum
+	// 净增人数
+	NetNum int64 `json:"net_num"`
+	// 当日结束时的群人数，由当前群人数和此后的变动倒推
+	Total int64 `json:"total"`
+}
+
+// GroupChatMemberLog 群成员入群退群记录
+type GroupChatMemberLog struct {
+	models.GroupChatMemberLog
+	ChatName    string `json:"chat_name"`
+	MemberName  string `json:"member_name"`
+	InvitorName string `json:"invitor_name"`
+	// 在群天数，退群记录时有值
+	StayDays int `json:"stay_days"`
+}
@@ -0,0 +1,17 @@
+package responses
+
+import "openscrm/app/models"
+
+// GroupChatViolation 客户群违规记录
+type GroupChatViolation struct {
+	models.GroupChatViolation
+	// 违规成员名称
+	MemberName string `json:"member_name"`
+}
+
+// GroupChatBlacklist 客户群黑名单成员
+type GroupChatBlacklist struct {
+	models.GroupChatBlacklist
+	// 成员名称
+	MemberName string `json:"member_name"`
+}
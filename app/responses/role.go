@@ -7,4 +7,6 @@ type Role struct {
 	// Count 成员数量
 	Count       int64               `json:"count" gorm:"default:0;comment:'成员数量'" validate:"gte=0"`
 	Permissions []models.Permission `json:"permissions"`
+	// CustomerStrategies 绑定该角色的客户联系规则组
+	CustomerStrategies []models.CustomerStrategy `json:"customer_strategies"`
 }
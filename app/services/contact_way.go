@@ -9,11 +9,12 @@ import (
 	"openscrm/app/models"
 	"openscrm/app/requests"
 	"openscrm/app/responses"
+	"openscrm/common/ecode"
 	"openscrm/common/id_generator"
 	"openscrm/common/log"
 	"openscrm/common/we_work"
 	"openscrm/pkg/easywework"
-	"strings"
+	"time"
 )
 
 type ContactWay struct {
@@ -183,6 +184,11 @@ func (o *ContactWay) Preprocess(mode preprocessMode, item *models.ContactWay, ex
 			item.Staffs[i].Name = staff.Name
 			item.Staffs[i].AvatarURL = staff.AvatarURL
 		}
+		// 按请求中的顺序分配
+		item.Staffs[i].Sort = i
+		if item.Staffs[i].Weight == 0 {
+			item.Staffs[i].Weight = 1
+		}
 		item.Staffs[i].ContactWayID = item.ID
 		item.Staffs[i].ExtCorpID = extCorpID
 		item.Staffs[i].ExtCreatorID = extCreatorID
@@ -212,6 +218,10 @@ func (o *ContactWay) Preprocess(mode preprocessMode, item *models.ContactWay, ex
 			item.Schedules[i].Staffs[j].ExtCorpID = extCorpID
 			item.Schedules[i].Staffs[j].ExtCreatorID = extCreatorID
 			item.Schedules[i].Staffs[j].DailyAddCustomerLimit = item.DailyAddCustomerLimit
+			item.Schedules[i].Staffs[j].Sort = j
+			if item.Schedules[i].Staffs[j].Weight == 0 {
+				item.Schedules[i].Staffs[j].Weight = 1
+			}
 		}
 	}
 
@@ -226,6 +236,11 @@ func (o *ContactWay) Create(req requests.CreateContactWayReq, extCorpID string,
 	}
 
 	item.ID = id_generator.StringID()
+	item.ExpireAt = o.expireAt(req.ExpireTime)
+	err = o.checkExpireAt(item.ExpireAt, item.IsTemp)
+	if err != nil {
+		return
+	}
 
 	err = o.Preprocess(preprocessModeCreate, &item, extCorpID, extCreatorID)
 	if err != nil {
@@ -244,6 +259,16 @@ func (o *ContactWay) Update(id string, req requests.UpdateContactWayReq, extCorp
 	}
 
 	item.ID = id
+	item.ExpireAt = o.expireAt(req.ExpireTime)
+	// 是否临时会话仅创建时有效，按已保存的渠道码校验
+	old, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+	err = o.checkExpireAt(item.ExpireAt, old.IsTemp)
+	if err != nil {
+		return
+	}
 
 	err = o.Preprocess(preprocessModeUpdate, &item, extCorpID, "")
 	if err != nil {
@@ -254,6 +279,29 @@ func (o *ContactWay) Update(id string, req requests.UpdateContactWayReq, extCorp
 	return o.model.Update(id, item, extCorpID)
 }
 
+// expireAt 解析过期时间，为空时返回nil
+func (o *ContactWay) expireAt(expireTime constants.DateTimeFiled) *time.Time {
+	if expireTime == "" {
+		return nil
+	}
+	t := time.Unix(expireTime.ToInt64(), 0)
+	return &t
+}
+
+// checkExpireAt 过期时间需晚于当前时间，临时会话二维码不能超过企微的最长有效期
+func (o *ContactWay) checkExpireAt(expireAt *time.Time, isTemp constants.Boolean) error {
+	if expireAt == nil {
+		return nil
+	}
+	if !expireAt.After(time.Now()) {
+		return errors.WithStack(ecode.InvalidContactWayExpireTimeErr)
+	}
+	if isTemp == constants.True && time.Until(*expireAt) > constants.ContactWayTempMaxExpiresIn*time.Second {
+		return errors.WithStack(ecode.InvalidContactWayExpireTimeErr)
+	}
+	return nil
+}
+
 func (o *ContactWay) Delete(ids []string, extCorpID string) (total int64, err error) {
 	return o.model.Delete(ids, extCorpID)
 }
@@ -271,14 +319,14 @@ func (o *ContactWay) BatchUpdate(req requests.BatchUpdateContactWayReq, extCorpI
 
 // DealAddCustomerEvent 处理添加客户时，渠道码应该做的操作
 func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExternalContact) (shouldSendWelcomeMsg bool, err error) {
-	if !strings.HasPrefix(event.GetState(), constants.ContactWayStatePrefix) {
+	contactWayID, subChannelCode, ok := models.ParseContactWayState(event.GetState())
+	if !ok {
 		return
 	}
 	shouldSendWelcomeMsg = true
 	staffSrv := NewStaffService()
 	extStaffID := event.GetUserID()
 	extCustomerID := event.GetExternalUserID()
-	contactWayID := strings.TrimPrefix(event.GetState(), constants.ContactWayStatePrefix)
 	contactWay := models.ContactWay{}
 	err = tx.Where("id = ?", contactWayID).First(&contactWay).Error
 	if err == gorm.ErrRecordNotFound {
@@ -290,6 +338,49 @@ func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExte
 		return
 	}
 
+	// 通过子渠道二维码添加时，记录具体的子渠道
+	subChannelID := ""
+	if subChannelCode != "" {
+		subChannel, err := models.ContactWaySubChannel{}.GetByCode(contactWayID, subChannelCode)
+		if err != nil && !errors.Is(err, ecode.ItemNotFoundError) {
+			return shouldSendWelcomeMsg, err
+		}
+		subChannelID = subChannel.ID
+	}
+
+	// 记录渠道来源，用于渠道分析
+	now := time.Now()
+	err = models.ContactWayAddRecord{}.Create(tx, models.ContactWayAddRecord{
+		ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: contactWay.ExtCorpID},
+		ContactWayID:  contactWay.ID,
+		SubChannelID:  subChannelID,
+		ExtStaffID:    extStaffID,
+		ExtCustomerID: extCustomerID,
+		AddedAt:       now,
+		Date:          constants.DateField(now.Format(constants.DateLayout)),
+	})
+	if err != nil {
+		return
+	}
+
+	// 达到累计添加上限后二维码失效，本次添加仍正常处理
+	if contactWay.AddCustomerLimit > 0 {
+		added, err := models.ContactWay{}.CountAdded(tx, contactWay.ID)
+		if err != nil {
+			return shouldSendWelcomeMsg, err
+		}
+		if added >= int64(contactWay.AddCustomerLimit) {
+			log.Sugar.Infow("[渠道码][达到累计添加上限]", "contactWayID", contactWayID, "added", added)
+			// 删除企微配置失败时保持生效中，下次添加客户时重试
+			err = models.ContactWay{}.Deactivate(tx, contactWay, constants.ContactWayStatusExhausted)
+			if err != nil {
+				log.Sugar.Errorw("Deactivate failed", "err", err, "contactWayID", contactWayID)
+			} else {
+				contactWay.Status = constants.ContactWayStatusExhausted
+			}
+		}
+	}
+
 	client, err := we_work.Clients.Get(contactWay.ExtCorpID)
 	if err != nil {
 		err = errors.Wrap(err, "get Client failed")
@@ -334,9 +425,11 @@ func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExte
 		shouldSendWelcomeMsg = false
 	}
 
+	// 渠道欢迎语没有内容变体，直接发送；使用渠道默认欢迎语时由SendDefaultWelcomeMsg分配变体
 	if !shouldBlockAutoReply && contactWay.AutoReplyType == constants.ContactWayAutoReplyTypeCustom {
 		shouldSendWelcomeMsg = false
-		sendErr := staffSrv.SendWelcomeMsg(contactWay.AutoReply, event.GetWelcomeCode(), contactWay.ExtCorpID, contactWay.ExtCreatorID)
+		sendErr := staffSrv.SendWelcomeMsg(
+			contactWay.AutoReply, event.GetWelcomeCode(), contactWay.ExtCorpID, extStaffID, extCustomerID)
 		if sendErr != nil {
 			// 其他三方应用可能使用掉这里的WelcomeCode，导致发送报错
 			log.Sugar.Infow("SendWelcomeMsg failed", "contactWay", contactWay, "err", sendErr)
@@ -357,6 +450,14 @@ func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExte
 	if err == gorm.ErrRecordNotFound {
 		// 如果刚好修改了渠道码，把绑定员工取消了，这里可能找不到，不算错误
 		log.Sugar.Warnw("contactWayStaff not found", "contactWayID", contactWayID, "extStaffID", extStaffID)
+		// 调度设置的员工不在普通绑定员工中，仍需按分配策略重新计算接待员工
+		if contactWay.DistributeStrategy > constants.ContactWayDistributeRandom {
+			_, err = models.ContactWay{}.Refresh(tx, contactWayID)
+			if err != nil {
+				err = errors.Wrap(err, "ContactWay Refresh failed")
+				return
+			}
+		}
 		return shouldSendWelcomeMsg, nil
 	}
 	if err != nil {
@@ -373,8 +474,11 @@ func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExte
 		return
 	}
 
-	if contactWayStaff.DailyAddCustomerCount >= contactWay.DailyAddCustomerLimit && contactWay.DailyAddCustomerLimit >= 1 {
-		log.Sugar.Infow("[渠道码][员工添加客户数量超过限制]", "contactWayID", contactWayID, "extStaffID", extStaffID)
+	// 按分配策略接待时，每次添加后重新计算接待员工
+	overLimit := contactWayStaff.DailyAddCustomerCount >= contactWay.DailyAddCustomerLimit && contactWay.DailyAddCustomerLimit >= 1
+	if contactWay.Status == constants.ContactWayStatusActive &&
+		(overLimit || contactWay.DistributeStrategy > constants.ContactWayDistributeRandom) {
+		log.Sugar.Infow("[渠道码][刷新接待员工]", "contactWayID", contactWayID, "extStaffID", extStaffID, "overLimit", overLimit)
 		contactWay, err = models.ContactWay{}.Refresh(tx, contactWayID)
 		if err != nil {
 			err = errors.Wrap(err, "ContactWay Refresh failed")
@@ -384,3 +488,24 @@ func (o *ContactWay) DealAddCustomerEvent(tx *gorm.DB, event workwx.EventAddExte
 
 	return
 }
+
+// SimulateDistribution
+// Description: 模拟渠道码接下来N次添加的分配结果，可指定其他策略预览效果
+func (o *ContactWay) SimulateDistribution(
+	id string, req requests.SimulateContactWayDistributionReq, extCorpID string) (res responses.ContactWayDistribution, err error) {
+	contactWay, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+	if req.DistributeStrategy > 0 {
+		contactWay.DistributeStrategy = req.DistributeStrategy
+	}
+
+	res.DistributeStrategy = contactWay.DistributeStrategy
+	res.Steps, res.Staffs, err = o.model.SimulateDistribution(contactWay, req.Num)
+	if err != nil {
+		err = errors.Wrap(err, "SimulateDistribution failed")
+		return
+	}
+	return
+}
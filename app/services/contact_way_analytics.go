@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/responses"
+	"openscrm/common/log"
+	"strconv"
+	"time"
+)
+
+// Analytics
+// Description: 渠道码数据分析，包括汇总、每日和员工维度的添加、留存和转化数据
+func (o *ContactWay) Analytics(
+	id string, req requests.QueryContactWayAnalyticsReq, extCorpID string) (res responses.ContactWayAnalytics, err error) {
+	contactWay, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+	if req.RetentionDays == 0 {
+		req.RetentionDays = constants.ContactWayAnalyticsDefaultDays
+	}
+
+	recordRepo := models.ContactWayAddRecord{}
+	res.Summary, err = recordRepo.QuerySummary(contactWay, req)
+	if err != nil {
+		return
+	}
+	res.Daily, err = recordRepo.QueryDaily(contactWay, req)
+	if err != nil {
+		return
+	}
+	res.Staffs, err = recordRepo.QueryStaffs(contactWay, req)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// ExportAnalytics
+// Description: 导出渠道码每日和员工维度的数据分析
+func (o *ContactWay) ExportAnalytics(
+	id string, req requests.QueryContactWayAnalyticsReq, extCorpID string) (*bytes.Buffer, string, error) {
+	res, err := o.Analytics(id, req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exportTime := time.Now().Format(constants.DateTimeLayout)
+	filename := fmt.Sprintf("%s-%s-%s-%s.xlsx", constants.DataExportContactWayFilenamePrefix, id, req.StartTime, req.EndTime)
+	metricTitles := []string{"添加人次", "添加客户数", "流失人次", "留存人次", "打标签人次", "聊天人次"}
+
+	dailyRows := make([][]string, 0, len(res.Daily))
+	for _, item := range res.Daily {
+		dailyRows = append(dailyRows, append([]string{item.Date}, o.analyticsValues(item.ContactWayAnalytics)...))
+	}
+	staffRows := make([][]string, 0, len(res.Staffs))
+	for _, item := range res.Staffs {
+		staffRows = append(staffRows, append([]string{item.StaffName}, o.analyticsValues(item.ContactWayAnalytics)...))
+	}
+
+	file := excelize.NewFile()
+	sheets := []struct {
+		name   string
+		titles []string
+		rows   [][]string
+	}{
+		{constants.DataExportContactWayDailySheetName, append([]string{"日期"}, metricTitles...), dailyRows},
+		{constants.DataExportContactWayStaffSheetName, append([]string{"员工"}, metricTitles...), staffRows},
+	}
+	for i, sheet := range sheets {
+		sheetIndex, err := file.NewSheet(sheet.name)
+		if err != nil {
+			log.Sugar.Error(err)
+			return nil, "", err
+		}
+		if i == 0 {
+			file.SetActiveSheet(sheetIndex)
+		}
+
+		err = PrettifySheet(sheet.name, file, exportTime, sheet.titles)
+		if err != nil {
+			log.Sugar.Error(err)
+			return nil, "", err
+		}
+
+		for k, values := range sheet.rows {
+			values := values
+			err = file.SetSheetRow(sheet.name, fmt.Sprint("A", k+3), &values)
+			if err != nil {
+				log.Sugar.Errorw("write excel failed", "err", err)
+				return nil, "", err
+			}
+		}
+	}
+	file.DeleteSheet("Sheet1")
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf, filename, nil
+}
+
+func (o *ContactWay) analyticsValues(item models.ContactWayAnalytics) []string {
+	return []string{
+		strconv.FormatInt(item.AddNum, 10),
+		strconv.FormatInt(item.CustomerNum, 10),
+		strconv.FormatInt(item.LostNum, 10),
+		strconv.FormatInt(item.RetainedNum, 10),
+		strconv.FormatInt(item.TaggedNum, 10),
+		strconv.FormatInt(item.ActiveNum, 10),
+	}
+}
@@ -0,0 +1,696 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/delay_queue"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/storage"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contactWayImportTitles 渠道码导入导出的表头，导出的表格修改后可直接导入
+// 渠道码ID为空时创建，否则更新对应渠道码，表格中没有的设置保持不变
+var contactWayImportTitles = []string{
+	"渠道码ID", "渠道码名称", "分组", "接待员工", "备份员工", "客户标签", "欢迎语类型", "欢迎语", "员工每日添加上限", "员工调度",
+}
+
+var contactWayAutoReplyTypeNames = map[constants.ContactWayAutoReplyType]string{
+	constants.ContactWayAutoReplyTypeCustom:  "渠道欢迎语",
+	constants.ContactWayAutoReplyTypeDefault: "渠道默认欢迎语",
+	constants.ContactWayAutoReplyTypeDisable: "不发送欢迎语",
+}
+
+// importNameIDRegexp 匹配 名称(ID) 格式的单元格内容
+var importNameIDRegexp = regexp.MustCompile(`^(.*)[(（]([^()（）]+)[)）]$`)
+
+// importListSplitter 单元格内多个值的分隔符
+var importListSplitter = regexp.MustCompile(`[,，、]`)
+
+// importLineSplitter 员工调度单元格内多个时段的分隔符
+var importLineSplitter = regexp.MustCompile(`[\n;；]`)
+
+// ExportContactWays
+// Description: 按查询条件导出渠道码，格式与导入一致
+func (o *ContactWay) ExportContactWays(req requests.QueryContactWayReq, extCorpID string) (*bytes.Buffer, string, error) {
+	req.Pager = app.Pager{Page: 1, PageSize: constants.ContactWayImportMaxRows}
+	contactWays, _, err := o.model.Query(req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	refs, err := o.loadImportRefs(extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exportTime := time.Now().Format(constants.DateTimeLayout)
+	filename := fmt.Sprintf("%s-%s.xlsx", constants.DataExportContactWayListFilenamePrefix, time.Now().Format("20060102150405"))
+	sheetName := constants.DataExportContactWayListSheetName
+
+	file := excelize.NewFile()
+	sheetIndex, err := file.NewSheet(sheetName)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+	file.SetActiveSheet(sheetIndex)
+
+	err = PrettifySheet(sheetName, file, exportTime, contactWayImportTitles)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+
+	for k, item := range contactWays {
+		values := o.exportValues(item, refs)
+		err = file.SetSheetRow(sheetName, fmt.Sprint("A", k+3), &values)
+		if err != nil {
+			log.Sugar.Errorw("write excel failed", "err", err)
+			return nil, "", err
+		}
+	}
+	file.DeleteSheet("Sheet1")
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf, filename, nil
+}
+
+func (o *ContactWay) exportValues(item models.ContactWay, refs contactWayImportRefs) []string {
+	staffs := make([]string, 0, len(item.Staffs))
+	for _, staff := range item.Staffs {
+		staffs = append(staffs, fmt.Sprintf("%s(%s)", staff.Name, staff.ExtStaffID))
+	}
+	backupStaffs := make([]string, 0, len(item.BackupStaffs))
+	for _, staff := range item.BackupStaffs {
+		backupStaffs = append(backupStaffs, fmt.Sprintf("%s(%s)", staff.Name, staff.ExtStaffID))
+	}
+	tags := make([]string, 0, len(item.CustomerTagExtIDs))
+	if item.AutoTagEnable == constants.True {
+		for _, extID := range item.CustomerTagExtIDs {
+			tags = append(tags, fmt.Sprintf("%s(%s)", refs.tagNames[extID], extID))
+		}
+	}
+
+	dailyLimit := "0"
+	if item.DailyAddCustomerLimitEnable == constants.True {
+		dailyLimit = strconv.Itoa(item.DailyAddCustomerLimit)
+	}
+
+	schedules := make([]string, 0, len(item.Schedules))
+	if item.ScheduleEnable == constants.True {
+		for _, schedule := range item.Schedules {
+			scheduleStaffs := make([]string, 0, len(schedule.Staffs))
+			for _, staff := range schedule.Staffs {
+				scheduleStaffs = append(scheduleStaffs, fmt.Sprintf("%s(%s)", staff.Name, staff.ExtStaffID))
+			}
+			schedules = append(schedules, fmt.Sprintf("%s|%s-%s|%s",
+				strings.Join(schedule.Weekdays, ","),
+				o.exportTime(schedule.StartTime), o.exportTime(schedule.EndTime),
+				strings.Join(scheduleStaffs, ",")))
+		}
+	}
+
+	return []string{
+		item.ID,
+		item.Name,
+		refs.groupNames[item.GroupID],
+		strings.Join(staffs, ","),
+		strings.Join(backupStaffs, ","),
+		strings.Join(tags, ","),
+		contactWayAutoReplyTypeNames[item.AutoReplyType],
+		item.AutoReply.Text,
+		dailyLimit,
+		strings.Join(schedules, "\n"),
+	}
+}
+
+func (o *ContactWay) exportTime(field constants.TimeField) string {
+	t, err := field.Time()
+	if err != nil {
+		return string(field)
+	}
+	return t.Format("15:04")
+}
+
+// contactWayImportRefs 导入导出时按名称或ID查找分组、员工、标签
+type contactWayImportRefs struct {
+	groupIDs       map[string]string
+	groupNames     map[string]string
+	defaultGroupID string
+	staffNames     map[string]string
+	staffIDs       map[string][]string
+	tagNames       map[string]string
+	tagIDs         map[string][]string
+}
+
+func (o *ContactWay) loadImportRefs(extCorpID string) (refs contactWayImportRefs, err error) {
+	refs = contactWayImportRefs{
+		groupIDs:   make(map[string]string),
+		groupNames: make(map[string]string),
+		staffNames: make(map[string]string),
+		staffIDs:   make(map[string][]string),
+		tagNames:   make(map[string]string),
+		tagIDs:     make(map[string][]string),
+	}
+
+	groups := make([]models.ContactWayGroup, 0)
+	err = models.DB.Where("ext_corp_id = ?", extCorpID).Find(&groups).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find ContactWayGroup failed")
+		return
+	}
+	for _, group := range groups {
+		refs.groupNames[group.ID] = group.Name
+		refs.groupIDs[group.Name] = group.ID
+		if group.IsDefault == constants.True {
+			refs.defaultGroupID = group.ID
+		}
+	}
+
+	staffs := make([]models.Staff, 0)
+	err = models.DB.Select("name,ext_id").Where("ext_corp_id = ?", extCorpID).Find(&staffs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find Staff failed")
+		return
+	}
+	for _, staff := range staffs {
+		refs.staffNames[staff.ExtID] = staff.Name
+		refs.staffIDs[staff.Name] = append(refs.staffIDs[staff.Name], staff.ExtID)
+	}
+
+	tags := make([]models.Tag, 0)
+	err = models.DB.Select("name,ext_id").Where("ext_corp_id = ?", extCorpID).Find(&tags).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find Tag failed")
+		return
+	}
+	for _, tag := range tags {
+		refs.tagNames[tag.ExtID] = tag.Name
+		refs.tagIDs[tag.Name] = append(refs.tagIDs[tag.Name], tag.ExtID)
+	}
+	return
+}
+
+// resolve 把 名称(ID)、ID 或 名称 格式的单元格值解析为ID，名称重复时需使用 名称(ID) 格式
+func (o *ContactWay) resolve(kind string, value string, names map[string]string, ids map[string][]string) (string, error) {
+	value = strings.TrimSpace(value)
+	if matches := importNameIDRegexp.FindStringSubmatch(value); matches != nil {
+		id := strings.TrimSpace(matches[2])
+		if _, ok := names[id]; ok {
+			return id, nil
+		}
+		return "", errors.Errorf("%s「%s」不存在", kind, value)
+	}
+	if _, ok := names[value]; ok {
+		return value, nil
+	}
+	switch len(ids[value]) {
+	case 0:
+		return "", errors.Errorf("%s「%s」不存在", kind, value)
+	case 1:
+		return ids[value][0], nil
+	default:
+		return "", errors.Errorf("%s「%s」重名，请使用 名称(ID) 格式", kind, value)
+	}
+}
+
+// resolveList 解析多个值，返回ID和错误信息
+func (o *ContactWay) resolveList(kind string, value string, names map[string]string, ids map[string][]string) ([]string, []string) {
+	result := make([]string, 0)
+	errs := make([]string, 0)
+	for _, item := range importListSplitter.Split(value, -1) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		id, err := o.resolve(kind, item, names, ids)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		result = append(result, id)
+	}
+	return result, errs
+}
+
+// staffParams 按表格中的顺序生成员工参数，已绑定的员工保留原有的ID和权重
+func (o *ContactWay) staffParams(extStaffIDs []string, origin []requests.ContactWayStaffParam, dailyLimit int) []requests.ContactWayStaffParam {
+	originMap := make(map[string]requests.ContactWayStaffParam)
+	for _, staff := range origin {
+		originMap[staff.ExtStaffID] = staff
+	}
+	params := make([]requests.ContactWayStaffParam, 0, len(extStaffIDs))
+	for _, extStaffID := range extStaffIDs {
+		param, ok := originMap[extStaffID]
+		if !ok {
+			param = requests.ContactWayStaffParam{ExtStaffID: extStaffID}
+		}
+		param.DailyAddCustomerLimit = dailyLimit
+		params = append(params, param)
+	}
+	return params
+}
+
+// parseSchedules 解析员工调度，每行一个时段，格式为 周一,周二|09:00-18:00|张三(zhangsan),李四(lisi)
+func (o *ContactWay) parseSchedules(value string, refs contactWayImportRefs, dailyLimit int) ([]requests.ContactWayScheduleParam, []string) {
+	schedules := make([]requests.ContactWayScheduleParam, 0)
+	errs := make([]string, 0)
+	for _, line := range importLineSplitter.Split(value, -1) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 {
+			errs = append(errs, fmt.Sprintf("员工调度「%s」格式错误，应为 工作日|开始时间-结束时间|员工", line))
+			continue
+		}
+
+		weekdays := make([]string, 0)
+		for _, weekday := range importListSplitter.Split(parts[0], -1) {
+			if weekday = strings.TrimSpace(weekday); weekday != "" {
+				weekdays = append(weekdays, weekday)
+			}
+		}
+
+		times := strings.Split(parts[1], "-")
+		if len(times) != 2 {
+			errs = append(errs, fmt.Sprintf("员工调度「%s」时间格式错误", line))
+			continue
+		}
+		startTime, err := o.importTime(times[0])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("员工调度「%s」时间格式错误", line))
+			continue
+		}
+		endTime, err := o.importTime(times[1])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("员工调度「%s」时间格式错误", line))
+			continue
+		}
+
+		extStaffIDs, staffErrs := o.resolveList("员工", parts[2], refs.staffNames, refs.staffIDs)
+		errs = append(errs, staffErrs...)
+		if len(extStaffIDs) == 0 {
+			errs = append(errs, fmt.Sprintf("员工调度「%s」未指定员工", line))
+			continue
+		}
+
+		schedules = append(schedules, requests.ContactWayScheduleParam{
+			DailyAddCustomerLimit: dailyLimit,
+			Weekdays:              weekdays,
+			StartTime:             startTime,
+			EndTime:               endTime,
+			Staffs:                o.staffParams(extStaffIDs, nil, dailyLimit),
+		})
+	}
+	return schedules, errs
+}
+
+func (o *ContactWay) importTime(value string) (constants.TimeField, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"15:04", constants.TimeLayout} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return constants.TimeField(t.Format(constants.TimeLayout)), nil
+		}
+	}
+	return "", errors.Errorf("invalid time %s", value)
+}
+
+// readImportFile 读取导入文件的第一个工作表，支持xlsx和csv
+func (o *ContactWay) readImportFile(objectKey string) ([][]string, error) {
+	ext := strings.ToLower(path.Ext(objectKey))
+	if !storage.IsValidObjectKey(objectKey) || (ext != ".xlsx" && ext != ".csv") {
+		return nil, errors.WithStack(ecode.InvalidContactWayImportFileErr)
+	}
+
+	content, err := storage.FileStorage.Get(objectKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "get file from storage failed")
+	}
+	defer content.Close()
+
+	if ext == ".csv" {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil, errors.Wrap(err, "read file failed")
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, errors.WithStack(ecode.InvalidContactWayImportFileErr)
+		}
+		return rows, nil
+	}
+
+	file, err := excelize.OpenReader(content)
+	if err != nil {
+		return nil, errors.WithStack(ecode.InvalidContactWayImportFileErr)
+	}
+	defer file.Close()
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.WithStack(ecode.InvalidContactWayImportFileErr)
+	}
+	rows, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, errors.WithStack(ecode.InvalidContactWayImportFileErr)
+	}
+	return rows, nil
+}
+
+// Import
+// Description: 导入渠道码，逐行校验后返回校验结果，校验通过的行由后台任务创建或更新
+func (o *ContactWay) Import(req requests.ImportContactWayReq, extCorpID string, extCreatorID string) (
+	task models.ContactWayImportTask, err error) {
+	rows, err := o.readImportFile(req.ObjectKey)
+	if err != nil {
+		return
+	}
+
+	// 导出的表格第一行为标题，表头所在行通过「渠道码名称」列定位
+	headerIndex := -1
+	columns := make(map[string]int)
+	for i := 0; i < len(rows) && i < 5 && headerIndex < 0; i++ {
+		for j, cell := range rows[i] {
+			columns[strings.TrimSpace(cell)] = j
+		}
+		if _, ok := columns["渠道码名称"]; ok {
+			headerIndex = i
+			break
+		}
+		columns = make(map[string]int)
+	}
+	if headerIndex < 0 {
+		err = errors.WithStack(ecode.InvalidContactWayImportFileErr)
+		return
+	}
+	rows = rows[headerIndex+1:]
+	if len(rows) > constants.ContactWayImportMaxRows {
+		err = errors.WithStack(ecode.InvalidContactWayImportFileErr)
+		return
+	}
+
+	refs, err := o.loadImportRefs(extCorpID)
+	if err != nil {
+		return
+	}
+
+	ids := make([]string, 0)
+	for _, row := range rows {
+		if id := o.importCell(row, columns, "渠道码ID"); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	existing := make([]models.ContactWay, 0)
+	if len(ids) > 0 {
+		err = models.DB.Preload("Staffs").Preload("BackupStaffs").
+			Where("ext_corp_id = ? and id in (?)", extCorpID, ids).
+			Find(&existing).Error
+		if err != nil {
+			err = errors.Wrap(err, "Find ContactWay failed")
+			return
+		}
+	}
+	existingMap := make(map[string]models.ContactWay)
+	for _, item := range existing {
+		existingMap[item.ID] = item
+	}
+
+	task = models.ContactWayImportTask{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
+		ObjectKey:    req.ObjectKey,
+		Status:       constants.AsyncTaskStatusCreating,
+		Rows:         make(models.ContactWayImportRows, 0, len(rows)),
+	}
+	seenIDs := make(map[string]bool)
+	for i, row := range rows {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		result := o.parseImportRow(row, columns, refs, existingMap)
+		result.Row = headerIndex + i + 2
+		if result.ContactWayID != "" {
+			if seenIDs[result.ContactWayID] {
+				result.Status = constants.ContactWayImportRowInvalid
+				result.Errors = append(result.Errors, "渠道码ID重复")
+				result.Req = nil
+			}
+			seenIDs[result.ContactWayID] = true
+		}
+		if result.Status == constants.ContactWayImportRowInvalid {
+			task.InvalidNum++
+		}
+		task.Rows = append(task.Rows, result)
+	}
+	task.Total = len(task.Rows)
+	if task.Total == task.InvalidNum {
+		task.Status = constants.AsyncTaskStatusFailed
+	}
+
+	err = task.Create(task)
+	if err != nil {
+		return
+	}
+
+	if task.Status == constants.AsyncTaskStatusCreating {
+		err = delay_queue.Add(delay_queue.Job{
+			Topic:     constants.ContactWayImportTopic,
+			ID:        task.ID,
+			ExecuteAt: time.Now().Unix(),
+			TTR:       600,
+			Body:      task.ID,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "add job failed")
+			return
+		}
+	}
+
+	return
+}
+
+func (o *ContactWay) importCell(row []string, columns map[string]int, title string) string {
+	i, ok := columns[title]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// parseImportRow 把表格中的一行转换为创建渠道码参数，并按接口的规则校验
+func (o *ContactWay) parseImportRow(row []string, columns map[string]int, refs contactWayImportRefs,
+	existingMap map[string]models.ContactWay) (result models.ContactWayImportRow) {
+	cell := func(title string) string {
+		return o.importCell(row, columns, title)
+	}
+	result.ContactWayID = cell("渠道码ID")
+	result.Name = cell("渠道码名称")
+	result.Status = constants.ContactWayImportRowInvalid
+	result.Errors = make([]string, 0)
+
+	req := requests.CreateContactWayReq{
+		GroupID:              refs.defaultGroupID,
+		AutoReplyType:        constants.ContactWayAutoReplyTypeDefault,
+		CustomerDescEnable:   constants.False,
+		CustomerRemarkEnable: constants.False,
+		SkipVerify:           constants.True,
+		AutoSkipVerifyEnable: constants.False,
+		StaffControlEnable:   constants.False,
+		NicknameBlockEnable:  constants.False,
+		DistributeStrategy:   constants.ContactWayDistributeRandom,
+	}
+	if result.ContactWayID != "" {
+		origin, ok := existingMap[result.ContactWayID]
+		if !ok {
+			result.Errors = append(result.Errors, "渠道码不存在")
+			return
+		}
+		if origin.Status > constants.ContactWayStatusActive {
+			result.Errors = append(result.Errors, ecode.ContactWayInactiveErr.Message())
+			return
+		}
+		err := copier.Copy(&req, &origin)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return
+		}
+		if origin.ExpireAt != nil {
+			req.ExpireTime = constants.DateTimeFiled(origin.ExpireAt.Local().Format(constants.DateTimeLayout))
+		}
+	}
+
+	if result.Name != "" {
+		req.Name = result.Name
+	}
+	result.Name = req.Name
+
+	// 分组按名称查找，也可以填写分组ID
+	if group := cell("分组"); group != "" {
+		if _, ok := refs.groupNames[group]; ok {
+			req.GroupID = group
+		} else if groupID, ok := refs.groupIDs[group]; ok {
+			req.GroupID = groupID
+		} else {
+			result.Errors = append(result.Errors, fmt.Sprintf("分组「%s」不存在", group))
+		}
+	}
+
+	dailyLimit := 0
+	if value := cell("员工每日添加上限"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			result.Errors = append(result.Errors, "员工每日添加上限应为不小于0的整数")
+		}
+		dailyLimit = limit
+	} else if req.DailyAddCustomerLimitEnable == constants.True {
+		dailyLimit = int(req.DailyAddCustomerLimit)
+	}
+	req.DailyAddCustomerLimit = int64(dailyLimit)
+	req.DailyAddCustomerLimitEnable = constants.False
+	if dailyLimit > 0 {
+		req.DailyAddCustomerLimitEnable = constants.True
+	}
+
+	extStaffIDs, errs := o.resolveList("员工", cell("接待员工"), refs.staffNames, refs.staffIDs)
+	result.Errors = append(result.Errors, errs...)
+	req.Staffs = o.staffParams(extStaffIDs, req.Staffs, dailyLimit)
+
+	extStaffIDs, errs = o.resolveList("员工", cell("备份员工"), refs.staffNames, refs.staffIDs)
+	result.Errors = append(result.Errors, errs...)
+	req.BackupStaffs = o.staffParams(extStaffIDs, req.BackupStaffs, 0)
+	if len(req.BackupStaffs) == 0 {
+		result.Errors = append(result.Errors, "备份员工不能为空")
+	}
+
+	tagExtIDs, errs := o.resolveList("标签", cell("客户标签"), refs.tagNames, refs.tagIDs)
+	result.Errors = append(result.Errors, errs...)
+	req.CustomerTagExtIDs = tagExtIDs
+	req.AutoTagEnable = constants.False
+	if len(tagExtIDs) > 0 {
+		req.AutoTagEnable = constants.True
+	}
+
+	if value := cell("欢迎语类型"); value != "" {
+		found := false
+		for autoReplyType, name := range contactWayAutoReplyTypeNames {
+			if name == value {
+				req.AutoReplyType = autoReplyType
+				found = true
+			}
+		}
+		if !found {
+			result.Errors = append(result.Errors, fmt.Sprintf("欢迎语类型「%s」不存在", value))
+		}
+	}
+	req.AutoReply.Text = cell("欢迎语")
+	if req.AutoReplyType == constants.ContactWayAutoReplyTypeCustom &&
+		req.AutoReply.Text == "" && len(req.AutoReply.Attachments) == 0 {
+		result.Errors = append(result.Errors, "渠道欢迎语不能为空")
+	}
+
+	req.ScheduleEnable = constants.False
+	req.Schedules = nil
+	if value := cell("员工调度"); value != "" {
+		req.ScheduleEnable = constants.True
+		req.Schedules, errs = o.parseSchedules(value, refs, dailyLimit)
+		result.Errors = append(result.Errors, errs...)
+	} else if len(req.Staffs) == 0 {
+		result.Errors = append(result.Errors, "接待员工和员工调度不能都为空")
+	}
+
+	if validErrs := app.ValidateStruct(&req); validErrs != nil {
+		result.Errors = append(result.Errors, validErrs.Errors()...)
+	}
+	if len(result.Errors) > 0 {
+		return
+	}
+
+	result.Status = constants.ContactWayImportRowPending
+	result.Req = &req
+	return
+}
+
+// GetImportTask
+// Description: 查询渠道码导入任务的进度和逐行结果
+func (o *ContactWay) GetImportTask(id string, extCorpID string) (models.ContactWayImportTask, error) {
+	return models.ContactWayImportTask{}.Get(id, extCorpID)
+}
+
+// ExecuteImport
+// Description: 后台逐行创建或更新导入的渠道码，每行处理后保存进度，任务重试时跳过已处理的行
+func (o *ContactWay) ExecuteImport(taskID string) error {
+	taskRepo := models.ContactWayImportTask{}
+	task, err := taskRepo.Get(taskID, "")
+	if err != nil {
+		return err
+	}
+	if task.Status != constants.AsyncTaskStatusCreating {
+		return nil
+	}
+
+	for i, row := range task.Rows {
+		if row.Status != constants.ContactWayImportRowPending || row.Req == nil {
+			continue
+		}
+
+		var item models.ContactWay
+		if row.ContactWayID != "" {
+			item, err = o.Update(row.ContactWayID, requests.UpdateContactWayReq{CreateContactWayReq: *row.Req}, task.ExtCorpID)
+		} else {
+			item, err = o.Create(*row.Req, task.ExtCorpID, task.ExtCreatorID)
+		}
+		if err != nil {
+			log.Sugar.Warnw("import contact way failed", "err", err, "taskID", task.ID, "row", row.Row)
+			task.Rows[i].Status = constants.ContactWayImportRowFailed
+			task.Rows[i].Errors = append(task.Rows[i].Errors, o.importErrMessage(err))
+			task.FailedNum++
+		} else {
+			task.Rows[i].Status = constants.ContactWayImportRowSuccess
+			task.Rows[i].ContactWayID = item.ID
+			task.SuccessNum++
+		}
+		task.Rows[i].Req = nil
+
+		err = taskRepo.UpdateProgress(task)
+		if err != nil {
+			return err
+		}
+	}
+
+	task.Status = constants.AsyncTaskStatusSuccess
+	if task.SuccessNum == 0 {
+		task.Status = constants.AsyncTaskStatusFailed
+	}
+	return taskRepo.UpdateProgress(task)
+}
+
+// importErrMessage 导入失败的原因，业务错误返回错误码对应的提示
+func (o *ContactWay) importErrMessage(err error) string {
+	if code, ok := errors.Cause(err).(ecode.Code); ok {
+		return code.Message()
+	}
+	return err.Error()
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/responses"
+)
+
+// QueryScheduleLogs
+// Description: 查询渠道码接待员工的变更记录
+func (o *ContactWay) QueryScheduleLogs(
+	id string, req requests.QueryContactWayScheduleLogReq, extCorpID string) ([]models.ContactWayScheduleLog, int64, error) {
+	return models.ContactWayScheduleLog{}.Query(id, extCorpID, &req.Pager)
+}
+
+// QueryStaffContactWays
+// Description: 查询员工可自行上下线的渠道码，包括普通绑定和调度设置中的渠道码
+func (o *ContactWay) QueryStaffContactWays(extCorpID string, extStaffID string) ([]responses.StaffContactWay, error) {
+	onlineMap := make(map[string]constants.Boolean)
+	staffs := make([]models.ContactWayStaff, 0)
+	err := models.DB.Model(&models.ContactWayStaff{}).
+		Where("ext_staff_id = ?", extStaffID).Find(&staffs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find ContactWayStaff failed")
+	}
+	for _, staff := range staffs {
+		onlineMap[staff.ContactWayID] = staff.Online
+	}
+
+	scheduleStaffs := make([]models.ContactWayScheduleStaff, 0)
+	err = models.DB.Model(&models.ContactWayScheduleStaff{}).
+		Where("ext_staff_id = ?", extStaffID).Find(&scheduleStaffs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find ContactWayScheduleStaff failed")
+	}
+	for _, staff := range scheduleStaffs {
+		if _, ok := onlineMap[staff.ContactWayID]; !ok {
+			onlineMap[staff.ContactWayID] = staff.Online
+		}
+	}
+
+	items := make([]responses.StaffContactWay, 0)
+	if len(onlineMap) == 0 {
+		return items, nil
+	}
+
+	contactWays := make([]models.ContactWay, 0)
+	err = models.DB.Model(&models.ContactWay{}).
+		Where("ext_corp_id = ? and id in (?) and staff_control_enable = ? and status = ?",
+			extCorpID, funk.Keys(onlineMap), constants.True, constants.ContactWayStatusActive).
+		Order("created_at desc").Find(&contactWays).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find ContactWay failed")
+	}
+
+	for _, contactWay := range contactWays {
+		receiving := constants.False
+		if funk.ContainsString(contactWay.ExtStaffIDs, extStaffID) {
+			receiving = constants.True
+		}
+		items = append(items, responses.StaffContactWay{
+			ContactWayID: contactWay.ID,
+			Name:         contactWay.Name,
+			QrCode:       contactWay.QrCode,
+			Online:       onlineMap[contactWay.ID],
+			Receiving:    receiving,
+		})
+	}
+	return items, nil
+}
+
+// StaffOnline
+// Description: 员工自行上下线，立即重新计算接待员工并记录变更
+func (o *ContactWay) StaffOnline(
+	id string, req requests.StaffOnlineContactWayReq, extCorpID string, extStaffID string) (err error) {
+	err = o.model.StaffOnline(id, extCorpID, extStaffID, req.Online)
+	if err != nil {
+		return err
+	}
+
+	trigger := constants.ContactWayScheduleLogTriggerStaffOnline
+	if req.Online == constants.False {
+		trigger = constants.ContactWayScheduleLogTriggerStaffOffline
+	}
+	_, err = models.ContactWayScheduleLog{}.RefreshWithLog(id, trigger, extStaffID)
+	return err
+}
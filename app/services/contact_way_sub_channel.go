@@ -0,0 +1,100 @@
+package services
+
+import (
+	"github.com/gogf/gf/util/grand"
+	"github.com/pkg/errors"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"strings"
+)
+
+// subChannelCodeChars 自动生成子渠道编码使用的字符
+const subChannelCodeChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// QuerySubChannels
+// Description: 查询渠道码的子渠道
+func (o *ContactWay) QuerySubChannels(id string, extCorpID string) ([]models.ContactWaySubChannel, error) {
+	_, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ContactWaySubChannel{}.Query(id, extCorpID)
+}
+
+// CreateSubChannel
+// Description: 创建渠道码子渠道，生成独立的二维码
+func (o *ContactWay) CreateSubChannel(
+	id string, req requests.CreateContactWaySubChannelReq, extCorpID string, extCreatorID string) (
+	item models.ContactWaySubChannel, err error) {
+	contactWay, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+	if contactWay.Status > constants.ContactWayStatusActive {
+		err = errors.WithStack(ecode.ContactWayInactiveErr)
+		return
+	}
+
+	code, err := o.subChannelCode(id, strings.ToLower(req.Code))
+	if err != nil {
+		return
+	}
+
+	item = models.ContactWaySubChannel{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
+		Name:         req.Name,
+		Code:         code,
+		Params:       req.Params,
+	}
+	return models.ContactWaySubChannel{}.Create(item, contactWay)
+}
+
+// subChannelCode 校验指定的子渠道编码，未指定时自动生成不重复的编码
+func (o *ContactWay) subChannelCode(contactWayID string, code string) (string, error) {
+	subChannelRepo := models.ContactWaySubChannel{}
+	if code != "" {
+		exists, err := subChannelRepo.CodeExists(contactWayID, code)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", errors.WithStack(ecode.DuplicateSubChannelCodeErr)
+		}
+		return code, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		code = grand.Str(subChannelCodeChars, constants.ContactWaySubChannelCodeLen)
+		exists, err := subChannelRepo.CodeExists(contactWayID, code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+	return "", errors.WithStack(ecode.DuplicateSubChannelCodeErr)
+}
+
+// UpdateSubChannel
+// Description: 更新渠道码子渠道的名称和自定义参数
+func (o *ContactWay) UpdateSubChannel(
+	id string, req requests.UpdateContactWaySubChannelReq, extCorpID string) error {
+	item, err := models.ContactWaySubChannel{}.Get(id, extCorpID)
+	if err != nil {
+		return err
+	}
+
+	item.Name = req.Name
+	item.Params = req.Params
+	return models.ContactWaySubChannel{}.Update(item)
+}
+
+// DeleteSubChannels
+// Description: 删除渠道码子渠道，已通过子渠道添加的客户仍保留来源
+func (o *ContactWay) DeleteSubChannels(ids []string, extCorpID string) error {
+	return models.ContactWaySubChannel{}.Delete(ids, extCorpID)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/storage"
+	"openscrm/common/util"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarEventMaxDays iCal中单个日程最多展开的天数
+const calendarEventMaxDays = 31
+
+type CorpCalendar struct {
+	model models.CorpCalendarDay
+}
+
+func NewCorpCalendar() *CorpCalendar {
+	return &CorpCalendar{model: models.CorpCalendarDay{}}
+}
+
+func (o *CorpCalendar) Query(req requests.QueryCorpCalendarDayReq, extCorpID string) ([]models.CorpCalendarDay, error) {
+	return o.model.Query(req, extCorpID)
+}
+
+// Save
+// Description: 保存企业日历，同一天已存在时覆盖
+func (o *CorpCalendar) Save(req requests.SaveCorpCalendarDaysReq, extCorpID string, extCreatorID string) error {
+	items := make([]models.CorpCalendarDay, 0, len(req.Days))
+	for _, day := range req.Days {
+		item := models.CorpCalendarDay{
+			Date:      day.Date,
+			Type:      day.Type,
+			Name:      day.Name,
+			AsWeekday: int(time.Monday),
+		}
+		if day.AsWeekday != nil {
+			item.AsWeekday = *day.AsWeekday
+		}
+		items = append(items, item)
+	}
+	items = o.prepare(items, extCorpID, extCreatorID)
+	err := o.model.Upsert(items)
+	if err != nil {
+		return err
+	}
+	return o.refreshTodaySchedules(items, extCorpID)
+}
+
+// Delete
+// Description: 删除企业日历，删除了今天的日历时立即刷新调度
+func (o *CorpCalendar) Delete(ids []string, extCorpID string) error {
+	items, err := o.model.Delete(ids, extCorpID)
+	if err != nil {
+		return err
+	}
+	return o.refreshTodaySchedules(items, extCorpID)
+}
+
+// Import
+// Description: 从iCal或CSV文件导入节假日和调休上班日，返回导入的天数
+func (o *CorpCalendar) Import(req requests.ImportCorpCalendarReq, extCorpID string, extCreatorID string) (int, error) {
+	ext := strings.ToLower(path.Ext(req.ObjectKey))
+	if !storage.IsValidObjectKey(req.ObjectKey) || (ext != ".ics" && ext != ".csv") {
+		return 0, errors.WithStack(ecode.InvalidCorpCalendarFileErr)
+	}
+
+	content, err := storage.FileStorage.Get(req.ObjectKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "get file from storage failed")
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, errors.Wrap(err, "read file failed")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var items []models.CorpCalendarDay
+	if ext == ".ics" {
+		items, err = o.parseICal(data)
+	} else {
+		items, err = o.parseCSV(data)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 || len(items) > constants.CorpCalendarImportMaxDays {
+		return 0, errors.WithStack(ecode.InvalidCorpCalendarFileErr)
+	}
+
+	items = o.prepare(items, extCorpID, extCreatorID)
+	err = o.model.Upsert(items)
+	if err != nil {
+		return 0, err
+	}
+	return len(items), o.refreshTodaySchedules(items, extCorpID)
+}
+
+// refreshTodaySchedules 修改了今天的日历时，立即刷新开启员工调度的渠道码，其余日期由每日凌晨的刷新任务生效
+func (o *CorpCalendar) refreshTodaySchedules(items []models.CorpCalendarDay, extCorpID string) error {
+	today := util.Today().Format(constants.DateLayout)
+	for _, item := range items {
+		if item.Date != today {
+			continue
+		}
+
+		ids := make([]string, 0)
+		err := models.DB.Model(&models.ContactWay{}).
+			Where("ext_corp_id = ? and schedule_enable = ? and status = ?",
+				extCorpID, constants.Enable, constants.ContactWayStatusActive).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return errors.Wrap(err, "Pluck ContactWay failed")
+		}
+		for _, id := range ids {
+			err = models.ContactWay{}.AddRefreshJob(id, time.Now().Add(time.Second), "CorpCalendar")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// prepare 按日期去重，后出现的覆盖先出现的，并补充企业信息
+func (o *CorpCalendar) prepare(items []models.CorpCalendarDay, extCorpID string, extCreatorID string) []models.CorpCalendarDay {
+	dayMap := make(map[string]models.CorpCalendarDay, len(items))
+	for _, item := range items {
+		dayMap[item.Date] = item
+	}
+
+	result := make([]models.CorpCalendarDay, 0, len(dayMap))
+	for _, item := range dayMap {
+		item.ID = id_generator.StringID()
+		item.ExtCorpID = extCorpID
+		item.ExtCreatorID = extCreatorID
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result
+}
+
+// parseCSV 解析CSV，列依次为 日期,类型,名称,调休按星期几排班，首行不是日期时作为表头跳过
+func (o *CorpCalendar) parseCSV(data []byte) ([]models.CorpCalendarDay, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.WithStack(ecode.InvalidCorpCalendarFileErr)
+	}
+
+	items := make([]models.CorpCalendarDay, 0, len(rows))
+	for i, row := range rows {
+		if len(row) == 0 || strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		date, ok := o.parseDate(row[0])
+		if !ok {
+			if i == 0 {
+				continue
+			}
+			return nil, errors.Wrapf(ecode.InvalidCorpCalendarFileErr, "invalid date in row %d", i+1)
+		}
+
+		item := models.CorpCalendarDay{Date: date, Type: constants.CorpCalendarHoliday, AsWeekday: int(time.Monday)}
+		if len(row) > 1 {
+			item.Type = o.parseType(row[1])
+		}
+		if len(row) > 2 {
+			item.Name = o.truncateName(row[2])
+		}
+		if len(row) > 3 && strings.TrimSpace(row[3]) != "" {
+			weekday, ok := o.parseWeekday(row[3])
+			if !ok {
+				return nil, errors.Wrapf(ecode.InvalidCorpCalendarFileErr, "invalid weekday in row %d", i+1)
+			}
+			item.AsWeekday = weekday
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseICal 解析iCal中的全天日程，DTEND不包含在内，标题含「班」的为调休上班日
+func (o *CorpCalendar) parseICal(data []byte) ([]models.CorpCalendarDay, error) {
+	// 展开折行，以空格或制表符开头的行是上一行的延续
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(ecode.InvalidCorpCalendarFileErr)
+	}
+
+	items := make([]models.CorpCalendarDay, 0)
+	inEvent := false
+	var start, end time.Time
+	var summary string
+	for _, line := range lines {
+		name, value := o.splitICalLine(line)
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+			start, end, summary = time.Time{}, time.Time{}, ""
+		case line == "END:VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			dayType := o.parseType(summary)
+			for day, n := start, 0; day.Before(end) && n < calendarEventMaxDays; day, n = day.AddDate(0, 0, 1), n+1 {
+				items = append(items, models.CorpCalendarDay{
+					Date:      day.Format(constants.DateLayout),
+					Type:      dayType,
+					Name:      o.truncateName(summary),
+					AsWeekday: int(time.Monday),
+				})
+			}
+		case !inEvent:
+		case name == "DTSTART":
+			start = o.parseICalDate(value)
+		case name == "DTEND":
+			end = o.parseICalDate(value)
+		case name == "SUMMARY":
+			summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ").Replace(value)
+		}
+	}
+	return items, nil
+}
+
+// splitICalLine 拆分iCal的属性名和值，忽略属性参数，如 DTSTART;VALUE=DATE:20261001
+func (o *CorpCalendar) splitICalLine(line string) (name string, value string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return line, ""
+	}
+	name, value = line[:i], line[i+1:]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+	return strings.ToUpper(name), value
+}
+
+// parseICalDate 取iCal日期的年月日部分，带时间的日程也按当天处理
+func (o *CorpCalendar) parseICalDate(value string) time.Time {
+	if len(value) < 8 {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation("20060102", value[:8], constants.PRCLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (o *CorpCalendar) parseDate(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{constants.DateLayout, "2006/1/2", "20060102", "2006-1-2"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.Format(constants.DateLayout), true
+		}
+	}
+	return "", false
+}
+
+// parseType 含「班」或workday的为调休上班日，其余为节假日
+func (o *CorpCalendar) parseType(value string) constants.CorpCalendarDayType {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if strings.Contains(value, "班") || strings.Contains(value, "workday") || value == "2" {
+		return constants.CorpCalendarWorkday
+	}
+	return constants.CorpCalendarHoliday
+}
+
+func (o *CorpCalendar) parseWeekday(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if weekday, ok := constants.WeekdayMap[value]; ok {
+		return weekday, true
+	}
+	weekday, err := strconv.Atoi(value)
+	if err != nil || weekday < 0 || weekday > 7 {
+		return 0, false
+	}
+	return weekday % 7, true
+}
+
+func (o *CorpCalendar) truncateName(value string) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) > 64 {
+		runes = runes[:64]
+	}
+	return string(runes)
+}
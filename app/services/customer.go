@@ -30,6 +30,7 @@ import (
 	"github.com/gogf/gf/os/grpool"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
 	"github.com/xuri/excelize/v2"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
@@ -290,12 +291,18 @@ func (o CustomerService) SyncSingleCustomerData(extStaffID, extCustomerID string
 			}
 
 			// 更新客户标签
-			err = o.UpdateCustomerTags(customerStaffRelation.ID, followUser.Tags)
+			addedExtTagIDs, err := o.UpdateCustomerTags(customerStaffRelation.ID, followUser.Tags)
 			if err != nil {
 				err = errors.WithStack(err)
 				return err
 			}
 
+			// 新打上的标签触发客户SOP
+			err = NewCustomerSop().TriggerByTags(addedExtTagIDs, extStaffID, extCustomerID, conf.Settings.WeWork.ExtCorpID)
+			if err != nil {
+				log.Sugar.Errorw("TriggerByTags failed", "err", err, "extStaffID", extStaffID, "extCustomerID", extCustomerID)
+			}
+
 			// upsert 客户画像
 			err = o.UpsertCustomerPortrait(extCustomerID, followUser.UserID)
 			if err != nil {
@@ -584,7 +591,7 @@ func (o CustomerService) Export(req requests.QueryCustomerReq, extCorpID string,
 
 	titles := []string{
 		"客户名称", "客户备注", "客户描述", "流失状态", "企业名称", "添加人", "添加时间",
-		"企业标签", "添加渠道", "性别", "电话", "年龄", "生日",
+		"企业标签", "添加渠道", "性别", "电话", "年龄", "生日", "来源子渠道",
 	}
 	err = PrettifySheet(constants.DataExportCustomerListSheetName, file, exportTime, titles)
 	if err != nil {
@@ -601,7 +608,7 @@ func (o CustomerService) Export(req requests.QueryCustomerReq, extCorpID string,
 
 	log.Sugar.Debug(util.JsonEncode(customers))
 
-	//"客户名称", "客户备注", "客户描述", "流失状态", "企业名称", "添加人", "添加时间", "企业标签", "添加渠道", "性别", "电话", "年龄", "生日",
+	//"客户名称", "客户备注", "客户描述", "流失状态", "企业名称", "添加人", "添加时间", "企业标签", "添加渠道", "性别", "电话", "年龄", "生日", "来源子渠道",
 
 	//0-未知来源 1-扫描二维码 2-搜索手机号 3-名片分享 4-群聊 5-手机通讯录 6-微信联系人 7-来自微信的添加好友申请 8-安装第三方应用时自动添加的客服人员 9-搜索邮箱 201-内部成员共享 202-管理员/负责人分配
 	mapAddWay := map[int]string{0: "未知来源", 1: "扫描二维码", 2: "搜索手机号", 3: "名片分享", 4: "群聊",
@@ -623,6 +630,7 @@ func (o CustomerService) Export(req requests.QueryCustomerReq, extCorpID string,
 				customer.PhoneNumber,
 				strconv.FormatInt(customer.Age, 10),
 				customer.Birthday,
+				customer.SubChannelName,
 			}
 
 			var tagName string
@@ -847,7 +855,13 @@ func (o CustomerService) CustomerStatistic(
 // Detail:
 // Param: relationID CustomerStaff 关系表的ID
 // Param: extTags wx返回的外部标签信息
-func (o CustomerService) UpdateCustomerTags(relationID string, extTags []workwx.FollowUserTag) (err error) {
+// return: addedExtTagIDs 本次新增的标签ID
+func (o CustomerService) UpdateCustomerTags(relationID string, extTags []workwx.FollowUserTag) (addedExtTagIDs []string, err error) {
+	existingExtTagIDs, err := o.customerStaffTagRepo.QueryExtTagIDs(relationID)
+	if err != nil {
+		return
+	}
+
 	tags := make([]models.CustomerStaffTag, 0)
 	extCorpID := conf.Settings.WeWork.ExtCorpID
 	for _, tag := range extTags {
@@ -861,6 +875,9 @@ func (o CustomerService) UpdateCustomerTags(relationID string, extTags []workwx.
 		}
 		customerStaffTag.DeletedAt = gorm.DeletedAt{} // 恢复被删除的
 		tags = append(tags, customerStaffTag)
+		if !funk.ContainsString(existingExtTagIDs, tag.TagID) {
+			addedExtTagIDs = append(addedExtTagIDs, tag.TagID)
+		}
 	}
 	err = o.customerStaffTagRepo.Upsert(tags)
 	if err != nil {
@@ -876,7 +893,7 @@ func (o CustomerService) UpdateCustomerTags(relationID string, extTags []workwx.
 	}
 	err = o.customerStaffTagRepo.Delete(relationID, extTagIDs, false)
 	if err != nil {
-		return err
+		return
 	}
 
 	return
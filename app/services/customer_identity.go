@@ -0,0 +1,262 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/util"
+	"openscrm/common/we_work"
+	"openscrm/conf"
+	workwx "openscrm/pkg/easywework"
+	"time"
+)
+
+// miniProgramLoginSignTTL 小程序登录签名有效期
+const miniProgramLoginSignTTL = 5 * time.Minute
+
+type CustomerIdentity struct {
+	customerRepo models.Customer
+	identityRepo models.CustomerIdentity
+}
+
+func NewCustomerIdentity() *CustomerIdentity {
+	return &CustomerIdentity{
+		customerRepo: models.Customer{},
+		identityRepo: models.CustomerIdentity{},
+	}
+}
+
+// Lookup
+// Description: 根据unionid或手机号查找客户
+// Detail: 优先使用unionid，本地未找到时通过企微接口将unionid转换为external_userid
+func (o CustomerIdentity) Lookup(req requests.LookupCustomerReq, extCorpID string) (customer models.Customer, err error) {
+	if req.Unionid != "" {
+		customer, _, err = o.resolveUnionid(req.Unionid, req.Openid, extCorpID)
+		return
+	}
+
+	customer, err = o.customerRepo.GetByPhoneNumber(req.PhoneNumber, extCorpID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ecode.ItemNotFoundError
+		return
+	}
+	return
+}
+
+// MiniProgramLogin
+// Description: 小程序/公众号用户登录，关联到企微客户
+// Detail: 请求由小程序服务端签名，登录成功后与CustomerLoginCallback写入相同的客户会话
+func (o CustomerIdentity) MiniProgramLogin(req requests.MiniProgramLoginReq, extCorpID string) (customer models.Customer, err error) {
+	err = o.checkSignature(req)
+	if err != nil {
+		return
+	}
+
+	customer, pendingID, err := o.resolveUnionid(req.Unionid, req.Openid, extCorpID)
+	if err != nil && !errors.Is(err, ecode.ItemNotFoundError) {
+		err = errors.WithStack(err)
+		return
+	}
+
+	// 客户尚未添加员工时也记录身份，添加后由定时任务补全关联
+	identity := models.CustomerIdentity{
+		ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+		SourceType:    req.SourceType,
+		AppID:         req.AppID,
+		Openid:        req.Openid,
+		Unionid:       req.Unionid,
+		PendingID:     pendingID,
+		ExtCustomerID: customer.ExtID,
+		LastLoginAt:   time.Now(),
+	}
+	upsertErr := o.identityRepo.Upsert(identity)
+	if upsertErr != nil {
+		err = errors.WithStack(upsertErr)
+		return
+	}
+
+	return
+}
+
+// checkSignature 校验小程序服务端的签名
+func (o CustomerIdentity) checkSignature(req requests.MiniProgramLoginReq) error {
+	signedAt := time.Unix(req.Timestamp, 0)
+	if time.Since(signedAt) > miniProgramLoginSignTTL || time.Until(signedAt) > miniProgramLoginSignTTL {
+		return ecode.ExpiredSignError
+	}
+
+	message, err := util.GenBytesOrderByColumn(requests.MiniProgramLoginSignParams{
+		SourceType: req.SourceType,
+		AppID:      req.AppID,
+		Openid:     req.Openid,
+		Unionid:    req.Unionid,
+		Timestamp:  req.Timestamp,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(conf.Settings.App.InnerSrvAppCode))
+	mac.Write(message)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(req.Signature)) {
+		return ecode.InvalidSignError
+	}
+
+	return nil
+}
+
+// resolveUnionid
+// Description: unionid转换为企微客户
+// Detail: 先查本地客户，未找到时调用企微接口转换；客户未添加员工时返回pending_id
+func (o CustomerIdentity) resolveUnionid(unionid, openid, extCorpID string) (customer models.Customer, pendingID string, err error) {
+	customer, err = o.customerRepo.GetByUnionid(unionid, extCorpID)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.WithStack(err)
+		return
+	}
+
+	err = ecode.ItemNotFoundError
+	if openid == "" {
+		return
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	extCustomerID, pendingID, err := client.Customer.UnionidToExternalUserid(workwx.UnionidToExternalUseridReq{
+		Unionid:     unionid,
+		Openid:      openid,
+		SubjectType: workwx.UnionidSubjectTypeCorp,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "UnionidToExternalUserid failed")
+		return
+	}
+
+	if extCustomerID == "" {
+		err = ecode.ItemNotFoundError
+		return
+	}
+
+	customer, err = o.customerRepo.GetByExtID(extCustomerID, nil, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ecode.ItemNotFoundError
+		return
+	}
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	// 顺便补全客户的unionid
+	err = o.customerRepo.UpdateUnionid(customer.ExtID, unionid)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	customer.Unionid = unionid
+
+	return
+}
+
+// FillMissingUnionids
+// Description: 为缺少unionid的微信客户补全unionid
+// Detail: 企业未绑定开放平台或客户数据同步较早时，客户记录中可能没有unionid
+//
+//	未能补全的客户记录尝试次数，间隔一段时间后重试，超过次数后不再尝试；每次调用接口后都会等待，避免限流
+func (o CustomerIdentity) FillMissingUnionids(extCorpID string) (filled int, err error) {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	lastID := ""
+	for {
+		customers, err := o.customerRepo.QueryWithoutUnionid(extCorpID, lastID, 100)
+		if err != nil {
+			return filled, errors.WithStack(err)
+		}
+		if len(customers) == 0 {
+			break
+		}
+
+		for _, customer := range customers {
+			lastID = customer.ID
+			contactInfo, err := client.Customer.GetExternalContact(customer.ExtID)
+			time.Sleep(100 * time.Millisecond) // 避免限流
+			if err != nil || contactInfo.ExternalContact.Unionid == "" {
+				if err != nil {
+					log.Sugar.Errorw("GetExternalContact failed", "extCustomerID", customer.ExtID, "err", err)
+				}
+				err = o.customerRepo.AddUnionidAttempt(customer.ExtID)
+				if err != nil {
+					return filled, errors.WithStack(err)
+				}
+				continue
+			}
+
+			err = o.customerRepo.UpdateUnionid(customer.ExtID, contactInfo.ExternalContact.Unionid)
+			if err != nil {
+				return filled, errors.WithStack(err)
+			}
+			filled++
+		}
+	}
+
+	return
+}
+
+// LinkPendingIdentities
+// Description: 将登录时尚未添加员工的小程序/公众号身份关联到企微客户
+// Detail: 未能关联的身份记录尝试次数，间隔一段时间后重试，超过次数后不再尝试，客户再次登录时重新计数
+func (o CustomerIdentity) LinkPendingIdentities(extCorpID string) (linked int64, err error) {
+	lastID := ""
+	for {
+		identities, err := o.identityRepo.QueryUnlinked(extCorpID, lastID, 100)
+		if err != nil {
+			return linked, errors.WithStack(err)
+		}
+		if len(identities) == 0 {
+			break
+		}
+
+		for _, identity := range identities {
+			lastID = identity.ID
+			customer, _, err := o.resolveUnionid(identity.Unionid, identity.Openid, extCorpID)
+			time.Sleep(100 * time.Millisecond) // 避免限流
+			if err != nil {
+				if !errors.Is(err, ecode.ItemNotFoundError) {
+					log.Sugar.Errorw("resolveUnionid failed", "unionid", identity.Unionid, "err", err)
+				}
+				err = o.identityRepo.AddLinkAttempt(identity.ID)
+				if err != nil {
+					return linked, errors.WithStack(err)
+				}
+				continue
+			}
+
+			rows, err := o.identityRepo.LinkByUnionid(extCorpID, identity.Unionid, customer.ExtID)
+			if err != nil {
+				return linked, errors.WithStack(err)
+			}
+			linked += rows
+		}
+	}
+
+	return
+}
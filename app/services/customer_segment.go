@@ -0,0 +1,83 @@
+package services
+
+import (
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/id_generator"
+)
+
+type CustomerSegment struct {
+	repo models.CustomerSegment
+}
+
+func NewCustomerSegment() *CustomerSegment {
+	return &CustomerSegment{repo: models.CustomerSegment{}}
+}
+
+func (o CustomerSegment) Query(
+	req requests.QueryCustomerSegmentReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.CustomerSegment, int64, error) {
+	return o.repo.Query(req, extCorpID, sorter, pager)
+}
+
+func (o CustomerSegment) Get(id string, extCorpID string) (models.CustomerSegment, error) {
+	return o.repo.Get(id, extCorpID)
+}
+
+func (o CustomerSegment) Create(
+	req requests.CreateCustomerSegmentReq, extStaffID string, extCorpID string) (item models.CustomerSegment, err error) {
+	err = models.ValidateSegmentRule(req.Rule)
+	if err != nil {
+		return
+	}
+
+	item = models.CustomerSegment{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extStaffID},
+		Name:         req.Name,
+		Description:  req.Description,
+		Rule:         req.Rule,
+	}
+	err = o.repo.Create(item)
+	return
+}
+
+func (o CustomerSegment) Update(
+	id string, req requests.UpdateCustomerSegmentReq, extCorpID string) (item models.CustomerSegment, err error) {
+	err = models.ValidateSegmentRule(req.Rule)
+	if err != nil {
+		return
+	}
+
+	item, err = o.repo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	item.Name = req.Name
+	item.Description = req.Description
+	item.Rule = req.Rule
+	err = o.repo.Update(item)
+	return
+}
+
+func (o CustomerSegment) Delete(ids []string, extCorpID string) error {
+	return o.repo.Delete(ids, extCorpID)
+}
+
+// Count 实时统计已保存分群的客户数
+func (o CustomerSegment) Count(id string, extCorpID string) (res models.SegmentCount, err error) {
+	item, err := o.repo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+	return o.repo.Count(item.Rule, extCorpID)
+}
+
+// Preview 按未保存的规则实时统计客户数
+func (o CustomerSegment) Preview(req requests.CountCustomerSegmentReq, extCorpID string) (res models.SegmentCount, err error) {
+	err = models.ValidateSegmentRule(req.Rule)
+	if err != nil {
+		return
+	}
+	return o.repo.Count(req.Rule, extCorpID)
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/delay_queue"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	gowx "openscrm/pkg/easywework"
+	"strings"
+	"time"
+)
+
+type CustomerSop struct {
+	repo              models.CustomerSop
+	taskRepo          models.CustomerSopTask
+	materialRepo      models.Material
+	customerRepo      models.Customer
+	customerStaffRepo models.CustomerStaff
+	tagRepo           models.CustomerStaffTag
+}
+
+func NewCustomerSop() *CustomerSop {
+	return &CustomerSop{
+		repo:              models.CustomerSop{},
+		taskRepo:          models.CustomerSopTask{},
+		materialRepo:      models.Material{},
+		customerRepo:      models.Customer{},
+		customerStaffRepo: models.CustomerStaff{},
+		tagRepo:           models.CustomerStaffTag{},
+	}
+}
+
+func (o CustomerSop) Query(
+	req requests.QueryCustomerSopReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.CustomerSop, int64, error) {
+	return o.repo.Query(req, extCorpID, sorter, pager)
+}
+
+func (o CustomerSop) Get(id string, extCorpID string) (models.CustomerSop, error) {
+	return o.repo.Get(id, extCorpID)
+}
+
+func (o CustomerSop) QueryTasks(
+	req requests.QueryCustomerSopTaskReq, extCorpID string, pager *app.Pager) ([]models.CustomerSopTask, int64, error) {
+	return o.taskRepo.Query(req, extCorpID, pager)
+}
+
+func (o CustomerSop) Create(
+	req requests.CreateCustomerSopReq, extStaffID string, extCorpID string) (item models.CustomerSop, err error) {
+	err = o.validate(req, extCorpID)
+	if err != nil {
+		return
+	}
+
+	item = models.CustomerSop{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extStaffID},
+	}
+	o.fill(&item, req, nil)
+	err = o.repo.Create(item)
+	return
+}
+
+func (o CustomerSop) Update(
+	id string, req requests.UpdateCustomerSopReq, extCorpID string) (item models.CustomerSop, err error) {
+	err = o.validate(req.CreateCustomerSopReq, extCorpID)
+	if err != nil {
+		return
+	}
+
+	item, err = o.repo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	stepIDs := make([]string, 0, len(item.Steps))
+	for _, step := range item.Steps {
+		stepIDs = append(stepIDs, step.ID)
+	}
+	o.fill(&item, req.CreateCustomerSopReq, stepIDs)
+	err = o.repo.Update(item)
+	if err != nil {
+		return
+	}
+
+	if item.Enable == constants.False {
+		err = o.taskRepo.CancelPending([]string{item.ID}, extCorpID)
+	}
+	return
+}
+
+// Delete 删除SOP，并取消待执行的任务
+func (o CustomerSop) Delete(ids []string, extCorpID string) error {
+	err := o.repo.Delete(ids, extCorpID)
+	if err != nil {
+		return err
+	}
+	return o.taskRepo.CancelPending(ids, extCorpID)
+}
+
+// validate 检查触发条件和步骤内容
+func (o CustomerSop) validate(req requests.CreateCustomerSopReq, extCorpID string) error {
+	if req.TriggerType == constants.CustomerSopTriggerContactWay && len(req.TriggerContactWayIDs) == 0 {
+		return errors.Wrap(ecode.InvalidCustomerSopErr, "请选择触发的渠道码")
+	}
+	if req.TriggerType == constants.CustomerSopTriggerTag && len(req.TriggerExtTagIDs) == 0 {
+		return errors.Wrap(ecode.InvalidCustomerSopErr, "请选择触发的标签")
+	}
+	if len(req.Steps) > constants.CustomerSopMaxSteps {
+		return errors.Wrapf(ecode.InvalidCustomerSopErr, "最多%d个步骤", constants.CustomerSopMaxSteps)
+	}
+
+	for i, step := range req.Steps {
+		if step.Msg.Text == "" && len(step.Msg.Attachments) == 0 && step.MaterialID == "" {
+			return errors.Wrapf(ecode.InvalidCustomerSopErr, "第%d步未设置消息内容", i+1)
+		}
+		if step.MaterialID != "" {
+			_, err := o.materialRepo.Get(step.MaterialID, extCorpID)
+			if err != nil {
+				return errors.Wrapf(ecode.InvalidCustomerSopErr, "第%d步的素材不存在", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// fill 用请求填充SOP，步骤ID不属于existingStepIDs时生成新ID
+func (o CustomerSop) fill(item *models.CustomerSop, req requests.CreateCustomerSopReq, existingStepIDs []string) {
+	item.Name = req.Name
+	item.Enable = req.Enable
+	item.TriggerType = req.TriggerType
+	item.TriggerContactWayIDs = constants.StringArrayField(funk.UniqString(req.TriggerContactWayIDs))
+	item.TriggerExtTagIDs = constants.StringArrayField(funk.UniqString(req.TriggerExtTagIDs))
+	item.DeliverType = req.DeliverType
+
+	item.Steps = make([]models.CustomerSopStep, 0, len(req.Steps))
+	for i, stepReq := range req.Steps {
+		stepID := stepReq.ID
+		if stepID == "" || !funk.ContainsString(existingStepIDs, stepID) {
+			stepID = id_generator.StringID()
+		}
+		conditionExtTagIDs := constants.StringArrayField(funk.UniqString(stepReq.ConditionExtTagIDs))
+		if conditionExtTagIDs == nil {
+			conditionExtTagIDs = constants.StringArrayField{}
+		}
+		item.Steps = append(item.Steps, models.CustomerSopStep{
+			ExtCorpModel:       models.ExtCorpModel{ID: stepID, ExtCorpID: item.ExtCorpID, ExtCreatorID: item.ExtCreatorID},
+			CustomerSopID:      item.ID,
+			Seq:                i + 1,
+			DelayMinutes:       stepReq.DelayMinutes,
+			Msg:                stepReq.Msg,
+			MaterialID:         stepReq.MaterialID,
+			ConditionExtTagIDs: conditionExtTagIDs,
+		})
+	}
+}
+
+// TriggerByContactWay
+// Description: 客户通过渠道码添加后，加入绑定该渠道码的SOP
+func (o CustomerSop) TriggerByContactWay(contactWayID string, extStaffID string, extCustomerID string, extCorpID string) error {
+	sops, err := o.repo.QueryEnabledByTrigger(constants.CustomerSopTriggerContactWay, extCorpID)
+	if err != nil {
+		return err
+	}
+	for _, sop := range sops {
+		if !sop.TriggerContactWayIDs.Contains(contactWayID) {
+			continue
+		}
+		err = o.enroll(sop, extStaffID, extCustomerID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TriggerByTags
+// Description: 员工给客户打上标签后，加入以其中任一标签触发的SOP
+func (o CustomerSop) TriggerByTags(extTagIDs []string, extStaffID string, extCustomerID string, extCorpID string) error {
+	if len(extTagIDs) == 0 {
+		return nil
+	}
+	sops, err := o.repo.QueryEnabledByTrigger(constants.CustomerSopTriggerTag, extCorpID)
+	if err != nil {
+		return err
+	}
+	for _, sop := range sops {
+		if len(funk.IntersectString(sop.TriggerExtTagIDs, extTagIDs)) == 0 {
+			continue
+		}
+		err = o.enroll(sop, extStaffID, extCustomerID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enroll
+// Description: 为员工-客户生成SOP每个步骤的任务，并按延迟时间加入延迟队列
+// Detail: 同一员工-客户的同一步骤只执行一次，重复触发时不再生成任务
+func (o CustomerSop) enroll(sop models.CustomerSop, extStaffID string, extCustomerID string) error {
+	now := time.Now()
+	for _, step := range sop.Steps {
+		task := models.CustomerSopTask{
+			ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: sop.ExtCorpID, ExtCreatorID: sop.ExtCreatorID},
+			CustomerSopID: sop.ID,
+			StepID:        step.ID,
+			Seq:           step.Seq,
+			ExtStaffID:    extStaffID,
+			ExtCustomerID: extCustomerID,
+			ExecuteAt:     now.Add(time.Duration(step.DelayMinutes) * time.Minute),
+			Status:        constants.CustomerSopTaskPending,
+		}
+		created, err := o.taskRepo.Create(task)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+
+		err = delay_queue.Add(delay_queue.Job{
+			Topic:     constants.CustomerSopTopic,
+			ID:        task.ID,
+			ExecuteAt: task.ExecuteAt.Unix(),
+			TTR:       30,
+			Body:      task.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "add customer sop job failed")
+		}
+	}
+	return nil
+}
+
+// ExecuteTask
+// Description: 执行到期的SOP步骤
+// Detail: SOP停用、步骤已删除时取消任务；客户已流失或不满足条件时跳过；发送失败时记录原因，不再重试
+func (o CustomerSop) ExecuteTask(taskID string) error {
+	task, err := o.taskRepo.Get(taskID)
+	if errors.Is(err, ecode.ItemNotFoundError) {
+		log.Sugar.Warnw("customer sop task not found", "taskID", taskID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if task.Status != constants.CustomerSopTaskPending {
+		return nil
+	}
+
+	sop, err := o.repo.Get(task.CustomerSopID, task.ExtCorpID)
+	if errors.Is(err, ecode.ItemNotFoundError) {
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskCanceled, "SOP已删除", "")
+	}
+	if err != nil {
+		return err
+	}
+	if sop.Enable != constants.True {
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskCanceled, "SOP已停用", "")
+	}
+	var step *models.CustomerSopStep
+	for i := range sop.Steps {
+		if sop.Steps[i].ID == task.StepID {
+			step = &sop.Steps[i]
+			break
+		}
+	}
+	if step == nil {
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskCanceled, "步骤已删除", "")
+	}
+
+	_, err = o.customerStaffRepo.Get(models.CustomerStaff{
+		ExtCorpModel: models.ExtCorpModel{ExtCorpID: task.ExtCorpID}, ExtStaffID: task.ExtStaffID, ExtCustomerID: task.ExtCustomerID})
+	if err != nil {
+		log.Sugar.Infow("customer staff relation not found", "err", err, "taskID", task.ID)
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskSkipped, "客户已不是好友", "")
+	}
+
+	if len(step.ConditionExtTagIDs) > 0 {
+		ok, err := o.tagRepo.HasAllTags(task.ExtStaffID, task.ExtCustomerID, step.ConditionExtTagIDs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskSkipped, "客户不满足标签条件", "")
+		}
+	}
+
+	text, err := NewMsgTemplate().Render(step.Msg.Text, task.ExtCorpID, task.ExtStaffID, task.ExtCustomerID)
+	if err != nil {
+		log.Sugar.Errorw("render customer sop content failed", "err", err, "taskID", task.ID)
+	}
+	attachments, err := ToWxAttachments(step.Msg.Attachments, task.ExtCorpID)
+	if err != nil {
+		return err
+	}
+	if step.MaterialID != "" {
+		material, err := o.materialRepo.Get(step.MaterialID, task.ExtCorpID)
+		if err != nil && !errors.Is(err, ecode.ItemNotFoundError) {
+			return err
+		}
+		if err == nil {
+			attachments = append(attachments, materialToWxAttachment(material))
+		}
+	}
+
+	client, err := we_work.Clients.Get(task.ExtCorpID)
+	if err != nil {
+		return err
+	}
+
+	if sop.DeliverType == constants.CustomerSopDeliverMsgTemplate {
+		extMsgID, failList, sendErr := client.Customer.AddMsgTemplate(gowx.AddMsgTemplateReq{
+			ChatType:       string(constants.Single),
+			ExternalUserid: []string{task.ExtCustomerID},
+			Sender:         task.ExtStaffID,
+			Text:           gowx.Text{Content: text},
+			Attachments:    attachments,
+		})
+		if sendErr != nil {
+			log.Sugar.Errorw("AddMsgTemplate failed", "err", sendErr, "taskID", task.ID)
+			return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskFailed, sendErr.Error(), "")
+		}
+		if len(failList) > 0 {
+			return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskFailed, "客户无法接收消息", extMsgID)
+		}
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskDone, "", extMsgID)
+	}
+
+	customer, err := o.customerRepo.GetByExtID(task.ExtCustomerID, nil, false)
+	if err != nil {
+		log.Sugar.Errorw("get customer failed", "err", err, "extCustomerID", task.ExtCustomerID)
+	}
+	content := fmt.Sprintf(constants.CustomerSopRemindMsg, sop.Name, step.Seq, customer.Name, describeWxMsg(text, attachments))
+	sendErr := client.MainApp.SendTextMessage(&gowx.Recipient{UserIDs: []string{task.ExtStaffID}}, content, false)
+	if sendErr != nil {
+		log.Sugar.Errorw("send customer sop remind failed", "err", sendErr, "taskID", task.ID)
+		return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskFailed, sendErr.Error(), "")
+	}
+	return o.taskRepo.Finish(task.ID, constants.CustomerSopTaskDone, "", "")
+}
+
+// materialToWxAttachment 素材库的素材转为消息附件，海报以图片发送，其余以链接发送
+func materialToWxAttachment(material models.Material) gowx.Attachments {
+	switch material.MaterialType {
+	case "poster":
+		return gowx.Attachments{
+			MsgType: string(constants.ImageMsgType),
+			Image:   gowx.Image{PicURL: material.FileUrl},
+		}
+	case "link":
+		return gowx.Attachments{
+			MsgType: string(constants.LinkMsgType),
+			Link:    gowx.Link{Title: material.Title, Desc: material.Digest, URL: material.Link},
+		}
+	default:
+		return gowx.Attachments{
+			MsgType: string(constants.LinkMsgType),
+			Link:    gowx.Link{Title: material.Title, URL: material.FileUrl},
+		}
+	}
+}
+
+// describeWxMsg 将消息内容和附件转为可读文本，用于提醒员工
+func describeWxMsg(text string, attachments []gowx.Attachments) string {
+	lines := make([]string, 0, len(attachments)+1)
+	if text != "" {
+		lines = append(lines, text)
+	}
+	for _, attachment := range attachments {
+		switch attachment.MsgType {
+		case string(constants.ImageMsgType):
+			lines = append(lines, "[图片] "+attachment.Image.PicURL)
+		case string(constants.LinkMsgType):
+			lines = append(lines, fmt.Sprintf("[链接] %s %s", attachment.Link.Title, attachment.Link.URL))
+		case string(constants.MiniProgramMsgType):
+			lines = append(lines, "[小程序] "+attachment.Miniprogram.Title)
+		default:
+			lines = append(lines, "["+attachment.MsgType+"]")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"github.com/pkg/errors"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	gowx "openscrm/pkg/easywework"
+	"strconv"
+)
+
+// maxStrategyAdmins 企微规则组最多可配置的负责人数
+const maxStrategyAdmins = 20
+
+type CustomerStrategy struct {
+	repo models.CustomerStrategy
+}
+
+func NewCustomerStrategy() *CustomerStrategy {
+	return &CustomerStrategy{repo: models.CustomerStrategy{}}
+}
+
+// Sync
+// Description: 从企微同步客户联系规则组到本地
+// Detail: 已绑定角色的规则组保留本地的角色绑定，企微中已删除的规则组本地同步删除
+func (o CustomerStrategy) Sync(extCorpID string) error {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	localIDs, err := o.repo.GetExtStrategyIDs(extCorpID)
+	if err != nil {
+		return err
+	}
+
+	cursor := ""
+	for {
+		extStrategyIDs, nextCursor, err := client.Customer.ListCustomerStrategy(cursor, 1000)
+		if err != nil {
+			return errors.Wrap(err, "ListCustomerStrategy failed")
+		}
+
+		for _, extStrategyID := range extStrategyIDs {
+			item, err := o.fetch(client, extStrategyID)
+			if err != nil {
+				return err
+			}
+
+			item.ExtCorpID = extCorpID
+			if id, ok := localIDs[extStrategyID]; ok {
+				item.ID = id
+				local, err := o.repo.Get(id, extCorpID)
+				if err != nil {
+					return err
+				}
+				item.RoleID = local.RoleID
+				delete(localIDs, extStrategyID)
+			} else {
+				item.ID = id_generator.StringID()
+			}
+
+			err = o.repo.Save(item)
+			if err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if len(localIDs) == 0 {
+		return nil
+	}
+
+	removedIDs := make([]string, 0, len(localIDs))
+	for _, id := range localIDs {
+		removedIDs = append(removedIDs, id)
+	}
+	return o.repo.Delete(removedIDs, extCorpID)
+}
+
+// fetch 获取企微规则组详情及完整的管理范围
+func (o CustomerStrategy) fetch(client we_work.Client, extStrategyID int64) (item models.CustomerStrategy, err error) {
+	strategy, err := client.Customer.GetCustomerStrategy(extStrategyID)
+	if err != nil {
+		err = errors.Wrap(err, "GetCustomerStrategy failed")
+		return
+	}
+
+	item = models.CustomerStrategy{
+		ExtStrategyID: strategy.StrategyID,
+		ExtParentID:   strategy.ParentID,
+		Name:          strategy.StrategyName,
+		AdminList:     strategy.AdminList,
+		Privilege:     constants.CustomerStrategyPrivilege(strategy.Privilege),
+		Ranges:        make([]models.CustomerStrategyRange, 0),
+	}
+
+	cursor := ""
+	for {
+		ranges, nextCursor, err := client.Customer.GetCustomerStrategyRange(extStrategyID, cursor, 1000)
+		if err != nil {
+			return item, errors.Wrap(err, "GetCustomerStrategyRange failed")
+		}
+
+		for _, r := range ranges {
+			item.Ranges = append(item.Ranges, models.CustomerStrategyRange{
+				ExtCorpModel:    models.ExtCorpModel{ID: id_generator.StringID()},
+				Type:            constants.CustomerStrategyRangeType(r.Type),
+				ExtStaffID:      r.Userid,
+				ExtDepartmentID: r.Partyid,
+			})
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	return
+}
+
+func (o CustomerStrategy) Query(
+	req requests.QueryCustomerStrategyReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.CustomerStrategy, int64, error) {
+	return o.repo.Query(req, extCorpID, sorter, pager)
+}
+
+func (o CustomerStrategy) Get(id string, extCorpID string) (models.CustomerStrategy, error) {
+	return o.repo.Get(id, extCorpID)
+}
+
+// Create 在企微创建规则组并保存到本地
+func (o CustomerStrategy) Create(req requests.CreateCustomerStrategyReq, extCorpID string) (item models.CustomerStrategy, err error) {
+	adminList, err := o.adminList(req.AdminList, req.RoleID, extCorpID)
+	if err != nil {
+		return
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	ranges := o.toRanges(req.Ranges)
+	extStrategyID, err := client.Customer.CreateCustomerStrategy(gowx.CreateCustomerStrategyReq{
+		ParentID:     req.ExtParentID,
+		StrategyName: req.Name,
+		AdminList:    adminList,
+		Privilege:    (*gowx.CustomerStrategyPrivilege)(req.Privilege),
+		Range:        o.toWxRanges(ranges),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "CreateCustomerStrategy failed")
+		return
+	}
+
+	// 企微会为未指定的权限填充默认值，以企微返回的详情为准
+	item, err = o.fetch(client, extStrategyID)
+	if err != nil {
+		return
+	}
+
+	item.ID = id_generator.StringID()
+	item.ExtCorpID = extCorpID
+	if req.RoleID != "" {
+		item.RoleID = &req.RoleID
+	}
+
+	err = o.repo.Save(item)
+	return
+}
+
+// Update 更新规则组，管理范围按差异增删
+func (o CustomerStrategy) Update(id string, req requests.UpdateCustomerStrategyReq, extCorpID string) (item models.CustomerStrategy, err error) {
+	item, err = o.repo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	adminList, err := o.adminList(req.AdminList, req.RoleID, extCorpID)
+	if err != nil {
+		return
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	ranges := o.toRanges(req.Ranges)
+	rangeAdd, rangeDel := o.diffRanges(item.Ranges, ranges)
+	err = client.Customer.EditCustomerStrategy(gowx.EditCustomerStrategyReq{
+		StrategyID:   item.ExtStrategyID,
+		StrategyName: req.Name,
+		AdminList:    adminList,
+		Privilege:    (*gowx.CustomerStrategyPrivilege)(req.Privilege),
+		RangeAdd:     o.toWxRanges(rangeAdd),
+		RangeDel:     o.toWxRanges(rangeDel),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "EditCustomerStrategy failed")
+		return
+	}
+
+	item.Name = req.Name
+	if len(adminList) > 0 {
+		item.AdminList = adminList
+	}
+	if req.Privilege != nil {
+		item.Privilege = *req.Privilege
+	}
+	item.RoleID = nil
+	if req.RoleID != "" {
+		item.RoleID = &req.RoleID
+	}
+	item.Ranges = ranges
+
+	err = o.repo.Save(item)
+	return
+}
+
+// Delete 删除企微和本地的规则组
+func (o CustomerStrategy) Delete(ids []string, extCorpID string) error {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, id := range ids {
+		item, err := o.repo.Get(id, extCorpID)
+		if err != nil {
+			return err
+		}
+
+		err = client.Customer.DelCustomerStrategy(item.ExtStrategyID)
+		if err != nil {
+			return errors.Wrap(err, "DelCustomerStrategy failed")
+		}
+	}
+
+	return o.repo.Delete(ids, extCorpID)
+}
+
+// SyncRoleAdmins
+// Description: 角色成员变化后，将绑定了这些角色的规则组负责人同步为角色下的员工
+func (o CustomerStrategy) SyncRoleAdmins(extCorpID string, roleIDs ...string) error {
+	strategies, err := o.repo.GetByRoleIDs(roleIDs)
+	if err != nil {
+		return err
+	}
+	if len(strategies) == 0 {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, strategy := range strategies {
+		adminList, err := o.adminList(nil, *strategy.RoleID, extCorpID)
+		if err != nil {
+			return err
+		}
+		// 企微不允许规则组没有负责人，角色下暂无员工时保留原负责人
+		if len(adminList) == 0 {
+			log.Sugar.Warnw("role has no staff, keep strategy admins", "strategyID", strategy.ID, "roleID", *strategy.RoleID)
+			continue
+		}
+
+		err = client.Customer.EditCustomerStrategy(gowx.EditCustomerStrategyReq{
+			StrategyID: strategy.ExtStrategyID,
+			AdminList:  adminList,
+		})
+		if err != nil {
+			return errors.Wrap(err, "EditCustomerStrategy failed")
+		}
+
+		err = o.repo.UpdateAdminList(strategy.ID, adminList)
+		if err != nil {
+			return errors.Wrap(err, "UpdateAdminList failed")
+		}
+	}
+
+	return nil
+}
+
+// adminList 绑定角色时负责人为角色下的员工，否则使用指定的负责人
+func (o CustomerStrategy) adminList(adminList []string, roleID string, extCorpID string) ([]string, error) {
+	if roleID != "" {
+		adminList = make([]string, 0)
+		err := models.DB.Model(&models.Staff{}).
+			Where("ext_corp_id = ? and role_id = ?", extCorpID, roleID).
+			Pluck("ext_id", &adminList).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Pluck role staffs failed")
+		}
+	}
+
+	if len(adminList) > maxStrategyAdmins {
+		return nil, errors.WithStack(ecode.TooManyStrategyAdminsErr)
+	}
+
+	return adminList, nil
+}
+
+func (o CustomerStrategy) toRanges(reqRanges []requests.CustomerStrategyRange) []models.CustomerStrategyRange {
+	ranges := make([]models.CustomerStrategyRange, 0, len(reqRanges))
+	for _, r := range reqRanges {
+		item := models.CustomerStrategyRange{
+			ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID()},
+			Type:         r.Type,
+		}
+		if r.Type == constants.CustomerStrategyRangeTypeStaff {
+			item.ExtStaffID = r.ExtStaffID
+		} else {
+			item.ExtDepartmentID = r.ExtDepartmentID
+		}
+		ranges = append(ranges, item)
+	}
+	return ranges
+}
+
+func (o CustomerStrategy) toWxRanges(ranges []models.CustomerStrategyRange) []gowx.CustomerStrategyRange {
+	wxRanges := make([]gowx.CustomerStrategyRange, 0, len(ranges))
+	for _, r := range ranges {
+		wxRanges = append(wxRanges, gowx.CustomerStrategyRange{
+			Type:    gowx.CustomerStrategyRangeType(r.Type),
+			Userid:  r.ExtStaffID,
+			Partyid: r.ExtDepartmentID,
+		})
+	}
+	return wxRanges
+}
+
+// diffRanges 计算管理范围需要新增和删除的节点
+func (o CustomerStrategy) diffRanges(
+	oldRanges, newRanges []models.CustomerStrategyRange) (rangeAdd, rangeDel []models.CustomerStrategyRange) {
+	key := func(r models.CustomerStrategyRange) string {
+		if r.Type == constants.CustomerStrategyRangeTypeStaff {
+			return "staff:" + r.ExtStaffID
+		}
+		return "department:" + strconv.FormatInt(r.ExtDepartmentID, 10)
+	}
+
+	oldKeys := make(map[string]bool, len(oldRanges))
+	for _, r := range oldRanges {
+		oldKeys[key(r)] = true
+	}
+
+	newKeys := make(map[string]bool, len(newRanges))
+	for _, r := range newRanges {
+		newKeys[key(r)] = true
+		if !oldKeys[key(r)] {
+			rangeAdd = append(rangeAdd, r)
+		}
+	}
+
+	for _, r := range oldRanges {
+		if !newKeys[key(r)] {
+			rangeDel = append(rangeDel, r)
+		}
+	}
+
+	return
+}
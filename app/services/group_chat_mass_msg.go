@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -24,6 +25,7 @@ type GroupChatMassMsg struct {
 	groupChatMassMsgRepo models.GroupChatMassMsg
 	massMsgStaffRepo     models.MassMsgStaff
 	staffRepo            models.Staff
+	chatRepo             models.GroupChatMassMsgChat
 }
 
 func NewGroupChatMassMsg() *GroupChatMassMsg {
@@ -31,6 +33,7 @@ func NewGroupChatMassMsg() *GroupChatMassMsg {
 		massMsgStaffRepo:     models.MassMsgStaff{},
 		groupChatMassMsgRepo: models.GroupChatMassMsg{},
 		staffRepo:            models.Staff{},
+		chatRepo:             models.GroupChatMassMsgChat{},
 	}
 }
 
@@ -74,7 +77,11 @@ func (o GroupChatMassMsg) SendMassMsgAsync(req requests.SendGroupChatMassMsgReq,
 		UnDeliveredNum: len(req.ExtStaffIDs),
 		SendAt:         req.SendAt,
 	}
-	err = o.groupChatMassMsgRepo.Create(msg)
+	chats, err := o.buildTargetChats(missionID, req.ExtStaffIDs, extCorpID, extStaffID)
+	if err != nil {
+		return
+	}
+	err = o.groupChatMassMsgRepo.Create(msg, chats)
 	if err != nil {
 		err = errors.WithStack(err)
 		return
@@ -82,15 +89,195 @@ func (o GroupChatMassMsg) SendMassMsgAsync(req requests.SendGroupChatMassMsgReq,
 	return
 }
 
+// Update
+// Description: 修改定时的客户群群发
+// Detail: 只能修改尚未到发送时间的定时群发，目标群按新的群主重新生成
+func (o GroupChatMassMsg) Update(
+	req requests.UpdateGroupChatMassMsgReq, id string, extStaffID string, extCorpID string) (msg models.GroupChatMassMsg, err error) {
+	oldMsg, err := o.groupChatMassMsgRepo.Get(id)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	if oldMsg.ExtCorpID != extCorpID {
+		err = errors.WithStack(ecode.ForbiddenError)
+		return
+	}
+	if oldMsg.SendType != constants.Timed || oldMsg.MissionStatus != constants.NotActive {
+		err = ecode.UnsupportedMsgError
+		return
+	}
+	if req.SendAt.ToInt64() <= time.Now().Unix() {
+		err = ecode.EarlierThanNowError
+		return
+	}
+
+	// 更新延迟发送的消息
+	msgBytes, err := json.Marshal(requests.SendGroupChatMassMsgReq{
+		ExtStaffIDs: req.ExtStaffIDs,
+		SendType:    constants.Timed,
+		SendAt:      req.SendAt,
+		Msg:         req.Msg,
+	})
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	job := delay_queue.Job{
+		Topic:     constants.GroupChatMassMsgTopic,
+		ID:        id,
+		ExecuteAt: req.SendAt.ToInt64(),
+		TTR:       5,
+		Body:      string(msgBytes),
+	}
+	err = delay_queue.Add(job)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	chats, err := o.buildTargetChats(id, req.ExtStaffIDs, extCorpID, extStaffID)
+	if err != nil {
+		return
+	}
+	msg = models.GroupChatMassMsg{
+		ExtCorpModel:   models.ExtCorpModel{ID: id, ExtCorpID: extCorpID, ExtCreatorID: oldMsg.ExtCreatorID},
+		SendType:       constants.Timed,
+		ExtStaffIDs:    req.ExtStaffIDs,
+		Msg:            req.Msg,
+		MissionStatus:  constants.NotActive,
+		UnDeliveredNum: len(req.ExtStaffIDs),
+		SendAt:         req.SendAt,
+	}
+	err = o.groupChatMassMsgRepo.Update(msg, chats)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	return o.groupChatMassMsgRepo.Get(id)
+}
+
+// buildTargetChats 群主名下未解散的群作为群发的目标群，用于统计每个群的发送结果
+func (o GroupChatMassMsg) buildTargetChats(
+	msgID string, extStaffIDs []string, extCorpID string, extCreatorID string) ([]models.GroupChatMassMsgChat, error) {
+	if len(extStaffIDs) == 0 {
+		return nil, nil
+	}
+	targets, err := o.chatRepo.QueryTargetChats(extCorpID, extStaffIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	chats := make([]models.GroupChatMassMsgChat, 0, len(targets))
+	for _, target := range targets {
+		chats = append(chats, models.GroupChatMassMsgChat{
+			ExtCorpModel:       models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
+			GroupChatMassMsgID: msgID,
+			ExtStaffID:         target.ExtStaffID,
+			ExtChatID:          target.ExtChatID,
+		})
+	}
+	return chats, nil
+}
+
+// ChatFilter 预览客户群群发将发送到的群
+func (o GroupChatMassMsg) ChatFilter(
+	req requests.GroupChatMassMsgChatFilterReq, extCorpID string) (res requests.GroupChatMassMsgChatFilterResp, err error) {
+	res.Chats, err = o.chatRepo.QueryTargetChats(extCorpID, req.ExtStaffIDs)
+	if err != nil {
+		return
+	}
+	if res.Chats == nil {
+		res.Chats = []requests.GroupChatMassMsgChatResult{}
+	}
+	res.Total = int64(len(res.Chats))
+	return
+}
+
+// Notify
+// Description: 通过主应用提醒还未发送的群主发送客户群群发
+func (o GroupChatMassMsg) Notify(ids []string, extCorpID string) error {
+	msgs, err := o.groupChatMassMsgRepo.GetByIDs(ids)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, msg := range msgs {
+		if msg.ExtCorpID != extCorpID {
+			return errors.WithStack(ecode.ForbiddenError)
+		}
+		if msg.MissionStatus != constants.Sending {
+			continue
+		}
+		owners, err := o.chatRepo.QueryOwnerResult(msg.ID)
+		if err != nil {
+			return err
+		}
+		for _, owner := range owners {
+			// 已发送或提交失败的群主无需提醒
+			if owner.Sent > 0 || owner.SubmitFailed == owner.Total {
+				continue
+			}
+			content := fmt.Sprintf(constants.NotifyOwnerSendGroupChatMassMsg,
+				msg.CreatedAt.Format(constants.DateTimeLayout), owner.Total)
+			err = client.MainApp.SendTextMessage(&gowx.Recipient{UserIDs: []string{owner.ExtStaffID}}, content, false)
+			if err != nil {
+				log.Sugar.Errorw("notify owner failed", "err", err, "msgID", msg.ID, "owner", owner.ExtStaffID)
+			}
+		}
+	}
+	return nil
+}
+
+// GetSendMassMsgResult
+// Description: 查询客户群群发结果
+// Detail: 返回群发的整体统计、每个群主的发送情况和分页的群发送状态
+func (o GroupChatMassMsg) GetSendMassMsgResult(
+	id string, req requests.QueryGroupChatMassMsgResultReq, extCorpID string) (resp requests.GroupChatMassMsgResultResp, err error) {
+	msg, err := o.groupChatMassMsgRepo.Get(id)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	if msg.ExtCorpID != extCorpID {
+		err = errors.WithStack(ecode.ForbiddenError)
+		return
+	}
+	resp = requests.GroupChatMassMsgResultResp{
+		MissionID:      id,
+		MissionStatus:  msg.MissionStatus,
+		DeliveredNum:   msg.DeliveredNum,
+		SuccessNum:     msg.SuccessNum,
+		UnDeliveredNum: msg.UnDeliveredNum,
+		FailedNum:      msg.FailedNum,
+	}
+
+	resp.Owners, err = o.chatRepo.QueryOwnerResult(id)
+	if err != nil {
+		return
+	}
+	resp.Chats, resp.ChatTotal, err = o.chatRepo.QueryChatResult(id, req, &req.Pager)
+	if err != nil {
+		return
+	}
+	return
+}
+
 // UpdateGroupMsgSentStatus
-// 发送状态：0-未发送 1-已发送 2-因客户不是好友导致发送失败 3-因客户已经收到其他群发消息导致发送失败
+// Description: 从企微拉取每个群主的客户群群发执行结果，更新群的发送状态和群发统计
+// Detail: 由于群主还未选择群，企微只返回已发送的群；所有群主都已发送后群发状态变为发送成功
 func (o GroupChatMassMsg) UpdateGroupMsgSentStatus() (err error) {
-	// 找出还没发送的员工和对应extMsgID
 	extCorpID := conf.Settings.WeWork.ExtCorpID
-	massMsgStaff := models.MassMsgStaff{ExtCorpModel: models.ExtCorpModel{ExtCorpID: extCorpID}, IsSent: 0}
-	msgs, err := o.massMsgStaffRepo.QueryNotSentMsg(massMsgStaff)
+	owners, err := o.chatRepo.QueryUnfinishedOwners(extCorpID)
 	if err != nil {
-		err = errors.WithStack(err)
+		return
+	}
+	if len(owners) == 0 {
 		return
 	}
 
@@ -100,40 +287,47 @@ func (o GroupChatMassMsg) UpdateGroupMsgSentStatus() (err error) {
 		return err
 	}
 
-	for _, msg := range msgs {
+	msgIDs := make(map[string]bool)
+	for _, owner := range owners {
 		req := gowx.GetGroupMsgSendResultExternalContactReq{
-			Msgid:  msg.ExtMsgID,
-			Userid: msg.ExtStaffID,
+			Msgid:  owner.ExtMsgID,
+			Userid: owner.ExtStaffID,
+			Limit:  1000,
 		}
-		res, err := client.Customer.GetGroupMsgSendResultExternalContact(req)
-		if err != nil {
-			err = errors.WithStack(err)
-			return err
-		}
-		for _, item := range res.SendList {
-			// 若为客户群群发，由于用户还未选择群，所以不返回未发送记录，只返回已发送记录
-			if item.ChatID != "" {
-				if item.Status == 1 {
-					err := o.massMsgStaffRepo.Update(
-						models.MassMsgStaff{
-							ExtCorpModel: models.ExtCorpModel{ID: msg.ID},
-							ExtStaffID:   msg.ExtStaffID,
-							ExtChatID:    msg.ExtMsgID,
-							IsSent:       uint8(item.Status),
-							IsDelivered:  uint8(item.Status),
-						},
-					)
-					if err != nil {
-						err = errors.WithStack(err)
-						return err
-					}
+		for {
+			res, err := client.Customer.GetGroupMsgSendResultExternalContact(req)
+			if err != nil {
+				log.Sugar.Errorw("GetGroupMsgSendResultExternalContact failed", "err", err, "owner", owner)
+				break
+			}
+			for _, item := range res.SendList {
+				if item.ChatID == "" || constants.MassMsgSendStatus(item.Status) != constants.MassMsgSent {
+					continue
+				}
+				sendTime := time.Now()
+				if item.SendTime > 0 {
+					sendTime = time.Unix(int64(item.SendTime), 0)
+				}
+				err = o.chatRepo.SetSent(owner.GroupChatMassMsgID, owner.ExtStaffID, item.ChatID, sendTime)
+				if err != nil {
+					log.Sugar.Errorw("SetSent failed", "err", err, "owner", owner, "chatID", item.ChatID)
 				}
-			} else {
-				// 群发给客户
 			}
+			if res.NextCursor == "" {
+				break
+			}
+			req.Cursor = res.NextCursor
 		}
+		msgIDs[owner.GroupChatMassMsgID] = true
 	}
-	return
+
+	for msgID := range msgIDs {
+		err = o.groupChatMassMsgRepo.RefreshStatistic(msgID)
+		if err != nil {
+			log.Sugar.Errorw("RefreshStatistic failed", "err", err, "msgID", msgID)
+		}
+	}
+	return nil
 }
 
 func (o GroupChatMassMsg) DoSendGroupChatMassMsg(jobBody, JobID string) (extMsgID string, err error) {
@@ -173,9 +367,8 @@ func (o GroupChatMassMsg) DoSendGroupChatMassMsg(jobBody, JobID string) (extMsgI
 
 	template.Text = gowx.Text{Content: req.Msg.Text}
 	template.ChatType = string(constants.Group)
-	err = copier.CopyWithOption(&template.Attachments, req.Msg.Attachments, copier.Option{IgnoreEmpty: true, DeepCopy: true})
+	template.Attachments, err = ToWxAttachments(req.Msg.Attachments, conf.Settings.WeWork.ExtCorpID)
 	if err != nil {
-		err = errors.WithStack(err)
 		return
 	}
 
@@ -185,16 +378,33 @@ func (o GroupChatMassMsg) DoSendGroupChatMassMsg(jobBody, JobID string) (extMsgI
 		return
 	}
 
-	// 每个群主发送给他所有的群
+	// 每个群主发送给他所有的群，部分群主失败时不影响其他群主
 	for _, extStaffID := range req.ExtStaffIDs {
 		template.Sender = extStaffID
-		extMsgID, _, err = client.Customer.AddMsgTemplate(template)
-		if err != nil {
-			log.Sugar.Error("AddMsgTemplate failed", err)
-			return
+		ownerMsgID, _, sendErr := client.Customer.AddMsgTemplate(template)
+		if sendErr != nil {
+			log.Sugar.Errorw("AddMsgTemplate failed", "err", sendErr, "sender", extStaffID)
+			err = sendErr
+			dbErr := o.chatRepo.SetSubmitFailed(JobID, extStaffID)
+			if dbErr != nil {
+				log.Sugar.Errorw("SetSubmitFailed failed", "err", dbErr, "msgID", JobID, "sender", extStaffID)
+			}
+			continue
+		}
+		if extMsgID == "" {
+			extMsgID = ownerMsgID
+		}
+
+		dbErr := o.chatRepo.SetExtMsgID(JobID, extStaffID, ownerMsgID)
+		if dbErr != nil {
+			log.Sugar.Errorw("SetExtMsgID failed", "err", dbErr, "msgID", JobID, "sender", extStaffID)
 		}
 	}
 
+	// 有群主提交成功时不返回错误，避免任务重试导致重复群发
+	if extMsgID != "" {
+		err = nil
+	}
 	return extMsgID, err
 }
 
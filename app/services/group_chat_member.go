@@ -0,0 +1,359 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/responses"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// groupChatMemberDefaultDays 群成员统计默认最近30天
+const groupChatMemberDefaultDays = 30
+
+var groupChatMemberLogTitles = []string{"时间", "群聊", "成员", "成员类型", "变动", "入群方式", "退群方式", "邀请者", "在群天数"}
+
+// QueryMemberTrend
+// Description: 客户群每日入群、退群、净增人数和群人数，没有变动的日期也会返回
+func (o GroupChatService) QueryMemberTrend(
+	id string, req requests.QueryGroupChatMemberTrendReq, extCorpID string) ([]responses.GroupChatMemberTrend, error) {
+	chat := models.GroupChat{}
+	err := models.DB.Where("ext_corp_id = ? and id = ?", extCorpID, id).First(&chat).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.WithStack(ecode.ItemNotFoundError)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "First GroupChat failed")
+	}
+
+	start, end, err := o.memberStatsRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate := start.Format(constants.DateLayout), end.Format(constants.DateLayout)
+
+	logRepo := models.GroupChatMemberLog{}
+	daily, err := logRepo.QueryDaily(extCorpID, chat.ExtChatID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	netAfter, err := logRepo.CountNetAfter(extCorpID, chat.ExtChatID, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	dailyMap := make(map[string]models.GroupChatMemberDailyNum, len(daily))
+	for _, item := range daily {
+		dailyMap[item.Date] = item
+	}
+
+	// 从结束日期往前倒推每天结束时的群人数
+	items := make([]responses.GroupChatMemberTrend, 0)
+	total := chat.Total - netAfter
+	for day := end; !day.Before(start); day = day.AddDate(0, 0, -1) {
+		date := day.Format(constants.DateLayout)
+		num, ok := dailyMap[date]
+		if !ok {
+			num = models.GroupChatMemberDailyNum{Date: date}
+		}
+		item := responses.GroupChatMemberTrend{
+			GroupChatMemberDailyNum: num,
+			NetNum:                  num.JoinNum - num.QuitNum,
+			Total:                   total,
+		}
+		items = append([]responses.GroupChatMemberTrend{item}, items...)
+		total -= item.NetNum
+	}
+	return items, nil
+}
+
+// ExportMemberTrend
+// Description: 导出客户群每日成员趋势
+func (o GroupChatService) ExportMemberTrend(
+	id string, req requests.QueryGroupChatMemberTrendReq, extCorpID string) (*bytes.Buffer, string, error) {
+	items, err := o.QueryMemberTrend(id, req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []string{
+			item.Date,
+			strconv.FormatInt(item.JoinNum, 10),
+			strconv.FormatInt(item.QuitNum, 10),
+			strconv.FormatInt(item.NetNum, 10),
+			strconv.FormatInt(item.Total, 10),
+		})
+	}
+	return o.exportMemberSheet(constants.DataExportGroupChatMemberTrendSheetName,
+		[]string{"日期", "入群人数", "退群人数", "净增人数", "群人数"}, rows)
+}
+
+// QueryMemberLogs
+// Description: 查询群成员入群退群记录，按客户查询时为客户加入和退出过的群
+func (o GroupChatService) QueryMemberLogs(
+	req requests.QueryGroupChatMemberLogReq, extCorpID string) ([]responses.GroupChatMemberLog, int64, error) {
+	logs, total, err := models.GroupChatMemberLog{}.Query(req, extCorpID, &req.Pager)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := o.fillMemberLogs(logs, extCorpID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// QueryChurns
+// Description: 查询退群成员列表
+func (o GroupChatService) QueryChurns(
+	req requests.QueryGroupChatChurnReq, extCorpID string) ([]responses.GroupChatMemberLog, int64, error) {
+	return o.QueryMemberLogs(o.churnLogReq(req), extCorpID)
+}
+
+// ExportMemberLogs
+// Description: 导出群成员入群退群记录
+func (o GroupChatService) ExportMemberLogs(
+	req requests.QueryGroupChatMemberLogReq, extCorpID string) (*bytes.Buffer, string, error) {
+	req.Pager = app.Pager{Page: 1, PageSize: constants.GroupChatMemberExportMaxRows}
+	items, _, err := o.QueryMemberLogs(req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	eventNames := map[constants.GroupChatMemberEventType]string{
+		constants.GroupChatMemberEventJoin: "入群",
+		constants.GroupChatMemberEventQuit: "退群",
+	}
+	joinScenes := map[int]string{1: "成员直接邀请", 2: "成员邀请链接", 3: "扫描群二维码"}
+	quitScenes := map[int]string{0: "自己退群", 1: "被移出群聊"}
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		memberType := "客户"
+		if item.Type == 1 {
+			memberType = "员工"
+		}
+		quitScene, stayDays := "", ""
+		if item.EventType == constants.GroupChatMemberEventQuit {
+			quitScene = quitScenes[item.QuitScene]
+			stayDays = strconv.Itoa(item.StayDays)
+		}
+		rows = append(rows, []string{
+			item.EventTime.In(constants.PRCLocation).Format(constants.DateTimeLayout),
+			item.ChatName,
+			item.MemberName,
+			memberType,
+			eventNames[item.EventType],
+			joinScenes[item.JoinScene],
+			quitScene,
+			item.InvitorName,
+			stayDays,
+		})
+	}
+	return o.exportMemberSheet(constants.DataExportGroupChatMemberLogSheetName, groupChatMemberLogTitles, rows)
+}
+
+// ExportChurns
+// Description: 导出退群成员列表
+func (o GroupChatService) ExportChurns(req requests.QueryGroupChatChurnReq, extCorpID string) (*bytes.Buffer, string, error) {
+	return o.ExportMemberLogs(o.churnLogReq(req), extCorpID)
+}
+
+// QueryInviterRank
+// Description: 员工邀请入群排行，包括邀请人次和仍在群内的人数
+func (o GroupChatService) QueryInviterRank(
+	req requests.QueryGroupChatInviterRankReq, extCorpID string) ([]models.GroupChatInviterRank, error) {
+	start, end, err := o.memberStatsRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	req.StartDate, req.EndDate = start.Format(constants.DateLayout), end.Format(constants.DateLayout)
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+	return models.GroupChatMemberLog{}.QueryInviterRank(req, extCorpID)
+}
+
+// ExportInviterRank
+// Description: 导出员工邀请入群排行
+func (o GroupChatService) ExportInviterRank(
+	req requests.QueryGroupChatInviterRankReq, extCorpID string) (*bytes.Buffer, string, error) {
+	items, err := o.QueryInviterRank(req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows := make([][]string, 0, len(items))
+	for i, item := range items {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			item.InvitorName,
+			strconv.FormatInt(item.JoinNum, 10),
+			strconv.FormatInt(item.RetainedNum, 10),
+		})
+	}
+	return o.exportMemberSheet(constants.DataExportGroupChatInviterSheetName,
+		[]string{"排名", "员工", "邀请入群人次", "仍在群内人数"}, rows)
+}
+
+func (o GroupChatService) churnLogReq(req requests.QueryGroupChatChurnReq) requests.QueryGroupChatMemberLogReq {
+	return requests.QueryGroupChatMemberLogReq{
+		ExtChatID: req.ExtChatID,
+		EventType: constants.GroupChatMemberEventQuit,
+		QuitScene: req.QuitScene,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Pager:     req.Pager,
+	}
+}
+
+// memberStatsRange 统计的日期范围，默认最近30天
+func (o GroupChatService) memberStatsRange(startDate string, endDate string) (start time.Time, end time.Time, err error) {
+	end = time.Now().In(constants.PRCLocation)
+	if endDate != "" {
+		end, err = time.ParseInLocation(constants.DateLayout, endDate, constants.PRCLocation)
+		if err != nil {
+			err = errors.WithStack(ecode.InvalidParams)
+			return
+		}
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, constants.PRCLocation)
+
+	start = end.AddDate(0, 0, 1-groupChatMemberDefaultDays)
+	if startDate != "" {
+		start, err = time.ParseInLocation(constants.DateLayout, startDate, constants.PRCLocation)
+		if err != nil {
+			err = errors.WithStack(ecode.InvalidParams)
+			return
+		}
+	}
+
+	if start.After(end) || end.Sub(start) >= constants.GroupChatMemberStatsMaxDays*24*time.Hour {
+		err = errors.WithStack(ecode.InvalidParams)
+		return
+	}
+	return
+}
+
+// fillMemberLogs 补充群名、成员名、邀请者名和在群天数
+func (o GroupChatService) fillMemberLogs(
+	logs []models.GroupChatMemberLog, extCorpID string) ([]responses.GroupChatMemberLog, error) {
+	chatIDs := make([]string, 0, len(logs))
+	staffIDs := make([]string, 0, len(logs))
+	customerIDs := make([]string, 0, len(logs))
+	for _, item := range logs {
+		chatIDs = append(chatIDs, item.ExtChatID)
+		if item.Invitor != "" {
+			staffIDs = append(staffIDs, item.Invitor)
+		}
+		if item.Type == 1 {
+			staffIDs = append(staffIDs, item.Userid)
+		} else {
+			customerIDs = append(customerIDs, item.Userid)
+		}
+	}
+
+	chatNames := make(map[string]string)
+	if len(chatIDs) > 0 {
+		chats := make([]models.GroupChat, 0)
+		err := models.DB.Model(&models.GroupChat{}).Select("ext_chat_id", "name").
+			Where("ext_chat_id in (?)", chatIDs).Find(&chats).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Find GroupChat failed")
+		}
+		for _, chat := range chats {
+			chatNames[strings.TrimSpace(chat.ExtChatID)] = chat.Name
+		}
+	}
+
+	staffNames := make(map[string]string)
+	if len(staffIDs) > 0 {
+		staffs := make([]models.Staff, 0)
+		err := models.DB.Model(&models.Staff{}).Select("ext_id", "name").
+			Where("ext_corp_id = ? and ext_id in (?)", extCorpID, staffIDs).Find(&staffs).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Find Staff failed")
+		}
+		for _, staff := range staffs {
+			staffNames[staff.ExtID] = staff.Name
+		}
+	}
+
+	customerNames := make(map[string]string)
+	if len(customerIDs) > 0 {
+		customers := make([]models.Customer, 0)
+		err := models.DB.Model(&models.Customer{}).Select("ext_id", "name").
+			Where("ext_corp_id = ? and ext_id in (?)", extCorpID, customerIDs).Find(&customers).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Find Customer failed")
+		}
+		for _, customer := range customers {
+			customerNames[customer.ExtID] = customer.Name
+		}
+	}
+
+	items := make([]responses.GroupChatMemberLog, 0, len(logs))
+	for _, item := range logs {
+		res := responses.GroupChatMemberLog{
+			GroupChatMemberLog: item,
+			ChatName:           chatNames[item.ExtChatID],
+			MemberName:         customerNames[item.Userid],
+			InvitorName:        staffNames[item.Invitor],
+		}
+		if item.Type == 1 {
+			res.MemberName = staffNames[item.Userid]
+		}
+		if item.EventType == constants.GroupChatMemberEventQuit && item.JoinTime > 0 {
+			res.StayDays = int(item.EventTime.Sub(time.Unix(int64(item.JoinTime), 0)).Hours() / 24)
+		}
+		items = append(items, res)
+	}
+	return items, nil
+}
+
+func (o GroupChatService) exportMemberSheet(sheetName string, titles []string, rows [][]string) (*bytes.Buffer, string, error) {
+	exportTime := time.Now().Format(constants.DateTimeLayout)
+	filename := fmt.Sprintf("%s-%s-%s.xlsx",
+		constants.DataExportGroupChatMemberFilenamePrefix, sheetName, time.Now().Format("20060102150405"))
+
+	file := excelize.NewFile()
+	sheetIndex, err := file.NewSheet(sheetName)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+	file.SetActiveSheet(sheetIndex)
+
+	err = PrettifySheet(sheetName, file, exportTime, titles)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+
+	for k, values := range rows {
+		values := values
+		err = file.SetSheetRow(sheetName, fmt.Sprint("A", k+3), &values)
+		if err != nil {
+			log.Sugar.Errorw("write excel failed", "err", err)
+			return nil, "", err
+		}
+	}
+	file.DeleteSheet("Sheet1")
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+	return buf, filename, nil
+}
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/xuri/excelize/v2"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/responses"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/redis"
+	"openscrm/common/we_work"
+	gowx "openscrm/pkg/easywework"
+	"strings"
+	"time"
+)
+
+// groupChatSpamAlertContentLen 提醒消息中展示的违规内容最大长度
+const groupChatSpamAlertContentLen = 100
+
+var groupChatSpamRuleTypeNames = map[constants.GroupChatSpamRuleType]string{
+	constants.GroupChatSpamRuleTypeKeyword:       "违禁词",
+	constants.GroupChatSpamRuleTypeLink:          "外部链接",
+	constants.GroupChatSpamRuleTypeQrImage:       "疑似二维码图片",
+	constants.GroupChatSpamRuleTypeRapidPost:     "频繁发言",
+	constants.GroupChatSpamRuleTypeNewMemberLink: "新成员发送链接",
+	constants.GroupChatSpamRuleTypeBlacklistJoin: "黑名单成员入群",
+}
+
+var groupChatViolationTitles = []string{"时间", "群聊", "成员", "成员ID", "规则", "违规类型", "命中详情", "消息内容"}
+
+type GroupChatSpam struct {
+	rule      models.GroupChatSpamRule
+	violation models.GroupChatViolation
+	blacklist models.GroupChatBlacklist
+}
+
+func NewGroupChatSpam() *GroupChatSpam {
+	return &GroupChatSpam{
+		rule:      models.GroupChatSpamRule{},
+		violation: models.GroupChatViolation{},
+		blacklist: models.GroupChatBlacklist{},
+	}
+}
+
+func (o *GroupChatSpam) QueryRules(
+	req requests.QueryGroupChatSpamRuleReq, extCorpID string) ([]models.GroupChatSpamRule, int64, error) {
+	return o.rule.Query(req, extCorpID, &req.Pager)
+}
+
+func (o *GroupChatSpam) GetRule(id string, extCorpID string) (models.GroupChatSpamRule, error) {
+	return o.rule.Get(id, extCorpID)
+}
+
+func (o *GroupChatSpam) CreateRule(
+	req requests.CreateGroupChatSpamRuleReq, extCorpID string, extCreatorID string) (item models.GroupChatSpamRule, err error) {
+	item = models.GroupChatSpamRule{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
+	}
+	o.fillRule(&item, req)
+	err = o.checkRule(item)
+	if err != nil {
+		return
+	}
+
+	err = o.rule.Create(item)
+	return
+}
+
+func (o *GroupChatSpam) UpdateRule(
+	id string, req requests.UpdateGroupChatSpamRuleReq, extCorpID string) (item models.GroupChatSpamRule, err error) {
+	item, err = o.rule.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	o.fillRule(&item, req.CreateGroupChatSpamRuleReq)
+	err = o.checkRule(item)
+	if err != nil {
+		return
+	}
+
+	err = o.rule.Update(item)
+	return
+}
+
+func (o *GroupChatSpam) DeleteRules(ids []string, extCorpID string) error {
+	return o.rule.Delete(ids, extCorpID)
+}
+
+func (o *GroupChatSpam) fillRule(item *models.GroupChatSpamRule, req requests.CreateGroupChatSpamRuleReq) {
+	item.Name = req.Name
+	item.ExtChatIDs = req.ExtChatIDs
+	item.GroupChatTagIDs = req.GroupChatTagIDs
+	item.Keywords = req.Keywords
+	item.LinkEnable = req.LinkEnable
+	item.LinkWhitelist = req.LinkWhitelist
+	item.QrImageEnable = req.QrImageEnable
+	item.RapidPostEnable = req.RapidPostEnable
+	item.RapidPostNum = req.RapidPostNum
+	item.RapidPostSeconds = req.RapidPostSeconds
+	item.NewMemberLinkEnable = req.NewMemberLinkEnable
+	item.NewMemberHours = req.NewMemberHours
+	item.BlacklistEnable = req.BlacklistEnable
+	item.BlacklistTagExtIDs = req.BlacklistTagExtIDs
+	item.Enable = req.Enable
+}
+
+// checkRule 规则至少开启一项检测
+func (o *GroupChatSpam) checkRule(item models.GroupChatSpamRule) error {
+	if len(item.Keywords) == 0 && !item.LinkEnable.Bool() && !item.QrImageEnable.Bool() &&
+		!item.RapidPostEnable.Bool() && !item.NewMemberLinkEnable.Bool() {
+		return errors.WithStack(ecode.InvalidGroupChatSpamRuleErr)
+	}
+	return nil
+}
+
+func (o *GroupChatSpam) QueryViolations(
+	req requests.QueryGroupChatViolationReq, extCorpID string) ([]responses.GroupChatViolation, int64, error) {
+	violations, total, err := o.violation.Query(req, extCorpID, &req.Pager)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userids := make([]string, 0, len(violations))
+	for _, item := range violations {
+		userids = append(userids, item.Userid)
+	}
+	names, err := o.memberNames(extCorpID, userids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]responses.GroupChatViolation, 0, len(violations))
+	for _, item := range violations {
+		items = append(items, responses.GroupChatViolation{GroupChatViolation: item, MemberName: names[item.Userid]})
+	}
+	return items, total, nil
+}
+
+// ExportViolations
+// Description: 导出客户群违规记录
+func (o *GroupChatSpam) ExportViolations(
+	req requests.QueryGroupChatViolationReq, extCorpID string) (*bytes.Buffer, string, error) {
+	req.Pager = app.Pager{Page: 1, PageSize: constants.GroupChatMemberExportMaxRows}
+	items, _, err := o.QueryViolations(req, extCorpID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sheetName := constants.DataExportGroupChatViolationSheetName
+	exportTime := time.Now().Format(constants.DateTimeLayout)
+	filename := fmt.Sprintf("%s-%s.xlsx", constants.DataExportGroupChatViolationFilenamePrefix, time.Now().Format("20060102150405"))
+
+	file := excelize.NewFile()
+	sheetIndex, err := file.NewSheet(sheetName)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+	file.SetActiveSheet(sheetIndex)
+
+	err = PrettifySheet(sheetName, file, exportTime, groupChatViolationTitles)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+
+	for k, item := range items {
+		values := []string{
+			item.EventTime.In(constants.PRCLocation).Format(constants.DateTimeLayout),
+			item.ChatName,
+			item.MemberName,
+			item.Userid,
+			item.RuleName,
+			groupChatSpamRuleTypeNames[item.RuleType],
+			item.Detail,
+			item.Content,
+		}
+		err = file.SetSheetRow(sheetName, fmt.Sprint("A", k+3), &values)
+		if err != nil {
+			log.Sugar.Errorw("write excel failed", "err", err)
+			return nil, "", err
+		}
+	}
+	file.DeleteSheet("Sheet1")
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+	return buf, filename, nil
+}
+
+func (o *GroupChatSpam) QueryBlacklist(
+	req requests.QueryGroupChatBlacklistReq, extCorpID string) ([]responses.GroupChatBlacklist, int64, error) {
+	blacklist, total, err := o.blacklist.Query(req, extCorpID, &req.Pager)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userids := make([]string, 0, len(blacklist))
+	for _, item := range blacklist {
+		userids = append(userids, item.Userid)
+	}
+	names, err := o.memberNames(extCorpID, userids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]responses.GroupChatBlacklist, 0, len(blacklist))
+	for _, item := range blacklist {
+		items = append(items, responses.GroupChatBlacklist{GroupChatBlacklist: item, MemberName: names[item.Userid]})
+	}
+	return items, total, nil
+}
+
+// DeleteBlacklist
+// Description: 移出黑名单，已打的客户标签不会移除
+func (o *GroupChatSpam) DeleteBlacklist(ids []string, extCorpID string) error {
+	return o.blacklist.Delete(ids, extCorpID)
+}
+
+// memberNames 查询客户名称
+func (o *GroupChatSpam) memberNames(extCorpID string, userids []string) (map[string]string, error) {
+	names := make(map[string]string)
+	if len(userids) == 0 {
+		return names, nil
+	}
+	customers := make([]models.Customer, 0)
+	err := models.DB.Model(&models.Customer{}).Select("ext_id", "name").
+		Where("ext_corp_id = ? and ext_id in (?)", extCorpID, userids).Find(&customers).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find Customer failed")
+	}
+	for _, customer := range customers {
+		names[customer.ExtID] = customer.Name
+	}
+	return names, nil
+}
+
+// CheckGroupMsgs
+// Description: 检查上次检查之后的会话存档群消息，命中规则时记录违规并提醒群主和群管理员
+// Detail: 企微没有移出外部群成员的接口，命中后只提醒群主和群管理员手动处理
+func (o *GroupChatSpam) CheckGroupMsgs(extCorpID string, rules []models.GroupChatSpamRule) error {
+	ctx := context.Background()
+	key := fmt.Sprintf(constants.GroupChatSpamCheckCursorKey, extCorpID)
+	cursor, err := redis.RedisClient.Get(ctx, key).Int64()
+	if errors.Is(err, goredis.Nil) {
+		// 首次检查从最新的消息开始，不检查历史消息
+		cursor, err = models.ChatMsg{}.GetLatestSeq(extCorpID)
+		if err != nil {
+			return err
+		}
+		return redis.RedisClient.Set(ctx, key, cursor, 0).Err()
+	}
+	if err != nil {
+		return errors.Wrap(err, "Get GroupChatSpamCheckCursor failed")
+	}
+
+	for {
+		msgs, err := models.ChatMsg{}.QueryGroupMsgsAfterSeq(extCorpID, cursor, constants.GroupChatSpamCheckBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		chats, members, err := o.loadMsgChats(extCorpID, msgs)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			cursor = int64(msg.Seq)
+			chat, ok := chats[msg.RoomID]
+			if !ok {
+				continue
+			}
+			member, isMember := members[msg.RoomID+"_"+msg.From]
+			if isMember && member.Type == 1 || !isMember && !isExternalUserid(msg.From) {
+				// 员工发言不检查
+				continue
+			}
+			err = o.checkMsg(chat, msg, member, isMember, rules)
+			if err != nil {
+				log.Sugar.Errorw("checkMsg failed", "err", err, "msgID", msg.MsgID)
+			}
+		}
+
+		err = redis.RedisClient.Set(ctx, key, cursor, 0).Err()
+		if err != nil {
+			return errors.Wrap(err, "Set GroupChatSpamCheckCursor failed")
+		}
+		if len(msgs) < constants.GroupChatSpamCheckBatchSize {
+			return nil
+		}
+	}
+}
+
+// loadMsgChats 查询消息所在的群聊和发言的群成员，群成员的key为群聊ID_成员ID
+func (o *GroupChatSpam) loadMsgChats(extCorpID string, msgs []models.ChatMsg) (
+	chats map[string]models.GroupChat, members map[string]models.GroupChatMember, err error) {
+	roomIDs := make([]string, 0, len(msgs))
+	userids := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		roomIDs = append(roomIDs, msg.RoomID)
+		userids = append(userids, msg.From)
+	}
+
+	groupChats := make([]models.GroupChat, 0)
+	err = models.DB.Model(&models.GroupChat{}).Preload("Tags").
+		Where("ext_corp_id = ? and ext_chat_id in (?)", extCorpID, roomIDs).Find(&groupChats).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChat failed")
+		return
+	}
+	chats = make(map[string]models.GroupChat, len(groupChats))
+	for _, chat := range groupChats {
+		chat.ExtChatID = strings.TrimSpace(chat.ExtChatID)
+		chat.Owner = strings.TrimSpace(chat.Owner)
+		chats[chat.ExtChatID] = chat
+	}
+
+	chatMembers := make([]models.GroupChatMember, 0)
+	err = models.DB.Model(&models.GroupChatMember{}).
+		Where("ext_corp_id = ? and ext_chat_id in (?) and userid in (?)", extCorpID, roomIDs, userids).
+		Find(&chatMembers).Error
+	if err != nil {
+		err = errors.Wrap(err, "Find GroupChatMember failed")
+		return
+	}
+	members = make(map[string]models.GroupChatMember, len(chatMembers))
+	for _, member := range chatMembers {
+		member.ExtChatID = strings.TrimSpace(member.ExtChatID)
+		member.Userid = strings.TrimSpace(member.Userid)
+		members[member.ExtChatID+"_"+member.Userid] = member
+	}
+	return
+}
+
+// checkMsg 按规则创建顺序检查消息，命中第一条规则后不再检查
+func (o *GroupChatSpam) checkMsg(chat models.GroupChat, msg models.ChatMsg, member models.GroupChatMember,
+	isMember bool, rules []models.GroupChatSpamRule) error {
+	tagIDs := make([]string, 0, len(chat.Tags))
+	for _, tag := range chat.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	text := msg.ContentText
+	if msg.MsgType == "link" {
+		// 链接消息的地址在消息内容中
+		text += "\n" + msg.ChatMsgContent.Content
+	}
+
+	for _, rule := range rules {
+		if !rule.AppliesTo(chat.ExtChatID, tagIDs) {
+			continue
+		}
+		ruleType, detail, ok, err := o.matchRule(rule, msg, text, member, isMember)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		violation := models.GroupChatViolation{
+			ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: chat.ExtCorpID},
+			RuleID:       rule.ID,
+			RuleName:     rule.Name,
+			RuleType:     ruleType,
+			ExtChatID:    chat.ExtChatID,
+			ChatName:     chat.Name,
+			Userid:       msg.From,
+			MsgID:        msg.MsgID,
+			MsgType:      msg.MsgType,
+			Content:      msg.ContentText,
+			Detail:       truncateRunes(detail, 255),
+			EventTime:    time.UnixMilli(msg.MsgTime),
+		}
+		return o.handleViolation(chat, rule, violation)
+	}
+	return nil
+}
+
+// matchRule 检查消息是否命中规则，依次检查违禁词、新成员链接、外部链接、二维码图片和频繁发言
+func (o *GroupChatSpam) matchRule(rule models.GroupChatSpamRule, msg models.ChatMsg, text string,
+	member models.GroupChatMember, isMember bool) (constants.GroupChatSpamRuleType, string, bool, error) {
+	if keyword, ok := rule.MatchKeyword(text); ok {
+		return constants.GroupChatSpamRuleTypeKeyword, keyword, true, nil
+	}
+
+	if rule.NewMemberLinkEnable.Bool() && isMember && member.JoinTime > 0 &&
+		msg.MsgTime/1000-int64(member.JoinTime) < int64(rule.NewMemberHours)*3600 {
+		if link, ok := rule.MatchLink(text, false); ok {
+			return constants.GroupChatSpamRuleTypeNewMemberLink, link, true, nil
+		}
+	}
+
+	if rule.LinkEnable.Bool() {
+		if link, ok := rule.MatchLink(text, true); ok {
+			return constants.GroupChatSpamRuleTypeLink, link, true, nil
+		}
+	}
+
+	if rule.QrImageEnable.Bool() && msg.MsgType == "image" {
+		return constants.GroupChatSpamRuleTypeQrImage, "", true, nil
+	}
+
+	if rule.RapidPostEnable.Bool() && rule.RapidPostNum > 0 {
+		num, err := models.ChatMsg{}.CountRoomMsgsBetween(
+			msg.ExtCorpID, msg.RoomID, msg.From, msg.MsgTime-int64(rule.RapidPostSeconds)*1000+1, msg.MsgTime)
+		if err != nil {
+			return "", "", false, err
+		}
+		// 只在刚达到条数时记录，避免持续刷屏时每条消息都提醒
+		if num == int64(rule.RapidPostNum) {
+			return constants.GroupChatSpamRuleTypeRapidPost,
+				fmt.Sprintf("%d秒内发言%d条", rule.RapidPostSeconds, num), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// handleViolation 记录违规，提醒群主和群管理员，规则开启黑名单时加入黑名单并给客户打标签，疑似二维码图片除外
+func (o *GroupChatSpam) handleViolation(
+	chat models.GroupChat, rule models.GroupChatSpamRule, violation models.GroupChatViolation) error {
+	log.Sugar.Infow("[客户群防骚扰][命中规则]", "extChatID", violation.ExtChatID, "userid", violation.Userid,
+		"ruleID", rule.ID, "ruleType", violation.RuleType)
+	err := o.violation.Create(violation)
+	if err != nil {
+		return err
+	}
+	o.alert(chat, violation)
+
+	// 会话存档不解析图片内容，疑似二维码图片可能误判，只记录和提醒，不加入黑名单
+	if !rule.BlacklistEnable.Bool() || violation.RuleType == constants.GroupChatSpamRuleTypeQrImage {
+		return nil
+	}
+	err = o.blacklist.Upsert(models.GroupChatBlacklist{
+		ID:          id_generator.StringID(),
+		ExtCorpID:   violation.ExtCorpID,
+		Userid:      violation.Userid,
+		RuleID:      rule.ID,
+		ViolationID: violation.ID,
+		ExtChatID:   violation.ExtChatID,
+		RuleType:    violation.RuleType,
+	})
+	if err != nil {
+		return err
+	}
+	if len(rule.BlacklistTagExtIDs) > 0 {
+		o.markBlacklistTags(chat, violation.Userid, rule.BlacklistTagExtIDs)
+	}
+	return nil
+}
+
+// markBlacklistTags 给黑名单客户打标签，需要由添加了该客户的员工打，优先使用群主
+func (o *GroupChatSpam) markBlacklistTags(chat models.GroupChat, extCustomerID string, tagExtIDs []string) {
+	extStaffIDs := make([]string, 0)
+	err := models.DB.Model(&models.CustomerStaff{}).
+		Where("ext_corp_id = ? and ext_customer_id = ?", chat.ExtCorpID, extCustomerID).
+		Pluck("ext_staff_id", &extStaffIDs).Error
+	if err != nil {
+		log.Sugar.Errorw("Find CustomerStaff failed", "err", err, "extCustomerID", extCustomerID)
+		return
+	}
+	if len(extStaffIDs) == 0 {
+		// 不是员工的客户，无法打标签
+		return
+	}
+	extStaffID := extStaffIDs[0]
+	for _, id := range extStaffIDs {
+		if id == chat.Owner {
+			extStaffID = id
+			break
+		}
+	}
+
+	client, err := we_work.Clients.Get(chat.ExtCorpID)
+	if err != nil {
+		log.Sugar.Errorw("get Client failed", "err", err)
+		return
+	}
+	err = client.Customer.MarkExternalContactTag(extStaffID, extCustomerID, tagExtIDs, nil)
+	if err != nil {
+		log.Sugar.Errorw("MarkExternalContactTag failed", "err", err, "extCustomerID", extCustomerID)
+	}
+}
+
+// CheckBlacklistJoin
+// Description: 成员入群时检查是否在黑名单中，在黑名单中时记录违规并提醒群主和群管理员
+func (o *GroupChatSpam) CheckBlacklistJoin(
+	extCorpID string, extChatID string, before []models.GroupChatMember, after []models.GroupChatMember) error {
+	beforeMap := make(map[string]bool, len(before))
+	for _, member := range before {
+		beforeMap[member.Userid] = true
+	}
+	userids := make([]string, 0)
+	for _, member := range after {
+		if !beforeMap[member.Userid] && member.Type != 1 {
+			userids = append(userids, member.Userid)
+		}
+	}
+	blacklist, err := o.blacklist.QueryByUserids(extCorpID, userids)
+	if err != nil || len(blacklist) == 0 {
+		return err
+	}
+
+	chat := models.GroupChat{}
+	err = models.DB.Model(&models.GroupChat{}).Where("ext_corp_id = ? and ext_chat_id = ?", extCorpID, extChatID).
+		First(&chat).Error
+	if err != nil {
+		return errors.Wrap(err, "First GroupChat failed")
+	}
+	chat.ExtChatID = strings.TrimSpace(chat.ExtChatID)
+	chat.Owner = strings.TrimSpace(chat.Owner)
+
+	for _, item := range blacklist {
+		ruleName := ""
+		rule, err := o.rule.Get(item.RuleID, extCorpID)
+		if err == nil {
+			ruleName = rule.Name
+		}
+		violation := models.GroupChatViolation{
+			ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+			RuleID:       item.RuleID,
+			RuleName:     ruleName,
+			RuleType:     constants.GroupChatSpamRuleTypeBlacklistJoin,
+			ExtChatID:    chat.ExtChatID,
+			ChatName:     chat.Name,
+			Userid:       item.Userid,
+			Detail:       fmt.Sprintf("曾因%s加入黑名单", groupChatSpamRuleTypeNames[item.RuleType]),
+			EventTime:    time.Now(),
+		}
+		err = o.violation.Create(violation)
+		if err != nil {
+			return err
+		}
+		o.alert(chat, violation)
+	}
+	return nil
+}
+
+// alert 通过主应用提醒群主和群管理员，失败只记录日志
+func (o *GroupChatSpam) alert(chat models.GroupChat, violation models.GroupChatViolation) {
+	recipients := make([]string, 0, len(chat.AdminList)+1)
+	seen := make(map[string]bool)
+	for _, id := range append([]string{chat.Owner}, chat.AdminList...) {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	memberName := violation.Userid
+	names, err := o.memberNames(violation.ExtCorpID, []string{violation.Userid})
+	if err == nil && names[violation.Userid] != "" {
+		memberName = names[violation.Userid]
+	}
+
+	content := fmt.Sprintf("【客户群防骚扰】群「%s」的成员「%s」触发%s", chat.Name, memberName,
+		groupChatSpamRuleTypeNames[violation.RuleType])
+	if violation.RuleName != "" {
+		content += fmt.Sprintf("（规则：%s）", violation.RuleName)
+	}
+	if violation.Detail != "" {
+		content += "\n命中：" + violation.Detail
+	}
+	if violation.Content != "" {
+		content += "\n内容：" + truncateRunes(violation.Content, groupChatSpamAlertContentLen)
+	}
+	content += "\n企业微信不支持自动移出外部群成员，请及时处理"
+
+	client, err := we_work.Clients.Get(violation.ExtCorpID)
+	if err != nil {
+		log.Sugar.Errorw("get Client failed", "err", err)
+		return
+	}
+	err = client.MainApp.SendTextMessage(&gowx.Recipient{UserIDs: recipients}, content, false)
+	if err != nil {
+		log.Sugar.Errorw("SendTextMessage failed", "err", err, "recipients", recipients)
+	}
+}
+
+// isExternalUserid 外部联系人和机器人的ID以wm、wo或wb开头
+func isExternalUserid(userid string) bool {
+	return strings.HasPrefix(userid, "wm") || strings.HasPrefix(userid, "wo") || strings.HasPrefix(userid, "wb")
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
 	"gorm.io/gorm"
 	"openscrm/app/constants"
 	"openscrm/app/models"
@@ -27,6 +28,9 @@ type MassMsgService struct {
 	MassMsgStaffRepo models.MassMsgStaff
 	CustomerRepo     models.Customer
 	staffRepo        models.Staff
+	quotaRepo        models.CustomerMassMsgQuota
+	approvalSrv      *MassMsgApprovalService
+	variantStatRepo  models.MsgVariantStat
 }
 
 func NewDefaultMassMsgService() *MassMsgService {
@@ -35,12 +39,20 @@ func NewDefaultMassMsgService() *MassMsgService {
 		MassMsgStaffRepo: models.MassMsgStaff{},
 		CustomerRepo:     models.Customer{},
 		staffRepo:        models.Staff{},
+		quotaRepo:        models.CustomerMassMsgQuota{},
+		approvalSrv:      NewMassMsgApprovalService(),
+		variantStatRepo:  models.MsgVariantStat{},
 	}
 }
 
 // Create
-// 定时和立即发送都统一异步发送
-func (o MassMsgService) Create(req requests.SendMassMsgReq, creator, extCorpID string) (msg models.MassMsg, err error) {
+// 定时和立即发送都统一异步发送，命中审批规则的群发待审批通过后再推送到发送队列
+func (o MassMsgService) Create(req requests.SendMassMsgReq, creator models.Staff) (msg models.MassMsg, err error) {
+	extCorpID := creator.ExtCorpID
+	err = ValidateMsgVariants(req.Variants)
+	if err != nil {
+		return
+	}
 	// 发送时间校验
 	if req.SendType == constants.Timed {
 		if req.SendAt.ToInt64() < time.Now().Unix() {
@@ -53,11 +65,12 @@ func (o MassMsgService) Create(req requests.SendMassMsgReq, creator, extCorpID s
 	}
 
 	msg = models.MassMsg{
-		ExtCorpModel:            models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: creator},
+		ExtCorpModel:            models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: creator.Name},
 		SendType:                req.SendType,
 		ExtStaffIDs:             req.ExtStaffIDs,
 		ExtDepartmentIDs:        req.ExtDepartmentIDs,
 		Msg:                     req.Msg,
+		Variants:                req.Variants,
 		MissionStatus:           constants.NotActive, // 默认为及时发送
 		ExtCustomerFilterEnable: req.ExtCustomerFilterEnable,
 		ExtCustomerFilter:       req.ExtCustomerFilter,
@@ -78,17 +91,31 @@ func (o MassMsgService) Create(req requests.SendMassMsgReq, creator, extCorpID s
 		return
 	}
 
+	staffsCustomers, err = o.applyQuotaPolicy(staffsCustomers, req.QuotaPolicy, req.SendAt, extCorpID)
+	if err != nil {
+		return
+	}
+
 	MassMsgStaffs := make([]models.MassMsgStaff, 0)
 	for _, staffCustomer := range staffsCustomers {
 		MassMsgStaffs = append(MassMsgStaffs,
 			models.MassMsgStaff{
-				ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCreatorID: creator, ExtCorpID: extCorpID},
+				ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCreatorID: creator.Name, ExtCorpID: extCorpID},
 				ExtStaffID:    staffCustomer.ExtStaffID,
 				ExtCustomerID: staffCustomer.ExtCustomerID,
 				MassMsgID:     msg.ID,
+				VariantKey:    assignMassMsgVariantKey(msg.ID, staffCustomer.ExtCustomerID, req.Variants),
 			})
 	}
 
+	rule, needApproval, err := o.approvalSrv.MatchRule(len(MassMsgStaffs), creator.RoleType, extCorpID)
+	if err != nil {
+		return
+	}
+	if needApproval {
+		msg.MissionStatus = constants.Pending
+	}
+
 	msg.Staffs = MassMsgStaffs
 	// 初始化为全部未送达
 	msg.FailedNum = len(MassMsgStaffs)
@@ -97,23 +124,23 @@ func (o MassMsgService) Create(req requests.SendMassMsgReq, creator, extCorpID s
 		err = errors.WithStack(err)
 		return
 	}
-	// 推消息
-	msgBytes, err := json.Marshal(req)
-	if err != nil {
-		err = errors.WithStack(err)
-		return
-	}
 
-	job := delay_queue.Job{
-		Topic:     constants.MassMsgTopic,
-		ID:        msg.ID,
-		ExecuteAt: req.SendAt.ToInt64(),
-		TTR:       5,
-		Body:      string(msgBytes),
+	if needApproval {
+		msgBytes, marshalErr := json.Marshal(req)
+		if marshalErr != nil {
+			err = errors.WithStack(marshalErr)
+			return
+		}
+		err = o.approvalSrv.Submit(rule, msg, len(MassMsgStaffs), creator, string(msgBytes))
+		if err != nil {
+			return
+		}
+		return o.massMsgRepo.Get(msg.ID)
 	}
-	err = delay_queue.Add(job)
+
+	// 推消息
+	err = addMassMsgJob(msg.ID, req)
 	if err != nil {
-		err = errors.WithStack(err)
 		return
 	}
 
@@ -121,9 +148,14 @@ func (o MassMsgService) Create(req requests.SendMassMsgReq, creator, extCorpID s
 }
 
 // UpdateMassMsg
-// 不支持删除立即发送消息
+// 不支持删除立即发送消息，修改后重新匹配审批规则，命中时需重新审批后再推送到发送队列
 func (o MassMsgService) UpdateMassMsg(
-	req requests.UpdateMassMsgReq, id string, name string, extCorpID string) (msg models.MassMsg, err error) {
+	req requests.UpdateMassMsgReq, id string, creator models.Staff) (msg models.MassMsg, err error) {
+	extCorpID := creator.ExtCorpID
+	err = ValidateMsgVariants(req.Variants)
+	if err != nil {
+		return
+	}
 
 	massMsg, err := o.massMsgRepo.Get(id)
 	if err != nil {
@@ -139,37 +171,24 @@ func (o MassMsgService) UpdateMassMsg(
 		err = ecode.EarlierThanNowError
 		return
 	}
-	// 更新延迟发送的消息
-	msgBytes, err := json.Marshal(req)
-	if err != nil {
-		err = errors.WithStack(err)
-		return
-	}
 
-	job := delay_queue.Job{
-		Topic:     constants.MassMsgTopic,
-		ID:        id,
-		ExecuteAt: req.SendAt.ToInt64(),
-		TTR:       5,
-		Body:      string(msgBytes),
-	}
-	err = delay_queue.Add(job)
-	if err != nil {
-		err = errors.WithStack(err)
-		return
-	}
 	// 接口不能更新消息发送人员统计数据
 	msg = models.MassMsg{
-		ExtCorpModel:            models.ExtCorpModel{ID: id, ExtCorpID: extCorpID, ExtCreatorID: name},
+		ExtCorpModel:            models.ExtCorpModel{ID: id, ExtCorpID: extCorpID, ExtCreatorID: creator.Name},
 		SendType:                req.SendType,
 		ExtStaffIDs:             req.ExtStaffIDs,
 		ExtDepartmentIDs:        req.ExtDepartmentIDs,
 		Msg:                     req.Msg,
+		Variants:                req.Variants,
 		MissionStatus:           constants.NotActive,
 		ExtCustomerFilter:       req.ExtCustomerFilter,
 		ExtCustomerFilterEnable: req.ExtCustomerFilterEnable,
 		SendAt:                  req.SendAt,
 	}
+	// 取消A/B测试时也要更新变体字段
+	if msg.Variants == nil {
+		msg.Variants = constants.MsgVariants{}
+	}
 	if msg.SendType == constants.Instant {
 		msg.MissionStatus = constants.Sending
 	}
@@ -186,17 +205,32 @@ func (o MassMsgService) UpdateMassMsg(
 		return
 	}
 
+	staffsCustomers, err = o.applyQuotaPolicy(staffsCustomers, req.QuotaPolicy, req.SendAt, extCorpID)
+	if err != nil {
+		return
+	}
+
 	MassMsgStaffs := make([]models.MassMsgStaff, 0)
 	for _, staffCustomer := range staffsCustomers {
 		MassMsgStaffs = append(MassMsgStaffs,
 			models.MassMsgStaff{
-				ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCreatorID: name, ExtCorpID: extCorpID},
+				ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCreatorID: creator.Name, ExtCorpID: extCorpID},
 				ExtStaffID:    staffCustomer.ExtStaffID,
 				ExtCustomerID: staffCustomer.ExtCustomerID,
 				MassMsgID:     id,
+				VariantKey:    assignMassMsgVariantKey(id, staffCustomer.ExtCustomerID, req.Variants),
 			})
 	}
 
+	// 修改内容或发送对象后重新匹配审批规则，已审批通过的群发也需要重新审批
+	rule, needApproval, err := o.approvalSrv.MatchRule(len(MassMsgStaffs), creator.RoleType, extCorpID)
+	if err != nil {
+		return
+	}
+	if needApproval {
+		msg.MissionStatus = constants.Pending
+	}
+
 	msg.Staffs = MassMsgStaffs
 
 	err = o.massMsgRepo.Update(msg)
@@ -204,6 +238,42 @@ func (o MassMsgService) UpdateMassMsg(
 		err = errors.WithStack(err)
 		return
 	}
+
+	sendReq := requests.SendMassMsgReq{
+		ExtStaffIDs:             req.ExtStaffIDs,
+		SendType:                req.SendType,
+		SendAt:                  req.SendAt,
+		ExtDepartmentIDs:        req.ExtDepartmentIDs,
+		ExtCustomerFilterEnable: req.ExtCustomerFilterEnable,
+		ExtCustomerFilter:       req.ExtCustomerFilter,
+		QuotaPolicy:             req.QuotaPolicy,
+		Msg:                     req.Msg,
+		Variants:                req.Variants,
+	}
+	if needApproval {
+		// 待审批期间不能发送，从发送队列中移除原来的定时任务
+		err = delay_queue.Remove(id)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+		msgBytes, marshalErr := json.Marshal(sendReq)
+		if marshalErr != nil {
+			err = errors.WithStack(marshalErr)
+			return
+		}
+		err = o.approvalSrv.Submit(rule, msg, len(MassMsgStaffs), creator, string(msgBytes))
+		if err != nil {
+			return
+		}
+		return o.massMsgRepo.Get(id)
+	}
+
+	// 更新延迟发送的消息
+	err = addMassMsgJob(id, sendReq)
+	if err != nil {
+		return
+	}
 	msg, err = o.massMsgRepo.Get(id)
 	return
 }
@@ -226,6 +296,14 @@ func (o MassMsgService) DeleteTimedMassMsg(ids []string) error {
 			return err
 		}
 
+		// 待审批的群发同时关闭审批单
+		if msg.MissionStatus == constants.Pending {
+			err = o.approvalSrv.Cancel(msg.ID)
+			if err != nil {
+				return err
+			}
+		}
+
 		err = delay_queue.Remove(msg.ID)
 		if err != nil {
 			err = errors.WithStack(err)
@@ -235,6 +313,107 @@ func (o MassMsgService) DeleteTimedMassMsg(ids []string) error {
 	return nil
 }
 
+// Cancel
+// Description: 取消群发，任意状态均可取消
+// Detail:
+//  先将群发任务置为已取消，尚未提交的批次不再推送到企微；
+//  待审批的关闭审批单；未推送到企微的从发送队列中移除；
+//  已推送到企微的先同步执行结果，再逐个停止企微群发，尚未发送的员工将无法再发送；
+//  仍未发送的客户标记为已取消。单个群发失败时继续处理其余群发，最后返回部分失败
+func (o MassMsgService) Cancel(ids []string, extCorpID string) error {
+	msgs := make([]models.MassMsg, 0, len(ids))
+	for _, id := range ids {
+		msg, err := o.massMsgRepo.Get(id)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if msg.ExtCorpID != extCorpID {
+			return errors.WithStack(ecode.ForbiddenError)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	failedIDs := make([]string, 0)
+	for _, msg := range msgs {
+		if msg.MissionStatus == constants.Deleted || msg.MissionStatus == constants.Sent {
+			continue
+		}
+		err := o.cancel(msg)
+		if err != nil {
+			log.Sugar.Errorw("cancel mass msg failed", "err", err, "msgID", msg.ID)
+			failedIDs = append(failedIDs, msg.ID)
+		}
+	}
+	if len(failedIDs) > 0 {
+		return errors.Wrapf(ecode.MassMsgCancelPartialFailedErr, "failed mass msg ids: %v", failedIDs)
+	}
+	return nil
+}
+
+// cancel 取消单个群发，停止企微群发失败时仍会取消其余企微群发和未发送的客户
+func (o MassMsgService) cancel(msg models.MassMsg) error {
+	err := o.massMsgRepo.UpdateMissionStatus(msg.ID, constants.Deleted)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var cancelErr error
+	switch msg.MissionStatus {
+	case constants.Pending:
+		cancelErr = o.approvalSrv.Cancel(msg.ID)
+	case constants.NotActive:
+		cancelErr = delay_queue.Remove(msg.ID)
+	case constants.Sending:
+		cancelErr = o.cancelWxMassMsg(msg)
+	}
+
+	err = o.MassMsgStaffRepo.CancelUnsent(msg.ID)
+	if err != nil {
+		return err
+	}
+	if msg.MissionStatus == constants.Sending {
+		err = o.refreshStatistic(msg.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(cancelErr)
+}
+
+// cancelWxMassMsg 同步已推送群发的执行结果后，停止全部企微群发，返回停止失败的企微群发
+func (o MassMsgService) cancelWxMassMsg(msg models.MassMsg) error {
+	senders, err := o.MassMsgStaffRepo.QueryMsgUnfinishedSenders(msg.ID)
+	if err != nil {
+		return err
+	}
+	err = o.syncSenders(senders, msg.ExtCorpID)
+	if err != nil {
+		log.Sugar.Errorw("syncSenders failed", "err", err, "msgID", msg.ID)
+	}
+
+	client, err := we_work.Clients.Get(msg.ExtCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// 群发已置为已取消，重新读取以包含取消前刚提交的企微群发
+	latest, err := o.massMsgRepo.Get(msg.ID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	failedExtMsgIDs := make([]string, 0)
+	for _, extMsgID := range funk.UniqString(latest.ExtMsgIDs) {
+		err = client.Customer.CancelGroupMsgSend(extMsgID)
+		if err != nil {
+			log.Sugar.Errorw("CancelGroupMsgSend failed", "err", err, "msgID", msg.ID, "extMsgID", extMsgID)
+			failedExtMsgIDs = append(failedExtMsgIDs, extMsgID)
+		}
+	}
+	if len(failedExtMsgIDs) > 0 {
+		return errors.Errorf("CancelGroupMsgSend failed, ext_msg_ids: %v", failedExtMsgIDs)
+	}
+	return nil
+}
+
 func (o MassMsgService) Get(msgID, extCorpID string) (res responses.MassMsgDetail, err error) {
 	msg, err := o.massMsgRepo.Get(msgID)
 	if err != nil {
@@ -247,8 +426,9 @@ func (o MassMsgService) Get(msgID, extCorpID string) (res responses.MassMsgDetai
 
 // GetSendMassMsgResult
 // Description:  查询群发结果
-// Detail:
-func (o MassMsgService) GetSendMassMsgResult(missionID string) (*requests.SendMassMsgResp, error) {
+// Detail: 返回群发的整体统计、每个员工的发送情况和分页的客户发送状态
+func (o MassMsgService) GetSendMassMsgResult(
+	missionID string, req requests.QueryMassMsgResultReq) (*requests.SendMassMsgResp, error) {
 	customer := models.Customer{}
 	msg, err := customer.GetMassMsg(missionID)
 	resp := &requests.SendMassMsgResp{MissionID: missionID, MissionStatus: constants.Sending}
@@ -264,22 +444,270 @@ func (o MassMsgService) GetSendMassMsgResult(missionID string) (*requests.SendMa
 	resp.SuccessNum = msg.SuccessNum
 	resp.UnDeliveredNum = msg.UnDeliveredNum
 	resp.FailedNum = msg.FailedNum
+
+	resp.Staffs, err = o.MassMsgStaffRepo.QueryStaffResult(missionID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Customers, resp.CustomerTotal, err = o.MassMsgStaffRepo.QueryCustomerResult(missionID, req, &req.Pager)
+	if err != nil {
+		return nil, err
+	}
+
 	return resp, nil
 }
 
-// SendMassMsgToWx
-// Description: 延迟队列发送消息到wx
-// Detail:
-//	延迟队列的消息->req->we_work request
-//  同一个企业每个自然月内仅可针对一个客户/客户群发送4条消息，超过接收上限的客户将无法再收到群发消息。
-func (o MassMsgService) SendMassMsgToWx(body string, msgID string) (extMsgID string, err error) {
-	log.Sugar.Debug(body)
+// QueryVariantStats
+// Description: 查询群发各内容变体的送达和转化数据
+func (o MassMsgService) QueryVariantStats(
+	id string, req requests.QueryMsgVariantStatsReq, extCorpID string) ([]requests.MsgVariantStat, error) {
+	msg, err := o.massMsgRepo.Get(id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if msg.ExtCorpID != extCorpID {
+		return nil, errors.WithStack(ecode.ForbiddenError)
+	}
+	if len(msg.Variants) == 0 {
+		return []requests.MsgVariantStat{}, nil
+	}
+	if req.Days == 0 {
+		req.Days = constants.MsgVariantDefaultDays
+	}
+
+	stats, err := o.variantStatRepo.QueryMassMsgVariantStats(msg, req.Days)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMsgVariantStats(msg.Variants, stats), nil
+}
+
+// SyncSendResult
+// Description: 从企微分页拉取每个发送人的群发执行结果，更新客户的发送状态和群发统计
+// Detail: 只处理发送中且仍有客户未发送的群发，所有员工都处理完后群发状态变为发送成功
+func (o MassMsgService) SyncSendResult(extCorpID string) error {
+	senders, err := o.MassMsgStaffRepo.QueryUnfinishedSenders(extCorpID)
+	if err != nil {
+		return err
+	}
+	return o.syncSenders(senders, extCorpID)
+}
+
+// syncSenders 拉取发送人的企微群发执行结果，更新客户发送状态、群发次数和群发统计
+func (o MassMsgService) syncSenders(senders []models.MassMsgSender, extCorpID string) error {
+	if len(senders) == 0 {
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	massMsgIDs := make(map[string]bool)
+	for _, sender := range senders {
+		statusCustomers := make(map[constants.MassMsgSendStatus][]string)
+		// 客户收到群发的月份
+		customerMonths := make(map[string]string)
+		req := gowx.GetGroupMsgSendResultExternalContactReq{
+			Msgid:  sender.ExtMsgID,
+			Userid: sender.ExtStaffID,
+			Limit:  1000,
+		}
+		for {
+			res, err := client.Customer.GetGroupMsgSendResultExternalContact(req)
+			if err != nil {
+				log.Sugar.Errorw("GetGroupMsgSendResultExternalContact failed", "err", err, "sender", sender)
+				break
+			}
+
+			for _, item := range res.SendList {
+				if item.ExternalUserid == "" {
+					continue
+				}
+				status := constants.MassMsgSendStatus(item.Status)
+				statusCustomers[status] = append(statusCustomers[status], item.ExternalUserid)
+				sendTime := time.Now()
+				if item.SendTime > 0 {
+					sendTime = time.Unix(int64(item.SendTime), 0)
+				}
+				customerMonths[item.ExternalUserid] = sendTime.Format(constants.MassMsgQuotaMonthLayout)
+			}
+
+			if res.NextCursor == "" {
+				break
+			}
+			req.Cursor = res.NextCursor
+		}
+
+		for status, extCustomerIDs := range statusCustomers {
+			if status == constants.MassMsgUnsent {
+				continue
+			}
+			extCustomerIDs, err = o.MassMsgStaffRepo.FilterUnsentCustomers(sender.MassMsgID, sender.ExtStaffID, extCustomerIDs)
+			if err != nil {
+				return err
+			}
+			if len(extCustomerIDs) == 0 {
+				continue
+			}
+			err = o.MassMsgStaffRepo.UpdateSendStatus(sender.MassMsgID, sender.ExtStaffID, extCustomerIDs, status)
+			if err != nil {
+				return err
+			}
+			err = o.updateQuota(extCorpID, status, extCustomerIDs, customerMonths)
+			if err != nil {
+				return err
+			}
+		}
+		massMsgIDs[sender.MassMsgID] = true
+	}
+
+	for massMsgID := range massMsgIDs {
+		err = o.refreshStatistic(massMsgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateQuota 根据客户的发送状态更新客户当月的群发次数
+func (o MassMsgService) updateQuota(
+	extCorpID string, status constants.MassMsgSendStatus, extCustomerIDs []string, customerMonths map[string]string) error {
+	if status != constants.MassMsgSent && status != constants.MassMsgFailedOverQuota {
+		return nil
+	}
+
+	monthCustomers := make(map[string][]string)
+	for _, extCustomerID := range extCustomerIDs {
+		month := customerMonths[extCustomerID]
+		monthCustomers[month] = append(monthCustomers[month], extCustomerID)
+	}
+
+	for month, ids := range monthCustomers {
+		var err error
+		if status == constants.MassMsgSent {
+			err = o.quotaRepo.IncrReceivedNum(extCorpID, month, ids)
+		} else {
+			err = o.quotaRepo.MarkExhausted(extCorpID, month, ids)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshStatistic 根据客户的发送状态重新计算群发统计
+func (o MassMsgService) refreshStatistic(massMsgID string) error {
+	staffResults, err := o.MassMsgStaffRepo.QueryStaffResult(massMsgID)
+	if err != nil {
+		return err
+	}
+
+	msg := models.MassMsg{ExtCorpModel: models.ExtCorpModel{ID: massMsgID}, MissionStatus: constants.Sending}
+	for _, item := range staffResults {
+		msg.SuccessNum += int(item.Sent)
+		// 只统计已确定失败或取消的客户，未发送的客户仍可能发送成功
+		msg.FailedNum += int(item.FailedNotFriend + item.FailedOverQuota + item.Canceled + item.SubmitFailed)
+		if item.Unsent > 0 {
+			msg.UnDeliveredNum++
+		} else {
+			msg.DeliveredNum++
+		}
+	}
+	if msg.UnDeliveredNum == 0 {
+		msg.MissionStatus = constants.Sent
+	}
+
+	return o.massMsgRepo.UpdateStatistic(msg)
+}
+
+// DispatchMassMsg
+// Description: 群发到达发送时间后，将发送人分批，每批作为单独的任务推送到发送队列
+// Detail: 批次任务串行消费，按配置的速率创建企微群发，避免触发企微频率限制
+func (o MassMsgService) DispatchMassMsg(body string, msgID string) error {
 	req := requests.SendMassMsgReq{}
-	err = json.Unmarshal([]byte(body), &req)
+	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
 		log.Sugar.Error("unmarshal group msg failed", err)
-		return
+		return err
+	}
+	// 定时发送可能被删，已入队的群发可能被取消
+	msg, err := o.massMsgRepo.Get(msgID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Sugar.Info("msg not found, msgID", msgID)
+			return nil
+		}
+		log.Sugar.Error("Get failed", err)
+		return err
 	}
+	if msg.MissionStatus == constants.Deleted {
+		log.Sugar.Info("msg has been canceled", req)
+		return nil
+	}
+	// 修改后需重新审批的群发，审批通过前不发送
+	if msg.MissionStatus == constants.Pending || msg.MissionStatus == constants.Rejected {
+		log.Sugar.Infow("msg is waiting for approval", "msgID", msgID, "status", msg.MissionStatus)
+		return nil
+	}
+
+	batchSize := conf.Settings.WeWork.MassMsgBatchSize
+	if batchSize <= 0 {
+		batchSize = constants.MassMsgDefaultBatchSize
+	}
+	batchNum, err := o.MassMsgStaffRepo.AssignBatches(msgID, batchSize)
+	if err != nil {
+		return err
+	}
+	log.Sugar.Infow("AssignBatches result", "msgID", msgID, "batchNum", batchNum)
+
+	err = o.massMsgRepo.UpdateMissionStatus(msgID, constants.Sending)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for batchNo := 1; batchNo <= batchNum; batchNo++ {
+		jobBytes, err := json.Marshal(requests.MassMsgBatchJob{MassMsgID: msgID, BatchNo: batchNo, Req: req})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		job := delay_queue.Job{
+			Topic:     constants.MassMsgBatchTopic,
+			ID:        fmt.Sprintf("%s%s:%d", constants.MassMsgBatchJobPrefix, msgID, batchNo),
+			ExecuteAt: time.Now().Unix(),
+			TTR:       constants.MassMsgBatchTTR,
+			Body:      string(jobBytes),
+		}
+		err = delay_queue.Add(job)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// 没有待提交的发送人时直接更新群发结果
+	if batchNum == 0 {
+		return o.refreshSubmitResult(msgID)
+	}
+	return nil
+}
+
+// SendMassMsgToWx
+// Description: 发送一批群发到wx
+// Detail:
+//	同一个企业每个自然月内仅可针对一个客户/客户群发送4条消息，超过接收上限的客户将无法再收到群发消息。
+//  每个发送人单独创建企微群发，按配置的速率调用，部分发送人失败时不影响其他发送人
+//  创建成功的发送人记录提交断点，批次失败重试时只提交剩余的发送人，重试次数用尽后标记为提交失败
+//  消息文本包含模板变量时，同一发送人按渲染结果拆分为多条企微群发
+//  设置了内容变体时，同一发送人的每个变体各自创建企微群发
+func (o MassMsgService) SendMassMsgToWx(batch requests.MassMsgBatchJob, failedCount int64) (err error) {
+	log.Sugar.Debugw("SendMassMsgToWx", "batch", batch, "failedCount", failedCount)
+	req := batch.Req
+	msgID := batch.MassMsgID
 	template := gowx.AddMsgTemplateReq{}
 	err = copier.Copy(&template, req)
 	if err != nil {
@@ -287,45 +715,54 @@ func (o MassMsgService) SendMassMsgToWx(body string, msgID string) (extMsgID str
 		return
 	}
 
-	template.Text = gowx.Text{Content: req.Msg.Text}
-	err = copier.CopyWithOption(&template.Attachments, req.Msg.Attachments, copier.Option{
-		IgnoreEmpty: true,
-		DeepCopy:    true,
-	})
+	template.Attachments, err = ToWxAttachments(req.Msg.Attachments, conf.Settings.WeWork.ExtCorpID)
 	if err != nil {
-		err = errors.WithStack(err)
 		return
 	}
-	// 定时发送可能被删
-	if req.SendType == constants.Timed {
-		msg, err := o.massMsgRepo.Get(msgID)
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				log.Sugar.Info("msg not found, msgID", msgID)
-				return "", err
-			}
-			log.Sugar.Error("Get failed", err)
-			return "", err
-		}
-		if msg.MissionStatus == constants.Deleted {
-			log.Sugar.Info("timed msg has been deleted", req)
-			return "", err
+	msg, err := o.massMsgRepo.Get(msgID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Sugar.Info("msg not found, msgID", msgID)
+			return nil
 		}
+		log.Sugar.Error("Get failed", err)
+		return err
+	}
+	if msg.MissionStatus == constants.Deleted {
+		log.Sugar.Info("msg has been canceled", msgID)
+		return nil
 	}
 
-	// 创建时保存了谁发给谁，这里只发送.
-	staffCustomers, err := o.massMsgRepo.GetExtStaffIDs(msgID)
+	// 创建时保存了谁发给谁，这里只发送本批尚未提交的.
+	staffCustomers, err := o.MassMsgStaffRepo.GetBatchStaffsCustomers(msgID, batch.BatchNo)
 	if err != nil {
-		err = errors.WithStack(err)
 		return
 	}
-	log.Sugar.Infow("GetExtStaffIDs result", "msgID", msgID, "count", len(staffCustomers), "staffCustomers", staffCustomers)
+	log.Sugar.Infow("GetBatchStaffsCustomers result", "msgID", msgID, "batchNo", batch.BatchNo, "count", len(staffCustomers))
+
+	// 变体标识->消息内容，未分配变体的客户发送req.Msg
+	variantMsgs := map[string]constants.AutoReplyField{"": req.Msg}
+	variantAttachments := map[string][]gowx.Attachments{"": template.Attachments}
+	for _, variant := range req.Variants {
+		variantMsgs[variant.Key] = variant.Msg
+		variantAttachments[variant.Key], err = ToWxAttachments(variant.Msg.Attachments, conf.Settings.WeWork.ExtCorpID)
+		if err != nil {
+			return
+		}
+	}
 
-	// Map 员工->客户列表
-	staffCustomerMap := map[string][]string{}
+	// Map 员工->变体->客户列表
+	staffCustomerMap := map[string]map[string][]string{}
 	for _, StaffCustomer := range staffCustomers {
 		extStaffID := StaffCustomer.ExtStaffID
-		staffCustomerMap[extStaffID] = append(staffCustomerMap[extStaffID], StaffCustomer.ExtCustomerID)
+		variantKey := StaffCustomer.VariantKey
+		if _, ok := variantMsgs[variantKey]; !ok {
+			variantKey = ""
+		}
+		if staffCustomerMap[extStaffID] == nil {
+			staffCustomerMap[extStaffID] = map[string][]string{}
+		}
+		staffCustomerMap[extStaffID][variantKey] = append(staffCustomerMap[extStaffID][variantKey], StaffCustomer.ExtCustomerID)
 	}
 	log.Sugar.Infow("staffCustomerMap", "map", staffCustomerMap)
 
@@ -334,27 +771,130 @@ func (o MassMsgService) SendMassMsgToWx(body string, msgID string) (extMsgID str
 		err = errors.WithStack(err)
 		return
 	}
-	for extStaffID, extCustomerIDs := range staffCustomerMap {
-		if len(extCustomerIDs) <= 0 || extCustomerIDs[0] == "" {
-			log.Sugar.Warnw("skipping empty customer list", "extStaffID", extStaffID)
-			continue
+	sendRate := conf.Settings.WeWork.MassMsgSendRate
+	if sendRate <= 0 {
+		sendRate = constants.MassMsgDefaultSendRate
+	}
+	limiter := time.NewTicker(time.Second / time.Duration(sendRate))
+	defer limiter.Stop()
+
+	msgTemplate := NewMsgTemplate()
+	for extStaffID, variantCustomers := range staffCustomerMap {
+		for variantKey, extCustomerIDs := range variantCustomers {
+			if len(extCustomerIDs) <= 0 || extCustomerIDs[0] == "" {
+				log.Sugar.Warnw("skipping empty customer list", "extStaffID", extStaffID, "variantKey", variantKey)
+				continue
+			}
+			// 每个变体单独创建企微群发
+			template.Attachments = variantAttachments[variantKey]
+			sendErr := o.addMsgTemplates(
+				client, limiter.C, msgTemplate, template, msgID, extStaffID, variantMsgs[variantKey].Text, extCustomerIDs)
+			if sendErr != nil {
+				err = sendErr
+			}
+		}
+	}
+
+	if err != nil && failedCount+1 >= constants.MassMsgBatchMaxRetry {
+		log.Sugar.Errorw("mass msg batch retry exhausted", "msgID", msgID, "batchNo", batch.BatchNo, "err", err)
+		err = o.MassMsgStaffRepo.SetBatchSubmitFailed(msgID, batch.BatchNo)
+		if err != nil {
+			return
+		}
+	}
+
+	refreshErr := o.refreshSubmitResult(msgID)
+	if refreshErr != nil {
+		log.Sugar.Errorw("refreshSubmitResult failed", "err", refreshErr, "msgID", msgID)
+	}
+
+	// 返回错误时批次任务按指数退避重试
+	return err
+}
+
+// refreshSubmitResult
+// Description: 汇总群发已创建的企微消息ID，所有发送人都提交失败时群发置为失败
+// Detail: 没有待提交的发送人且部分提交成功时重新计算统计，提交失败的客户计为失败，已提交的都发送后群发完成
+func (o MassMsgService) refreshSubmitResult(msgID string) error {
+	extMsgIDs, err := o.MassMsgStaffRepo.QueryExtMsgIDs(msgID)
+	if err != nil {
+		return err
+	}
+	pending, submitted, err := o.MassMsgStaffRepo.CountSubmitStatus(msgID)
+	if err != nil {
+		return err
+	}
+
+	msg := models.MassMsg{ExtCorpModel: models.ExtCorpModel{ID: msgID}, ExtMsgIDs: extMsgIDs}
+	if len(extMsgIDs) > 0 {
+		msg.ExtMsgID = extMsgIDs[0]
+	}
+	if pending == 0 && submitted == 0 {
+		msg.MissionStatus = constants.Failed
+	}
+	res := models.DB.Where("id = ? and mission_status = ?", msgID, constants.Sending).Updates(&msg)
+	if res.Error != nil {
+		return errors.WithStack(res.Error)
+	}
+	if res.RowsAffected > 0 && pending == 0 && submitted > 0 {
+		return o.refreshStatistic(msgID)
+	}
+	return nil
+}
+
+// addMsgTemplates
+// Description: 为一个发送人创建企微群发
+// Detail: 文本包含模板变量时按客户渲染，渲染结果相同的客户合并为一条企微群发；每次调用前等待限速
+func (o MassMsgService) addMsgTemplates(
+	client we_work.Client, limiter <-chan time.Time, msgTemplate *MsgTemplate, template gowx.AddMsgTemplateReq,
+	msgID string, extStaffID string, text string, extCustomerIDs []string) (err error) {
+	texts, renderErr := msgTemplate.RenderForCustomers(
+		text, conf.Settings.WeWork.ExtCorpID, extStaffID, extCustomerIDs)
+	if renderErr != nil {
+		log.Sugar.Errorw("RenderForCustomers failed", "err", renderErr, "sender", extStaffID)
+		texts = make(map[string]string, len(extCustomerIDs))
+		for _, extCustomerID := range extCustomerIDs {
+			texts[extCustomerID] = RenderMsgTemplate(text, nil)
 		}
+	}
+	textCustomers := make(map[string][]string)
+	for _, extCustomerID := range extCustomerIDs {
+		text := texts[extCustomerID]
+		textCustomers[text] = append(textCustomers[text], extCustomerID)
+	}
+
+	for text, receivers := range textCustomers {
 		template.Sender = extStaffID
-		template.ExternalUserid = staffCustomerMap[extStaffID]
+		template.ExternalUserid = receivers
+		template.Text = gowx.Text{Content: text}
 
-		log.Sugar.Infow("Calling AddMsgTemplate", "sender", extStaffID, "customerCount", len(extCustomerIDs), "template", util.JsonEncode(template))
+		log.Sugar.Infow("Calling AddMsgTemplate", "sender", extStaffID, "customerCount", len(receivers), "template", util.JsonEncode(template))
 
+		<-limiter
 		//同一个企业每个自然月内仅可针对一个客户/客户群发送4条消息，超过接收上限的客户将无法再收到群发消息
 		//接受消息的userid列表中每个id接收者都已收到超过4条消息, 则会返回 no customer to send 错误
-		extMsgID, _, err = client.Customer.AddMsgTemplate(template)
-		if err != nil {
-			log.Sugar.Errorw("AddMsgTemplate failed", "error", err, "sender", extStaffID)
-			return
+		extMsgID, failList, sendErr := client.Customer.AddMsgTemplate(template)
+		if sendErr != nil {
+			log.Sugar.Errorw("AddMsgTemplate failed", "error", sendErr, "sender", extStaffID)
+			err = sendErr
+			continue
 		}
 		log.Sugar.Infow("AddMsgTemplate success", "extMsgID", extMsgID, "sender", extStaffID)
-	}
 
-	return extMsgID, err
+		dbErr := o.MassMsgStaffRepo.SetExtMsgID(msgID, extStaffID, receivers, extMsgID)
+		if dbErr != nil {
+			log.Sugar.Errorw("SetExtMsgID failed", "err", dbErr, "msgID", msgID, "sender", extStaffID)
+		}
+
+		// 无效或无法发送的客户不会出现在执行结果中，直接标记为发送失败
+		if len(failList) > 0 {
+			dbErr = o.MassMsgStaffRepo.UpdateSendStatus(msgID, extStaffID, failList, constants.MassMsgFailedNotFriend)
+			if dbErr != nil {
+				log.Sugar.Errorw("UpdateSendStatus failed", "err", dbErr, "msgID", msgID, "sender", extStaffID)
+			}
+		}
+	}
+	return
 }
 
 // Notify
@@ -430,3 +970,83 @@ func (o MassMsgService) GetStaffsCustomers(
 	}
 	return
 }
+
+// CustomerFilter
+// Description: 群发客户筛选预览，统计筛选出的客户数及其中本月群发次数已达上限的数量
+func (o MassMsgService) CustomerFilter(
+	filterEnable constants.Boolean, filter constants.ExtCustomerFilter, extCorpID string) (
+	res requests.MassMsgCustomerFilterResp, err error) {
+	staffsCustomers, total, err := o.GetStaffsCustomers([]string{}, filterEnable, filter)
+	if err != nil {
+		return
+	}
+	res.Total = total
+
+	exhausted, err := o.exhaustedCustomers(staffsCustomers, time.Now(), extCorpID)
+	if err != nil {
+		return
+	}
+	for _, staffCustomer := range staffsCustomers {
+		if exhausted[staffCustomer.ExtCustomerID] {
+			res.ExhaustedNum++
+		}
+	}
+	return
+}
+
+// applyQuotaPolicy 按处理方式处理本月群发次数已达上限的客户
+func (o MassMsgService) applyQuotaPolicy(
+	staffsCustomers []models.StaffsCustomers, policy constants.MassMsgQuotaPolicy,
+	sendAt constants.DateTimeFiled, extCorpID string) ([]models.StaffsCustomers, error) {
+	if policy == constants.MassMsgQuotaIgnore {
+		return staffsCustomers, nil
+	}
+
+	sendTime := time.Now()
+	if sendAt.ToInt64() > 0 {
+		sendTime = time.Unix(sendAt.ToInt64(), 0)
+	}
+	exhausted, err := o.exhaustedCustomers(staffsCustomers, sendTime, extCorpID)
+	if err != nil {
+		return nil, err
+	}
+	if len(exhausted) == 0 {
+		return staffsCustomers, nil
+	}
+	if policy == constants.MassMsgQuotaWarn {
+		return nil, ecode.MassMsgQuotaExhaustedErr
+	}
+
+	res := make([]models.StaffsCustomers, 0, len(staffsCustomers))
+	for _, staffCustomer := range staffsCustomers {
+		if !exhausted[staffCustomer.ExtCustomerID] {
+			res = append(res, staffCustomer)
+		}
+	}
+	if len(res) == 0 {
+		return nil, ecode.NoMassMsgReceiversErr
+	}
+	return res, nil
+}
+
+// exhaustedCustomers 查询客户中发送时间所在月份群发次数已达上限的客户
+func (o MassMsgService) exhaustedCustomers(
+	staffsCustomers []models.StaffsCustomers, sendTime time.Time, extCorpID string) (map[string]bool, error) {
+	extCustomerIDs := make([]string, 0, len(staffsCustomers))
+	for _, staffCustomer := range staffsCustomers {
+		extCustomerIDs = append(extCustomerIDs, staffCustomer.ExtCustomerID)
+	}
+	extCustomerIDs = funk.UniqString(extCustomerIDs)
+
+	ids, err := o.quotaRepo.QueryExhaustedCustomerIDs(
+		extCorpID, sendTime.Format(constants.MassMsgQuotaMonthLayout), extCustomerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		res[id] = true
+	}
+	return res, nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/delay_queue"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	gowx "openscrm/pkg/easywework"
+	"time"
+)
+
+type MassMsgApprovalService struct {
+	ruleRepo    models.MassMsgApprovalRule
+	repo        models.MassMsgApproval
+	massMsgRepo models.MassMsg
+}
+
+func NewMassMsgApprovalService() *MassMsgApprovalService {
+	return &MassMsgApprovalService{
+		ruleRepo:    models.MassMsgApprovalRule{},
+		repo:        models.MassMsgApproval{},
+		massMsgRepo: models.MassMsg{},
+	}
+}
+
+func (o MassMsgApprovalService) QueryRules(
+	extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.MassMsgApprovalRule, int64, error) {
+	return o.ruleRepo.Query(extCorpID, sorter, pager)
+}
+
+func (o MassMsgApprovalService) CreateRule(
+	req requests.CreateMassMsgApprovalRuleReq, extStaffID string, extCorpID string) (item models.MassMsgApprovalRule, err error) {
+	item = models.MassMsgApprovalRule{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extStaffID},
+	}
+	fillApprovalRule(&item, req)
+	err = o.ruleRepo.Create(item)
+	return
+}
+
+func (o MassMsgApprovalService) UpdateRule(
+	id string, req requests.UpdateMassMsgApprovalRuleReq, extCorpID string) (item models.MassMsgApprovalRule, err error) {
+	item, err = o.ruleRepo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	fillApprovalRule(&item, req.CreateMassMsgApprovalRuleReq)
+	err = o.ruleRepo.Update(item)
+	return
+}
+
+func (o MassMsgApprovalService) DeleteRules(ids []string, extCorpID string) error {
+	return o.ruleRepo.Delete(ids, extCorpID)
+}
+
+func fillApprovalRule(item *models.MassMsgApprovalRule, req requests.CreateMassMsgApprovalRuleReq) {
+	item.Name = req.Name
+	item.Enable = req.Enable
+	item.MinCustomerNum = req.MinCustomerNum
+	item.CreatorRoleTypes = req.CreatorRoleTypes
+	item.ApproverExtStaffIDs = req.ApproverExtStaffIDs
+	item.Mode = req.Mode
+	item.OATemplateID = req.OATemplateID
+	item.OAControlID = req.OAControlID
+	item.ApprovalURL = req.ApprovalURL
+}
+
+func (o MassMsgApprovalService) Query(
+	req requests.QueryMassMsgApprovalReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.MassMsgApproval, int64, error) {
+	return o.repo.Query(req, extCorpID, sorter, pager)
+}
+
+// MatchRule 查找群发命中的第一条审批规则
+func (o MassMsgApprovalService) MatchRule(
+	customerNum int, creatorRoleType string, extCorpID string) (rule models.MassMsgApprovalRule, matched bool, err error) {
+	rules, err := o.ruleRepo.QueryEnabled(extCorpID)
+	if err != nil {
+		return
+	}
+	for _, rule = range rules {
+		if rule.Match(customerNum, creatorRoleType) {
+			matched = true
+			return
+		}
+	}
+	return
+}
+
+// Submit
+// Description: 为待审批的群发创建审批单，并通知审批人或提交企业微信审批
+// Detail: body为审批通过后推送到发送队列的群发请求
+func (o MassMsgApprovalService) Submit(
+	rule models.MassMsgApprovalRule, msg models.MassMsg, customerNum int, creator models.Staff, body string) error {
+	item := models.MassMsgApproval{
+		ExtCorpModel:        models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: msg.ExtCorpID, ExtCreatorID: creator.ExtID},
+		MassMsgID:           msg.ID,
+		RuleID:              rule.ID,
+		Mode:                rule.Mode,
+		CreatorExtStaffID:   creator.ExtID,
+		ApproverExtStaffIDs: rule.ApproverExtStaffIDs,
+		CustomerNum:         customerNum,
+		Status:              constants.MassMsgApprovalPending,
+		Body:                body,
+	}
+	err := o.repo.Create(item)
+	if err != nil {
+		return err
+	}
+
+	if rule.Mode == constants.MassMsgApprovalOA {
+		err = o.submitOA(item.ID, rule, msg, customerNum, creator)
+		if err != nil {
+			// 提交失败的群发无法再被审批，关闭审批单并将群发置为失败
+			if cancelErr := o.repo.CancelByMassMsgID(msg.ID); cancelErr != nil {
+				log.Sugar.Errorw("CancelByMassMsgID failed", "err", cancelErr, "mass_msg_id", msg.ID)
+			}
+			if statusErr := o.massMsgRepo.UpdateMissionStatus(msg.ID, constants.Failed); statusErr != nil {
+				log.Sugar.Errorw("UpdateMissionStatus failed", "err", statusErr)
+			}
+			return err
+		}
+		return nil
+	}
+
+	client, err := we_work.Clients.Get(msg.ExtCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	recipient := gowx.Recipient{UserIDs: rule.ApproverExtStaffIDs}
+	desc := fmt.Sprintf(constants.MassMsgApprovalNotifyDesc, creator.Name, msg.SendAt, customerNum, msg.Msg.Text)
+	if rule.ApprovalURL != "" {
+		err = client.MainApp.SendTextCardMessage(&recipient, constants.MassMsgApprovalNotifyTitle, desc, rule.ApprovalURL, "去审批", false)
+	} else {
+		err = client.MainApp.SendTextMessage(&recipient, fmt.Sprintf("%s\n创建人：%s\n发送时间：%s\n将群发给%d个客户：%s",
+			constants.MassMsgApprovalNotifyTitle, creator.Name, msg.SendAt, customerNum, msg.Msg.Text), false)
+	}
+	if err != nil {
+		// 通知失败不影响审批，审批人仍可在审批列表中处理
+		log.Sugar.Errorw("notify mass msg approvers failed", "err", err, "mass_msg_id", msg.ID)
+	}
+	return nil
+}
+
+// submitOA 提交企业微信审批，并记录审批单号
+func (o MassMsgApprovalService) submitOA(
+	id string, rule models.MassMsgApprovalRule, msg models.MassMsg, customerNum int, creator models.Staff) error {
+	client, err := we_work.Clients.Get(msg.ExtCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	spNo, err := client.MainApp.ApplyOAEvent(gowx.OAApplyEvent{
+		CreatorUserID: creator.ExtID,
+		TemplateID:    rule.OATemplateID,
+		Approver:      []gowx.OAApprover{{Attr: 1, UserID: rule.ApproverExtStaffIDs}},
+		ApplyData: gowx.OAContents{Contents: []gowx.OAContent{{
+			Control: gowx.OAControlTextarea,
+			ID:      rule.OAControlID,
+			Value:   gowx.OAContentValue{Text: msg.Msg.Text},
+		}}},
+		SummaryList: []gowx.OASummaryList{
+			{SummaryInfo: []gowx.OAText{{Text: fmt.Sprintf("群发客户数：%d", customerNum), Lang: "zh_CN"}}},
+			{SummaryInfo: []gowx.OAText{{Text: fmt.Sprintf("发送时间：%s", msg.SendAt), Lang: "zh_CN"}}},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "ApplyOAEvent failed")
+	}
+	return o.repo.SetSpNo(id, spNo)
+}
+
+// Approve 审批人在本系统中通过群发
+func (o MassMsgApprovalService) Approve(id string, req requests.HandleMassMsgApprovalReq, approver models.Staff) error {
+	item, err := o.getForApprover(id, approver)
+	if err != nil {
+		return err
+	}
+	return o.finish(item, constants.MassMsgApprovalApproved, approver.ExtID, req.Comment)
+}
+
+// Reject 审批人在本系统中驳回群发
+func (o MassMsgApprovalService) Reject(id string, req requests.HandleMassMsgApprovalReq, approver models.Staff) error {
+	item, err := o.getForApprover(id, approver)
+	if err != nil {
+		return err
+	}
+	return o.finish(item, constants.MassMsgApprovalRejected, approver.ExtID, req.Comment)
+}
+
+func (o MassMsgApprovalService) getForApprover(id string, approver models.Staff) (item models.MassMsgApproval, err error) {
+	item, err = o.repo.Get(id, approver.ExtCorpID)
+	if err != nil {
+		return
+	}
+	if item.Mode == constants.MassMsgApprovalOA {
+		err = ecode.MassMsgApprovalInOAErr
+		return
+	}
+	if item.Status != constants.MassMsgApprovalPending {
+		err = ecode.MassMsgApprovalHandledErr
+		return
+	}
+	for _, extStaffID := range item.ApproverExtStaffIDs {
+		if extStaffID == approver.ExtID {
+			return
+		}
+	}
+	err = ecode.NotMassMsgApproverErr
+	return
+}
+
+// Cancel 群发取消时关闭待审批的审批单，之后的审批操作和企业微信审批结果均被忽略
+func (o MassMsgApprovalService) Cancel(massMsgID string) error {
+	return o.repo.CancelByMassMsgID(massMsgID)
+}
+
+// HandleOAApproval
+// Description: 处理企业微信审批状态变化，同步审批结果
+// Detail: 非群发的审批单、审批中或已处理的审批单直接忽略
+func (o MassMsgApprovalService) HandleOAApproval(info gowx.OAApprovalInfo, extCorpID string) error {
+	var status constants.MassMsgApprovalStatus
+	switch info.SpStatus {
+	case constants.OASpStatusApproved:
+		status = constants.MassMsgApprovalApproved
+	case constants.OASpStatusRejected, constants.OASpStatusCanceled:
+		status = constants.MassMsgApprovalRejected
+	default:
+		return nil
+	}
+
+	item, err := o.repo.GetBySpNo(info.SpNo, extCorpID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if item.Status != constants.MassMsgApprovalPending {
+		return nil
+	}
+
+	extApproverID, comment := info.Applicant.UserID, ""
+	for _, record := range info.SpRecord {
+		for _, detail := range record.Details {
+			if detail.SpStatus == info.SpStatus {
+				extApproverID, comment = detail.Approver.UserID, detail.Speech
+			}
+		}
+	}
+
+	err = o.finish(item, status, extApproverID, comment)
+	if errors.Is(err, ecode.MassMsgApprovalHandledErr) {
+		return nil
+	}
+	return err
+}
+
+// finish 记录审批结果，通过的群发推送到发送队列，驳回的群发置为已驳回，并通知创建人
+func (o MassMsgApprovalService) finish(
+	item models.MassMsgApproval, status constants.MassMsgApprovalStatus, extApproverID string, comment string) error {
+	now := time.Now()
+	item.Status = status
+	item.ExtApproverID = extApproverID
+	item.Comment = comment
+	item.ApprovedAt = &now
+	ok, err := o.repo.Finish(item)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(ecode.MassMsgApprovalHandledErr)
+	}
+
+	if status == constants.MassMsgApprovalApproved {
+		err = o.enqueue(item)
+	} else {
+		err = o.massMsgRepo.UpdateMissionStatus(item.MassMsgID, constants.Rejected)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	o.notifyCreator(item)
+	return nil
+}
+
+// enqueue 审批通过后推送群发到发送队列，发送时间已过的立即发送
+func (o MassMsgApprovalService) enqueue(item models.MassMsgApproval) error {
+	req := requests.SendMassMsgReq{}
+	err := json.Unmarshal([]byte(item.Body), &req)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal mass msg req failed")
+	}
+
+	// 写redis比写DB快，等待2秒后让db写完,执行任务才能读到数据
+	earliest := time.Now().Add(2 * time.Second)
+	if req.SendAt.ToInt64() < earliest.Unix() {
+		req.SendAt = constants.DateTimeFiled(earliest.Format(constants.DateTimeLayout))
+	}
+
+	err = o.massMsgRepo.UpdateMissionStatus(item.MassMsgID, constants.NotActive)
+	if err != nil {
+		return err
+	}
+	return addMassMsgJob(item.MassMsgID, req)
+}
+
+func (o MassMsgApprovalService) notifyCreator(item models.MassMsgApproval) {
+	if item.CreatorExtStaffID == "" {
+		return
+	}
+	client, err := we_work.Clients.Get(item.ExtCorpID)
+	if err != nil {
+		log.Sugar.Errorw("get we_work client failed", "err", err)
+		return
+	}
+
+	result := "已通过审批，将按时发送"
+	if item.Status == constants.MassMsgApprovalRejected {
+		result = "已被驳回"
+	}
+	content := fmt.Sprintf(constants.MassMsgApprovalResultMsg, item.CreatedAt.Format(constants.DateTimeLayout), result, item.Comment)
+	err = client.MainApp.SendTextMessage(&gowx.Recipient{UserIDs: []string{item.CreatorExtStaffID}}, content, false)
+	if err != nil {
+		log.Sugar.Errorw("notify mass msg creator failed", "err", err, "mass_msg_id", item.MassMsgID)
+	}
+}
+
+// addMassMsgJob 推送群发到发送队列，在req.SendAt时发送
+func addMassMsgJob(massMsgID string, req requests.SendMassMsgReq) error {
+	msgBytes, err := json.Marshal(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	job := delay_queue.Job{
+		Topic:     constants.MassMsgTopic,
+		ID:        massMsgID,
+		ExecuteAt: req.SendAt.ToInt64(),
+		TTR:       5,
+		Body:      string(msgBytes),
+	}
+	return errors.WithStack(delay_queue.Add(job))
+}
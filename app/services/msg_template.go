@@ -0,0 +1,163 @@
+package services
+
+import (
+	"github.com/pkg/errors"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// msgTemplatePattern 消息模板变量，如{{customer_name}}、{{customer_name|朋友}}
+var msgTemplatePattern = regexp.MustCompile(`\{\{\s*([^{}|\s]+)\s*(?:\|([^{}]*))?\}\}`)
+
+// HasMsgTemplateVars 文本中是否包含模板变量
+func HasMsgTemplateVars(text string) bool {
+	return msgTemplatePattern.MatchString(text)
+}
+
+// RenderMsgTemplate 用变量值替换文本中的模板变量，变量值为空时使用默认值
+func RenderMsgTemplate(text string, vars map[string]string) string {
+	return msgTemplatePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		match := msgTemplatePattern.FindStringSubmatch(placeholder)
+		if value := strings.TrimSpace(vars[match[1]]); value != "" {
+			return value
+		}
+		return match[2]
+	})
+}
+
+type MsgTemplate struct {
+	customerStaffRepo models.CustomerStaff
+	staffRepo         models.Staff
+	remarkRepo        models.CustomerRemark
+}
+
+func NewMsgTemplate() *MsgTemplate {
+	return &MsgTemplate{
+		customerStaffRepo: models.CustomerStaff{},
+		staffRepo:         models.Staff{},
+		remarkRepo:        models.CustomerRemark{},
+	}
+}
+
+// Render 为员工的单个客户渲染消息文本
+func (o MsgTemplate) Render(text string, extCorpID string, extStaffID string, extCustomerID string) (string, error) {
+	texts, err := o.RenderForCustomers(text, extCorpID, extStaffID, []string{extCustomerID})
+	if err != nil {
+		return RenderMsgTemplate(text, nil), err
+	}
+	return texts[extCustomerID], nil
+}
+
+// RenderForCustomers
+// Description: 为员工的每个客户渲染消息文本，返回客户ID->文本
+// Detail: 文本不含变量时不查询数据；找不到客户数据的客户按默认值渲染
+func (o MsgTemplate) RenderForCustomers(
+	text string, extCorpID string, extStaffID string, extCustomerIDs []string) (res map[string]string, err error) {
+	res = make(map[string]string, len(extCustomerIDs))
+	if !HasMsgTemplateVars(text) {
+		for _, extCustomerID := range extCustomerIDs {
+			res[extCustomerID] = text
+		}
+		return
+	}
+
+	staffVars := make(map[string]string)
+	staff, err := o.staffRepo.Get(extStaffID, extCorpID, false)
+	if err != nil {
+		return
+	}
+	staffVars[constants.MsgVarStaffName] = staff.Name
+	staffVars[constants.MsgVarStaffAlias] = staff.Alias
+
+	remarks, err := o.remarkRepo.Get(extCorpID)
+	if err != nil {
+		err = errors.Wrap(err, "Get customer remarks failed")
+		return
+	}
+
+	items, err := o.customerStaffRepo.QueryTemplateData(extStaffID, extCustomerIDs)
+	if err != nil {
+		return
+	}
+	customerVars := make(map[string]map[string]string, len(items))
+	for _, item := range items {
+		vars := customerTemplateVars(item, remarks)
+		for k, v := range staffVars {
+			vars[k] = v
+		}
+		customerVars[item.ExtCustomerID] = vars
+	}
+
+	for _, extCustomerID := range extCustomerIDs {
+		vars, ok := customerVars[extCustomerID]
+		if !ok {
+			vars = staffVars
+		}
+		res[extCustomerID] = RenderMsgTemplate(text, vars)
+	}
+	return
+}
+
+// Preview 为示例客户渲染消息文本，未指定客户时取员工的任意一个客户
+func (o MsgTemplate) Preview(req requests.PreviewMsgTemplateReq, extCorpID string) (res string, err error) {
+	extCustomerID := req.ExtCustomerID
+	if extCustomerID == "" {
+		relation, relationErr := o.customerStaffRepo.Get(models.CustomerStaff{
+			ExtCorpModel: models.ExtCorpModel{ExtCorpID: extCorpID},
+			ExtStaffID:   req.ExtStaffID,
+		})
+		if relationErr != nil {
+			return RenderMsgTemplate(req.Text, nil), nil
+		}
+		extCustomerID = relation.ExtCustomerID
+	}
+
+	return o.Render(req.Text, extCorpID, req.ExtStaffID, extCustomerID)
+}
+
+// customerTemplateVars 客户数据转为模板变量
+func customerTemplateVars(item models.CustomerTemplateData, remarks []*models.CustomerRemark) map[string]string {
+	corpName := item.RemarkCorpName
+	if corpName == "" {
+		corpName = item.CustomerCorpName
+	}
+	vars := map[string]string{
+		constants.MsgVarCustomerName:     item.CustomerName,
+		constants.MsgVarCustomerRemark:   item.Remark,
+		constants.MsgVarCustomerCorpName: corpName,
+		constants.MsgVarCustomerDesc:     item.Description,
+
+		constants.MsgVarInfoPrefix + "email":        item.Email,
+		constants.MsgVarInfoPrefix + "phone_number": item.PhoneNumber,
+		constants.MsgVarInfoPrefix + "qq":           item.QQ,
+		constants.MsgVarInfoPrefix + "address":      item.Address,
+		constants.MsgVarInfoPrefix + "birthday":     item.Birthday,
+		constants.MsgVarInfoPrefix + "weibo":        item.Weibo,
+		constants.MsgVarInfoPrefix + "description":  item.InfoDescription,
+	}
+	if item.Age > 0 {
+		vars[constants.MsgVarInfoPrefix+"age"] = strconv.Itoa(item.Age)
+	}
+
+	values := make(map[string]string, len(item.RemarkField))
+	for _, field := range item.RemarkField {
+		values[field.RemarkID] = field.RemarkValue
+	}
+	for _, remark := range remarks {
+		value := values[remark.ID]
+		if remark.FieldType == constants.RemarkFieldTypeOption {
+			for _, option := range remark.Options {
+				if option.ID == value {
+					value = strings.TrimSpace(option.Name)
+					break
+				}
+			}
+		}
+		vars[constants.MsgVarRemarkPrefix+strings.TrimSpace(remark.Name)] = value
+	}
+	return vars
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"hash/fnv"
+	"openscrm/app/constants"
+	"openscrm/app/requests"
+	"openscrm/common/ecode"
+)
+
+// ValidateMsgVariants 校验内容变体，至少两个变体，标识不重复且比例之和为100
+func ValidateMsgVariants(variants constants.MsgVariants) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) < 2 || len(variants) > constants.MsgVariantMaxNum {
+		return ecode.InvalidMsgVariantErr
+	}
+
+	keys := make(map[string]bool, len(variants))
+	total := 0
+	for _, variant := range variants {
+		if variant.Key == "" || keys[variant.Key] || variant.Percent <= 0 {
+			return ecode.InvalidMsgVariantErr
+		}
+		keys[variant.Key] = true
+		total += variant.Percent
+	}
+	if total != 100 {
+		return ecode.InvalidMsgVariantErr
+	}
+	return nil
+}
+
+// AssignMsgVariant
+// Description: 按比例为客户分配内容变体
+// Detail: 以消息ID和客户ID取哈希，同一消息中同一客户总是分到同一变体，不同消息之间互不影响
+func AssignMsgVariant(seed string, extCustomerID string, variants constants.MsgVariants) constants.MsgVariant {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(seed + ":" + extCustomerID))
+	bucket := int(h.Sum32() % 100)
+
+	for _, variant := range variants {
+		if bucket < variant.Percent {
+			return variant
+		}
+		bucket -= variant.Percent
+	}
+	return variants[len(variants)-1]
+}
+
+// assignMassMsgVariantKey 群发客户分配到的变体标识，未设置变体时为空
+func assignMassMsgVariantKey(massMsgID string, extCustomerID string, variants constants.MsgVariants) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	return AssignMsgVariant(massMsgID, extCustomerID, variants).Key
+}
+
+// mergeMsgVariantStats 按消息的变体顺序输出统计，没有数据的变体补0
+func mergeMsgVariantStats(variants constants.MsgVariants, stats []requests.MsgVariantStat) []requests.MsgVariantStat {
+	statMap := make(map[string]requests.MsgVariantStat, len(stats))
+	for _, stat := range stats {
+		statMap[stat.Key] = stat
+	}
+
+	res := make([]requests.MsgVariantStat, 0, len(variants))
+	for _, variant := range variants {
+		stat := statMap[variant.Key]
+		stat.Key = variant.Key
+		stat.Name = variant.Name
+		stat.Percent = variant.Percent
+		res = append(res, stat)
+	}
+	return res
+}
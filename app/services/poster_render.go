@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/common/ecode"
+	"openscrm/common/storage"
+	"openscrm/conf"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// loadStorageImage 从文件存储读取并解码图片
+func loadStorageImage(objectKey string) (image.Image, error) {
+	content, err := storage.FileStorage.Get(objectKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "get file from storage failed")
+	}
+	defer content.Close()
+
+	img, _, err := image.Decode(content)
+	if err != nil {
+		return nil, errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+	return img, nil
+}
+
+// downloadImage 下载并解码网络图片，如渠道码二维码、员工头像
+func downloadImage(client *resty.Client, url string) (image.Image, error) {
+	res, err := client.R().Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "download image failed")
+	}
+	if res.IsError() {
+		return nil, errors.Errorf("download image failed, status: %d", res.StatusCode())
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(res.Body()))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode image failed")
+	}
+	return img, nil
+}
+
+// renderPoster 在背景图上绘制二维码、员工头像和员工名称，返回png数据
+// Detail: avatar为nil或模板未设置头像位置时不绘制头像，staffName为空或模板未设置名称位置时不绘制名称
+func renderPoster(
+	background image.Image, tpl models.PosterTemplate, qrCode image.Image, avatar image.Image, staffName string) ([]byte, error) {
+	bounds := background.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), background, bounds.Min, draw.Src)
+
+	slot := tpl.QrCodeSlot
+	draw.Draw(canvas, slotRect(slot), scaleImage(qrCode, slot.Size), image.Point{}, draw.Over)
+
+	slot = tpl.AvatarSlot
+	if avatar != nil && slot.Size > 0 {
+		scaled := scaleImage(avatar, slot.Size)
+		if tpl.AvatarRound == constants.True {
+			draw.DrawMask(canvas, slotRect(slot), scaled, image.Point{}, circleMask{size: slot.Size}, image.Point{}, draw.Over)
+		} else {
+			draw.Draw(canvas, slotRect(slot), scaled, image.Point{}, draw.Over)
+		}
+	}
+
+	if staffName != "" && tpl.NameSlot.FontSize > 0 {
+		err := drawText(canvas, tpl.NameSlot, staffName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, canvas)
+	if err != nil {
+		return nil, errors.Wrap(err, "png.Encode failed")
+	}
+	return buf.Bytes(), nil
+}
+
+func slotRect(slot constants.PosterSlotField) image.Rectangle {
+	return image.Rect(slot.X, slot.Y, slot.X+slot.Size, slot.Y+slot.Size)
+}
+
+// scaleImage 双线性插值，把图片缩放为边长为size的正方形
+func scaleImage(src image.Image, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+var (
+	posterFont     *sfnt.Font
+	posterFontErr  error
+	posterFontOnce sync.Once
+)
+
+// loadPosterFont 读取配置的海报字体，只解析一次
+func loadPosterFont() (*sfnt.Font, error) {
+	posterFontOnce.Do(func() {
+		if conf.Settings.App.PosterFontPath == "" {
+			posterFontErr = errors.WithStack(ecode.PosterFontNotConfiguredErr)
+			return
+		}
+		data, err := os.ReadFile(conf.Settings.App.PosterFontPath)
+		if err != nil {
+			posterFontErr = errors.Wrap(err, "read poster font failed")
+			return
+		}
+		posterFont, err = opentype.Parse(data)
+		if err != nil {
+			posterFontErr = errors.Wrap(err, "parse poster font failed")
+		}
+	})
+	return posterFont, posterFontErr
+}
+
+// drawText 以slot为左上角绘制单行文字
+func drawText(canvas draw.Image, slot constants.PosterTextSlotField, text string) error {
+	f, err := loadPosterFont()
+	if err != nil {
+		return err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: float64(slot.FontSize), DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return errors.Wrap(err, "opentype.NewFace failed")
+	}
+	defer face.Close()
+
+	drawer := font.Drawer{
+		Dst:  canvas,
+		Src:  image.NewUniform(parseHexColor(slot.Color)),
+		Face: face,
+		Dot:  fixed.P(slot.X, slot.Y).Add(fixed.Point26_6{Y: face.Metrics().Ascent}),
+	}
+	drawer.DrawString(text)
+	return nil
+}
+
+// parseHexColor 解析#RGB或#RRGGBB格式的颜色，格式不正确时为黑色
+func parseHexColor(s string) color.RGBA {
+	c := color.RGBA{A: 255}
+	if s == "" {
+		s = constants.PosterDefaultTextColor
+	}
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 6 {
+		return c
+	}
+	c.R, c.G, c.B = uint8(v>>16), uint8(v>>8), uint8(v)
+	return c
+}
+
+// circleMask 圆形遮罩，用于把头像裁剪为圆形
+type circleMask struct {
+	size int
+}
+
+func (o circleMask) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (o circleMask) Bounds() image.Rectangle {
+	return image.Rect(0, 0, o.size, o.size)
+}
+
+func (o circleMask) At(x, y int) color.Color {
+	r := float64(o.size) / 2
+	dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+	if dx*dx+dy*dy <= r*r {
+		return color.Alpha{A: 255}
+	}
+	return color.Alpha{}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"image"
+	"net/http"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/app/responses"
+	"openscrm/common/app"
+	"openscrm/common/ecode"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/storage"
+	"path"
+	"strings"
+	"time"
+)
+
+type PosterTemplate struct {
+	model            models.PosterTemplate
+	staffQrCodeModel models.ContactWayStaffQrCode
+	httpClient       *resty.Client
+}
+
+func NewPosterTemplate() *PosterTemplate {
+	return &PosterTemplate{
+		model:            models.PosterTemplate{},
+		staffQrCodeModel: models.ContactWayStaffQrCode{},
+		httpClient:       resty.New().SetRetryCount(1).SetTimeout(time.Second * 15),
+	}
+}
+
+func (o *PosterTemplate) Query(
+	req requests.QueryPosterTemplateReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.PosterTemplate, int64, error) {
+	return o.model.Query(req, extCorpID, sorter, pager)
+}
+
+func (o *PosterTemplate) Get(id string, extCorpID string) (models.PosterTemplate, error) {
+	return o.model.Get(id, extCorpID)
+}
+
+func (o *PosterTemplate) Create(
+	req requests.CreatePosterTemplateReq, extCorpID string, extCreatorID string) (item models.PosterTemplate, err error) {
+	item = models.PosterTemplate{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
+		Name:         req.Name,
+		Background:   req.Background,
+		QrCodeSlot:   req.QrCodeSlot,
+		AvatarSlot:   req.AvatarSlot,
+		AvatarRound:  req.AvatarRound,
+		NameSlot:     req.NameSlot,
+	}
+	err = o.checkTemplate(&item)
+	if err != nil {
+		return
+	}
+
+	err = o.model.Create(item)
+	return
+}
+
+func (o *PosterTemplate) Update(
+	id string, req requests.UpdatePosterTemplateReq, extCorpID string) (item models.PosterTemplate, err error) {
+	item, err = o.model.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	item.Name = req.Name
+	item.Background = req.Background
+	item.QrCodeSlot = req.QrCodeSlot
+	item.AvatarSlot = req.AvatarSlot
+	item.AvatarRound = req.AvatarRound
+	item.NameSlot = req.NameSlot
+	err = o.checkTemplate(&item)
+	if err != nil {
+		return
+	}
+
+	err = o.model.Update(item)
+	return
+}
+
+func (o *PosterTemplate) Delete(ids []string, extCorpID string) error {
+	return o.model.Delete(ids, extCorpID)
+}
+
+// checkTemplate 读取背景图尺寸，校验二维码、头像和名称不超出背景图，绘制名称时需配置字体
+func (o *PosterTemplate) checkTemplate(item *models.PosterTemplate) error {
+	ext := strings.ToLower(path.Ext(item.Background))
+	if !storage.IsValidObjectKey(item.Background) || !funk.ContainsString([]string{".png", ".jpg", ".jpeg"}, ext) {
+		return errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+
+	content, err := storage.FileStorage.Get(item.Background)
+	if err != nil {
+		return errors.Wrap(err, "get background from storage failed")
+	}
+	defer content.Close()
+
+	config, _, err := image.DecodeConfig(content)
+	if err != nil {
+		return errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+	if config.Width > constants.PosterMaxBackgroundSize || config.Height > constants.PosterMaxBackgroundSize {
+		return errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+	item.Width = config.Width
+	item.Height = config.Height
+
+	if item.QrCodeSlot.Size == 0 {
+		return errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+	for _, slot := range []constants.PosterSlotField{item.QrCodeSlot, item.AvatarSlot} {
+		if slot.X+slot.Size > item.Width || slot.Y+slot.Size > item.Height {
+			return errors.WithStack(ecode.InvalidPosterTemplateErr)
+		}
+	}
+
+	if item.NameSlot.FontSize > 0 {
+		if item.NameSlot.X+item.NameSlot.FontSize > item.Width || item.NameSlot.Y+item.NameSlot.FontSize > item.Height {
+			return errors.WithStack(ecode.InvalidPosterTemplateErr)
+		}
+		_, err = loadPosterFont()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate
+// Description: 按模板生成渠道码海报，保存到文件存储并返回下载地址
+func (o *PosterTemplate) Generate(
+	id string, req requests.GeneratePosterReq, extCorpID string) ([]responses.ContactWayPoster, error) {
+	return o.render(id, req, extCorpID, func(poster *responses.ContactWayPoster, fileName string, data []byte) error {
+		err := storage.FileStorage.Put(poster.ObjectKey, bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrap(err, "put poster to storage failed")
+		}
+		poster.URL, err = storage.FileStorage.SignURL(poster.ObjectKey, http.MethodGet, 86400*7)
+		if err != nil {
+			return errors.Wrap(err, "SignURL failed")
+		}
+		return nil
+	})
+}
+
+// Download
+// Description: 按模板生成渠道码海报，打包为zip下载
+func (o *PosterTemplate) Download(
+	id string, req requests.GeneratePosterReq, extCorpID string) (buf *bytes.Buffer, filename string, err error) {
+	buf = &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	_, err = o.render(id, req, extCorpID, func(poster *responses.ContactWayPoster, fileName string, data []byte) error {
+		w, err := writer.Create(fileName)
+		if err != nil {
+			return errors.Wrap(err, "zip Create failed")
+		}
+		_, err = w.Write(data)
+		return errors.Wrap(err, "zip Write failed")
+	})
+	if err != nil {
+		return
+	}
+
+	err = writer.Close()
+	if err != nil {
+		err = errors.Wrap(err, "zip Close failed")
+		return
+	}
+
+	filename = fmt.Sprintf("渠道码海报-%s.zip", time.Now().Format("20060102150405"))
+	return
+}
+
+// render 逐个渲染渠道码海报，每张海报渲染后交给fn保存
+// Detail: 按员工生成时为渠道码的每个接待员工各生成一张，二维码为该员工的单人二维码，并绘制员工的头像和名称
+//
+//	海报在请求中同步渲染，背景图尺寸和单次生成的海报数均有上限
+func (o *PosterTemplate) render(id string, req requests.GeneratePosterReq, extCorpID string,
+	fn func(poster *responses.ContactWayPoster, fileName string, data []byte) error) ([]responses.ContactWayPoster, error) {
+	tpl, err := o.model.Get(id, extCorpID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 限制背景图尺寸前创建的模板需重新上传背景图
+	if tpl.Width > constants.PosterMaxBackgroundSize || tpl.Height > constants.PosterMaxBackgroundSize {
+		return nil, errors.WithStack(ecode.InvalidPosterTemplateErr)
+	}
+
+	contactWays := make([]models.ContactWay, 0)
+	err = models.DB.Model(&models.ContactWay{}).Preload("Staffs").
+		Where("ext_corp_id = ? and id in (?)", extCorpID, req.ContactWayIDs).
+		Find(&contactWays).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Find ContactWay failed")
+	}
+	if len(contactWays) == 0 {
+		return nil, errors.WithStack(ecode.ItemNotFoundError)
+	}
+
+	total := 0
+	for _, contactWay := range contactWays {
+		if req.PerStaff == constants.True && len(contactWay.Staffs) > 0 {
+			total += len(contactWay.Staffs)
+		} else {
+			total++
+		}
+	}
+	if total > constants.PosterMaxPerRequest {
+		return nil, errors.WithStack(ecode.TooManyPostersErr)
+	}
+
+	background, err := loadStorageImage(tpl.Background)
+	if err != nil {
+		return nil, err
+	}
+
+	posters := make([]responses.ContactWayPoster, 0)
+	for _, contactWay := range contactWays {
+		if contactWay.QrCode == "" {
+			log.Sugar.Warnw("contact way has no qr code", "contactWayID", contactWay.ID)
+			continue
+		}
+		// 已失效的渠道码企微配置已删除，二维码无法使用
+		if contactWay.Status > constants.ContactWayStatusActive {
+			log.Sugar.Warnw("contact way is inactive", "contactWayID", contactWay.ID)
+			continue
+		}
+		// 不按员工生成时只生成一张，不绘制头像和名称
+		staffs := []models.ContactWayStaff{{}}
+		if req.PerStaff == constants.True && len(contactWay.Staffs) > 0 {
+			staffs = contactWay.Staffs
+		}
+		for _, staff := range staffs {
+			// 已下线的员工不生成海报
+			if staff.ExtStaffID != "" && staff.Online == constants.False {
+				continue
+			}
+			qrCodeURL := contactWay.QrCode
+			if staff.ExtStaffID != "" {
+				staffQrCode, err := o.staffQrCodeModel.GetOrCreate(contactWay, staff.ExtStaffID)
+				if err != nil {
+					return nil, err
+				}
+				qrCodeURL = staffQrCode.QrCode
+			}
+			qrCode, err := downloadImage(o.httpClient, qrCodeURL)
+			if err != nil {
+				return nil, err
+			}
+
+			var avatar image.Image
+			if staff.AvatarURL != "" && tpl.AvatarSlot.Size > 0 {
+				avatar, err = downloadImage(o.httpClient, staff.AvatarURL)
+				if err != nil {
+					// 头像下载失败时不绘制头像
+					log.Sugar.Warnw("download avatar failed", "err", err, "extStaffID", staff.ExtStaffID)
+				}
+			}
+
+			data, err := renderPoster(background, tpl, qrCode, avatar, staff.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			poster := responses.ContactWayPoster{
+				ContactWayID: contactWay.ID,
+				ExtStaffID:   staff.ExtStaffID,
+				StaffName:    staff.Name,
+				ObjectKey:    o.objectKey(tpl.ID, contactWay.ID, staff.ExtStaffID),
+			}
+			fileName := contactWay.Name
+			if staff.Name != "" {
+				fileName += "-" + staff.Name
+			}
+			fileName = strings.NewReplacer("/", "_", "\\", "_").Replace(fileName) + "-" + path.Base(poster.ObjectKey)
+
+			err = fn(&poster, fileName, data)
+			if err != nil {
+				return nil, err
+			}
+			posters = append(posters, poster)
+		}
+	}
+
+	return posters, nil
+}
+
+// objectKey 海报在文件存储中的ObjectKey，重复生成时覆盖
+func (o *PosterTemplate) objectKey(templateID string, contactWayID string, extStaffID string) string {
+	name := contactWayID
+	if extStaffID != "" {
+		name += "-" + extStaffID
+	}
+	return fmt.Sprintf("%s%s/%s.png", constants.PosterStorageDir, templateID, name)
+}
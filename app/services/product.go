@@ -0,0 +1,327 @@
+package services
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/app"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/storage"
+	"openscrm/common/we_work"
+	gowx "openscrm/pkg/easywework"
+	"path"
+)
+
+// maxLinkTitleBytes 企微链接消息标题最多128个字节
+const maxLinkTitleBytes = 128
+
+type Product struct {
+	repo models.Product
+}
+
+func NewProduct() *Product {
+	return &Product{repo: models.Product{}}
+}
+
+func (o Product) Query(
+	req requests.QueryProductReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) ([]models.Product, int64, error) {
+	return o.repo.Query(req, extCorpID, sorter, pager)
+}
+
+func (o Product) Get(id string, extCorpID string) (models.Product, error) {
+	return o.repo.Get(id, extCorpID)
+}
+
+// Create
+// Description: 在企微创建商品图册并保存到本地
+// Detail: 商品图片上传为企微临时素材用于创建图册，封面另外上传为永久图片，用于作为消息附件发送
+func (o Product) Create(req requests.CreateProductReq, extCorpID string) (item models.Product, err error) {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	album, coverURL, err := o.toWxProductAlbum(client, req)
+	if err != nil {
+		return
+	}
+
+	extProductID, err := client.Customer.AddProductAlbum(album)
+	if err != nil {
+		err = errors.Wrap(err, "AddProductAlbum failed")
+		return
+	}
+
+	item = models.Product{
+		ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+		ExtProductID: extProductID,
+		Description:  req.Description,
+		Price:        req.Price,
+		ProductSN:    req.ProductSN,
+		Images:       req.Images,
+		CoverURL:     coverURL,
+		URL:          req.URL,
+	}
+	err = o.repo.Save(item)
+	return
+}
+
+// Update 更新企微和本地的商品图册
+func (o Product) Update(id string, req requests.UpdateProductReq, extCorpID string) (item models.Product, err error) {
+	item, err = o.repo.Get(id, extCorpID)
+	if err != nil {
+		return
+	}
+
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	album, coverURL, err := o.toWxProductAlbum(client, req.CreateProductReq)
+	if err != nil {
+		return
+	}
+
+	album.ProductID = item.ExtProductID
+	err = client.Customer.UpdateProductAlbum(album)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateProductAlbum failed")
+		return
+	}
+
+	item.Description = req.Description
+	item.Price = req.Price
+	item.ProductSN = req.ProductSN
+	item.Images = req.Images
+	item.CoverURL = coverURL
+	item.URL = req.URL
+	err = o.repo.Save(item)
+	return
+}
+
+// Delete 删除企微和本地的商品图册
+func (o Product) Delete(ids []string, extCorpID string) error {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, id := range ids {
+		item, err := o.repo.Get(id, extCorpID)
+		if err != nil {
+			return err
+		}
+
+		err = client.Customer.DeleteProductAlbum(item.ExtProductID)
+		if err != nil {
+			return errors.Wrap(err, "DeleteProductAlbum failed")
+		}
+	}
+
+	return o.repo.Delete(ids, extCorpID)
+}
+
+// Sync
+// Description: 从企微同步商品图册到本地
+// Detail: 企微只返回图片的临时素材ID，本地已有的商品仅更新描述、价格和编码，企微后台新建的商品需编辑补充图片后才能发送
+func (o Product) Sync(extCorpID string) error {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	localIDs, err := o.repo.GetExtProductIDs(extCorpID)
+	if err != nil {
+		return err
+	}
+
+	req := gowx.ProductAlbumListReq{Limit: 100}
+	for {
+		resp, err := client.Customer.GetProductAlbumList(req)
+		if err != nil {
+			return errors.Wrap(err, "GetProductAlbumList failed")
+		}
+
+		for _, album := range resp.ProductList {
+			item := models.Product{
+				ExtCorpModel: models.ExtCorpModel{ExtCorpID: extCorpID},
+				ExtProductID: album.ProductID,
+				Description:  album.Description,
+				Price:        album.Price,
+				ProductSN:    album.ProductSn,
+			}
+
+			if id, ok := localIDs[album.ProductID]; ok {
+				item.ID = id
+				delete(localIDs, album.ProductID)
+				err = o.repo.UpdateFromWx(item)
+			} else {
+				item.ID = id_generator.StringID()
+				item.Images = make(constants.StringArrayField, 0)
+				err = o.repo.Save(item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if resp.NextCursor == "" {
+			break
+		}
+		req.Cursor = resp.NextCursor
+	}
+
+	if len(localIDs) == 0 {
+		return nil
+	}
+
+	removedIDs := make([]string, 0, len(localIDs))
+	for _, id := range localIDs {
+		removedIDs = append(removedIDs, id)
+	}
+	return o.repo.Delete(removedIDs, extCorpID)
+}
+
+// toWxProductAlbum 上传商品图片到企微，返回企微商品图册及封面的永久图片URL
+func (o Product) toWxProductAlbum(client we_work.Client, req requests.CreateProductReq) (album gowx.ProductAlbum, coverURL string, err error) {
+	album = gowx.ProductAlbum{
+		Description: req.Description,
+		Price:       req.Price,
+		ProductSn:   req.ProductSN,
+		Attachments: make([]gowx.ProductAlbumAttachment, 0, len(req.Images)),
+	}
+
+	for i, obj := range req.Images {
+		var data []byte
+		data, err = o.readStorageObject(obj)
+		if err != nil {
+			return
+		}
+
+		var media *gowx.Media
+		media, err = gowx.NewMediaFromBuffer(path.Base(obj), data)
+		if err != nil {
+			return
+		}
+
+		var result *gowx.MediaUploadResult
+		result, err = client.Customer.UploadTempImageMedia(media)
+		if err != nil {
+			err = errors.Wrap(err, "UploadTempImageMedia failed")
+			return
+		}
+
+		attachment := gowx.ProductAlbumAttachment{Type: string(constants.ImageMsgType)}
+		attachment.Image.MediaID = result.MediaID
+		album.Attachments = append(album.Attachments, attachment)
+
+		if i == 0 {
+			// 上传素材会消费media中的reader，需重新创建
+			media, err = gowx.NewMediaFromBuffer(path.Base(obj), data)
+			if err != nil {
+				return
+			}
+
+			coverURL, err = client.Customer.UploadPermanentImageMedia(media)
+			if err != nil {
+				err = errors.Wrap(err, "UploadPermanentImageMedia failed")
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// readStorageObject 从文件存储读取图片
+func (o Product) readStorageObject(obj string) ([]byte, error) {
+	readCloser, err := storage.FileStorage.Get(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "FileStorage.Get failed")
+	}
+	defer readCloser.Close()
+
+	data, err := ioutil.ReadAll(readCloser)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadAll failed")
+	}
+
+	return data, nil
+}
+
+// ToWxAttachments
+// Description: 将消息附件转换为企微附件
+// Detail: 企微消息不支持商品图册，有详情链接的商品转换为链接消息，否则转换为封面图片，已删除或没有封面的商品会被忽略
+func ToWxAttachments(attachments constants.AttachmentArrayField, extCorpID string) ([]gowx.Attachments, error) {
+	productIDs := make([]string, 0)
+	for _, attachment := range attachments {
+		if attachment.MsgType == string(constants.ProductMsgType) {
+			productIDs = append(productIDs, attachment.Product.ProductID)
+		}
+	}
+
+	products := make(map[string]models.Product)
+	if len(productIDs) > 0 {
+		var err error
+		products, err = models.Product{}.GetByIDs(productIDs, extCorpID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]gowx.Attachments, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.MsgType != string(constants.ProductMsgType) {
+			res = append(res, attachment.Attachments)
+			continue
+		}
+
+		product, ok := products[attachment.Product.ProductID]
+		if !ok || product.CoverURL == "" {
+			log.Sugar.Warnw("product unavailable, skip attachment", "productID", attachment.Product.ProductID)
+			continue
+		}
+
+		if product.URL == "" {
+			res = append(res, gowx.Attachments{
+				MsgType: string(constants.ImageMsgType),
+				Image:   gowx.Image{PicURL: product.CoverURL},
+			})
+			continue
+		}
+
+		res = append(res, gowx.Attachments{
+			MsgType: string(constants.LinkMsgType),
+			Link: gowx.Link{
+				Title:  truncateBytes(product.Description, maxLinkTitleBytes),
+				Desc:   fmt.Sprintf("¥%.2f", float64(product.Price)/100),
+				PicURL: product.CoverURL,
+				URL:    product.URL,
+			},
+		})
+	}
+
+	return res, nil
+}
+
+// truncateBytes 按字节截断字符串，不截断多字节字符
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	end := 0
+	for i := range s {
+		if i > max {
+			break
+		}
+		end = i
+	}
+	return s[:end]
+}
@@ -118,7 +118,11 @@ func (o Remainder) SendRemainderMsg(job delay_queue.Job) error {
 	if err != nil {
 		return err
 	}
-	content := fmt.Sprintf(constants.RemainderContent, req.ExtStaffID, req.CustomerName, remainder.Content)
+	text, err := NewMsgTemplate().Render(remainder.Content, remainder.ExtCorpID, remainder.ExtStaffID, remainder.ExtCustomerID)
+	if err != nil {
+		log.Sugar.Errorw("render remainder content failed", "err", err, "remainder", remainder.ID)
+	}
+	content := fmt.Sprintf(constants.RemainderContent, req.ExtStaffID, req.CustomerName, text)
 	err = client.MainApp.SendTextMessage(recipient, content, false)
 	if err != nil {
 		log.Sugar.Errorw("send msg to staff failed", "err", err)
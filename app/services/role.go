@@ -11,11 +11,13 @@ import (
 )
 
 type Role struct {
-	model models.Role
+	model        models.Role
+	strategyRepo models.CustomerStrategy
+	strategySrv  *CustomerStrategy
 }
 
 func NewRole() *Role {
-	return &Role{model: models.Role{}}
+	return &Role{model: models.Role{}, strategyRepo: models.CustomerStrategy{}, strategySrv: NewCustomerStrategy()}
 }
 
 func (o *Role) Query(req requests.QueryRoleReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []responses.Role, total int64, err error) {
@@ -74,6 +76,12 @@ func (o *Role) Get(id string) (item responses.Role, err error) {
 		return
 	}
 
+	item.CustomerStrategies, err = o.strategyRepo.GetByRoleIDs([]string{role.ID})
+	if err != nil {
+		err = errors.Wrap(err, "get CustomerStrategy failed")
+		return
+	}
+
 	return
 }
 
@@ -98,8 +106,28 @@ func (o *Role) Update(id string, param requests.UpdateRoleReq) (item models.Role
 	return o.model.Update(id, item)
 }
 
-func (o *Role) AssignToStaffs(extStaffIDs []string, roleID string) (total int64, err error) {
-	return o.model.AssignToStaffs(extStaffIDs, roleID)
+// AssignToStaffs 授权角色给员工，并同步绑定了新旧角色的客户联系规则组负责人
+func (o *Role) AssignToStaffs(extStaffIDs []string, roleID string, extCorpID string) (total int64, err error) {
+	roleIDs := make([]string, 0)
+	err = models.DB.Model(&models.Staff{}).Where("ext_corp_id = ? and ext_id in (?)", extCorpID, extStaffIDs).
+		Distinct().Pluck("role_id", &roleIDs).Error
+	if err != nil {
+		err = errors.Wrap(err, "Pluck staff roles failed")
+		return
+	}
+
+	total, err = o.model.AssignToStaffs(extStaffIDs, roleID)
+	if err != nil {
+		return
+	}
+
+	err = o.strategySrv.SyncRoleAdmins(extCorpID, append(roleIDs, roleID)...)
+	if err != nil {
+		err = errors.Wrap(err, "SyncRoleAdmins failed")
+		return
+	}
+
+	return
 }
 
 func (o *Role) QueryStaffs(req requests.QueryRoleStaffsReq, extCorpID string, sorter *app.Sorter, pager *app.Pager) (items []models.Staff, total int64, err error) {
@@ -35,6 +35,7 @@ type StaffService struct {
 	csRelationHistoryRepo models.CustomerStaffRelationHistory
 	EventRepo             models.CustomerEvent
 	CustomerRepo          models.Customer
+	variantRecordRepo     models.WelcomeMsgVariantRecord
 }
 
 func NewStaffService() *StaffService {
@@ -49,6 +50,7 @@ func NewStaffService() *StaffService {
 		csRelationHistoryRepo: models.CustomerStaffRelationHistory{},
 		CustomerRepo:          models.Customer{},
 		EventRepo:             models.CustomerEvent{},
+		variantRecordRepo:     models.WelcomeMsgVariantRecord{},
 	}
 }
 
@@ -358,6 +360,10 @@ func (o StaffService) UpdateCustomerTag(req requests.UpdateCustomerTagsReq, extS
 					err = errors.WithStack(err)
 					return err
 				}
+				err = NewCustomerSop().TriggerByTags(req.AddExtTagIDs, relation.ExtStaffID, extCustomerID, extCorpID)
+				if err != nil {
+					log.Sugar.Errorw("TriggerByTags failed", "err", err, "relationID", relation.ID)
+				}
 				// 记录事件流水
 				for _, extTagID := range req.RemoveExtTagIDs {
 					content := fmt.Sprintf(constants.AddTagEvent, staff.Name, customer.Name, tagsMap[extTagID].Name)
@@ -517,7 +523,7 @@ func (o StaffService) SyncStaffSignatures() error {
 // Description: 执行发送欢迎语的流程
 // Detail: 查DB中的欢迎语,发送到wx
 func (o StaffService) SendWelcomeMsg(
-	welcomeMsg constants.AutoReplyField, welcomeCode string, extCorpID string, extStaffID string) error {
+	welcomeMsg constants.AutoReplyField, welcomeCode string, extCorpID string, extStaffID string, extCustomerID string) error {
 
 	wxClient, err := we_work.Clients.Get(extCorpID)
 	if err != nil {
@@ -525,15 +531,18 @@ func (o StaffService) SendWelcomeMsg(
 		return err
 	}
 
-	attachments := make([]gowx.Attachments, len(welcomeMsg.Attachments))
-	err = copier.CopyWithOption(&attachments, welcomeMsg.Attachments, copier.Option{IgnoreEmpty: true, DeepCopy: true})
+	attachments, err := ToWxAttachments(welcomeMsg.Attachments, extCorpID)
 	if err != nil {
-		err = errors.WithStack(err)
 		return err
 	}
+	// 模板变量渲染失败时按默认值发送
+	text, err := NewMsgTemplate().Render(welcomeMsg.Text, extCorpID, extStaffID, extCustomerID)
+	if err != nil {
+		log.Sugar.Errorw("render welcome msg failed", "err", err, "extStaffID", extStaffID, "extCustomerID", extCustomerID)
+	}
 	req := gowx.SendWelcomeMsgReq{
 		Attachments: attachments,
-		Text:        gowx.Text{Content: welcomeMsg.Text},
+		Text:        gowx.Text{Content: text},
 		WelcomeCode: welcomeCode,
 	}
 
@@ -548,15 +557,78 @@ func (o StaffService) SendWelcomeMsg(
 
 // SendDefaultWelcomeMsg
 // Description: 发送默认欢迎语
-// Detail: 渠道码没有欢迎语,使用默认欢迎语
+// Detail: 渠道码没有欢迎语,使用默认欢迎语；设置了内容变体时按客户分配变体发送并记录
+//  渠道码的渠道欢迎语不经过这里，不参与A/B测试
+//  分时段欢迎语没有内容变体，生效时直接发送分时段内容，变体只在发送主欢迎语时生效
 func (o StaffService) SendDefaultWelcomeMsg(
-	welcomeCode string, extCorpID string, extStaffID string) error {
+	welcomeCode string, extCorpID string, extStaffID string, extCustomerID string) error {
 	welcomeMsg, err := o.staffRepo.GetWelcomeMsgByExtStaffID(extStaffID, extCorpID)
 	if err != nil {
 		log.Sugar.Errorw("s.staffRepo.GetWelcomeMsgByExtStaffID failed", "err", err)
 		return err
 	}
-	return o.SendWelcomeMsg(welcomeMsg.WelcomeMsg, welcomeCode, extCorpID, extStaffID)
+	periodMsg := o.selectTimePeriodMsg(welcomeMsg, extCorpID)
+	if periodMsg.ID != welcomeMsg.ID || len(welcomeMsg.Variants) == 0 {
+		return o.SendWelcomeMsg(periodMsg.WelcomeMsg, welcomeCode, extCorpID, extStaffID, extCustomerID)
+	}
+
+	variant := AssignMsgVariant(welcomeMsg.ID, extCustomerID, welcomeMsg.Variants)
+	err = o.SendWelcomeMsg(variant.Msg, welcomeCode, extCorpID, extStaffID, extCustomerID)
+	if err != nil {
+		return err
+	}
+	err = o.variantRecordRepo.Create(models.WelcomeMsgVariantRecord{
+		ExtCorpModel:  models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+		WelcomeMsgID:  welcomeMsg.ID,
+		VariantKey:    variant.Key,
+		ExtStaffID:    extStaffID,
+		ExtCustomerID: extCustomerID,
+		SentAt:        time.Now(),
+	})
+	if err != nil {
+		log.Sugar.Errorw("create welcome msg variant record failed", "err", err, "welcomeMsgID", welcomeMsg.ID)
+	}
+	return nil
+}
+
+// selectTimePeriodMsg 开启分时段欢迎语时，选出当前生效的分时段欢迎语，没有生效的时使用主欢迎语
+// Detail: 按企业日历判断星期，节假日不使用分时段欢迎语，调休上班日按指定的星期
+func (o StaffService) selectTimePeriodMsg(welcomeMsg models.WelcomeMsg, extCorpID string) models.WelcomeMsg {
+	if welcomeMsg.ID == "" || welcomeMsg.EnableTimePeriodMsg != constants.True {
+		return welcomeMsg
+	}
+
+	weekday, isHoliday, err := models.CorpCalendarDay{}.ResolveWeekday(extCorpID, time.Now())
+	if err != nil {
+		log.Sugar.Errorw("ResolveWeekday failed", "err", err, "welcomeMsgID", welcomeMsg.ID)
+		return welcomeMsg
+	}
+	if isHoliday {
+		return welcomeMsg
+	}
+
+	timePeriodMsgs := make([]models.WelcomeMsg, 0)
+	err = models.DB.Where("main_welcome_msg_id = ?", welcomeMsg.ID).Order("start_time").Find(&timePeriodMsgs).Error
+	if err != nil {
+		log.Sugar.Errorw("find time period welcome msg failed", "err", err, "welcomeMsgID", welcomeMsg.ID)
+		return welcomeMsg
+	}
+
+	seconds := util.Now().Unix() - util.Today().Unix()
+	for _, msg := range timePeriodMsgs {
+		// 生效时间为星期n，周日可能为0或7
+		effective := false
+		for _, day := range msg.EffectiveAt {
+			if time.Weekday(day%7) == weekday {
+				effective = true
+			}
+		}
+		if !effective || seconds < msg.StartTime.Seconds() || seconds > msg.EndTime.Seconds() {
+			continue
+		}
+		return msg
+	}
+	return welcomeMsg
 }
 
 // QueryMainInfo
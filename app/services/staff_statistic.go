@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+	"openscrm/app/constants"
+	"openscrm/app/models"
+	"openscrm/app/requests"
+	"openscrm/common/id_generator"
+	"openscrm/common/log"
+	"openscrm/common/we_work"
+	workwx "openscrm/pkg/easywework"
+	"strconv"
+	"time"
+)
+
+// groupChatStatisticOwnerBatch 群聊统计每次最多查询的群主数
+const groupChatStatisticOwnerBatch = 100
+
+type StaffStatisticService struct {
+	behaviorRepo  models.StaffBehaviorStatistic
+	groupChatRepo models.GroupChatStatistic
+}
+
+func NewStaffStatisticService() *StaffStatisticService {
+	return &StaffStatisticService{
+		behaviorRepo:  models.StaffBehaviorStatistic{},
+		groupChatRepo: models.GroupChatStatistic{},
+	}
+}
+
+// SyncDailyStatistic
+// Description: 同步企微「联系客户统计」和「群聊数据统计」中指定日期的数据
+// Detail: 联系客户统计需逐个员工查询才能拿到员工维度的数据，群聊统计按群主批量查询
+func (o StaffStatisticService) SyncDailyStatistic(extCorpID string, date time.Time) error {
+	client, err := we_work.Clients.Get(extCorpID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var extStaffIDs []string
+	err = models.DB.Model(&models.Staff{}).Where("ext_corp_id = ?", extCorpID).Pluck("ext_id", &extStaffIDs).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dayBegin := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	statDate := constants.DateField(dayBegin.Format(constants.DateLayout))
+
+	behaviors := make([]models.StaffBehaviorStatistic, 0, len(extStaffIDs))
+	for _, extStaffID := range extStaffIDs {
+		data, err := client.Customer.GetUserBehaviorData(workwx.GetUserBehaviorDataReq{
+			Userid:    []string{extStaffID},
+			StartTime: dayBegin.Unix(),
+			EndTime:   dayBegin.Unix(),
+		})
+		if err != nil {
+			log.Sugar.Errorw("GetUserBehaviorData failed", "extStaffID", extStaffID, "err", err)
+			continue
+		}
+
+		for _, item := range data {
+			behaviors = append(behaviors, models.StaffBehaviorStatistic{
+				ExtCorpModel:        models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+				ExtStaffID:          extStaffID,
+				ChatCnt:             item.ChatCnt,
+				MessageCnt:          item.MessageCnt,
+				ReplyPercentage:     item.ReplyPercentage,
+				AvgReplyTime:        item.AvgReplyTime,
+				NegativeFeedbackCnt: item.NegativeFeedbackCnt,
+				NewApplyCnt:         item.NewApplyCnt,
+				NewContactCnt:       item.NewContactCnt,
+				Date:                constants.DateField(time.Unix(item.StatTime, 0).Format(constants.DateLayout)),
+			})
+		}
+	}
+
+	err = o.behaviorRepo.BatchUpsert(behaviors)
+	if err != nil {
+		return errors.Wrap(err, "upsert StaffBehaviorStatistic failed")
+	}
+
+	groupChatStatistics := make([]models.GroupChatStatistic, 0)
+	for start := 0; start < len(extStaffIDs); start += groupChatStatisticOwnerBatch {
+		end := start + groupChatStatisticOwnerBatch
+		if end > len(extStaffIDs) {
+			end = len(extStaffIDs)
+		}
+
+		req := workwx.GroupChatStatisticReq{
+			DayBeginTime: dayBegin.Unix(),
+			OwnerFilter:  workwx.GroupChatStatisticOwnerFilter{UseridList: extStaffIDs[start:end]},
+			Limit:        1000,
+		}
+		for {
+			resp, err := client.Customer.GetGroupChatStatistic(req)
+			if err != nil {
+				log.Sugar.Errorw("GetGroupChatStatistic failed", "req", req, "err", err)
+				break
+			}
+
+			for _, item := range resp.Items {
+				groupChatStatistics = append(groupChatStatistics, models.GroupChatStatistic{
+					ExtCorpModel: models.ExtCorpModel{ID: id_generator.StringID(), ExtCorpID: extCorpID},
+					ExtStaffID:   item.Owner,
+					NewChatCnt:   item.Data.NewChatCnt,
+					ChatTotal:    item.Data.ChatTotal,
+					ChatHasMsg:   item.Data.ChatHasMsg,
+					NewMemberCnt: item.Data.NewMemberCnt,
+					MemberTotal:  item.Data.MemberTotal,
+					MemberHasMsg: item.Data.MemberHasMsg,
+					MsgTotal:     item.Data.MsgTotal,
+					Date:         statDate,
+				})
+			}
+
+			if resp.NextOffset == 0 || resp.NextOffset >= resp.Total {
+				break
+			}
+			req.Offset = resp.NextOffset
+		}
+	}
+
+	err = o.groupChatRepo.BatchUpsert(groupChatStatistics)
+	if err != nil {
+		return errors.Wrap(err, "upsert GroupChatStatistic failed")
+	}
+
+	return nil
+}
+
+// QueryStaffSummary 按员工查询时间段内的统计
+func (o StaffStatisticService) QueryStaffSummary(
+	req requests.QueryStaffBehaviorStatisticReq, extCorpID string) ([]models.StaffBehaviorSummary, int64, error) {
+	return o.behaviorRepo.QueryStaffSummary(req, extCorpID)
+}
+
+// QueryDepartmentSummary 按部门查询时间段内的统计
+func (o StaffStatisticService) QueryDepartmentSummary(
+	req requests.QueryStaffBehaviorStatisticReq, extCorpID string) ([]models.DepartmentBehaviorSummary, int64, error) {
+	return o.behaviorRepo.QueryDepartmentSummary(req, extCorpID)
+}
+
+// Export
+// Description: 导出员工或部门维度的统计数据
+func (o StaffStatisticService) Export(
+	req requests.ExportStaffBehaviorStatisticReq, extCorpID string) (*bytes.Buffer, string, error) {
+	// 导出全部数据，不分页
+	req.PageSize = 0
+
+	exportTime := time.Now().Format(constants.DateTimeLayout)
+	filename := fmt.Sprintf("%s-%s-%s.xlsx", constants.DataExportStaffBehaviorFilenamePrefix, req.StartTime, req.EndTime)
+
+	metricTitles := []string{"聊天总数", "发送消息数", "已回复聊天占比(%)", "平均首次回复时长(分钟)", "删除/拉黑成员的客户数",
+		"发起申请数", "新增客户数", "新增群数", "群总数", "新增群人数", "群总人数", "群消息总数"}
+
+	var sheetName string
+	var titles []string
+	rows := make([][]string, 0)
+	switch req.Dimension {
+	case constants.StaffStatisticDimensionDepartment:
+		items, _, err := o.behaviorRepo.QueryDepartmentSummary(req.QueryStaffBehaviorStatisticReq, extCorpID)
+		if err != nil {
+			return nil, "", err
+		}
+		sheetName = constants.DataExportDeptBehaviorSheetName
+		titles = append([]string{"部门", "员工数"}, metricTitles...)
+		for _, item := range items {
+			rows = append(rows, append([]string{item.DepartmentName, strconv.FormatInt(item.StaffNum, 10)},
+				o.summaryValues(item.BehaviorSummary)...))
+		}
+	default:
+		items, _, err := o.behaviorRepo.QueryStaffSummary(req.QueryStaffBehaviorStatisticReq, extCorpID)
+		if err != nil {
+			return nil, "", err
+		}
+		sheetName = constants.DataExportStaffBehaviorSheetName
+		titles = append([]string{"员工"}, metricTitles...)
+		for _, item := range items {
+			rows = append(rows, append([]string{item.StaffName}, o.summaryValues(item.BehaviorSummary)...))
+		}
+	}
+
+	file := excelize.NewFile()
+	sheetIndex, err := file.NewSheet(sheetName)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+	file.DeleteSheet("Sheet1")
+	file.SetActiveSheet(sheetIndex)
+
+	err = PrettifySheet(sheetName, file, exportTime, titles)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, "", err
+	}
+
+	for k, values := range rows {
+		values := values
+		err = file.SetSheetRow(sheetName, fmt.Sprint("A", k+3), &values)
+		if err != nil {
+			log.Sugar.Errorw("write excel failed", "err", err)
+			return nil, "", err
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf, filename, nil
+}
+
+func (o StaffStatisticService) summaryValues(item models.BehaviorSummary) []string {
+	return []string{
+		strconv.FormatInt(item.ChatCnt, 10),
+		strconv.FormatInt(item.MessageCnt, 10),
+		strconv.FormatFloat(item.ReplyPercentage, 'f', 2, 64),
+		strconv.FormatFloat(item.AvgReplyTime, 'f', 1, 64),
+		strconv.FormatInt(item.NegativeFeedbackCnt, 10),
+		strconv.FormatInt(item.NewApplyCnt, 10),
+		strconv.FormatInt(item.NewContactCnt, 10),
+		strconv.FormatInt(item.NewChatCnt, 10),
+		strconv.FormatInt(item.ChatTotal, 10),
+		strconv.FormatInt(item.NewMemberCnt, 10),
+		strconv.FormatInt(item.MemberTotal, 10),
+		strconv.FormatInt(item.MsgTotal, 10),
+	}
+}
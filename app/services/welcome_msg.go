@@ -22,16 +22,18 @@ import (
 )
 
 type WelcomeMsgService struct {
-	departmentRepo models.Department
-	staffRepo      models.Staff
-	msgRepo        models.WelcomeMsg
+	departmentRepo  models.Department
+	staffRepo       models.Staff
+	msgRepo         models.WelcomeMsg
+	variantStatRepo models.MsgVariantStat
 }
 
 func NewWelcomeMsgService() *WelcomeMsgService {
 	return &WelcomeMsgService{
-		msgRepo:        models.WelcomeMsg{},
-		staffRepo:      models.Staff{},
-		departmentRepo: models.Department{},
+		msgRepo:         models.WelcomeMsg{},
+		staffRepo:       models.Staff{},
+		departmentRepo:  models.Department{},
+		variantStatRepo: models.MsgVariantStat{},
 	}
 }
 
@@ -80,11 +82,17 @@ func GetObjFromSignedURL(url string) (obj string, err error) {
 // Param creator string 创建者的外部ID
 // return msg models.WelcomeMsg 欢迎语model
 func (o WelcomeMsgService) Create(req requests.CreateWelcomeMsgReq, extCorpID string, extCreatorID string) (msg models.WelcomeMsg, err error) {
+	err = ValidateMsgVariants(req.Variants)
+	if err != nil {
+		return
+	}
+
 	// 欢迎语主要内容
 	mainMsgID := id_generator.StringID()
 	mainMsg := models.WelcomeMsg{
 		ExtCorpModel:        models.ExtCorpModel{ID: mainMsgID, ExtCorpID: extCorpID, ExtCreatorID: extCreatorID},
 		WelcomeMsg:          req.WelcomeMsg,
+		Variants:            req.Variants,
 		EnableTimePeriodMsg: req.EnableTimePeriodMsg,
 		Name:                req.Name,
 	}
@@ -158,9 +166,18 @@ func (o WelcomeMsgService) Update(ID string, req requests.UpdateWelcomeMsgReq, e
 	mainMsg := models.WelcomeMsg{
 		ExtCorpModel:        models.ExtCorpModel{ID: ID, ExtCorpID: extCorpID},
 		WelcomeMsg:          req.WelcomeMsg,
+		Variants:            req.Variants,
 		EnableTimePeriodMsg: req.EnableTimePeriodMsg,
 		Name:                req.Name,
 	}
+	err := ValidateMsgVariants(req.Variants)
+	if err != nil {
+		return mainMsg, err
+	}
+	// 取消A/B测试时也要更新变体字段
+	if mainMsg.Variants == nil {
+		mainMsg.Variants = constants.MsgVariants{}
+	}
 	if req.TimePeriodMsg != nil {
 		var timePeriodMsgs []models.WelcomeMsg
 		for _, msg := range req.TimePeriodMsg {
@@ -177,7 +194,7 @@ func (o WelcomeMsgService) Update(ID string, req requests.UpdateWelcomeMsgReq, e
 		}
 		mainMsg.TimePeriodMsg = timePeriodMsgs
 	}
-	err := models.DB.Transaction(func(tx *gorm.DB) error {
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
 		if req.EnableTimePeriodMsg == constants.False {
 			err := o.msgRepo.DeleteTimePeriodMsg(tx, ID)
 			if err != nil {
@@ -274,6 +291,28 @@ func (o WelcomeMsgService) Get(id string, extCorpID string) (msg models.WelcomeM
 	return
 }
 
+// QueryVariantStats
+// Description: 查询欢迎语各内容变体的发送和转化数据
+func (o WelcomeMsgService) QueryVariantStats(
+	id string, req requests.QueryMsgVariantStatsReq, extCorpID string) ([]requests.MsgVariantStat, error) {
+	welcomeMsg, err := o.msgRepo.Get(id, extCorpID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(welcomeMsg.Variants) == 0 {
+		return []requests.MsgVariantStat{}, nil
+	}
+	if req.Days == 0 {
+		req.Days = constants.MsgVariantDefaultDays
+	}
+
+	stats, err := o.variantStatRepo.QueryWelcomeMsgVariantStats(id, extCorpID, req.Days)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMsgVariantStats(welcomeMsg.Variants, stats), nil
+}
+
 func (o WelcomeMsgService) UploadImg(body io.ReadCloser, filename, extCorpID string) (url string, err error) {
 
 	data, err := ioutil.ReadAll(body)
@@ -312,9 +351,24 @@ func (o WelcomeMsgService) UploadImg(body io.ReadCloser, filename, extCorpID str
 }
 
 func (o WelcomeMsgService) createImageWxURL(req *requests.CreateWelcomeMsgReq, extCorpID string) (err error) {
-	for i, _ := range req.WelcomeMsg.Attachments {
-		if req.WelcomeMsg.Attachments[i].MsgType == string(constants.ImageMsgType) {
-			uploadURL := req.WelcomeMsg.Attachments[i].Image.PicURL
+	err = o.uploadImageAttachments(&req.WelcomeMsg, extCorpID)
+	if err != nil {
+		return
+	}
+	for i := range req.Variants {
+		err = o.uploadImageAttachments(&req.Variants[i].Msg, extCorpID)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// uploadImageAttachments 将消息中的图片附件上传到微信
+func (o WelcomeMsgService) uploadImageAttachments(msg *constants.AutoReplyField, extCorpID string) (err error) {
+	for i, _ := range msg.Attachments {
+		if msg.Attachments[i].MsgType == string(constants.ImageMsgType) {
+			uploadURL := msg.Attachments[i].Image.PicURL
 			// 从oss下载，上传到wx
 			var obj string
 			obj, err = GetObjFromSignedURL(uploadURL)
@@ -355,7 +409,7 @@ func (o WelcomeMsgService) createImageWxURL(req *requests.CreateWelcomeMsgReq, e
 				err = errors.WithStack(err)
 				return
 			}
-			req.WelcomeMsg.Attachments[i].Image.PicURL = url
+			msg.Attachments[i].Image.PicURL = url
 		}
 	}
 	return
@@ -371,17 +425,25 @@ func (o WelcomeMsgService) checkAttachments(req *requests.CreateWelcomeMsgReq, e
 	if req == nil {
 		return
 	}
-	for i, attachment := range req.WelcomeMsg.Attachments {
+	truncateLinkAttachments(&req.WelcomeMsg)
+	for i := range req.Variants {
+		truncateLinkAttachments(&req.Variants[i].Msg)
+	}
+	return
+}
+
+// truncateLinkAttachments 截断超出wx长度限制的链接标题和描述
+func truncateLinkAttachments(msg *constants.AutoReplyField) {
+	for i, attachment := range msg.Attachments {
 		switch attachment.MsgType {
 		case string(constants.LinkMsgType):
 			if len(attachment.Link.Desc) > 512 {
-				req.WelcomeMsg.Attachments[i].Link.Desc = req.WelcomeMsg.Attachments[i].Link.Desc[:256]
-				//req.WelcomeMsg.Attachments[i].Link.Desc = string([]byte(desc)[:256])
+				msg.Attachments[i].Link.Desc = msg.Attachments[i].Link.Desc[:256]
+				//msg.Attachments[i].Link.Desc = string([]byte(desc)[:256])
 			}
 			if len(attachment.Link.Title) > 128 {
-				req.WelcomeMsg.Attachments[i].Link.Title = req.WelcomeMsg.Attachments[i].Link.Title[:128]
+				msg.Attachments[i].Link.Title = msg.Attachments[i].Link.Title[:128]
 			}
 		}
 	}
-	return
 }
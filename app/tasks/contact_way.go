@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"openscrm/app/constants"
 	"openscrm/app/models"
 	"openscrm/common/log"
 	"time"
@@ -29,3 +30,74 @@ func (o ContactWay) DailyClean() {
 		return
 	}
 }
+
+// ExpireCheck 渠道码过期检查任务，删除已过期渠道码的企微配置
+func (o ContactWay) ExpireCheck() {
+	taskKey := "ContactWayExpireCheck"
+	//获取分布式锁
+	ok, err := o.Lock(taskKey, time.Minute)
+	if err != nil {
+		log.Sugar.Errorw("Lock failed", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer o.Unlock(taskKey)
+
+	items, err := (models.ContactWay{}).QueryExpired(100)
+	if err != nil {
+		log.Sugar.Errorw("QueryExpired failed", "err", err)
+		return
+	}
+	for _, item := range items {
+		err = (models.ContactWay{}).Deactivate(models.DB, item, constants.ContactWayStatusExpired)
+		if err != nil {
+			log.Sugar.Errorw("Deactivate failed", "err", err, "contactWayID", item.ID)
+			// 失效失败的渠道码下次排在最后，避免一直失败的渠道码占满每次的检查
+			err = (models.ContactWay{}).AddDeactivateAttempt(item.ID)
+			if err != nil {
+				log.Sugar.Errorw("AddDeactivateAttempt failed", "err", err, "contactWayID", item.ID)
+			}
+		}
+	}
+}
+
+// ScheduleCheck 渠道码调度检查任务，接待员工与调度时段要求的不一致时刷新
+// Detail: 每分钟按当前生效的调度时段计算应接待的员工，与已保存的接待员工比较，不一致时调用企微接口更新
+// 上次刷新失败或错过调度边界时，下一分钟仍会刷新，节假日和调休按企业日历
+func (o ContactWay) ScheduleCheck() {
+	taskKey := "ContactWayScheduleCheck"
+	//获取分布式锁
+	ok, err := o.Lock(taskKey, time.Minute)
+	if err != nil {
+		log.Sugar.Errorw("Lock failed", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer o.Unlock(taskKey)
+
+	err = (models.ContactWay{}).FindScheduleEnabled(func(items []models.ContactWay) error {
+		for _, item := range items {
+			changed, err := (models.ContactWay{}).ScheduleStaffChanged(&item)
+			if err != nil {
+				log.Sugar.Errorw("ScheduleStaffChanged failed", "err", err, "contactWayID", item.ID)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			_, err = (models.ContactWayScheduleLog{}).RefreshWithLog(item.ID, constants.ContactWayScheduleLogTriggerSchedule, "")
+			if err != nil {
+				log.Sugar.Errorw("RefreshWithLog failed", "err", err, "contactWayID", item.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Sugar.Errorw("FindScheduleEnabled failed", "err", err)
+		return
+	}
+}
@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"openscrm/app/services"
+	"openscrm/common/log"
+	"openscrm/conf"
+	"time"
+)
+
+type Customer struct {
+	Base
+}
+
+// LinkIdentity 补全客户unionid，并关联小程序/公众号登录时尚未添加员工的客户身份
+func (o Customer) LinkIdentity() {
+	taskKey := "CustomerLinkIdentity"
+
+	ok, err := o.Lock(taskKey, 30*time.Minute)
+	if err != nil {
+		log.Sugar.Errorw("Lock failed", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer o.Unlock(taskKey)
+
+	extCorpID := conf.Settings.WeWork.ExtCorpID
+	identitySrv := services.NewCustomerIdentity()
+	filled, err := identitySrv.FillMissingUnionids(extCorpID)
+	if err != nil {
+		log.Sugar.Errorw("FillMissingUnionids failed", "err", err)
+		return
+	}
+
+	linked, err := identitySrv.LinkPendingIdentities(extCorpID)
+	if err != nil {
+		log.Sugar.Errorw("LinkPendingIdentities failed", "err", err)
+		return
+	}
+
+	log.Sugar.Infow("LinkIdentity finished", "filled", filled, "linked", linked)
+}
@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"openscrm/app/models"
+	"openscrm/app/services"
 	"openscrm/common/log"
 	"time"
 )
@@ -30,3 +31,37 @@ func (o GroupChat) CleanGroupChatIncrement() {
 		return
 	}
 }
+
+// SpamCheck
+// 每分钟按防骚扰规则检查新的会话存档群消息
+func (o GroupChat) SpamCheck() {
+	taskKey := "GroupChatSpamCheck"
+	//获取分布式锁
+	ok, err := o.Lock(taskKey, time.Minute)
+	if err != nil {
+		log.Sugar.Errorw("Lock failed", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer o.Unlock(taskKey)
+
+	rules, err := models.GroupChatSpamRule{}.QueryEnabled("")
+	if err != nil {
+		log.Sugar.Errorw("QueryEnabled failed", "err", err)
+		return
+	}
+	corpRules := make(map[string][]models.GroupChatSpamRule)
+	for _, rule := range rules {
+		corpRules[rule.ExtCorpID] = append(corpRules[rule.ExtCorpID], rule)
+	}
+
+	srv := services.NewGroupChatSpam()
+	for extCorpID, items := range corpRules {
+		err = srv.CheckGroupMsgs(extCorpID, items)
+		if err != nil {
+			log.Sugar.Errorw("CheckGroupMsgs failed", "err", err, "extCorpID", extCorpID)
+		}
+	}
+}
@@ -19,6 +19,18 @@ func Start() {
 		log.Sugar.Errorw("AddSingleton failed", "err", err)
 	}
 
+	// 每分钟检查过期的渠道码（秒 分 时 日 月 周）
+	_, err = gcron.AddSingleton("0 * * * * *", (ContactWay{}).ExpireCheck, "ContactWayExpireCheck")
+	if err != nil {
+		log.Sugar.Errorw("AddSingleton failed", "err", err)
+	}
+
+	// 每分钟检查渠道码调度时段，开始或结束时切换接待员工（秒 分 时 日 月 周）
+	_, err = gcron.AddSingleton("0 * * * * *", (ContactWay{}).ScheduleCheck, "ContactWayScheduleCheck")
+	if err != nil {
+		log.Sugar.Errorw("AddSingleton failed", "err", err)
+	}
+
 	_, err = gcron.AddSingleton("@hourly", (Staff{}).UpdateMsgArchStatus, "UpdateStaffMsgArchStatus")
 	if err != nil {
 		log.Sugar.Errorw("AddSingleton failed", "err", err)
@@ -34,6 +46,23 @@ func Start() {
 		log.Sugar.Errorw("AddSingleton failed", "err", err)
 	}
 
+	// 每分钟按防骚扰规则检查新的群消息（秒 分 时 日 月 周）
+	_, err = gcron.AddSingleton("0 * * * * *", (GroupChat{}).SpamCheck, "GroupChatSpamCheck")
+	if err != nil {
+		log.Sugar.Errorw("AddSingleton failed", "err", err)
+	}
+
+	_, err = gcron.AddSingleton("@hourly", (Customer{}).LinkIdentity, "CustomerLinkIdentity")
+	if err != nil {
+		log.Sugar.Errorw("AddSingleton failed", "err", err)
+	}
+
+	// 企微统计数据在次日生成，凌晨同步前一天的数据
+	_, err = gcron.AddSingleton("0 30 3 * * *", (Staff{}).SyncDailyStatistic, "SyncStaffDailyStatistic")
+	if err != nil {
+		log.Sugar.Errorw("AddSingleton failed", "err", err)
+	}
+
 	// 明道云增量同步任务 - 每10分钟执行（秒 分 时 日 月 周）
 	_, err = gcron.AddSingleton("0 */10 * * * *", (MingDaoYunSync{}).IncrementalSync, "MingDaoYunIncrementalSync")
 	if err != nil {
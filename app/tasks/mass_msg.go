@@ -3,6 +3,7 @@ package tasks
 import (
 	"openscrm/app/services"
 	"openscrm/common/log"
+	"openscrm/conf"
 	"time"
 )
 
@@ -29,4 +30,11 @@ func (o MassMsg) UpdateMassMsgStatus() {
 		log.Sugar.Errorw("UpdateMassMsgStatus failed", "err", err)
 		return
 	}
+
+	// 更新客户群发中每个客户的发送状态
+	err = services.NewDefaultMassMsgService().SyncSendResult(conf.Settings.WeWork.ExtCorpID)
+	if err != nil {
+		log.Sugar.Errorw("SyncSendResult failed", "err", err)
+		return
+	}
 }
@@ -0,0 +1,30 @@
+package tasks
+
+import (
+	"openscrm/app/services"
+	"openscrm/common/log"
+	"openscrm/conf"
+	"time"
+)
+
+// SyncDailyStatistic 每天同步前一天的联系客户统计和群聊数据统计
+func (o Staff) SyncDailyStatistic() {
+	taskKey := "SyncStaffDailyStatistic"
+
+	ok, err := o.Lock(taskKey, time.Hour)
+	if err != nil {
+		log.Sugar.Errorw("Lock failed", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer o.Unlock(taskKey)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	err = services.NewStaffStatisticService().SyncDailyStatistic(conf.Settings.WeWork.ExtCorpID, yesterday)
+	if err != nil {
+		log.Sugar.Errorw("SyncDailyStatistic failed", "err", err)
+		return
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	ut "github.com/go-playground/universal-translator"
 	val "github.com/go-playground/validator/v10"
 	"github.com/pkg/errors"
@@ -69,6 +70,33 @@ func BindAndValid(c *gin.Context, v interface{}) ValidErrors {
 	return nil
 }
 
+// ValidateStruct 按请求参数的校验规则校验非HTTP来源的数据，如导入的表格
+func ValidateStruct(v interface{}) ValidErrors {
+	var errs ValidErrors
+	err := binding.Validator.ValidateStruct(v)
+	if err != nil {
+		verrs, ok := err.(val.ValidationErrors)
+		if !ok {
+			errs = append(errs, &ValidError{
+				Key:     "",
+				Message: err.Error(),
+			})
+			return errs
+		}
+
+		trans, _ := (*vt).(ut.Translator)
+		for key, value := range verrs.Translate(trans) {
+			errs = append(errs, &ValidError{
+				Key:     key,
+				Message: value,
+			})
+		}
+		return errs
+	}
+
+	return nil
+}
+
 func ResponseErr(c *gin.Context, err error) {
 	//检查wrap过的错误
 	rootErr := errors.Cause(err) //获取根错误
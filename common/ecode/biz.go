@@ -23,6 +23,9 @@ var (
 	TimedMsgUnchangeableError         = add(20000402)
 	NoMassMsgReceiversErr             = add(20000403) // 群发消息未找到有效接收人
 	UnsupportedFileTypeError          = add(20000404) // 不支持的上传文件类型
+	MassMsgQuotaExhaustedErr          = add(20000405) // 部分客户本月群发次数已达上限
+	InvalidMsgVariantErr              = add(20000406) // 消息内容变体不合法
+	MassMsgCancelPartialFailedErr     = add(20000407) // 部分群发取消失败
 	InfoFieldDuplicateError           = add(20000500) // 客户信息字段重复, 客户信息错误 20000500 - 20000599
 	DuplicateRemarkNameError          = add(20000600) // 自定义客户信息字段名重复, 客户自定义信息错误 20000600 - 20000699
 	GroupChatNotExistsError           = add(20000700) // 自动拉群 20000700
@@ -39,6 +42,21 @@ var (
 	DeleteOtherRecordNotAllowedErr    = add(20004003)
 	EmptyExternalContactInfoErr       = add(20005001) // 同步员工数据为空
 	UnknownEventTypeErr               = add(20006001)
+	TooManyStrategyAdminsErr          = add(20007001) // 客户联系规则组负责人超过上限
+	InvalidSegmentRuleErr             = add(20008001) // 客户分群规则不合法, <客户分群>错误 20008000 - 20008999
+	MassMsgApprovalHandledErr         = add(20009001) // 群发审批单已处理, <群发审批>错误 20009000 - 20009999
+	NotMassMsgApproverErr             = add(20009002) // 不是群发的审批人
+	MassMsgApprovalInOAErr            = add(20009003) // 群发需在企业微信审批应用中审批
+	InvalidCustomerSopErr             = add(20010001) // 客户SOP配置不合法, <客户SOP>错误 20010000 - 20010999
+	DuplicateSubChannelCodeErr        = add(20011001) // 子渠道编码重复, <渠道码>错误 20011000 - 20011999
+	ContactWayInactiveErr             = add(20011002) // 渠道码已过期或已达到添加上限
+	InvalidPosterTemplateErr          = add(20011003) // 海报模板不合法
+	InvalidContactWayImportFileErr    = add(20011004) // 渠道码导入文件格式错误
+	TooManyPostersErr                 = add(20011005) // 单次生成的海报过多
+	PosterFontNotConfiguredErr        = add(20011006) // 未配置海报字体
+	InvalidContactWayExpireTimeErr    = add(20011007) // 渠道码过期时间不合法
+	InvalidCorpCalendarFileErr        = add(20012001) // 企业日历导入文件格式错误, <企业日历>错误 20012000 - 20012999
+	InvalidGroupChatSpamRuleErr       = add(20013001) // 客户群防骚扰规则不合法, <客户群防骚扰>错误 20013000 - 20013999
 )
 
 func init() {
@@ -139,12 +157,66 @@ func init() {
 		NoMassMsgReceiversErr.Code(): {
 			Msg: "群发消息未找到有效接收人",
 		},
+		MassMsgQuotaExhaustedErr.Code(): {
+			Msg: "部分客户本月已收到4条群发消息，将无法收到本次群发",
+		},
+		InvalidMsgVariantErr.Code(): {
+			Msg: "内容变体需至少2个、标识不重复且比例之和为100",
+		},
+		MassMsgCancelPartialFailedErr.Code(): {
+			Msg: "部分群发未能在企业微信停止，尚未发送的员工可能仍可发送",
+		},
 		EmptyExternalContactInfoErr.Code(): {
 			Msg: "空员工数据",
 		},
 		UnknownEventTypeErr.Code(): {
 			Msg: "未知事件类型错误",
 		},
+		TooManyStrategyAdminsErr.Code(): {
+			Msg: "客户联系规则组最多配置20个负责人",
+		},
+		InvalidSegmentRuleErr.Code(): {
+			Msg: "客户分群规则不合法",
+		},
+		MassMsgApprovalHandledErr.Code(): {
+			Msg: "群发审批单已处理",
+		},
+		NotMassMsgApproverErr.Code(): {
+			Msg: "你不是该群发的审批人",
+		},
+		MassMsgApprovalInOAErr.Code(): {
+			Msg: "该群发需在企业微信审批应用中审批",
+		},
+		InvalidCustomerSopErr.Code(): {
+			Msg: "客户SOP配置不合法",
+		},
+		DuplicateSubChannelCodeErr.Code(): {
+			Msg: "子渠道编码重复",
+		},
+		ContactWayInactiveErr.Code(): {
+			Msg: "渠道码已过期或已达到添加上限",
+		},
+		InvalidPosterTemplateErr.Code(): {
+			Msg: "海报模板不合法，请检查背景图和图片位置",
+		},
+		InvalidContactWayImportFileErr.Code(): {
+			Msg: "导入文件格式错误，请使用导出的渠道码表格，单次最多导入1000行",
+		},
+		TooManyPostersErr.Code(): {
+			Msg: "单次最多生成100张海报，请减少渠道码数量",
+		},
+		PosterFontNotConfiguredErr.Code(): {
+			Msg: "未配置海报字体，无法绘制员工名称",
+		},
+		InvalidContactWayExpireTimeErr.Code(): {
+			Msg: "过期时间需晚于当前时间，临时会话渠道码最长有效14天",
+		},
+		InvalidCorpCalendarFileErr.Code(): {
+			Msg: "日历文件格式错误，请使用iCal或CSV文件，单次最多导入1000天",
+		},
+		InvalidGroupChatSpamRuleErr.Code(): {
+			Msg: "防骚扰规则不合法，请至少开启一项检测",
+		},
 	}
 
 	for code, message := range _commonMessage {
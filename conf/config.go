@@ -61,6 +61,8 @@ type AppConfig struct {
 	// SuperAdmin 此处userID对应员工的赋予超级管理员权限
 	SuperAdmin      []string `validate:"required,dive,gt=1"`
 	InnerSrvAppCode string   // 内部服务调用key
+	// PosterFontPath 海报绘制文字使用的字体文件，支持ttf、otf，需包含中文字形
+	PosterFontPath string
 }
 
 type serverConfig struct {
@@ -99,6 +101,10 @@ type weWorkConfig struct {
 	MsgArchProxy string `json:"msg_arch_proxy"`
 	// MsgArchProxyPasswd  会话存档拉取代理密码
 	MsgArchProxyPasswd string `json:"msg_arch_proxy_passwd"`
+	// MassMsgBatchSize 客户群发分批发送，每批的发送人数
+	MassMsgBatchSize int `json:"mass_msg_batch_size"`
+	// MassMsgSendRate 客户群发每秒创建的企微群发数
+	MassMsgSendRate int `json:"mass_msg_send_rate"`
 }
 
 type DBConfig struct {
@@ -269,6 +275,8 @@ func SetupSettingFromEnv() error {
 			MsgArchTimeout:     getEnvInt("WEWORK_MSG_ARCH_TIMEOUT", 10),
 			MsgArchProxy:       getEnv("WEWORK_MSG_ARCH_PROXY", ""),
 			MsgArchProxyPasswd: getEnv("WEWORK_MSG_ARCH_PROXY_PASSWD", ""),
+			MassMsgBatchSize:   getEnvInt("WEWORK_MASS_MSG_BATCH_SIZE", 50),
+			MassMsgSendRate:    getEnvInt("WEWORK_MASS_MSG_SEND_RATE", 5),
 		},
 		MingDaoYun: MingDaoYunConfig{
 			APIBase:               getEnv("MINGDAOYUN_API_BASE", "https://api.mingdao.com"),
@@ -0,0 +1,60 @@
+package workwx
+
+// ListCustomerStrategy 获取规则组列表
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组列表
+func (c *App) ListCustomerStrategy(cursor string, limit int) (strategyIDs []int64, nextCursor string, err error) {
+	resp, err := c.execListCustomerStrategy(listCustomerStrategyReq{Cursor: cursor, Limit: limit})
+	if err != nil {
+		return nil, "", err
+	}
+
+	strategyIDs = make([]int64, 0, len(resp.Strategy))
+	for _, item := range resp.Strategy {
+		strategyIDs = append(strategyIDs, item.StrategyID)
+	}
+	return strategyIDs, resp.NextCursor, nil
+}
+
+// GetCustomerStrategy 获取规则组详情
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组详情
+func (c *App) GetCustomerStrategy(strategyID int64) (CustomerStrategy, error) {
+	resp, err := c.execGetCustomerStrategy(customerStrategyIDReq{StrategyID: strategyID})
+	if err != nil {
+		return CustomerStrategy{}, err
+	}
+	return resp.Strategy, nil
+}
+
+// GetCustomerStrategyRange 获取规则组管理范围
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组管理范围
+func (c *App) GetCustomerStrategyRange(strategyID int64, cursor string, limit int) ([]CustomerStrategyRange, string, error) {
+	resp, err := c.execGetCustomerStrategyRange(getCustomerStrategyRangeReq{StrategyID: strategyID, Cursor: cursor, Limit: limit})
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Range, resp.NextCursor, nil
+}
+
+// CreateCustomerStrategy 创建新的规则组
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#创建新的规则组
+func (c *App) CreateCustomerStrategy(req CreateCustomerStrategyReq) (int64, error) {
+	resp, err := c.execCreateCustomerStrategy(req)
+	if err != nil {
+		return 0, err
+	}
+	return resp.StrategyID, nil
+}
+
+// EditCustomerStrategy 编辑规则组及其管理范围
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#编辑规则组及其管理范围
+func (c *App) EditCustomerStrategy(req EditCustomerStrategyReq) error {
+	_, err := c.execEditCustomerStrategy(req)
+	return err
+}
+
+// DelCustomerStrategy 删除规则组
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#删除规则组
+func (c *App) DelCustomerStrategy(strategyID int64) error {
+	_, err := c.execDelCustomerStrategy(customerStrategyIDReq{StrategyID: strategyID})
+	return err
+}
@@ -0,0 +1,204 @@
+package workwx
+
+import (
+	"encoding/json"
+)
+
+// listCustomerStrategyReq 获取规则组列表请求
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组列表
+type listCustomerStrategyReq struct {
+	// Cursor 分页查询游标，首次调用可不填
+	Cursor string `json:"cursor,omitempty"`
+	// Limit 每个分页的最大数据条数，最大值1000，默认1000
+	Limit int `json:"limit,omitempty"`
+}
+
+var _ bodyer = listCustomerStrategyReq{}
+
+func (x listCustomerStrategyReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// listCustomerStrategyResp 获取规则组列表响应
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组列表
+type listCustomerStrategyResp struct {
+	CommonResp
+	Strategy []struct {
+		StrategyID int64 `json:"strategy_id"`
+	} `json:"strategy"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// execListCustomerStrategy 获取规则组列表
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组列表
+func (c *App) execListCustomerStrategy(req listCustomerStrategyReq) (listCustomerStrategyResp, error) {
+	var resp listCustomerStrategyResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/list", req, &resp, true)
+	if err != nil {
+		return listCustomerStrategyResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return listCustomerStrategyResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// customerStrategyIDReq 只包含规则组id的请求，用于获取详情和删除规则组
+type customerStrategyIDReq struct {
+	StrategyID int64 `json:"strategy_id"`
+}
+
+var _ bodyer = customerStrategyIDReq{}
+
+func (x customerStrategyIDReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getCustomerStrategyResp 获取规则组详情响应
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组详情
+type getCustomerStrategyResp struct {
+	CommonResp
+	Strategy CustomerStrategy `json:"strategy"`
+}
+
+// execGetCustomerStrategy 获取规则组详情
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组详情
+func (c *App) execGetCustomerStrategy(req customerStrategyIDReq) (getCustomerStrategyResp, error) {
+	var resp getCustomerStrategyResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/get", req, &resp, true)
+	if err != nil {
+		return getCustomerStrategyResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getCustomerStrategyResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// getCustomerStrategyRangeReq 获取规则组管理范围请求
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组管理范围
+type getCustomerStrategyRangeReq struct {
+	StrategyID int64  `json:"strategy_id"`
+	Cursor     string `json:"cursor,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+var _ bodyer = getCustomerStrategyRangeReq{}
+
+func (x getCustomerStrategyRangeReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getCustomerStrategyRangeResp 获取规则组管理范围响应
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组管理范围
+type getCustomerStrategyRangeResp struct {
+	CommonResp
+	Range      []CustomerStrategyRange `json:"range"`
+	NextCursor string                  `json:"next_cursor"`
+}
+
+// execGetCustomerStrategyRange 获取规则组管理范围
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组管理范围
+func (c *App) execGetCustomerStrategyRange(req getCustomerStrategyRangeReq) (getCustomerStrategyRangeResp, error) {
+	var resp getCustomerStrategyRangeResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/get_range", req, &resp, true)
+	if err != nil {
+		return getCustomerStrategyRangeResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getCustomerStrategyRangeResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+var _ bodyer = CreateCustomerStrategyReq{}
+
+func (x CreateCustomerStrategyReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// createCustomerStrategyResp 创建新的规则组响应
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#创建新的规则组
+type createCustomerStrategyResp struct {
+	CommonResp
+	StrategyID int64 `json:"strategy_id"`
+}
+
+// execCreateCustomerStrategy 创建新的规则组
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#创建新的规则组
+func (c *App) execCreateCustomerStrategy(req CreateCustomerStrategyReq) (createCustomerStrategyResp, error) {
+	var resp createCustomerStrategyResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/create", req, &resp, true)
+	if err != nil {
+		return createCustomerStrategyResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return createCustomerStrategyResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+var _ bodyer = EditCustomerStrategyReq{}
+
+func (x EditCustomerStrategyReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// execEditCustomerStrategy 编辑规则组及其管理范围
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#编辑规则组及其管理范围
+func (c *App) execEditCustomerStrategy(req EditCustomerStrategyReq) (CommonResp, error) {
+	var resp CommonResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/edit", req, &resp, true)
+	if err != nil {
+		return CommonResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return CommonResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// execDelCustomerStrategy 删除规则组
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#删除规则组
+func (c *App) execDelCustomerStrategy(req customerStrategyIDReq) (CommonResp, error) {
+	var resp CommonResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/customer_strategy/del", req, &resp, true)
+	if err != nil {
+		return CommonResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return CommonResp{}, bizErr
+	}
+
+	return resp, nil
+}
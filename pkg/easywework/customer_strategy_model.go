@@ -0,0 +1,118 @@
+package workwx
+
+// CustomerStrategyRangeType 规则组管理范围的类型
+// 1-成员
+// 2-部门
+type CustomerStrategyRangeType int
+
+const (
+	// CustomerStrategyRangeTypeUser 成员
+	CustomerStrategyRangeTypeUser CustomerStrategyRangeType = 1
+	// CustomerStrategyRangeTypeParty 部门
+	CustomerStrategyRangeTypeParty CustomerStrategyRangeType = 2
+)
+
+// CustomerStrategyPrivilege 客户联系规则组的权限配置
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组详情
+type CustomerStrategyPrivilege struct {
+	// ViewCustomerList 查看客户列表，基础权限，不可取消
+	ViewCustomerList bool `json:"view_customer_list"`
+	// ViewCustomerData 查看客户统计数据，基础权限，不可取消
+	ViewCustomerData bool `json:"view_customer_data"`
+	// ViewRoomList 查看群聊列表，基础权限，不可取消
+	ViewRoomList bool `json:"view_room_list"`
+	// ContactMe 可使用联系我，基础权限，不可取消
+	ContactMe bool `json:"contact_me"`
+	// JoinRoom 可加入群聊，基础权限，不可取消
+	JoinRoom bool `json:"join_room"`
+	// ShareCustomer 允许分享客户给其他成员，默认为true
+	ShareCustomer bool `json:"share_customer"`
+	// OperResignCustomer 允许分配离职成员客户，默认为true
+	OperResignCustomer bool `json:"oper_resign_customer"`
+	// OperResignGroup 允许分配离职成员客户群，默认为true
+	OperResignGroup bool `json:"oper_resign_group"`
+	// SendCustomerMsg 允许给企业客户发送消息，默认为true
+	SendCustomerMsg bool `json:"send_customer_msg"`
+	// EditWelcomeMsg 允许配置欢迎语，默认为true
+	EditWelcomeMsg bool `json:"edit_welcome_msg"`
+	// ViewBehaviorData 允许查看成员联系客户统计
+	ViewBehaviorData bool `json:"view_behavior_data"`
+	// ViewRoomData 允许查看群聊数据统计，默认为true
+	ViewRoomData bool `json:"view_room_data"`
+	// SendGroupMsg 允许发送消息到企业的客户群，默认为true
+	SendGroupMsg bool `json:"send_group_msg"`
+	// RoomDeduplication 允许对企业客户群进行去重，默认为true
+	RoomDeduplication bool `json:"room_deduplication"`
+	// RapidReply 配置快捷回复，默认为true
+	RapidReply bool `json:"rapid_reply"`
+	// OnjobCustomerTransfer 转接在职成员的客户，默认为true
+	OnjobCustomerTransfer bool `json:"onjob_customer_transfer"`
+	// EditAntiSpamRule 编辑企业成员防骚扰规则，默认为true
+	EditAntiSpamRule bool `json:"edit_anti_spam_rule"`
+	// ExportCustomerList 导出客户列表，默认为true
+	ExportCustomerList bool `json:"export_customer_list"`
+	// ExportCustomerData 导出成员客户统计，默认为true
+	ExportCustomerData bool `json:"export_customer_data"`
+	// ExportCustomerGroupList 导出客户群列表，默认为true
+	ExportCustomerGroupList bool `json:"export_customer_group_list"`
+	// ManageCustomerTag 配置企业客户标签，默认为true
+	ManageCustomerTag bool `json:"manage_customer_tag"`
+}
+
+// CustomerStrategy 客户联系规则组
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#获取规则组详情
+type CustomerStrategy struct {
+	// StrategyID 规则组id
+	StrategyID int64 `json:"strategy_id"`
+	// ParentID 父规则组id，如果当前规则组没父规则组，则为0
+	ParentID int64 `json:"parent_id"`
+	// StrategyName 规则组名称
+	StrategyName string `json:"strategy_name"`
+	// CreateTime 规则组创建时间戳
+	CreateTime int64 `json:"create_time"`
+	// AdminList 规则组管理员userid列表
+	AdminList []string                  `json:"admin_list"`
+	Privilege CustomerStrategyPrivilege `json:"privilege"`
+}
+
+// CustomerStrategyRange 规则组的管理范围
+type CustomerStrategyRange struct {
+	// Type 节点类型，1-成员 2-部门
+	Type CustomerStrategyRangeType `json:"type"`
+	// Userid 管理范围内配置的成员userid，仅type为1时返回
+	Userid string `json:"userid,omitempty"`
+	// Partyid 管理范围内配置的部门partyid，仅type为2时返回
+	Partyid int64 `json:"partyid,omitempty"`
+}
+
+// CreateCustomerStrategyReq 创建新的规则组请求
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#创建新的规则组
+type CreateCustomerStrategyReq struct {
+	// ParentID 父规则组id
+	ParentID int64 `json:"parent_id,omitempty"`
+	// StrategyName 规则组名称，必填
+	StrategyName string `json:"strategy_name"`
+	// AdminList 规则组管理员userid列表，不可配置超级管理员，每个规则组最多可以配置20个负责人，必填
+	AdminList []string `json:"admin_list"`
+	// Privilege 权限配置
+	Privilege *CustomerStrategyPrivilege `json:"privilege,omitempty"`
+	// Range 规则组的管理范围，最多支持传1000个节点，必填
+	Range []CustomerStrategyRange `json:"range"`
+}
+
+// EditCustomerStrategyReq 编辑规则组及其管理范围请求
+// 文档：https://developer.work.weixin.qq.com/document/path/94883#编辑规则组及其管理范围
+type EditCustomerStrategyReq struct {
+	// StrategyID 规则组id，必填
+	StrategyID int64 `json:"strategy_id"`
+	// StrategyName 规则组名称
+	StrategyName string `json:"strategy_name,omitempty"`
+	// AdminList 管理员列表，如果为空则不对负责人做编辑，如果有则覆盖旧的负责人列表
+	AdminList []string `json:"admin_list,omitempty"`
+	// Privilege 权限配置，如果为空则不对权限做编辑，如果有则覆盖旧的权限配置
+	Privilege *CustomerStrategyPrivilege `json:"privilege,omitempty"`
+	// RangeAdd 向管理范围添加的节点
+	RangeAdd []CustomerStrategyRange `json:"range_add,omitempty"`
+	// RangeDel 从管理范围删除的节点
+	RangeDel []CustomerStrategyRange `json:"range_del,omitempty"`
+}
@@ -0,0 +1,49 @@
+package workwx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	fakeAccessToken = "fake_access_token"
+	// fakeErrorResp 模拟企微返回的业务错误
+	fakeErrorResp = `{"errcode":40001,"errmsg":"invalid credential"}`
+)
+
+// newFakeApp 启动模拟的企微服务端，返回请求该服务端的App
+// gettoken 接口固定返回 fakeAccessToken，其余请求交给handler处理
+func newFakeApp(t *testing.T, handler http.HandlerFunc) *App {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/gettoken", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok","access_token":"` + fakeAccessToken + `","expires_in":7200}`))
+	})
+	mux.HandleFunc("/", handler)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	// 不使用WithApp，避免启动access_token刷新协程
+	app := &App{
+		WorkWX:      New("fake_corp_id", WithQYAPIHost(server.URL)),
+		CorpSecret:  "fake_corp_secret",
+		AgentID:     1,
+		accessToken: &token{mutex: &sync.RWMutex{}},
+	}
+	app.accessToken.setGetTokenFunc(app.getAccessToken)
+
+	return app
+}
+
+// assertFakeClientError 断言err为fakeErrorResp对应的业务错误
+func assertFakeClientError(t *testing.T, err error) {
+	var clientErr *ClientError
+	if assert.True(t, errors.As(err, &clientErr)) {
+		assert.Equal(t, int64(40001), clientErr.Code)
+	}
+}
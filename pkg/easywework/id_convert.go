@@ -0,0 +1,36 @@
+package workwx
+
+// UnionidToExternalUserid unionid与external_userid的关联
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#unionid转换为external_userid
+func (c *App) UnionidToExternalUserid(req UnionidToExternalUseridReq) (externalUserID string, pendingID string, err error) {
+	var resp unionidToExternalUseridResp
+	resp, err = c.execUnionidToExternalUserid(req)
+	if err != nil {
+		return "", "", err
+	}
+	return resp.ExternalUserid, resp.PendingID, nil
+}
+
+// ExternalUseridToPendingID external_userid查询pending_id
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#external_userid查询pending_id
+func (c *App) ExternalUseridToPendingID(req ExternalUseridToPendingIDReq) (result []ExternalUseridPendingID, err error) {
+	var resp externalUseridToPendingIDResp
+	resp, err = c.execExternalUseridToPendingID(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// GetNewExternalUserid 转换客户external_userid
+// 文档：https://developer.work.weixin.qq.com/document/path/95884#转换客户external_userid
+func (c *App) GetNewExternalUserid(externalUserIDs []string) (items []NewExternalUserid, err error) {
+	var resp getNewExternalUseridResp
+	resp, err = c.execGetNewExternalUserid(getNewExternalUseridReq{
+		ExternalUseridList: externalUserIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
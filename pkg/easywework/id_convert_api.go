@@ -0,0 +1,114 @@
+package workwx
+
+import (
+	"encoding/json"
+)
+
+var _ bodyer = UnionidToExternalUseridReq{}
+
+func (x UnionidToExternalUseridReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// unionidToExternalUseridResp unionid转换为external_userid响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#unionid转换为external_userid
+type unionidToExternalUseridResp struct {
+	CommonResp
+	// ExternalUserid 该授权企业的外部联系人ID
+	ExternalUserid string `json:"external_userid"`
+	// PendingID 该微信客户尚未添加企业成员时返回的临时id
+	PendingID string `json:"pending_id"`
+}
+
+// execUnionidToExternalUserid unionid转换为external_userid
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#unionid转换为external_userid
+func (c *App) execUnionidToExternalUserid(req UnionidToExternalUseridReq) (unionidToExternalUseridResp, error) {
+	var resp unionidToExternalUseridResp
+	err := c.executeWXApiJSONPost("/cgi-bin/idconvert/unionid_to_external_userid", req, &resp, true)
+	if err != nil {
+		return unionidToExternalUseridResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return unionidToExternalUseridResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+var _ bodyer = ExternalUseridToPendingIDReq{}
+
+func (x ExternalUseridToPendingIDReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// externalUseridToPendingIDResp external_userid查询pending_id响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#external_userid查询pending_id
+type externalUseridToPendingIDResp struct {
+	CommonResp
+	Result []ExternalUseridPendingID `json:"result"`
+}
+
+// execExternalUseridToPendingID external_userid查询pending_id
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#external_userid查询pending_id
+func (c *App) execExternalUseridToPendingID(req ExternalUseridToPendingIDReq) (externalUseridToPendingIDResp, error) {
+	var resp externalUseridToPendingIDResp
+	err := c.executeWXApiJSONPost("/cgi-bin/idconvert/batch/external_userid_to_pending_id", req, &resp, true)
+	if err != nil {
+		return externalUseridToPendingIDResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return externalUseridToPendingIDResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// getNewExternalUseridReq 转换客户external_userid请求
+// 文档：https://developer.work.weixin.qq.com/document/path/95884#转换客户external_userid
+type getNewExternalUseridReq struct {
+	// ExternalUseridList 旧外部联系人ID列表，最多不超过1000个，必填
+	ExternalUseridList []string `json:"external_userid_list"`
+}
+
+var _ bodyer = getNewExternalUseridReq{}
+
+func (x getNewExternalUseridReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getNewExternalUseridResp 转换客户external_userid响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95884#转换客户external_userid
+type getNewExternalUseridResp struct {
+	CommonResp
+	Items []NewExternalUserid `json:"items"`
+}
+
+// execGetNewExternalUserid 转换客户external_userid
+// 文档：https://developer.work.weixin.qq.com/document/path/95884#转换客户external_userid
+func (c *App) execGetNewExternalUserid(req getNewExternalUseridReq) (getNewExternalUseridResp, error) {
+	var resp getNewExternalUseridResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/get_new_external_userid", req, &resp, true)
+	if err != nil {
+		return getNewExternalUseridResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getNewExternalUseridResp{}, bizErr
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,49 @@
+package workwx
+
+// UnionidSubjectType unionid对应的主体类型
+// 0-企业主体
+// 1-服务商主体
+type UnionidSubjectType int
+
+const (
+	// UnionidSubjectTypeCorp 企业主体
+	UnionidSubjectTypeCorp UnionidSubjectType = 0
+	// UnionidSubjectTypeProvider 服务商主体
+	UnionidSubjectTypeProvider UnionidSubjectType = 1
+)
+
+// UnionidToExternalUseridReq unionid转换为external_userid请求
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#unionid转换为external_userid
+type UnionidToExternalUseridReq struct {
+	// Unionid 微信客户的unionid，必填
+	Unionid string `json:"unionid"`
+	// Openid 微信客户的openid，必填
+	Openid string `json:"openid"`
+	// SubjectType 小程序或公众号的主体类型，0表示主体名称是企业的，1表示主体名称是服务商的
+	SubjectType UnionidSubjectType `json:"subject_type"`
+}
+
+// ExternalUseridToPendingIDReq external_userid查询pending_id请求
+// 文档：https://developer.work.weixin.qq.com/document/path/95900#external_userid查询pending_id
+type ExternalUseridToPendingIDReq struct {
+	// ChatID 群id，如果有传入该参数，则只检查群主是否在可见范围，同时会忽略在该群以外的external_userid
+	ChatID string `json:"chat_id,omitempty"`
+	// ExternalUserid 该企业的外部联系人ID，最多可同时查询100个外部联系人，必填
+	ExternalUserid []string `json:"external_userid"`
+}
+
+// ExternalUseridPendingID external_userid与pending_id的对应关系
+type ExternalUseridPendingID struct {
+	// ExternalUserid 外部联系人ID
+	ExternalUserid string `json:"external_userid"`
+	// PendingID 该微信客户的临时id，有效期90天，关联上external_userid后才能通过unionid查询到
+	PendingID string `json:"pending_id"`
+}
+
+// NewExternalUserid 新旧external_userid的对应关系
+type NewExternalUserid struct {
+	// ExternalUserid 旧外部联系人ID
+	ExternalUserid string `json:"external_userid"`
+	// NewExternalUserid 新外部联系人ID
+	NewExternalUserid string `json:"new_external_userid"`
+}
@@ -164,12 +164,18 @@ func generateCode(apis []*Api) (result []byte, err error) {
 
 //注意设置运行的主目录
 //apicodegen https://work.weixin.qq.com/api/doc/90000/90135/92572 output/contact_way_api.go
+//根据接口声明文件生成代码，见spec目录
+//apicodegen spec ./spec/moment.yaml ../..
 func main() {
 	//检查启动参数
 	if len(os.Args) <= 1 {
 		die("invalid param, example: apicodegen URL [SAVEPATH]")
 		return
 	}
+	if os.Args[1] == "spec" {
+		runSpec(os.Args[2:])
+		return
+	}
 	docURL := os.Args[1]
 	savePath := os.Args[2]
 	fmt.Printf("开始抓取和生成API代码，文档地址:%s，代码保存路径:%s\n", docURL, savePath)
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/pkg/errors"
+	"go/format"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed spec_*.tmpl
+var specTemplates embed.FS
+
+// 字段类型，数组类型在前面加[]，如[]string、[]object
+const (
+	specTypeString  = "string"
+	specTypeInt     = "int"
+	specTypeInt64   = "int64"
+	specTypeFloat64 = "float64"
+	specTypeBool    = "bool"
+	specTypeObject  = "object"
+)
+
+// 调用凭证类型
+const (
+	specTokenAccessToken = "access_token"
+	specTokenNone        = "none"
+)
+
+// acronyms 字段名中需要全大写的缩写
+var acronyms = map[string]string{
+	"id":  "ID",
+	"url": "URL",
+	"api": "API",
+	"ip":  "IP",
+}
+
+// Spec 接口声明文件
+type Spec struct {
+	// DocURL 接口文档地址
+	DocURL string `yaml:"doc_url"`
+	// Apis 接口列表
+	Apis []*SpecApi `yaml:"apis"`
+}
+
+// SpecApi 单个接口的声明
+type SpecApi struct {
+	// Name 接口名，生成 XxxReq、xxxResp 和 execXxx
+	Name string `yaml:"name"`
+	// Title 接口中文名
+	Title string `yaml:"title"`
+	// Anchor 文档锚点，默认为 Title
+	Anchor string `yaml:"anchor"`
+	// Method 请求方式，GET或POST
+	Method string `yaml:"method"`
+	// Path 请求路径，如/cgi-bin/externalcontact/get_moment_list
+	Path string `yaml:"path"`
+	// Token 调用凭证类型，access_token或none，默认access_token
+	Token string `yaml:"token"`
+	// Request 请求参数
+	Request []*SpecField `yaml:"request"`
+	// Response 返回参数，不包含errcode和errmsg
+	Response []*SpecField `yaml:"response"`
+
+	ReqType       string
+	RespType      string
+	WithToken     bool
+	ReqStruct     string
+	RespStruct    string
+	ReqSample     string
+	RespSample    string
+	QuerySample   [][2]string
+	URLValuesCode string
+}
+
+// SpecField 接口字段的声明
+type SpecField struct {
+	// Name json字段名
+	Name string `yaml:"name"`
+	// GoName Go字段名，默认由Name转为驼峰
+	GoName string `yaml:"go_name"`
+	// Type 字段类型
+	Type string `yaml:"type"`
+	// TypeName object类型生成的结构体名，默认为 接口名+字段名
+	TypeName string `yaml:"type_name"`
+	// Required 是否必填，非必填的请求参数序列化时忽略零值
+	Required bool `yaml:"required"`
+	// Desc 字段说明
+	Desc string `yaml:"desc"`
+	// Fields object类型的子字段
+	Fields []*SpecField `yaml:"fields"`
+}
+
+// specStruct 待生成的结构体
+type specStruct struct {
+	Name   string
+	Desc   string
+	Fields []*SpecField
+	IsReq  bool
+	Embed  string
+}
+
+// specGenerator 根据接口声明生成代码
+type specGenerator struct {
+	spec       Spec
+	specPath   string
+	baseName   string
+	models     []specStruct
+	modelNames map[string]bool
+}
+
+// generateFromSpec 根据声明文件生成 xxx_model.go、xxx_api.go 和 xxx_api_test.go
+func generateFromSpec(specPath string, outputDir string) error {
+	content, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		return errors.Wrap(err, "read spec failed")
+	}
+
+	g := &specGenerator{
+		specPath:   filepath.ToSlash(filepath.Clean(specPath)),
+		baseName:   strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath)),
+		modelNames: make(map[string]bool),
+	}
+	err = yaml.Unmarshal(content, &g.spec)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal spec failed")
+	}
+
+	for _, api := range g.spec.Apis {
+		err = g.prepare(api)
+		if err != nil {
+			return errors.Wrapf(err, "prepare api %s failed", api.Name)
+		}
+	}
+
+	files := map[string]string{
+		"spec_model.tmpl":    g.baseName + "_model.go",
+		"spec_api.tmpl":      g.baseName + "_api.go",
+		"spec_api_test.tmpl": g.baseName + "_api_test.go",
+	}
+	for _, tmplName := range sortedKeys(files) {
+		fileName := files[tmplName]
+		code, err := g.render(tmplName)
+		if err != nil {
+			return err
+		}
+
+		savePath := filepath.Join(outputDir, fileName)
+		err = ioutil.WriteFile(savePath, code, 0644)
+		if err != nil {
+			return errors.Wrap(err, "write file failed")
+		}
+		fmt.Printf("保存文件成功:%s\n", savePath)
+	}
+
+	return nil
+}
+
+// prepare 校验接口声明，生成结构体和测试数据
+func (g *specGenerator) prepare(api *SpecApi) error {
+	if api.Name == "" || api.Path == "" {
+		return errors.New("name and path are required")
+	}
+
+	api.Method = strings.ToUpper(api.Method)
+	if api.Method == "" {
+		api.Method = "POST"
+	}
+	if api.Method != "GET" && api.Method != "POST" {
+		return errors.Errorf("unsupported method %s", api.Method)
+	}
+
+	if api.Token == "" {
+		api.Token = specTokenAccessToken
+	}
+	if api.Token != specTokenAccessToken && api.Token != specTokenNone {
+		return errors.Errorf("unsupported token %s", api.Token)
+	}
+	api.WithToken = api.Token == specTokenAccessToken
+
+	if api.Anchor == "" {
+		api.Anchor = api.Title
+	}
+
+	api.ReqType = api.Name + "Req"
+	api.RespType = strcase.ToLowerCamel(api.Name) + "Resp"
+
+	err := g.prepareFields(api.Name, api.Request, api.Method == "GET")
+	if err != nil {
+		return err
+	}
+	err = g.prepareFields(api.Name, api.Response, false)
+	if err != nil {
+		return err
+	}
+
+	api.ReqStruct = g.renderStruct(specStruct{Name: api.ReqType, Desc: api.Title + "请求", Fields: api.Request, IsReq: true})
+	api.RespStruct = g.renderStruct(specStruct{Name: api.RespType, Desc: api.Title + "响应", Fields: api.Response, Embed: "CommonResp"})
+
+	reqSample, err := json.Marshal(sampleObject(api.Request))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	api.ReqSample = string(reqSample)
+
+	respSample := sampleObject(api.Response)
+	respSample["errcode"] = 0
+	respSample["errmsg"] = "ok"
+	respJSON, err := json.Marshal(respSample)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	api.RespSample = string(respJSON)
+
+	if api.Method == "GET" {
+		api.URLValuesCode = renderURLValues(api.Request)
+		for _, field := range api.Request {
+			api.QuerySample = append(api.QuerySample, [2]string{field.Name, fmt.Sprint(sampleValue(field))})
+		}
+	}
+
+	return nil
+}
+
+// prepareFields 补全字段的Go名称和类型，收集需要生成的子结构体
+func (g *specGenerator) prepareFields(prefix string, fields []*SpecField, scalarOnly bool) error {
+	for _, field := range fields {
+		if field.Name == "" {
+			return errors.New("field name is required")
+		}
+		if field.GoName == "" {
+			field.GoName = goName(field.Name)
+		}
+
+		elemType := strings.TrimPrefix(field.Type, "[]")
+		isArray := elemType != field.Type
+		switch elemType {
+		case specTypeString, specTypeInt, specTypeInt64, specTypeFloat64, specTypeBool:
+			if scalarOnly && isArray {
+				return errors.Errorf("GET request field %s must be scalar", field.Name)
+			}
+		case specTypeObject:
+			if scalarOnly {
+				return errors.Errorf("GET request field %s must be scalar", field.Name)
+			}
+			if field.TypeName == "" {
+				field.TypeName = prefix + field.GoName
+			}
+			if g.modelNames[field.TypeName] {
+				return errors.Errorf("duplicated type name %s", field.TypeName)
+			}
+			g.modelNames[field.TypeName] = true
+
+			err := g.prepareFields(field.TypeName, field.Fields, false)
+			if err != nil {
+				return err
+			}
+			g.models = append(g.models, specStruct{Name: field.TypeName, Desc: field.Desc, Fields: field.Fields})
+		default:
+			return errors.Errorf("unsupported type %s of field %s", field.Type, field.Name)
+		}
+	}
+
+	return nil
+}
+
+// render 渲染模板并格式化代码
+func (g *specGenerator) render(tmplName string) ([]byte, error) {
+	tpl, err := template.New(tmplName).ParseFS(specTemplates, tmplName)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template failed")
+	}
+
+	hasMethod := func(method string) bool {
+		for _, api := range g.spec.Apis {
+			if api.Method == method {
+				return true
+			}
+		}
+		return false
+	}
+
+	models := make([]string, 0, len(g.models))
+	for _, model := range g.models {
+		models = append(models, g.renderStruct(model))
+	}
+
+	buf := bytes.NewBufferString("")
+	err = tpl.Execute(buf, map[string]interface{}{
+		"SpecPath": g.specPath,
+		"DocURL":   g.spec.DocURL,
+		"Apis":     g.spec.Apis,
+		"Models":   models,
+		"HasGet":   hasMethod("GET"),
+		"HasPost":  hasMethod("POST"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "execute template failed")
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "format %s failed:\n%s", tmplName, buf.String())
+	}
+
+	return code, nil
+}
+
+// renderStruct 生成结构体定义
+func (g *specGenerator) renderStruct(s specStruct) string {
+	buf := bytes.NewBufferString("")
+	fmt.Fprintf(buf, "// %s %s\n", s.Name, s.Desc)
+	fmt.Fprintf(buf, "type %s struct {\n", s.Name)
+	if s.Embed != "" {
+		fmt.Fprintf(buf, "\t%s\n", s.Embed)
+	}
+	for _, field := range s.Fields {
+		desc := field.Desc
+		jsonFlag := ""
+		if s.IsReq {
+			if field.Required {
+				desc += "，必填"
+			} else {
+				jsonFlag = ",omitempty"
+			}
+		}
+		fmt.Fprintf(buf, "\t// %s %s\n", field.GoName, desc)
+		fmt.Fprintf(buf, "\t%s %s `json:\"%s%s\"`\n", field.GoName, goType(field), field.Name, jsonFlag)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// renderURLValues 生成GET请求的intoURLValues方法体
+func renderURLValues(fields []*SpecField) string {
+	buf := bytes.NewBufferString("values := url.Values{}\n")
+	for _, field := range fields {
+		value := fmt.Sprintf("fmt.Sprint(x.%s)", field.GoName)
+		notZero := fmt.Sprintf("x.%s != 0", field.GoName)
+		switch field.Type {
+		case specTypeString:
+			value = "x." + field.GoName
+			notZero = fmt.Sprintf(`x.%s != ""`, field.GoName)
+		case specTypeBool:
+			notZero = "x." + field.GoName
+		}
+
+		if field.Required {
+			fmt.Fprintf(buf, "values.Set(%q, %s)\n", field.Name, value)
+			continue
+		}
+		fmt.Fprintf(buf, "if %s {\nvalues.Set(%q, %s)\n}\n", notZero, field.Name, value)
+	}
+	buf.WriteString("return values")
+	return buf.String()
+}
+
+// goName 将json字段名转为Go字段名，如product_id转为ProductID
+func goName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if acronym, ok := acronyms[strings.ToLower(part)]; ok {
+			parts[i] = acronym
+			continue
+		}
+		parts[i] = strcase.ToCamel(part)
+	}
+	return strings.Join(parts, "")
+}
+
+func goType(field *SpecField) string {
+	elemType := strings.TrimPrefix(field.Type, "[]")
+	prefix := strings.TrimSuffix(field.Type, elemType)
+	if elemType == specTypeObject {
+		return prefix + field.TypeName
+	}
+	return prefix + elemType
+}
+
+// sampleObject 生成用于往返测试的样例数据，所有字段均为非零值
+func sampleObject(fields []*SpecField) map[string]interface{} {
+	res := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		res[field.Name] = sampleValue(field)
+	}
+	return res
+}
+
+func sampleValue(field *SpecField) interface{} {
+	elemType := strings.TrimPrefix(field.Type, "[]")
+	var value interface{}
+	switch elemType {
+	case specTypeString:
+		value = field.Name + "_value"
+	case specTypeInt, specTypeInt64:
+		value = len(field.Name)
+	case specTypeFloat64:
+		value = float64(len(field.Name)) + 0.5
+	case specTypeBool:
+		value = true
+	case specTypeObject:
+		value = sampleObject(field.Fields)
+	}
+
+	if elemType != field.Type {
+		return []interface{}{value}
+	}
+	return value
+}
+
+// sortedKeys 用于保证生成代码的顺序稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runSpec apicodegen spec SPECFILE [OUTPUTDIR]
+func runSpec(args []string) {
+	if len(args) < 1 {
+		die("invalid param, example: apicodegen spec ./spec/moment.yaml ../..\n")
+	}
+
+	outputDir := "."
+	if len(args) > 1 {
+		outputDir = args[1]
+	}
+
+	err := generateFromSpec(args[0], outputDir)
+	if err != nil {
+		die("generateFromSpec failed: %+v\n", err)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,10 @@
+package workwx
+
+//go:generate go run ./internal/apicodegen spec ./internal/apicodegen/spec/mass_msg_cancel.yaml .
+
+// CancelGroupMsgSend 停止企业群发，已发送的消息不受影响，尚未发送的成员将无法再发送
+// 文档：https://developer.work.weixin.qq.com/document/path/97610#停止企业群发
+func (c *App) CancelGroupMsgSend(msgID string) error {
+	_, err := c.execCancelGroupMsgSend(CancelGroupMsgSendReq{MsgID: msgID})
+	return err
+}
@@ -0,0 +1,44 @@
+// Code generated by apicodegen from internal/apicodegen/spec/mass_msg_cancel.yaml. DO NOT EDIT.
+
+package workwx
+
+import (
+	"encoding/json"
+)
+
+// CancelGroupMsgSendReq 停止企业群发请求
+type CancelGroupMsgSendReq struct {
+	// MsgID 群发消息的id，通过获取群发记录列表接口返回，必填
+	MsgID string `json:"msgid"`
+}
+
+var _ bodyer = CancelGroupMsgSendReq{}
+
+func (x CancelGroupMsgSendReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// cancelGroupMsgSendResp 停止企业群发响应
+type cancelGroupMsgSendResp struct {
+	CommonResp
+}
+
+// execCancelGroupMsgSend 停止企业群发
+// 文档：https://developer.work.weixin.qq.com/document/path/97610#停止企业群发
+func (c *App) execCancelGroupMsgSend(req CancelGroupMsgSendReq) (cancelGroupMsgSendResp, error) {
+	var resp cancelGroupMsgSendResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/cancel_groupmsg_send", req, &resp, true)
+	if err != nil {
+		return cancelGroupMsgSendResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return cancelGroupMsgSendResp{}, bizErr
+	}
+
+	return resp, nil
+}
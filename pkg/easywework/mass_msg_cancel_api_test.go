@@ -0,0 +1,46 @@
+// Code generated by apicodegen from internal/apicodegen/spec/mass_msg_cancel.yaml. DO NOT EDIT.
+
+package workwx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCancelGroupMsgSend(t *testing.T) {
+	reqJSON := `{"msgid":"msgid_value"}`
+	respJSON := `{"errcode":0,"errmsg":"ok"}`
+
+	var req CancelGroupMsgSendReq
+	assert.NoError(t, json.Unmarshal([]byte(reqJSON), &req))
+
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/cgi-bin/externalcontact/cancel_groupmsg_send", r.URL.Path)
+		assert.Equal(t, fakeAccessToken, r.URL.Query().Get("access_token"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, reqJSON, string(body))
+		_, _ = w.Write([]byte(respJSON))
+	})
+
+	resp, err := app.execCancelGroupMsgSend(req)
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, respJSON, string(actual))
+}
+
+func TestExecCancelGroupMsgSendError(t *testing.T) {
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeErrorResp))
+	})
+
+	_, err := app.execCancelGroupMsgSend(CancelGroupMsgSendReq{})
+	assertFakeClientError(t, err)
+}
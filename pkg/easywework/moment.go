@@ -0,0 +1,43 @@
+package workwx
+
+//go:generate go run ./internal/apicodegen spec ./internal/apicodegen/spec/moment.yaml .
+
+// GetMomentList 获取企业全部的发表列表
+// 文档：https://developer.work.weixin.qq.com/document/path/93333#获取企业全部的发表列表
+func (c *App) GetMomentList(req GetMomentListReq) (moments []Moment, nextCursor string, err error) {
+	resp, err := c.execGetMomentList(req)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.MomentList, resp.NextCursor, nil
+}
+
+// GetMomentTask 获取客户朋友圈企业发表的列表
+// 文档：https://developer.work.weixin.qq.com/document/path/93333#获取客户朋友圈企业发表的列表
+func (c *App) GetMomentTask(req GetMomentTaskReq) (tasks []MomentTask, nextCursor string, err error) {
+	resp, err := c.execGetMomentTask(req)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.TaskList, resp.NextCursor, nil
+}
+
+// GetMomentCustomerList 获取客户朋友圈发表时选择的可见范围
+// 文档：https://developer.work.weixin.qq.com/document/path/93333#获取客户朋友圈发表时选择的可见范围
+func (c *App) GetMomentCustomerList(req GetMomentCustomerListReq) (customers []MomentCustomer, nextCursor string, err error) {
+	resp, err := c.execGetMomentCustomerList(req)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.CustomerList, resp.NextCursor, nil
+}
+
+// GetMomentComments 获取客户朋友圈的互动数据
+// 文档：https://developer.work.weixin.qq.com/document/path/93333#获取客户朋友圈的互动数据
+func (c *App) GetMomentComments(momentID string, userID string) (comments []MomentComment, likes []MomentLike, err error) {
+	resp, err := c.execGetMomentComments(GetMomentCommentsReq{MomentID: momentID, UserID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.CommentList, resp.LikeList, nil
+}
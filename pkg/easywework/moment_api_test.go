@@ -0,0 +1,148 @@
+// Code generated by apicodegen from internal/apicodegen/spec/moment.yaml. DO NOT EDIT.
+
+package workwx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecGetMomentList(t *testing.T) {
+	reqJSON := `{"creator":"creator_value","cursor":"cursor_value","end_time":8,"filter_type":11,"limit":5,"start_time":10}`
+	respJSON := `{"errcode":0,"errmsg":"ok","moment_list":[{"create_time":11,"create_type":11,"creator":"creator_value","image":[{"media_id":"media_id_value"}],"link":{"title":"title_value","url":"url_value"},"location":{"latitude":"latitude_value","longitude":"longitude_value","name":"name_value"},"moment_id":"moment_id_value","text":{"content":"content_value"},"video":{"media_id":"media_id_value","thumb_media_id":"thumb_media_id_value"},"visible_type":12}],"next_cursor":"next_cursor_value"}`
+
+	var req GetMomentListReq
+	assert.NoError(t, json.Unmarshal([]byte(reqJSON), &req))
+
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/cgi-bin/externalcontact/get_moment_list", r.URL.Path)
+		assert.Equal(t, fakeAccessToken, r.URL.Query().Get("access_token"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, reqJSON, string(body))
+		_, _ = w.Write([]byte(respJSON))
+	})
+
+	resp, err := app.execGetMomentList(req)
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, respJSON, string(actual))
+}
+
+func TestExecGetMomentListError(t *testing.T) {
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeErrorResp))
+	})
+
+	_, err := app.execGetMomentList(GetMomentListReq{})
+	assertFakeClientError(t, err)
+}
+
+func TestExecGetMomentTask(t *testing.T) {
+	reqJSON := `{"cursor":"cursor_value","limit":5,"moment_id":"moment_id_value"}`
+	respJSON := `{"errcode":0,"errmsg":"ok","next_cursor":"next_cursor_value","task_list":[{"publish_status":14,"userid":"userid_value"}]}`
+
+	var req GetMomentTaskReq
+	assert.NoError(t, json.Unmarshal([]byte(reqJSON), &req))
+
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/cgi-bin/externalcontact/get_moment_task", r.URL.Path)
+		assert.Equal(t, fakeAccessToken, r.URL.Query().Get("access_token"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, reqJSON, string(body))
+		_, _ = w.Write([]byte(respJSON))
+	})
+
+	resp, err := app.execGetMomentTask(req)
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, respJSON, string(actual))
+}
+
+func TestExecGetMomentTaskError(t *testing.T) {
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeErrorResp))
+	})
+
+	_, err := app.execGetMomentTask(GetMomentTaskReq{})
+	assertFakeClientError(t, err)
+}
+
+func TestExecGetMomentCustomerList(t *testing.T) {
+	reqJSON := `{"cursor":"cursor_value","limit":5,"moment_id":"moment_id_value","userid":"userid_value"}`
+	respJSON := `{"customer_list":[{"external_userid":"external_userid_value","userid":"userid_value"}],"errcode":0,"errmsg":"ok","next_cursor":"next_cursor_value"}`
+
+	var req GetMomentCustomerListReq
+	assert.NoError(t, json.Unmarshal([]byte(reqJSON), &req))
+
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/cgi-bin/externalcontact/get_moment_customer_list", r.URL.Path)
+		assert.Equal(t, fakeAccessToken, r.URL.Query().Get("access_token"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, reqJSON, string(body))
+		_, _ = w.Write([]byte(respJSON))
+	})
+
+	resp, err := app.execGetMomentCustomerList(req)
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, respJSON, string(actual))
+}
+
+func TestExecGetMomentCustomerListError(t *testing.T) {
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeErrorResp))
+	})
+
+	_, err := app.execGetMomentCustomerList(GetMomentCustomerListReq{})
+	assertFakeClientError(t, err)
+}
+
+func TestExecGetMomentComments(t *testing.T) {
+	reqJSON := `{"moment_id":"moment_id_value","userid":"userid_value"}`
+	respJSON := `{"comment_list":[{"create_time":11,"external_userid":"external_userid_value","userid":"userid_value"}],"errcode":0,"errmsg":"ok","like_list":[{"create_time":11,"external_userid":"external_userid_value","userid":"userid_value"}]}`
+
+	var req GetMomentCommentsReq
+	assert.NoError(t, json.Unmarshal([]byte(reqJSON), &req))
+
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/cgi-bin/externalcontact/get_moment_comments", r.URL.Path)
+		assert.Equal(t, fakeAccessToken, r.URL.Query().Get("access_token"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, reqJSON, string(body))
+		_, _ = w.Write([]byte(respJSON))
+	})
+
+	resp, err := app.execGetMomentComments(req)
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, respJSON, string(actual))
+}
+
+func TestExecGetMomentCommentsError(t *testing.T) {
+	app := newFakeApp(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeErrorResp))
+	})
+
+	_, err := app.execGetMomentComments(GetMomentCommentsReq{})
+	assertFakeClientError(t, err)
+}
@@ -0,0 +1,101 @@
+// Code generated by apicodegen from internal/apicodegen/spec/moment.yaml. DO NOT EDIT.
+
+package workwx
+
+// MomentText 文本消息结构
+type MomentText struct {
+	// Content 文本消息内容
+	Content string `json:"content"`
+}
+
+// MomentImage 图片消息附件
+type MomentImage struct {
+	// MediaID 图片的media_id列表，可以通过获取临时素材下载资源
+	MediaID string `json:"media_id"`
+}
+
+// MomentVideo 视频消息附件
+type MomentVideo struct {
+	// MediaID 视频media_id，可以通过获取临时素材下载资源
+	MediaID string `json:"media_id"`
+	// ThumbMediaID 视频封面media_id，可以通过获取临时素材下载资源
+	ThumbMediaID string `json:"thumb_media_id"`
+}
+
+// MomentLink 网页链接消息附件
+type MomentLink struct {
+	// Title 网页链接标题
+	Title string `json:"title"`
+	// URL 网页链接url
+	URL string `json:"url"`
+}
+
+// MomentLocation 地理位置消息附件
+type MomentLocation struct {
+	// Latitude 地理位置纬度
+	Latitude string `json:"latitude"`
+	// Longitude 地理位置经度
+	Longitude string `json:"longitude"`
+	// Name 地理位置名称
+	Name string `json:"name"`
+}
+
+// Moment 朋友圈列表
+type Moment struct {
+	// MomentID 朋友圈id
+	MomentID string `json:"moment_id"`
+	// Creator 朋友圈创建者userid，企业发表内容到客户的朋友圈则返回企业的userid
+	Creator string `json:"creator"`
+	// CreateTime 创建时间
+	CreateTime int64 `json:"create_time"`
+	// CreateType 朋友圈创建来源。0：企业 1：个人
+	CreateType int64 `json:"create_type"`
+	// VisibleType 可见范围类型。0：部分可见 1：公开
+	VisibleType int64 `json:"visible_type"`
+	// Text 文本消息结构
+	Text MomentText `json:"text"`
+	// Image 图片消息附件
+	Image []MomentImage `json:"image"`
+	// Video 视频消息附件
+	Video MomentVideo `json:"video"`
+	// Link 网页链接消息附件
+	Link MomentLink `json:"link"`
+	// Location 地理位置消息附件
+	Location MomentLocation `json:"location"`
+}
+
+// MomentTask 发表任务列表
+type MomentTask struct {
+	// UserID 发表成员用户userid
+	UserID string `json:"userid"`
+	// PublishStatus 成员发表状态。0：未发表 1：已发表
+	PublishStatus int64 `json:"publish_status"`
+}
+
+// MomentCustomer 成员可见客户列表
+type MomentCustomer struct {
+	// UserID 发表成员用户userid
+	UserID string `json:"userid"`
+	// ExternalUserID 发送成功的外部联系人userid
+	ExternalUserID string `json:"external_userid"`
+}
+
+// MomentComment 评论列表
+type MomentComment struct {
+	// ExternalUserID 评论的外部联系人userid，与userid二选一
+	ExternalUserID string `json:"external_userid"`
+	// UserID 评论的企业内部联系人userid，与external_userid二选一
+	UserID string `json:"userid"`
+	// CreateTime 评论时间
+	CreateTime int64 `json:"create_time"`
+}
+
+// MomentLike 点赞列表
+type MomentLike struct {
+	// ExternalUserID 点赞的外部联系人userid，与userid二选一
+	ExternalUserID string `json:"external_userid"`
+	// UserID 点赞的企业内部联系人userid，与external_userid二选一
+	UserID string `json:"userid"`
+	// CreateTime 点赞时间
+	CreateTime int64 `json:"create_time"`
+}
@@ -0,0 +1,45 @@
+package workwx
+
+// AddProductAlbum 创建商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#创建商品图册
+func (c *App) AddProductAlbum(req ProductAlbum) (productID string, err error) {
+	resp, err := c.execAddProductAlbum(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.ProductID, nil
+}
+
+// GetProductAlbum 获取商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册
+func (c *App) GetProductAlbum(productID string) (ProductAlbum, error) {
+	resp, err := c.execGetProductAlbum(productAlbumIDReq{ProductID: productID})
+	if err != nil {
+		return ProductAlbum{}, err
+	}
+	return resp.Product, nil
+}
+
+// GetProductAlbumList 获取商品图册列表
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册列表
+func (c *App) GetProductAlbumList(req ProductAlbumListReq) (ProductAlbumListResp, error) {
+	resp, err := c.execGetProductAlbumList(req)
+	if err != nil {
+		return ProductAlbumListResp{}, err
+	}
+	return resp.ProductAlbumListResp, nil
+}
+
+// UpdateProductAlbum 编辑商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#编辑商品图册
+func (c *App) UpdateProductAlbum(req ProductAlbum) error {
+	_, err := c.execUpdateProductAlbum(req)
+	return err
+}
+
+// DeleteProductAlbum 删除商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#删除商品图册
+func (c *App) DeleteProductAlbum(productID string) error {
+	_, err := c.execDeleteProductAlbum(productAlbumIDReq{ProductID: productID})
+	return err
+}
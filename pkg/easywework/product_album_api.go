@@ -0,0 +1,139 @@
+package workwx
+
+import (
+	"encoding/json"
+)
+
+var _ bodyer = ProductAlbum{}
+
+func (x ProductAlbum) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// addProductAlbumResp 创建商品图册响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#创建商品图册
+type addProductAlbumResp struct {
+	CommonResp
+	ProductID string `json:"product_id"`
+}
+
+// execAddProductAlbum 创建商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#创建商品图册
+func (c *App) execAddProductAlbum(req ProductAlbum) (addProductAlbumResp, error) {
+	var resp addProductAlbumResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/add_product_album", req, &resp, true)
+	if err != nil {
+		return addProductAlbumResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return addProductAlbumResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// productAlbumIDReq 只包含商品id的请求，用于获取和删除商品图册
+type productAlbumIDReq struct {
+	ProductID string `json:"product_id"`
+}
+
+var _ bodyer = productAlbumIDReq{}
+
+func (x productAlbumIDReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getProductAlbumResp 获取商品图册响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册
+type getProductAlbumResp struct {
+	CommonResp
+	Product ProductAlbum `json:"product"`
+}
+
+// execGetProductAlbum 获取商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册
+func (c *App) execGetProductAlbum(req productAlbumIDReq) (getProductAlbumResp, error) {
+	var resp getProductAlbumResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/get_product_album", req, &resp, true)
+	if err != nil {
+		return getProductAlbumResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getProductAlbumResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+var _ bodyer = ProductAlbumListReq{}
+
+func (x ProductAlbumListReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getProductAlbumListResp 获取商品图册列表响应
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册列表
+type getProductAlbumListResp struct {
+	CommonResp
+	ProductAlbumListResp
+}
+
+// execGetProductAlbumList 获取商品图册列表
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册列表
+func (c *App) execGetProductAlbumList(req ProductAlbumListReq) (getProductAlbumListResp, error) {
+	var resp getProductAlbumListResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/get_product_album_list", req, &resp, true)
+	if err != nil {
+		return getProductAlbumListResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getProductAlbumListResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// execUpdateProductAlbum 编辑商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#编辑商品图册
+func (c *App) execUpdateProductAlbum(req ProductAlbum) (CommonResp, error) {
+	var resp CommonResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/update_product_album", req, &resp, true)
+	if err != nil {
+		return CommonResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return CommonResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+// execDeleteProductAlbum 删除商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#删除商品图册
+func (c *App) execDeleteProductAlbum(req productAlbumIDReq) (CommonResp, error) {
+	var resp CommonResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/delete_product_album", req, &resp, true)
+	if err != nil {
+		return CommonResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return CommonResp{}, bizErr
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,44 @@
+package workwx
+
+// ProductAlbumAttachment 商品图册的附件，目前仅支持图片
+type ProductAlbumAttachment struct {
+	// Type 附件类型，目前仅支持image
+	Type  string `json:"type"`
+	Image struct {
+		// MediaID 图片的media_id，可以通过上传临时素材接口获得
+		MediaID string `json:"media_id"`
+	} `json:"image"`
+}
+
+// ProductAlbum 商品图册
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册
+type ProductAlbum struct {
+	// ProductID 商品id
+	ProductID string `json:"product_id,omitempty"`
+	// Description 商品的名称、特色等，不超过300个字，必填
+	Description string `json:"description"`
+	// Price 商品的价格，单位为分，最大不超过5万元，必填
+	Price int64 `json:"price"`
+	// ProductSn 商品编码，不超过128个字节，只能输入数字和字母
+	ProductSn string `json:"product_sn,omitempty"`
+	// CreateTime 商品图册创建时间
+	CreateTime int64 `json:"create_time,omitempty"`
+	// Attachments 附件类型，仅支持image，最多不超过9个附件，必填
+	Attachments []ProductAlbumAttachment `json:"attachments"`
+}
+
+// ProductAlbumListReq 获取商品图册列表请求
+// 文档：https://developer.work.weixin.qq.com/document/path/95096#获取商品图册列表
+type ProductAlbumListReq struct {
+	// Limit 返回的最大记录数，整型，最大值100，默认值50
+	Limit int `json:"limit,omitempty"`
+	// Cursor 用于分页查询的游标，由上一次调用返回，首次调用可不填
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ProductAlbumListResp 获取商品图册列表响应
+type ProductAlbumListResp struct {
+	// NextCursor 用于分页查询的游标，字符串类型，用于下一次调用
+	NextCursor  string         `json:"next_cursor"`
+	ProductList []ProductAlbum `json:"product_list"`
+}
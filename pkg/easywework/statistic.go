@@ -0,0 +1,21 @@
+package workwx
+
+// GetUserBehaviorData 获取「联系客户统计」数据
+// 文档：https://developer.work.weixin.qq.com/document/path/92132#获取「联系客户统计」数据
+func (c *App) GetUserBehaviorData(req GetUserBehaviorDataReq) ([]BehaviorData, error) {
+	resp, err := c.execGetUserBehaviorData(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.BehaviorData, nil
+}
+
+// GetGroupChatStatistic 获取「群聊数据统计」数据（按群主聚合）
+// 文档：https://developer.work.weixin.qq.com/document/path/92133#按群主聚合的方式
+func (c *App) GetGroupChatStatistic(req GroupChatStatisticReq) (GroupChatStatisticResp, error) {
+	resp, err := c.execGroupChatStatistic(req)
+	if err != nil {
+		return GroupChatStatisticResp{}, err
+	}
+	return resp.GroupChatStatisticResp, nil
+}
@@ -0,0 +1,71 @@
+package workwx
+
+import (
+	"encoding/json"
+)
+
+var _ bodyer = GetUserBehaviorDataReq{}
+
+func (x GetUserBehaviorDataReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getUserBehaviorDataResp 获取「联系客户统计」数据响应
+// 文档：https://developer.work.weixin.qq.com/document/path/92132#获取「联系客户统计」数据
+type getUserBehaviorDataResp struct {
+	CommonResp
+	BehaviorData []BehaviorData `json:"behavior_data"`
+}
+
+// execGetUserBehaviorData 获取「联系客户统计」数据
+// 文档：https://developer.work.weixin.qq.com/document/path/92132#获取「联系客户统计」数据
+func (c *App) execGetUserBehaviorData(req GetUserBehaviorDataReq) (getUserBehaviorDataResp, error) {
+	var resp getUserBehaviorDataResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/get_user_behavior_data", req, &resp, true)
+	if err != nil {
+		return getUserBehaviorDataResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return getUserBehaviorDataResp{}, bizErr
+	}
+
+	return resp, nil
+}
+
+var _ bodyer = GroupChatStatisticReq{}
+
+func (x GroupChatStatisticReq) intoBody() ([]byte, error) {
+	result, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// groupChatStatisticResp 获取「群聊数据统计」数据响应
+// 文档：https://developer.work.weixin.qq.com/document/path/92133#按群主聚合的方式
+type groupChatStatisticResp struct {
+	CommonResp
+	GroupChatStatisticResp
+}
+
+// execGroupChatStatistic 获取「群聊数据统计」数据（按群主聚合）
+// 文档：https://developer.work.weixin.qq.com/document/path/92133#按群主聚合的方式
+func (c *App) execGroupChatStatistic(req GroupChatStatisticReq) (groupChatStatisticResp, error) {
+	var resp groupChatStatisticResp
+	err := c.executeWXApiJSONPost("/cgi-bin/externalcontact/groupchat/statistic", req, &resp, true)
+	if err != nil {
+		return groupChatStatisticResp{}, err
+	}
+	if bizErr := resp.TryIntoErr(); bizErr != nil {
+		return groupChatStatisticResp{}, bizErr
+	}
+
+	return resp, nil
+}
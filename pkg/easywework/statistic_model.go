@@ -0,0 +1,106 @@
+package workwx
+
+// GetUserBehaviorDataReq 获取「联系客户统计」数据请求
+// 文档：https://developer.work.weixin.qq.com/document/path/92132#获取「联系客户统计」数据
+type GetUserBehaviorDataReq struct {
+	// Userid 成员ID列表，最多100个，与Partyid不可同时为空
+	Userid []string `json:"userid,omitempty"`
+	// Partyid 部门ID列表，最多100个，与Userid不可同时为空
+	Partyid []int64 `json:"partyid,omitempty"`
+	// StartTime 数据起始时间，必填
+	StartTime int64 `json:"start_time"`
+	// EndTime 数据结束时间，与起始时间相差不超过30天，必填
+	EndTime int64 `json:"end_time"`
+}
+
+// BehaviorData 联系客户统计数据，按天返回
+type BehaviorData struct {
+	// StatTime 数据日期，为当日0点的时间戳
+	StatTime int64 `json:"stat_time"`
+	// ChatCnt 聊天总数，成员有主动发送过消息的单聊总数
+	ChatCnt int64 `json:"chat_cnt"`
+	// MessageCnt 发送消息数，成员在单聊中发送的消息总数
+	MessageCnt int64 `json:"message_cnt"`
+	// ReplyPercentage 已回复聊天占比，客户主动发起的聊天中成员在20小时内回复的比例，单位为百分比
+	ReplyPercentage float64 `json:"reply_percentage"`
+	// AvgReplyTime 平均首次回复时长，单位为分钟
+	AvgReplyTime int64 `json:"avg_reply_time"`
+	// NegativeFeedbackCnt 删除/拉黑成员的客户数
+	NegativeFeedbackCnt int64 `json:"negative_feedback_cnt"`
+	// NewApplyCnt 发起申请数，成员主动向客户发起的好友申请数
+	NewApplyCnt int64 `json:"new_apply_cnt"`
+	// NewContactCnt 新增客户数，成员新添加的客户数量
+	NewContactCnt int64 `json:"new_contact_cnt"`
+}
+
+// GroupChatStatisticOrderBy 群聊统计的排序方式
+// 1-新增群的数量 2-群总数 3-新增群人数 4-群总人数
+type GroupChatStatisticOrderBy int
+
+const (
+	GroupChatStatisticOrderByNewChatCnt   GroupChatStatisticOrderBy = 1
+	GroupChatStatisticOrderByChatTotal    GroupChatStatisticOrderBy = 2
+	GroupChatStatisticOrderByNewMemberCnt GroupChatStatisticOrderBy = 3
+	GroupChatStatisticOrderByMemberTotal  GroupChatStatisticOrderBy = 4
+)
+
+// GroupChatStatisticReq 获取「群聊数据统计」数据（按群主聚合）请求
+// 文档：https://developer.work.weixin.qq.com/document/path/92133#按群主聚合的方式
+type GroupChatStatisticReq struct {
+	// DayBeginTime 起始日期的时间戳，必填
+	DayBeginTime int64 `json:"day_begin_time"`
+	// DayEndTime 结束日期的时间戳，不填默认同DayBeginTime
+	DayEndTime int64 `json:"day_end_time,omitempty"`
+	// OwnerFilter 群主过滤，必填
+	OwnerFilter GroupChatStatisticOwnerFilter `json:"owner_filter"`
+	// OrderBy 排序方式，默认为1
+	OrderBy GroupChatStatisticOrderBy `json:"order_by,omitempty"`
+	// OrderAsc 是否升序，0-否 1-是，默认降序
+	OrderAsc int `json:"order_asc,omitempty"`
+	// Offset 分页，偏移量
+	Offset int `json:"offset,omitempty"`
+	// Limit 分页，预期请求的数据量，默认为500，取值范围 1 ~ 1000
+	Limit int `json:"limit,omitempty"`
+}
+
+// GroupChatStatisticOwnerFilter 群主过滤
+type GroupChatStatisticOwnerFilter struct {
+	// UseridList 群主ID列表，最多100个
+	UseridList []string `json:"userid_list"`
+}
+
+// GroupChatStatisticItem 单个群主的群聊统计
+type GroupChatStatisticItem struct {
+	// Owner 群主ID
+	Owner string                 `json:"owner"`
+	Data  GroupChatStatisticData `json:"data"`
+}
+
+// GroupChatStatisticData 群聊统计数据
+type GroupChatStatisticData struct {
+	// NewChatCnt 新增客户群数量
+	NewChatCnt int64 `json:"new_chat_cnt"`
+	// ChatTotal 截至当天客户群总数量
+	ChatTotal int64 `json:"chat_total"`
+	// ChatHasMsg 有发过消息的客户群数量
+	ChatHasMsg int64 `json:"chat_has_msg"`
+	// NewMemberCnt 客户群新增群人数
+	NewMemberCnt int64 `json:"new_member_cnt"`
+	// MemberTotal 截至当天客户群总人数
+	MemberTotal int64 `json:"member_total"`
+	// MemberHasMsg 有发过消息的群成员数
+	MemberHasMsg int64 `json:"member_has_msg"`
+	// MsgTotal 客户群消息总数
+	MsgTotal int64 `json:"msg_total"`
+	// MigrateTraineeChatCnt 截至当天新增迁移群数
+	MigrateTraineeChatCnt int64 `json:"migrate_trainee_chat_cnt"`
+}
+
+// GroupChatStatisticResp 获取「群聊数据统计」数据响应
+type GroupChatStatisticResp struct {
+	// Total 命中过滤条件的记录总个数
+	Total int `json:"total"`
+	// NextOffset 当前分页的下一个offset
+	NextOffset int                      `json:"next_offset"`
+	Items      []GroupChatStatisticItem `json:"items"`
+}
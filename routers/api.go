@@ -66,6 +66,8 @@ func NewRouter() *gin.Engine {
 	customer := controller.NewCustomer()
 	department := controller.NewDepartment()
 	loginHandler := controller.NewLogin()
+	customerIdentityHandler := controller.NewCustomerIdentity()
+	productHandler := controller.NewProduct()
 	callbackHandler := callback.NewHandler()
 	util := controller.NewUtil()
 
@@ -89,6 +91,8 @@ func NewRouter() *gin.Engine {
 		//公开可访问的Api
 		//customerPublicApiV1.Any("/action/login", loginHandler.CustomerLogin)
 		customerPublicApiV1.GET("/action/login-callback", loginHandler.CustomerLoginCallback)
+		// 小程序/公众号客户登录，由小程序服务端签名调用
+		customerPublicApiV1.POST("/action/mini-program-login", customerIdentityHandler.MiniProgramLogin)
 
 		//登录后才可访问的Api
 		//staffApiV1 := staffPublicApiV1.Use(m.RequireStaffLogin())
@@ -121,6 +125,9 @@ func NewRouter() *gin.Engine {
 		customerFrontendHandler := controller.NewCustomerFrontend()
 		staffApiV1.GET("/customer/:ext_id", customerFrontendHandler.Get)
 
+		// 侧边栏-商品图册
+		staffApiV1.GET("/products", productHandler.QueryFrontend)
+
 		// 侧边栏-提醒
 		remainderHandler := controller.NewRemainderFrontend()
 		staffApiV1.POST("/customer/remainder", remainderHandler.Create)
@@ -144,6 +151,11 @@ func NewRouter() *gin.Engine {
 		staffApiV1.POST("/quick-reply-group/action/delete", quickReplyGroupFrontend.Delete)
 		staffApiV1.PUT("/quick-reply-group", quickReplyGroupFrontend.Update)
 
+		// 渠道码-员工自行上下线
+		contactWayFrontend := controller.NewContactWayFrontend()
+		staffApiV1.GET("/contact-ways", contactWayFrontend.Query)
+		staffApiV1.POST("/contact-way/:id/action/online", contactWayFrontend.Online)
+
 		// 素材
 		materialLibFrontend := controller.NewMaterialLibFrontend()
 		staffApiV1.GET("/material/lib", materialLibFrontend.Query)
@@ -189,15 +201,42 @@ func NewRouter() *gin.Engine {
 		contactWayHandler := controller.NewContactWay()
 		staffAdminApiV1.GET("/contact-ways", m.Guard(c.BizContactWay, c.Read), contactWayHandler.Query)
 		staffAdminApiV1.GET("/contact-way/:id", m.Guard(c.BizContactWay, c.Read), contactWayHandler.Get)
+		staffAdminApiV1.GET("/contact-way/:id/analytics", m.Guard(c.BizContactWay, c.Read), contactWayHandler.Analytics)
+		staffAdminApiV1.GET("/contact-way/:id/analytics/action/export", m.Guard(c.BizContactWay, c.Read), contactWayHandler.ExportAnalytics)
+		staffAdminApiV1.GET("/contact-way/:id/distribution/action/simulate", m.Guard(c.BizContactWay, c.Read), contactWayHandler.SimulateDistribution)
+		staffAdminApiV1.GET("/contact-way/:id/schedule-logs", m.Guard(c.BizContactWay, c.Read), contactWayHandler.QueryScheduleLogs)
 		staffAdminApiV1.POST("/contact-way", m.Guard(c.BizContactWay, c.Full), contactWayHandler.Create)
 		staffAdminApiV1.PUT("/contact-way/:id", m.Guard(c.BizContactWay, c.Full), contactWayHandler.Update)
 		staffAdminApiV1.POST("/contact-way/action/delete", m.Guard(c.BizContactWay, c.Full), contactWayHandler.Delete)
 		staffAdminApiV1.POST("/contact-way/action/batch-update", m.Guard(c.BizContactWay, c.Full), contactWayHandler.BatchUpdate)
+		staffAdminApiV1.GET("/contact-way/action/export", m.Guard(c.BizContactWay, c.Read), contactWayHandler.Export)
+		staffAdminApiV1.POST("/contact-way/action/import", m.Guard(c.BizContactWay, c.Full), contactWayHandler.Import)
+		staffAdminApiV1.GET("/contact-way-import/:id", m.Guard(c.BizContactWay, c.Read), contactWayHandler.GetImportTask)
+		staffAdminApiV1.GET("/contact-way/:id/sub-channels", m.Guard(c.BizContactWay, c.Read), contactWayHandler.QuerySubChannels)
+		staffAdminApiV1.POST("/contact-way/:id/sub-channel", m.Guard(c.BizContactWay, c.Full), contactWayHandler.CreateSubChannel)
+		staffAdminApiV1.PUT("/contact-way-sub-channel/:id", m.Guard(c.BizContactWay, c.Full), contactWayHandler.UpdateSubChannel)
+		staffAdminApiV1.POST("/contact-way-sub-channel/action/delete", m.Guard(c.BizContactWay, c.Full), contactWayHandler.DeleteSubChannels)
+
+		posterTemplateHandler := controller.NewPosterTemplate()
+		staffAdminApiV1.GET("/poster-templates", m.Guard(c.BizContactWay, c.Read), posterTemplateHandler.Query)
+		staffAdminApiV1.GET("/poster-template/:id", m.Guard(c.BizContactWay, c.Read), posterTemplateHandler.Get)
+		staffAdminApiV1.POST("/poster-template", m.Guard(c.BizContactWay, c.Full), posterTemplateHandler.Create)
+		staffAdminApiV1.PUT("/poster-template/:id", m.Guard(c.BizContactWay, c.Full), posterTemplateHandler.Update)
+		staffAdminApiV1.POST("/poster-template/action/delete", m.Guard(c.BizContactWay, c.Full), posterTemplateHandler.Delete)
+		staffAdminApiV1.POST("/poster-template/:id/action/generate", m.Guard(c.BizContactWay, c.Full), posterTemplateHandler.Generate)
+		staffAdminApiV1.GET("/poster-template/:id/action/download", m.Guard(c.BizContactWay, c.Read), posterTemplateHandler.Download)
+
+		corpCalendarHandler := controller.NewCorpCalendar()
+		staffAdminApiV1.GET("/corp-calendar-days", m.Guard(c.BizContactWay, c.Read), corpCalendarHandler.Query)
+		staffAdminApiV1.POST("/corp-calendar-days", m.Guard(c.BizContactWay, c.Full), corpCalendarHandler.Save)
+		staffAdminApiV1.POST("/corp-calendar/action/import", m.Guard(c.BizContactWay, c.Full), corpCalendarHandler.Import)
+		staffAdminApiV1.POST("/corp-calendar-day/action/delete", m.Guard(c.BizContactWay, c.Full), corpCalendarHandler.Delete)
 
 		// 企业管理-部门
 		staffAdminApiV1.POST("/department", m.Guard(c.BizDepartment, c.Full), department.Sync)
 		staffAdminApiV1.GET("/department", m.Guard(c.BizDepartment, c.Read), department.Get)
 		staffAdminApiV1.GET("/departments", m.Guard(c.BizDepartment, c.Read), department.Query)
+		staffAdminApiV1.GET("/department/action/customer-strategies", m.Guard(c.BizDepartment, c.Read), department.QueryCustomerStrategies)
 
 		// 企业管理-员工
 		staffAdminApiV1.POST("/staff", m.Guard(c.BizStaffInfo, c.Full), staff.Sync)
@@ -217,11 +256,38 @@ func NewRouter() *gin.Engine {
 		staffAdminApiV1.GET("/customers", m.Guard(c.BizCustomerInfo, c.Read), customer.Query)
 		staffAdminApiV1.GET("/customers/action/export", m.Guard(c.BizCustomerInfo, c.Read), customer.Export)
 		staffAdminApiV1.GET("/customers/statistic", m.Guard(c.BizCustomerInfo, c.Read), customer.Statistic)
+		// 根据unionid或手机号查找客户
+		staffAdminApiV1.GET("/customer/action/lookup", m.Guard(c.BizCustomerInfo, c.Read), customerIdentityHandler.Lookup)
+
+		// 客户分群
+		customerSegment := controller.NewCustomerSegment()
+		staffAdminApiV1.GET("/customer-segments", m.Guard(c.BizCustomerInfo, c.Read), customerSegment.Query)
+		staffAdminApiV1.GET("/customer-segment/:id", m.Guard(c.BizCustomerInfo, c.Read), customerSegment.Get)
+		staffAdminApiV1.GET("/customer-segment/:id/count", m.Guard(c.BizCustomerInfo, c.Read), customerSegment.Count)
+		staffAdminApiV1.POST("/customer-segment", m.Guard(c.BizCustomerInfo, c.Full), customerSegment.Create)
+		staffAdminApiV1.PUT("/customer-segment/:id", m.Guard(c.BizCustomerInfo, c.Full), customerSegment.Update)
+		staffAdminApiV1.POST("/customer-segment/action/delete", m.Guard(c.BizCustomerInfo, c.Full), customerSegment.Delete)
+		staffAdminApiV1.POST("/customer-segment/action/count", m.Guard(c.BizCustomerInfo, c.Read), customerSegment.Preview)
+
+		// 客户SOP
+		customerSop := controller.NewCustomerSop()
+		staffAdminApiV1.GET("/customer-sops", m.Guard(c.BizCustomerInfo, c.Read), customerSop.Query)
+		staffAdminApiV1.GET("/customer-sop/:id", m.Guard(c.BizCustomerInfo, c.Read), customerSop.Get)
+		staffAdminApiV1.POST("/customer-sop", m.Guard(c.BizCustomerInfo, c.Full), customerSop.Create)
+		staffAdminApiV1.PUT("/customer-sop/:id", m.Guard(c.BizCustomerInfo, c.Full), customerSop.Update)
+		staffAdminApiV1.POST("/customer-sop/action/delete", m.Guard(c.BizCustomerInfo, c.Full), customerSop.Delete)
+		staffAdminApiV1.GET("/customer-sop-tasks", m.Guard(c.BizCustomerInfo, c.Read), customerSop.QueryTasks)
 
 		homePageHandler := controller.NewHomePageHandler()
 		staffAdminApiV1.GET("/action/get-summary", m.Guard(c.BizCustomerInfo, c.Full), homePageHandler.GetCustomerSummary)
 		staffAdminApiV1.GET("/action/get-trend", m.Guard(c.BizCustomerInfo, c.Full), homePageHandler.GetCustomersTrend)
 
+		// 员工/部门数据统计
+		staffStatistic := controller.NewStaffStatistic()
+		staffAdminApiV1.GET("/staff-statistics", m.Guard(c.BizStaffInfo, c.Read), staffStatistic.QueryStaffSummary)
+		staffAdminApiV1.GET("/staff-statistics/action/export", m.Guard(c.BizStaffInfo, c.Read), staffStatistic.Export)
+		staffAdminApiV1.GET("/department-statistics", m.Guard(c.BizStaffInfo, c.Read), staffStatistic.QueryDepartmentSummary)
+
 		// 侧边栏-提醒
 		remainder := controller.NewRemainder()
 		staffAdminApiV1.POST("/customer/remainder", m.Guard(c.BizCustomerInfo, c.Full), remainder.Create)
@@ -303,30 +369,47 @@ func NewRouter() *gin.Engine {
 		massMsgHandler := controller.NewDefaultMassMsg()
 		staffAdminApiV1.POST("/customer/mass-msg", m.Guard(c.BizMassMsg, c.Full), massMsgHandler.Create)
 		staffAdminApiV1.POST("/customer/mass-msg/action/delete", m.Guard(c.BizMassMsg, c.Full), massMsgHandler.Delete)
+		staffAdminApiV1.POST("/customer/mass-msg/action/cancel", m.Guard(c.BizMassMsg, c.Full), massMsgHandler.Cancel)
 		staffAdminApiV1.GET("/customer/mass-msg/:id", m.Guard(c.BizMassMsg, c.Read), massMsgHandler.Get)
 		staffAdminApiV1.PUT("/customer/mass-msg/:id", m.Guard(c.BizQuickReply, c.Full), massMsgHandler.Update)
 		staffAdminApiV1.GET("/customer/mass-msgs", m.Guard(c.BizMassMsg, c.Read), massMsgHandler.Query)
 		staffAdminApiV1.POST("/customer/mass-msg/action/notify", m.Guard(c.BizMassMsg, c.Full), massMsgHandler.Notify)
 		staffAdminApiV1.GET("/customer/mass-msg/result/:id", m.Guard(c.BizMassMsg, c.Read), massMsgHandler.GetSendMassMsgResult)
+		staffAdminApiV1.GET("/customer/mass-msg/:id/variant-stats", m.Guard(c.BizMassMsg, c.Read), massMsgHandler.QueryVariantStats)
 		staffAdminApiV1.GET("/customer/mass-msg/customer-filter", m.Guard(c.BizMassMsg, c.Read), massMsgHandler.CustomerFilter)
 		staffAdminApiV1.POST("/customer/mass-msg/action/get-upload-url", m.Guard(c.BizQuickReply, c.Full), massMsgHandler.GetUploadUrl)
 
+		// 客户转化-群发审批
+		massMsgApprovalHandler := controller.NewMassMsgApproval()
+		staffAdminApiV1.GET("/customer/mass-msg-approval-rules", m.Guard(c.BizMassMsg, c.Read), massMsgApprovalHandler.QueryRules)
+		staffAdminApiV1.POST("/customer/mass-msg-approval-rule", m.Guard(c.BizMassMsg, c.Full), massMsgApprovalHandler.CreateRule)
+		staffAdminApiV1.PUT("/customer/mass-msg-approval-rule/:id", m.Guard(c.BizMassMsg, c.Full), massMsgApprovalHandler.UpdateRule)
+		staffAdminApiV1.POST("/customer/mass-msg-approval-rule/action/delete", m.Guard(c.BizMassMsg, c.Full), massMsgApprovalHandler.DeleteRules)
+		staffAdminApiV1.GET("/customer/mass-msg-approvals", m.Guard(c.BizMassMsg, c.Read), massMsgApprovalHandler.Query)
+		staffAdminApiV1.POST("/customer/mass-msg-approval/:id/action/approve", m.Guard(c.BizMassMsg, c.Read), massMsgApprovalHandler.Approve)
+		staffAdminApiV1.POST("/customer/mass-msg-approval/:id/action/reject", m.Guard(c.BizMassMsg, c.Read), massMsgApprovalHandler.Reject)
+
+		// 消息模板变量预览
+		msgTemplateHandler := controller.NewMsgTemplate()
+		staffAdminApiV1.POST("/msg-template/action/preview", m.Guard(c.BizMassMsg, c.Read), msgTemplateHandler.Preview)
+
 		//// 客户群-群发
 		groupChatMassMsgHandler := controller.NewDefaultGroupChatMassMsg()
 		staffAdminApiV1.POST("/group-chat/mass-msg", m.Guard(c.BizMassMsg, c.Full), groupChatMassMsgHandler.Create)
 		staffAdminApiV1.POST("/group-chat/mass-msg/action/delete", m.Guard(c.BizMassMsg, c.Full), groupChatMassMsgHandler.Delete)
 		staffAdminApiV1.GET("/group-chat/mass-msg/:id", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.Get)
-		//staffAdminApiV1.PUT("/group-chat/mass-msg/:id", m.Guard(c.BizQuickReply, c.Full), groupChatMassMsgHandler.Update)
+		staffAdminApiV1.PUT("/group-chat/mass-msg/:id", m.Guard(c.BizMassMsg, c.Full), groupChatMassMsgHandler.Update)
 		staffAdminApiV1.GET("/group-chat/mass-msgs", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.Query)
-		//staffAdminApiV1.POST("/group-chat/mass-msg/action/notify", m.Guard(c.BizMassMsg, c.Full), groupChatMassMsgHandler.Notify)
-		//staffAdminApiV1.GET("/group-chat/mass-msg/result/:id", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.GetSendMassMsgResult)
-		//staffAdminApiV1.GET("/group-chat/mass-msg/customer-filter", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.CustomerFilter)
+		staffAdminApiV1.POST("/group-chat/mass-msg/action/notify", m.Guard(c.BizMassMsg, c.Full), groupChatMassMsgHandler.Notify)
+		staffAdminApiV1.GET("/group-chat/mass-msg/result/:id", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.GetSendMassMsgResult)
+		staffAdminApiV1.GET("/group-chat/mass-msg/customer-filter", m.Guard(c.BizMassMsg, c.Read), groupChatMassMsgHandler.CustomerFilter)
 
 		// 客户转化-欢迎语
 		welcomeMsgHandler := controller.NewWelComeMsg()
 		staffAdminApiV1.POST("/customer/welcome-msg", m.Guard(c.BizWelcomeMsg, c.Full), welcomeMsgHandler.Create)
 		staffAdminApiV1.GET("/customer/welcome-msgs", m.Guard(c.BizWelcomeMsg, c.Read), welcomeMsgHandler.Query)
 		staffAdminApiV1.GET("/customer/welcome-msg/:id", m.Guard(c.BizWelcomeMsg, c.Read), welcomeMsgHandler.Get)
+		staffAdminApiV1.GET("/customer/welcome-msg/:id/variant-stats", m.Guard(c.BizWelcomeMsg, c.Read), welcomeMsgHandler.QueryVariantStats)
 		staffAdminApiV1.PUT("/customer/welcome-msg/:id", m.Guard(c.BizWelcomeMsg, c.Full), welcomeMsgHandler.Update)
 		staffAdminApiV1.POST("/customer/welcome-msg/action/delete", m.Guard(c.BizWelcomeMsg, c.Full), welcomeMsgHandler.Delete)
 		staffAdminApiV1.POST("/customer/welcome-msg/action/upload-image", m.Guard(c.BizWelcomeMsg, c.Full), welcomeMsgHandler.UploadFileUrl)
@@ -364,6 +447,25 @@ func NewRouter() *gin.Engine {
 		staffAdminApiV1.GET("/group-chat/owners", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.GetAllOwners)
 		staffAdminApiV1.POST("/group-chat/action/get-all", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.GetAll)
 		staffAdminApiV1.POST("/group-chat/action/update-tags", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.UpdateTags)
+		staffAdminApiV1.GET("/group-chat/:id/member-trends", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.MemberTrend)
+		staffAdminApiV1.GET("/group-chat/:id/member-trends/action/export", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.ExportMemberTrend)
+		staffAdminApiV1.GET("/group-chat/member-logs", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.MemberLogs)
+		staffAdminApiV1.GET("/group-chat/member-logs/action/export", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.ExportMemberLogs)
+		staffAdminApiV1.GET("/group-chat/member-churns", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.Churns)
+		staffAdminApiV1.GET("/group-chat/member-churns/action/export", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.ExportChurns)
+		staffAdminApiV1.GET("/group-chat/inviter-rank", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.InviterRank)
+		staffAdminApiV1.GET("/group-chat/inviter-rank/action/export", m.Guard(c.BizCustomerGroupChat, c.Read), CustomerGroupChatHandler.ExportInviterRank)
+
+		groupChatSpamHandler := controller.NewGroupChatSpam()
+		staffAdminApiV1.GET("/group-chat/spam-rules", m.Guard(c.BizCustomerGroupChat, c.Read), groupChatSpamHandler.QueryRules)
+		staffAdminApiV1.GET("/group-chat/spam-rule/:id", m.Guard(c.BizCustomerGroupChat, c.Read), groupChatSpamHandler.GetRule)
+		staffAdminApiV1.POST("/group-chat/spam-rule", m.Guard(c.BizCustomerGroupChat, c.Full), groupChatSpamHandler.CreateRule)
+		staffAdminApiV1.PUT("/group-chat/spam-rule/:id", m.Guard(c.BizCustomerGroupChat, c.Full), groupChatSpamHandler.UpdateRule)
+		staffAdminApiV1.POST("/group-chat/spam-rule/action/delete", m.Guard(c.BizCustomerGroupChat, c.Full), groupChatSpamHandler.DeleteRules)
+		staffAdminApiV1.GET("/group-chat/violations", m.Guard(c.BizCustomerGroupChat, c.Read), groupChatSpamHandler.QueryViolations)
+		staffAdminApiV1.GET("/group-chat/violations/action/export", m.Guard(c.BizCustomerGroupChat, c.Read), groupChatSpamHandler.ExportViolations)
+		staffAdminApiV1.GET("/group-chat/blacklist", m.Guard(c.BizCustomerGroupChat, c.Read), groupChatSpamHandler.QueryBlacklist)
+		staffAdminApiV1.POST("/group-chat/blacklist/action/delete", m.Guard(c.BizCustomerGroupChat, c.Full), groupChatSpamHandler.DeleteBlacklist)
 
 		// 客户群标签
 		CustomerGroupTagHandler := controller.NewGroupChatTag()
@@ -420,6 +522,23 @@ func NewRouter() *gin.Engine {
 		staffAdminApiV1.POST("/role/action/assign-to-staffs", m.Guard(c.BizRole, c.Full), roleHandler.AssignToStaffs)
 		staffAdminApiV1.GET("/role/action/query-staffs", m.Guard(c.BizRole, c.Read), roleHandler.QueryStaffs)
 
+		// 客户联系规则组
+		customerStrategyHandler := controller.NewCustomerStrategy()
+		staffAdminApiV1.GET("/customer-strategies", m.Guard(c.BizRole, c.Read), customerStrategyHandler.Query)
+		staffAdminApiV1.GET("/customer-strategy/:id", m.Guard(c.BizRole, c.Read), customerStrategyHandler.Get)
+		staffAdminApiV1.POST("/customer-strategy", m.Guard(c.BizRole, c.Full), customerStrategyHandler.Create)
+		staffAdminApiV1.PUT("/customer-strategy/:id", m.Guard(c.BizRole, c.Full), customerStrategyHandler.Update)
+		staffAdminApiV1.POST("/customer-strategy/action/delete", m.Guard(c.BizRole, c.Full), customerStrategyHandler.Delete)
+		staffAdminApiV1.POST("/customer-strategy/action/sync", m.Guard(c.BizRole, c.Full), customerStrategyHandler.Sync)
+
+		// 商品图册
+		staffAdminApiV1.GET("/products", m.Guard(c.BizMediaMgr, c.Read), productHandler.Query)
+		staffAdminApiV1.GET("/product/:id", m.Guard(c.BizMediaMgr, c.Read), productHandler.Get)
+		staffAdminApiV1.POST("/product", m.Guard(c.BizMediaMgr, c.Full), productHandler.Create)
+		staffAdminApiV1.PUT("/product/:id", m.Guard(c.BizMediaMgr, c.Full), productHandler.Update)
+		staffAdminApiV1.POST("/product/action/delete", m.Guard(c.BizMediaMgr, c.Full), productHandler.Delete)
+		staffAdminApiV1.POST("/product/action/sync", m.Guard(c.BizMediaMgr, c.Full), productHandler.Sync)
+
 		// 获取当前登录员工
 		staffAdminApiV1.GET("/action/get-current-staff", staff.GetCurrent)
 